@@ -16,6 +16,10 @@ func (hs *HTTPServer) AdminProvisioningReloadDashboards(c *models.ReqContext) re
 	return response.Success("Dashboards config reloaded")
 }
 
+func (hs *HTTPServer) AdminProvisioningGetDashboardsStatus(c *models.ReqContext) response.Response {
+	return response.JSON(200, hs.ProvisioningService.GetDashboardProvisionerStatus())
+}
+
 func (hs *HTTPServer) AdminProvisioningReloadDatasources(c *models.ReqContext) response.Response {
 	err := hs.ProvisioningService.ProvisionDatasources(c.Req.Context())
 	if err != nil {
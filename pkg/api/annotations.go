@@ -13,7 +13,27 @@ import (
 )
 
 func GetAnnotations(c *models.ReqContext) response.Response {
-	query := &annotations.ItemQuery{
+	result, err := searchAnnotations(c, annotationsQuery(c))
+	if err != nil {
+		return toAnnotationsSearchError(err)
+	}
+
+	return response.JSON(200, result.Annotations)
+}
+
+// SearchAnnotations returns a page of the annotations the user can see, and
+// the cursor of the next page.
+func SearchAnnotations(c *models.ReqContext) response.Response {
+	result, err := searchAnnotations(c, annotationsQuery(c))
+	if err != nil {
+		return toAnnotationsSearchError(err)
+	}
+
+	return response.JSON(200, result)
+}
+
+func annotationsQuery(c *models.ReqContext) *annotations.ItemQuery {
+	return &annotations.ItemQuery{
 		From:        c.QueryInt64("from"),
 		To:          c.QueryInt64("to"),
 		OrgId:       c.OrgId,
@@ -25,22 +45,80 @@ func GetAnnotations(c *models.ReqContext) response.Response {
 		Tags:        c.QueryStrings("tags"),
 		Type:        c.Query("type"),
 		MatchAny:    c.QueryBool("matchAny"),
+		Query:       c.Query("query"),
+		Cursor:      c.Query("cursor"),
 	}
+}
 
-	repo := annotations.GetRepository()
+// maxScannedAnnotations is the number of annotations a search reads from the
+// repository before returning the annotations found so far, with the cursor
+// to continue from.
+var maxScannedAnnotations = 5000
 
-	items, err := repo.Find(query)
-	if err != nil {
-		return response.Error(500, "Failed to get annotations", err)
+// searchAnnotations finds the annotations matching the query that are on
+// dashboards the user can view. Organization annotations are visible to all
+// users of the organization. Pages are read from the repository
+// until the result is full, there are no more annotations or
+// maxScannedAnnotations are read.
+func searchAnnotations(c *models.ReqContext, query *annotations.ItemQuery) (*annotations.SearchResult, error) {
+	if query.Limit <= 0 {
+		query.Limit = 100
 	}
 
-	for _, item := range items {
-		if item.Email != "" {
-			item.AvatarUrl = dtos.GetGravatarUrl(item.Email)
+	repo := annotations.GetRepository()
+	canView := map[int64]bool{}
+	result := &annotations.SearchResult{Annotations: []*annotations.ItemDTO{}}
+
+	scanned := 0
+	for {
+		items, err := repo.Find(query)
+		if err != nil {
+			return nil, err
+		}
+
+		for _, item := range items {
+			if item.DashboardId > 0 {
+				allowed, ok := canView[item.DashboardId]
+				if !ok {
+					guard := guardian.New(c.Req.Context(), item.DashboardId, c.OrgId, c.SignedInUser)
+					if allowed, err = guard.CanView(); err != nil {
+						return nil, err
+					}
+					canView[item.DashboardId] = allowed
+				}
+				if !allowed {
+					continue
+				}
+			}
+
+			if item.Email != "" {
+				item.AvatarUrl = dtos.GetGravatarUrl(item.Email)
+			}
+			result.Annotations = append(result.Annotations, item)
+			if int64(len(result.Annotations)) == query.Limit {
+				result.NextCursor = annotations.NewCursor(item).String()
+				return result, nil
+			}
+		}
+
+		if int64(len(items)) < query.Limit {
+			return result, nil
+		}
+		query.Cursor = annotations.NewCursor(items[len(items)-1]).String()
+
+		scanned += len(items)
+		if scanned >= maxScannedAnnotations {
+			result.NextCursor = query.Cursor
+			return result, nil
 		}
 	}
+}
 
-	return response.JSON(200, items)
+func toAnnotationsSearchError(err error) response.Response {
+	if errors.Is(err, annotations.ErrInvalidCursor) {
+		return response.Error(400, "Invalid cursor", err)
+	}
+	return response.Error(500, "Failed to get annotations", err)
 }
 
 type CreateAnnotationError struct {
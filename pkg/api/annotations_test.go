@@ -1,7 +1,10 @@
 package api
 
 import (
+	"context"
+	"encoding/json"
 	"fmt"
+	"net/http"
 	"testing"
 
 	"github.com/grafana/grafana/pkg/api/dtos"
@@ -10,7 +13,13 @@ import (
 	"github.com/grafana/grafana/pkg/bus"
 	"github.com/grafana/grafana/pkg/models"
 	"github.com/grafana/grafana/pkg/services/annotations"
+	"github.com/grafana/grafana/pkg/services/annotationwebhooks"
+	"github.com/grafana/grafana/pkg/services/encryption/ossencryption"
+	"github.com/grafana/grafana/pkg/services/guardian"
+	"github.com/grafana/grafana/pkg/services/sqlstore"
+	"github.com/grafana/grafana/pkg/setting"
 	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
 )
 
 func TestAnnotationsAPIEndpoint(t *testing.T) {
@@ -242,6 +251,167 @@ func TestAnnotationsAPIEndpoint(t *testing.T) {
 	})
 }
 
+func TestSearchAnnotations(t *testing.T) {
+	repo := &searchAnnotationsRepo{items: []*annotations.ItemDTO{
+		{Id: 6, DashboardId: 1, Time: 60, TimeEnd: 60},
+		{Id: 5, DashboardId: 2, Time: 50, TimeEnd: 50},
+		{Id: 4, Time: 40, TimeEnd: 40},
+		{Id: 3, DashboardId: 2, Time: 30, TimeEnd: 30},
+		{Id: 2, DashboardId: 2, Time: 20, TimeEnd: 20},
+		{Id: 1, DashboardId: 1, Time: 10, TimeEnd: 10},
+	}}
+
+	origNewGuardian := guardian.New
+	t.Cleanup(func() {
+		guardian.New = origNewGuardian
+	})
+	guardian.New = func(_ context.Context, dashID int64, _ int64, _ *models.SignedInUser) guardian.DashboardGuardian {
+		return &guardian.FakeDashboardGuardian{CanViewValue: dashID == 1}
+	}
+
+	search := func(t *testing.T, role models.RoleType, url string) (int, annotations.SearchResult) {
+		sc := setupScenarioContext(t, url)
+		annotations.SetRepository(repo)
+		sc.defaultHandler = routing.Wrap(func(c *models.ReqContext) response.Response {
+			sc.context = c
+			sc.context.OrgId = testOrgID
+			sc.context.OrgRole = role
+			return SearchAnnotations(c)
+		})
+		sc.m.Get("/api/annotations/search", sc.defaultHandler)
+		sc.fakeReqWithParams("GET", sc.url, map[string]string{}).exec()
+
+		result := annotations.SearchResult{}
+		if sc.resp.Code == 200 {
+			require.NoError(t, json.Unmarshal(sc.resp.Body.Bytes(), &result))
+		}
+		return sc.resp.Code, result
+	}
+
+	ids := func(result annotations.SearchResult) []int64 {
+		ids := []int64{}
+		for _, item := range result.Annotations {
+			ids = append(ids, item.Id)
+		}
+		return ids
+	}
+
+	t.Run("Should only return annotations of dashboards the user can view", func(t *testing.T) {
+		code, result := search(t, models.ROLE_EDITOR, "/api/annotations/search")
+		require.Equal(t, 200, code)
+		assert.Equal(t, []int64{6, 4, 1}, ids(result))
+		assert.Empty(t, result.NextCursor)
+	})
+
+	t.Run("Should return organization annotations to viewers", func(t *testing.T) {
+		code, result := search(t, models.ROLE_VIEWER, "/api/annotations/search")
+		require.Equal(t, 200, code)
+		assert.Equal(t, []int64{6, 4, 1}, ids(result))
+		assert.Empty(t, result.NextCursor)
+	})
+
+	t.Run("Should page through the annotations with cursors", func(t *testing.T) {
+		code, result := search(t, models.ROLE_EDITOR, "/api/annotations/search?limit=2")
+		require.Equal(t, 200, code)
+		assert.Equal(t, []int64{6, 4}, ids(result))
+		require.NotEmpty(t, result.NextCursor)
+
+		code, result = search(t, models.ROLE_EDITOR, "/api/annotations/search?limit=2&cursor="+result.NextCursor)
+		require.Equal(t, 200, code)
+		assert.Equal(t, []int64{1}, ids(result))
+		assert.Empty(t, result.NextCursor)
+	})
+
+	t.Run("Should stop reading after maxScannedAnnotations and return the cursor to continue from", func(t *testing.T) {
+		origMaxScannedAnnotations := maxScannedAnnotations
+		t.Cleanup(func() {
+			maxScannedAnnotations = origMaxScannedAnnotations
+		})
+		maxScannedAnnotations = 2
+
+		code, result := search(t, models.ROLE_VIEWER, "/api/annotations/search?limit=2")
+		require.Equal(t, 200, code)
+		assert.Equal(t, []int64{6}, ids(result))
+		require.NotEmpty(t, result.NextCursor)
+
+		code, result = search(t, models.ROLE_VIEWER, "/api/annotations/search?limit=2&cursor="+result.NextCursor)
+		require.Equal(t, 200, code)
+		assert.Equal(t, []int64{4}, ids(result))
+		require.NotEmpty(t, result.NextCursor)
+	})
+
+	t.Run("Should return 400 for an invalid cursor", func(t *testing.T) {
+		code, _ := search(t, models.ROLE_VIEWER, "/api/annotations/search?cursor=invalid")
+		assert.Equal(t, 400, code)
+	})
+}
+
+func TestSearchAnnotations_WebhookAnnotations(t *testing.T) {
+	ctx := context.Background()
+	sqlStore := sqlstore.InitTestDB(t)
+	sc := setupScenarioContext(t, "/api/annotations/search")
+	annotations.SetRepository(&sqlstore.SQLAnnotationRepo{})
+
+	// Webhooks without a dashboard create organization annotations.
+	webhooks := annotationwebhooks.ProvideService(setting.NewCfg(), sqlStore, routing.NewRouteRegister(), ossencryption.ProvideService())
+	webhook, err := webhooks.CreateWebhook(ctx, &models.SignedInUser{OrgId: testOrgID, UserId: 1, OrgRole: models.ROLE_ADMIN},
+		annotationwebhooks.CreateWebhookCommand{
+			Name:    "Deploys",
+			Source:  annotationwebhooks.SourceGeneric,
+			Mapping: map[string]string{annotationwebhooks.MappingText: "message"},
+		})
+	require.NoError(t, err)
+	_, err = webhooks.Receive(ctx, webhook.UID, &annotationwebhooks.Request{
+		Header: http.Header{},
+		Body:   []byte(`{"message": "Version 42 deployed"}`),
+		Token:  webhook.Token,
+	})
+	require.NoError(t, err)
+
+	sc.defaultHandler = routing.Wrap(func(c *models.ReqContext) response.Response {
+		sc.context = c
+		sc.context.OrgId = testOrgID
+		sc.context.OrgRole = models.ROLE_VIEWER
+		return SearchAnnotations(c)
+	})
+	sc.m.Get("/api/annotations/search", sc.defaultHandler)
+	sc.fakeReqWithParams("GET", sc.url, map[string]string{}).exec()
+	require.Equal(t, 200, sc.resp.Code)
+
+	result := annotations.SearchResult{}
+	require.NoError(t, json.Unmarshal(sc.resp.Body.Bytes(), &result))
+	require.Len(t, result.Annotations, 1)
+	require.Equal(t, "Version 42 deployed", result.Annotations[0].Text)
+	require.Zero(t, result.Annotations[0].DashboardId)
+}
+
+// searchAnnotationsRepo is a fake repository returning pages of annotations
+// ordered like the SQL repository.
+type searchAnnotationsRepo struct {
+	fakeAnnotationsRepo
+	items []*annotations.ItemDTO
+}
+
+func (repo *searchAnnotationsRepo) Find(query *annotations.ItemQuery) ([]*annotations.ItemDTO, error) {
+	items := repo.items
+	if query.Cursor != "" {
+		cursor, err := annotations.ParseCursor(query.Cursor)
+		if err != nil {
+			return nil, err
+		}
+		for i, item := range items {
+			if item.Id == cursor.Id {
+				items = items[i+1:]
+				break
+			}
+		}
+	}
+	if int64(len(items)) > query.Limit {
+		items = items[:query.Limit]
+	}
+	return items, nil
+}
+
 type fakeAnnotationsRepo struct {
 }
 
@@ -374,6 +374,13 @@ func (hs *HTTPServer) PostDashboard(c *models.ReqContext, cmd models.SaveDashboa
 		return response.Error(500, "Error while connecting library panels", err)
 	}
 
+	if provisioningData != nil {
+		err := hs.ProvisioningService.WriteDashboardToProvisioner(ctx, provisioningData, dashboard, cmd.Message, c.SignedInUser)
+		if err != nil {
+			hs.log.Warn("Failed to write dashboard back to provisioning", "uid", dashboard.Uid, "provisioner", provisioningData.Name, "error", err)
+		}
+	}
+
 	c.TimeRequest(metrics.MApiDashboardSave)
 	return response.JSON(200, util.DynMap{
 		"status":  "success",
@@ -561,21 +568,33 @@ func GetDashboardVersion(c *models.ReqContext) response.Response {
 		return response.Error(500, fmt.Sprintf("Dashboard version %d not found for dashboardId %d", query.Version, dashID), err)
 	}
 
+	return dashboardVersionMetaResponse(c, query.Result)
+}
+
+// dashboardVersionMetaResponse returns the dashboard version with the login
+// of its creator and its labels.
+func dashboardVersionMetaResponse(c *models.ReqContext, version *models.DashboardVersion) response.Response {
 	creator := anonString
-	if query.Result.CreatedBy > 0 {
-		creator = getUserLogin(c.Req.Context(), query.Result.CreatedBy)
+	if version.CreatedBy > 0 {
+		creator = getUserLogin(c.Req.Context(), version.CreatedBy)
+	}
+
+	labelsQuery := models.GetDashboardVersionLabelsQuery{DashboardId: version.DashboardId, Version: version.Version}
+	if err := bus.DispatchCtx(c.Req.Context(), &labelsQuery); err != nil {
+		return response.Error(500, "Failed to get dashboard version labels", err)
 	}
 
 	dashVersionMeta := &models.DashboardVersionMeta{
-		Id:            query.Result.Id,
-		DashboardId:   query.Result.DashboardId,
-		Data:          query.Result.Data,
-		ParentVersion: query.Result.ParentVersion,
-		RestoredFrom:  query.Result.RestoredFrom,
-		Version:       query.Result.Version,
-		Created:       query.Result.Created,
-		Message:       query.Result.Message,
+		Id:            version.Id,
+		DashboardId:   version.DashboardId,
+		Data:          version.Data,
+		ParentVersion: version.ParentVersion,
+		RestoredFrom:  version.RestoredFrom,
+		Version:       version.Version,
+		Created:       version.Created,
+		Message:       version.Message,
 		CreatedBy:     creator,
+		Labels:        labelsQuery.Result,
 	}
 
 	return response.JSON(200, dashVersionMeta)
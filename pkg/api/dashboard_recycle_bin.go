@@ -0,0 +1,48 @@
+package api
+
+import (
+	"errors"
+	"fmt"
+
+	"github.com/grafana/grafana/pkg/api/response"
+	"github.com/grafana/grafana/pkg/bus"
+	"github.com/grafana/grafana/pkg/models"
+	"github.com/grafana/grafana/pkg/util"
+)
+
+// GetDeletedDashboards returns the dashboards and folders in the recycle bin.
+func GetDeletedDashboards(c *models.ReqContext) response.Response {
+	query := models.GetDeletedDashboardsQuery{OrgId: c.OrgId}
+	if err := bus.DispatchCtx(c.Req.Context(), &query); err != nil {
+		return response.Error(500, "Failed to get recently deleted dashboards", err)
+	}
+
+	return response.JSON(200, query.Result)
+}
+
+// RestoreDeletedDashboard restores a dashboard or folder from the recycle bin.
+func (hs *HTTPServer) RestoreDeletedDashboard(c *models.ReqContext) response.Response {
+	cmd := models.RestoreDeletedDashboardCommand{Id: c.ParamsInt64(":id"), OrgId: c.OrgId}
+	if err := bus.DispatchCtx(c.Req.Context(), &cmd); err != nil {
+		var dashboardErr models.DashboardErr
+		if errors.As(err, &dashboardErr) {
+			return response.Error(dashboardErr.StatusCode, dashboardErr.Error(), err)
+		}
+		return response.Error(500, "Failed to restore dashboard", err)
+	}
+
+	dash := cmd.Result
+	if !dash.IsFolder {
+		if err := hs.LibraryPanelService.ConnectLibraryPanelsForDashboard(c.Req.Context(), c.SignedInUser, dash); err != nil {
+			hs.log.Error("Failed to connect library panels", "dashboard", dash.Id, "error", err)
+		}
+	}
+
+	return response.JSON(200, util.DynMap{
+		"id":      dash.Id,
+		"uid":     dash.Uid,
+		"title":   dash.Title,
+		"url":     models.GetDashboardFolderUrl(dash.IsFolder, dash.Uid, dash.Slug),
+		"message": fmt.Sprintf("%s restored", dash.Title),
+	})
+}
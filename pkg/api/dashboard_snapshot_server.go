@@ -0,0 +1,534 @@
+package api
+
+import (
+	"fmt"
+	"math"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/grafana/grafana-plugin-sdk-go/backend"
+	"github.com/grafana/grafana-plugin-sdk-go/data"
+	"github.com/grafana/grafana/pkg/api/dtos"
+	"github.com/grafana/grafana/pkg/api/response"
+	"github.com/grafana/grafana/pkg/bus"
+	"github.com/grafana/grafana/pkg/components/simplejson"
+	"github.com/grafana/grafana/pkg/expr"
+	"github.com/grafana/grafana/pkg/infra/metrics"
+	"github.com/grafana/grafana/pkg/models"
+	"github.com/grafana/grafana/pkg/plugins"
+	"github.com/grafana/grafana/pkg/services/guardian"
+	"github.com/grafana/grafana/pkg/setting"
+	"github.com/grafana/grafana/pkg/tsdb/grafanads"
+	"github.com/grafana/grafana/pkg/util"
+	"github.com/grafana/grafana/pkg/web"
+)
+
+// snapshotMaxDataPoints is the number of data points requested for panels
+// that don't set maxDataPoints, about the width of a panel in pixels.
+const snapshotMaxDataPoints = 1000
+
+const mixedDataSourceName = "-- Mixed --"
+
+// snapshotPanelError is a panel query that failed while creating a snapshot.
+type snapshotPanelError struct {
+	PanelID int64  `json:"panelId"`
+	RefID   string `json:"refId,omitempty"`
+	Error   string `json:"error"`
+}
+
+// CreateServerSideDashboardSnapshot creates a snapshot of a dashboard by
+// executing its panel queries on the server, without rendering the dashboard
+// in a browser. Panels whose queries fail are stored without data and the
+// errors are returned along with the snapshot.
+// POST /api/dashboards/uid/:uid/snapshot
+func (hs *HTTPServer) CreateServerSideDashboardSnapshot(c *models.ReqContext, cmd dtos.CreateServerSideSnapshotCommand) response.Response {
+	dash, rsp := getDashboardHelper(c.Req.Context(), c.OrgId, 0, web.Params(c.Req)[":uid"])
+	if rsp != nil {
+		return rsp
+	}
+
+	guardian := guardian.New(c.Req.Context(), dash.Id, c.OrgId, c.SignedInUser)
+	if canView, err := guardian.CanView(); err != nil || !canView {
+		return dashboardGuardianResponse(err)
+	}
+
+	if err := hs.LibraryPanelService.LoadLibraryPanelsForDashboard(c.Req.Context(), dash); err != nil {
+		return response.Error(500, "Error while loading library panels", err)
+	}
+
+	if cmd.From == "" {
+		cmd.From = dash.Data.GetPath("time", "from").MustString("now-6h")
+	}
+	if cmd.To == "" {
+		cmd.To = dash.Data.GetPath("time", "to").MustString("now")
+	}
+	timeRange := plugins.NewDataTimeRange(cmd.From, cmd.To)
+	from, err := timeRange.ParseFrom()
+	if err != nil {
+		return response.Error(400, "Invalid time range", err)
+	}
+	to, err := timeRange.ParseTo()
+	if err != nil {
+		return response.Error(400, "Invalid time range", err)
+	}
+	if !from.Before(to) {
+		return response.Error(400, "Invalid time range, from must be before to", nil)
+	}
+
+	vars := newSnapshotVariables(dash.Data, cmd.Variables, from, to)
+	errs := hs.snapshotPanels(c, dash.Data, from, to, vars)
+
+	dash.Data.Set("time", map[string]interface{}{
+		"from": from.UTC().Format(time.RFC3339Nano),
+		"to":   to.UTC().Format(time.RFC3339Nano),
+	})
+	dash.Data.Set("snapshot", map[string]interface{}{
+		"timestamp":   time.Now().UTC().Format(time.RFC3339Nano),
+		"originalUrl": dash.GetUrl(),
+	})
+
+	snapshotCmd := models.CreateDashboardSnapshotCommand{
+		Dashboard: dash.Data,
+		Name:      cmd.Name,
+		Expires:   cmd.Expires,
+		OrgId:     c.OrgId,
+		UserId:    c.UserId,
+	}
+	if snapshotCmd.Name == "" {
+		snapshotCmd.Name = dash.Title
+	}
+	if snapshotCmd.Key, err = util.GetRandomString(32); err != nil {
+		return response.Error(500, "Could not generate random string", err)
+	}
+	if snapshotCmd.DeleteKey, err = util.GetRandomString(32); err != nil {
+		return response.Error(500, "Could not generate random string", err)
+	}
+
+	if err := bus.DispatchCtx(c.Req.Context(), &snapshotCmd); err != nil {
+		return response.Error(500, "Failed to create snapshot", err)
+	}
+
+	metrics.MApiDashboardSnapshotCreate.Inc()
+
+	return response.JSON(200, util.DynMap{
+		"key":       snapshotCmd.Key,
+		"deleteKey": snapshotCmd.DeleteKey,
+		"url":       setting.ToAbsUrl("dashboard/snapshot/" + snapshotCmd.Key),
+		"deleteUrl": setting.ToAbsUrl("api/snapshots-delete/" + snapshotCmd.DeleteKey),
+		"id":        snapshotCmd.Result.Id,
+		"errors":    errs,
+	})
+}
+
+// snapshotPanels replaces the queries of the panels, including the panels of
+// collapsed rows, with their results.
+func (hs *HTTPServer) snapshotPanels(c *models.ReqContext, parent *simplejson.Json, from, to time.Time,
+	vars snapshotVariables) []snapshotPanelError {
+	errs := []snapshotPanelError{}
+	for _, panelObj := range parent.Get("panels").MustArray() {
+		panel := simplejson.NewFromAny(panelObj)
+		if _, ok := panel.CheckGet("panels"); ok {
+			errs = append(errs, hs.snapshotPanels(c, panel, from, to, vars)...)
+		}
+		errs = append(errs, hs.snapshotPanel(c, panel, from, to, vars)...)
+	}
+	return errs
+}
+
+func (hs *HTTPServer) snapshotPanel(c *models.ReqContext, panel *simplejson.Json, from, to time.Time,
+	vars snapshotVariables) []snapshotPanelError {
+	targets := panel.Get("targets").MustArray()
+	if len(targets) == 0 {
+		return nil
+	}
+
+	panelID := panel.Get("id").MustInt64()
+	panelDataSource := panel.Get("datasource").Interface()
+	if isMixedDataSource(panelDataSource) {
+		panelDataSource = nil
+	}
+
+	maxDataPoints := panel.Get("maxDataPoints").MustInt64(snapshotMaxDataPoints)
+	if maxDataPoints <= 0 {
+		maxDataPoints = snapshotMaxDataPoints
+	}
+	intervalMS := to.Sub(from).Milliseconds() / maxDataPoints
+	if intervalMS < 1 {
+		intervalMS = 1
+	}
+
+	var errs []snapshotPanelError
+	var queries []*simplejson.Json
+	hasExpression := false
+	for _, targetObj := range targets {
+		target := simplejson.NewFromAny(vars.interpolateValue(targetObj))
+		refID := target.Get("refId").MustString("A")
+
+		dataSource := target.Get("datasource").Interface()
+		if dataSource == nil {
+			dataSource = panelDataSource
+		}
+		ds, err := hs.snapshotDataSource(c, dataSource, vars)
+		if err != nil {
+			errs = append(errs, snapshotPanelError{PanelID: panelID, RefID: refID, Error: err.Error()})
+			continue
+		}
+
+		if ds == nil {
+			hasExpression = true
+			target.Set("datasource", expr.DatasourceName)
+			target.Set("datasourceId", expr.DatasourceID)
+		} else {
+			target.Set("datasource", ds.Name)
+			target.Set("datasourceId", ds.Id)
+		}
+		target.Set("refId", refID)
+		target.Set("maxDataPoints", maxDataPoints)
+		target.Set("intervalMs", intervalMS)
+		queries = append(queries, target)
+	}
+
+	reqDTO := dtos.MetricRequest{
+		From: strconv.FormatInt(from.UnixNano()/int64(time.Millisecond), 10),
+		To:   strconv.FormatInt(to.UnixNano()/int64(time.Millisecond), 10),
+	}
+	var groups []*dataSourceQueries
+	groupsByID := map[int64]*dataSourceQueries{}
+	for _, query := range queries {
+		// hidden queries are only executed as input of expressions
+		if query.Get("hide").MustBool() && !hasExpression {
+			continue
+		}
+		reqDTO.Queries = append(reqDTO.Queries, query)
+
+		dsID := query.Get("datasourceId").MustInt64()
+		group, ok := groupsByID[dsID]
+		if !ok {
+			group = &dataSourceQueries{id: dsID}
+			groupsByID[dsID] = group
+			groups = append(groups, group)
+		}
+		group.queries = append(group.queries, query)
+	}
+
+	var qdr *backend.QueryDataResponse
+	switch {
+	case len(reqDTO.Queries) == 0:
+		qdr = backend.NewQueryDataResponse()
+	case hasExpression:
+		var err error
+		if qdr, err = hs.transformData(c, reqDTO); err != nil {
+			hs.log.Error("Expression request error", "err", err, "panelId", panelID)
+			qdr = errorDataResponse(reqDTO.Queries, fmt.Errorf("expression request error: %w", err))
+		}
+	default:
+		qdr, _ = hs.queryMixedDataSources(c, reqDTO, groups)
+	}
+
+	snapshotData := []interface{}{}
+	for _, query := range reqDTO.Queries {
+		if query.Get("hide").MustBool() {
+			continue
+		}
+		refID := query.Get("refId").MustString()
+		res, ok := qdr.Responses[refID]
+		if !ok {
+			continue
+		}
+		if res.Error != nil {
+			errs = append(errs, snapshotPanelError{PanelID: panelID, RefID: refID, Error: res.Error.Error()})
+			continue
+		}
+		for _, frame := range res.Frames {
+			if frame.RefID == "" {
+				frame.RefID = refID
+			}
+			snapshotData = append(snapshotData, snapshotFrame(frame))
+		}
+	}
+
+	panel.Set("snapshotData", snapshotData)
+	panel.Set("targets", []interface{}{})
+	panel.Set("links", []interface{}{})
+	panel.Set("datasource", nil)
+	return errs
+}
+
+// snapshotDataSource returns the data source of a panel or query data source
+// reference, which is a name or an object with a uid. The default data source
+// is returned if there is no reference, and nil for expressions.
+func (hs *HTTPServer) snapshotDataSource(c *models.ReqContext, ref interface{}, vars snapshotVariables) (*models.DataSource, error) {
+	var name, uid string
+	switch ref := ref.(type) {
+	case string:
+		name = vars.interpolate(ref)
+	case map[string]interface{}:
+		uid, _ = ref["uid"].(string)
+		uid = vars.interpolate(uid)
+	}
+
+	switch {
+	case name == expr.DatasourceName || uid == expr.DatasourceName || uid == expr.DatasourceUID:
+		return nil, nil
+	case name == grafanads.DatasourceName || uid == grafanads.DatasourceUID:
+		return grafanads.DataSourceModel(c.OrgId), nil
+	case uid != "":
+		return hs.DataSourceCache.GetDatasourceByUID(uid, c.SignedInUser, c.SkipCache)
+	case name != "":
+		query := &models.GetDataSourceQuery{Name: name, OrgId: c.OrgId}
+		if err := bus.DispatchCtx(c.Req.Context(), query); err != nil {
+			return nil, err
+		}
+		return hs.DataSourceCache.GetDatasource(query.Result.Id, c.SignedInUser, c.SkipCache)
+	default:
+		query := &models.GetDefaultDataSourceQuery{OrgId: c.OrgId, User: c.SignedInUser}
+		if err := bus.DispatchCtx(c.Req.Context(), query); err != nil {
+			return nil, err
+		}
+		return hs.DataSourceCache.GetDatasource(query.Result.Id, c.SignedInUser, c.SkipCache)
+	}
+}
+
+func isMixedDataSource(ref interface{}) bool {
+	switch ref := ref.(type) {
+	case string:
+		return ref == mixedDataSourceName
+	case map[string]interface{}:
+		return ref["uid"] == mixedDataSourceName
+	}
+	return false
+}
+
+// snapshotFrame converts a data frame to the JSON format of the data frames
+// stored as snapshotData of panels.
+func snapshotFrame(frame *data.Frame) map[string]interface{} {
+	fields := make([]interface{}, 0, len(frame.Fields))
+	for _, field := range frame.Fields {
+		values := make([]interface{}, field.Len())
+		for i := range values {
+			values[i] = snapshotValue(field, i)
+		}
+
+		config := field.Config
+		if config == nil {
+			config = &data.FieldConfig{}
+		}
+		f := map[string]interface{}{
+			"name":   field.Name,
+			"type":   snapshotFieldType(field.Type()),
+			"config": config,
+			"values": values,
+		}
+		if len(field.Labels) > 0 {
+			f["labels"] = field.Labels
+		}
+		fields = append(fields, f)
+	}
+
+	result := map[string]interface{}{
+		"name":   frame.Name,
+		"refId":  frame.RefID,
+		"fields": fields,
+	}
+	if frame.Meta != nil {
+		result["meta"] = frame.Meta
+	}
+	return result
+}
+
+// snapshotValue returns a field value as stored in JSON, times as epoch
+// milliseconds and non-finite numbers as null.
+func snapshotValue(field *data.Field, idx int) interface{} {
+	value, ok := field.ConcreteAt(idx)
+	if !ok {
+		return nil
+	}
+
+	switch v := value.(type) {
+	case time.Time:
+		return v.UnixNano() / int64(time.Millisecond)
+	case float64:
+		if math.IsNaN(v) || math.IsInf(v, 0) {
+			return nil
+		}
+	case float32:
+		if math.IsNaN(float64(v)) || math.IsInf(float64(v), 0) {
+			return nil
+		}
+	}
+	return value
+}
+
+func snapshotFieldType(fieldType data.FieldType) string {
+	switch {
+	case fieldType.Time():
+		return "time"
+	case fieldType.Numeric():
+		return "number"
+	case fieldType.NonNullableType() == data.FieldTypeString:
+		return "string"
+	case fieldType.NonNullableType() == data.FieldTypeBool:
+		return "boolean"
+	default:
+		return "other"
+	}
+}
+
+// snapshotVariableRegex matches $var, [[var:format]] and ${var.path:format}
+// template variable references.
+var snapshotVariableRegex = regexp.MustCompile(`\$(\w+)|\[\[(\w+?)(?::(\w+))?\]\]|\$\{(\w+)(?:\.([^:^\}]+))?(?::([^\}]+))?\}`)
+
+// snapshotVariables are the values of the template variables of a dashboard.
+type snapshotVariables map[string][]string
+
+// newSnapshotVariables returns the values of the dashboard template variables,
+// overridden by the requested values, and sets them as the only option of
+// the variables so that the snapshot shows them.
+func newSnapshotVariables(dash *simplejson.Json, overrides map[string]interface{}, from, to time.Time) snapshotVariables {
+	vars := snapshotVariables{
+		"__from": {strconv.FormatInt(from.UnixNano()/int64(time.Millisecond), 10)},
+		"__to":   {strconv.FormatInt(to.UnixNano()/int64(time.Millisecond), 10)},
+	}
+
+	for _, variableObj := range dash.GetPath("templating", "list").MustArray() {
+		variable := simplejson.NewFromAny(variableObj)
+		name := variable.Get("name").MustString()
+		if name == "" {
+			continue
+		}
+
+		var values []string
+		if override, ok := overrides[name]; ok {
+			values = variableValues(override)
+		} else {
+			values = variableValues(variable.GetPath("current", "value").Interface())
+		}
+
+		for _, value := range values {
+			if value != "$__all" {
+				continue
+			}
+			if allValue := variable.Get("allValue").MustString(); allValue != "" {
+				values = []string{allValue}
+				break
+			}
+			values = []string{}
+			for _, optionObj := range variable.Get("options").MustArray() {
+				option := simplejson.NewFromAny(optionObj)
+				if v := option.Get("value").MustString(); v != "$__all" {
+					values = append(values, v)
+				}
+			}
+			break
+		}
+		vars[name] = values
+
+		var current interface{} = values
+		if len(values) == 1 && !variable.Get("multi").MustBool() {
+			current = values[0]
+		}
+		variable.Set("current", map[string]interface{}{
+			"text":  strings.Join(values, " + "),
+			"value": current,
+		})
+		variable.Set("options", []interface{}{variable.Get("current").Interface()})
+		variable.Set("query", "")
+		variable.Set("refresh", 0)
+	}
+
+	return vars
+}
+
+func variableValues(value interface{}) []string {
+	switch value := value.(type) {
+	case string:
+		return []string{value}
+	case []interface{}:
+		values := make([]string, 0, len(value))
+		for _, v := range value {
+			values = append(values, fmt.Sprint(v))
+		}
+		return values
+	case []string:
+		return value
+	case nil:
+		return []string{}
+	default:
+		return []string{fmt.Sprint(value)}
+	}
+}
+
+// interpolate replaces the template variables of a string with their values.
+// References to unknown variables are kept.
+func (vars snapshotVariables) interpolate(s string) string {
+	if !strings.ContainsAny(s, "$[") {
+		return s
+	}
+
+	return snapshotVariableRegex.ReplaceAllStringFunc(s, func(match string) string {
+		groups := snapshotVariableRegex.FindStringSubmatch(match)
+		name, format := groups[1], ""
+		switch {
+		case groups[2] != "":
+			name, format = groups[2], groups[3]
+		case groups[4] != "":
+			name, format = groups[4], groups[6]
+		}
+
+		values, ok := vars[name]
+		if !ok {
+			return match
+		}
+		return formatVariableValues(values, format)
+	})
+}
+
+// interpolateValue returns a copy of a JSON value with the template variables
+// of all strings replaced.
+func (vars snapshotVariables) interpolateValue(value interface{}) interface{} {
+	switch value := value.(type) {
+	case string:
+		return vars.interpolate(value)
+	case map[string]interface{}:
+		result := make(map[string]interface{}, len(value))
+		for k, v := range value {
+			result[k] = vars.interpolateValue(v)
+		}
+		return result
+	case []interface{}:
+		result := make([]interface{}, len(value))
+		for i, v := range value {
+			result[i] = vars.interpolateValue(v)
+		}
+		return result
+	default:
+		return value
+	}
+}
+
+// formatVariableValues formats the values of a variable, multiple values are
+// formatted as a glob by default.
+func formatVariableValues(values []string, format string) string {
+	switch format {
+	case "csv", "raw":
+		return strings.Join(values, ",")
+	case "pipe":
+		return strings.Join(values, "|")
+	case "regex":
+		escaped := make([]string, len(values))
+		for i, v := range values {
+			escaped[i] = regexp.QuoteMeta(v)
+		}
+		if len(escaped) == 1 {
+			return escaped[0]
+		}
+		return "(" + strings.Join(escaped, "|") + ")"
+	default:
+		if len(values) == 1 {
+			return values[0]
+		}
+		return "{" + strings.Join(values, ",") + "}"
+	}
+}
@@ -0,0 +1,147 @@
+package api
+
+import (
+	"context"
+	"encoding/json"
+	"math"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/grafana/grafana-plugin-sdk-go/backend"
+	"github.com/grafana/grafana-plugin-sdk-go/data"
+	"github.com/grafana/grafana/pkg/bus"
+	"github.com/grafana/grafana/pkg/components/simplejson"
+	"github.com/grafana/grafana/pkg/infra/log"
+	"github.com/grafana/grafana/pkg/models"
+	"github.com/grafana/grafana/pkg/services/datasources"
+	"github.com/grafana/grafana/pkg/services/encryption/ossencryption"
+	"github.com/grafana/grafana/pkg/setting"
+	"github.com/grafana/grafana/pkg/tsdb"
+	"github.com/grafana/grafana/pkg/web"
+	"github.com/stretchr/testify/require"
+)
+
+func TestSnapshotPanels(t *testing.T) {
+	bus.ClearBusHandlers()
+	t.Cleanup(bus.ClearBusHandlers)
+	bus.AddHandlerCtx("test", func(ctx context.Context, query *models.GetDataSourceQuery) error {
+		if query.Name != "prometheus" {
+			return models.ErrDataSourceNotFound
+		}
+		query.Result = &models.DataSource{Id: 1, Name: "prometheus"}
+		return nil
+	})
+	bus.AddHandlerCtx("test", func(ctx context.Context, query *models.GetDefaultDataSourceQuery) error {
+		query.Result = &models.DataSource{Id: 2, Name: "loki"}
+		return nil
+	})
+
+	pm := &fakeQueryPluginManager{requests: map[int64]*backend.QueryDataRequest{}}
+	hs := &HTTPServer{
+		Cfg: setting.NewCfg(),
+		log: log.New("test"),
+		DataSourceCache: &fakeDataSourceCache{dataSources: map[int64]*models.DataSource{
+			1: {Id: 1, Name: "prometheus", Type: "prometheus", JsonData: simplejson.New()},
+			2: {Id: 2, Name: "loki", Type: "loki", JsonData: simplejson.New()},
+		}},
+		PluginRequestValidator: &fakeQueryRequestValidator{},
+		DataService: &tsdb.Service{
+			Cfg:                  setting.NewCfg(),
+			BackendPluginManager: pm,
+			OAuthTokenService:    &fakeQueryOAuthTokenService{},
+			DataSourcesService:   datasources.ProvideService(bus.New(), nil, ossencryption.ProvideService()),
+		},
+	}
+
+	httpReq, err := http.NewRequest(http.MethodPost, "/api/dashboards/uid/abc/snapshot", nil)
+	require.NoError(t, err)
+	c := &models.ReqContext{
+		Context:      &web.Context{Req: httpReq, Resp: web.NewResponseWriter(http.MethodPost, httptest.NewRecorder())},
+		SignedInUser: &models.SignedInUser{OrgId: 1},
+		Logger:       log.New("test"),
+	}
+
+	dash := simplejson.New()
+	require.NoError(t, dash.UnmarshalJSON([]byte(`{
+		"templating": {"list": [
+			{"name": "job", "multi": true, "current": {"value": ["api", "db"]}, "options": [], "query": "label_values(job)", "refresh": 1}
+		]},
+		"panels": [
+			{"id": 1, "datasource": "prometheus", "targets": [{"refId": "A", "expr": "up{job=~\"$job\"}"}, {"refId": "B", "hide": true}]},
+			{"id": 2, "type": "row", "collapsed": true, "panels": [
+				{"id": 3, "targets": [{"refId": "A", "expr": "{job=\"${job:regex}\"}"}]}
+			]},
+			{"id": 4, "datasource": "missing", "targets": [{"refId": "A"}]},
+			{"id": 5, "type": "text"}
+		]
+	}`)))
+
+	from := time.Unix(0, 0)
+	to := from.Add(time.Hour)
+	vars := newSnapshotVariables(dash, map[string]interface{}{"job": []interface{}{"api", "web"}}, from, to)
+	errs := hs.snapshotPanels(c, dash, from, to, vars)
+
+	require.Equal(t, []snapshotPanelError{{PanelID: 4, RefID: "A", Error: models.ErrDataSourceNotFound.Error()}}, errs)
+
+	require.Len(t, pm.requests[1].Queries, 1)
+	query := simplejson.New()
+	require.NoError(t, query.UnmarshalJSON(pm.requests[1].Queries[0].JSON))
+	require.Equal(t, `up{job=~"{api,web}"}`, query.Get("expr").MustString())
+	require.Equal(t, int64(3600), query.Get("intervalMs").MustInt64())
+
+	require.Len(t, pm.requests[2].Queries, 1)
+	require.NoError(t, query.UnmarshalJSON(pm.requests[2].Queries[0].JSON))
+	require.Equal(t, `{job="(api|web)"}`, query.Get("expr").MustString())
+
+	panel := dash.Get("panels").GetIndex(0)
+	require.Empty(t, panel.Get("targets").MustArray())
+	require.Nil(t, panel.Get("datasource").Interface())
+	snapshotData := panel.Get("snapshotData").MustArray()
+	require.Len(t, snapshotData, 1)
+	frame := simplejson.NewFromAny(snapshotData[0])
+	require.Equal(t, "prometheus", frame.Get("name").MustString())
+	require.Equal(t, "A", frame.Get("refId").MustString())
+
+	nested := dash.Get("panels").GetIndex(1).Get("panels").GetIndex(0)
+	require.Len(t, nested.Get("snapshotData").MustArray(), 1)
+
+	variable := dash.GetPath("templating", "list").GetIndex(0)
+	require.Equal(t, []string{"api", "web"}, variable.GetPath("current", "value").Interface())
+	require.Equal(t, "", variable.Get("query").MustString())
+	require.Len(t, variable.Get("options").MustArray(), 1)
+}
+
+func TestSnapshotFrame(t *testing.T) {
+	frame := data.NewFrame("cpu",
+		data.NewField("time", nil, []time.Time{time.Unix(1, 0), time.Unix(2, 0)}),
+		data.NewField("value", data.Labels{"host": "a"}, []*float64{nil, float64Ptr(math.NaN())}),
+	)
+	frame.RefID = "A"
+
+	raw, err := json.Marshal(snapshotFrame(frame))
+	require.NoError(t, err)
+	require.JSONEq(t, `{
+		"name": "cpu",
+		"refId": "A",
+		"fields": [
+			{"name": "time", "type": "time", "config": {}, "values": [1000, 2000]},
+			{"name": "value", "type": "number", "config": {}, "labels": {"host": "a"}, "values": [null, null]}
+		]
+	}`, string(raw))
+}
+
+func TestSnapshotVariablesInterpolate(t *testing.T) {
+	vars := snapshotVariables{"host": {"a", "b.c"}, "env": {"prod"}}
+
+	require.Equal(t, "prod {a,b.c}", vars.interpolate("$env $host"))
+	require.Equal(t, "prod a,b.c", vars.interpolate("${env} ${host:csv}"))
+	require.Equal(t, "a|b.c", vars.interpolate("[[host:pipe]]"))
+	require.Equal(t, `(a|b\.c)`, vars.interpolate("${host:regex}"))
+	require.Equal(t, "$unknown", vars.interpolate("$unknown"))
+}
+
+func float64Ptr(f float64) *float64 {
+	return &f
+}
@@ -89,7 +89,7 @@ type testState struct {
 
 func newTestLive(t *testing.T) *live.GrafanaLive {
 	cfg := &setting.Cfg{AppURL: "http://localhost:3000/"}
-	gLive, err := live.ProvideService(nil, cfg, routing.NewRouteRegister(), nil, nil, nil, nil, sqlstore.InitTestDB(t), &usagestats.UsageStatsMock{T: t})
+	gLive, err := live.ProvideService(nil, cfg, routing.NewRouteRegister(), nil, nil, nil, nil, sqlstore.InitTestDB(t), nil, &usagestats.UsageStatsMock{T: t})
 	require.NoError(t, err)
 	return gLive
 }
@@ -740,7 +740,8 @@ func TestDashboardAPIEndpoint(t *testing.T) {
 				{SaveError: models.ErrDashboardWithSameNameInFolderExists, ExpectedStatusCode: 412},
 				{SaveError: models.ErrDashboardVersionMismatch, ExpectedStatusCode: 412},
 				{SaveError: models.ErrDashboardTitleEmpty, ExpectedStatusCode: 400},
-				{SaveError: models.ErrDashboardFolderCannotHaveParent, ExpectedStatusCode: 400},
+				{SaveError: models.ErrDashboardFolderTooDeep, ExpectedStatusCode: 400},
+				{SaveError: models.ErrDashboardFolderCannotBeOwnAncestor, ExpectedStatusCode: 400},
 				{SaveError: alerting.ValidationError{Reason: "Mu"}, ExpectedStatusCode: 422},
 				{SaveError: models.ErrDashboardFailedGenerateUniqueUid, ExpectedStatusCode: 500},
 				{SaveError: models.ErrDashboardTypeMismatch, ExpectedStatusCode: 400},
@@ -1053,6 +1054,10 @@ func callGetDashboardVersion(sc *scenarioContext) {
 		query.Result = &models.DashboardVersion{}
 		return nil
 	})
+	bus.AddHandlerCtx("test", func(ctx context.Context, query *models.GetDashboardVersionLabelsQuery) error {
+		query.Result = []string{}
+		return nil
+	})
 
 	sc.handlerFunc = GetDashboardVersion
 	sc.fakeReqWithParams("GET", sc.url, map[string]string{}).exec()
@@ -1262,6 +1267,11 @@ func (l *mockLibraryElementService) GetElement(c context.Context, signedInUser *
 	return libraryelements.LibraryElementDTO{}, nil
 }
 
+// PatchElement updates a Library Element.
+func (l *mockLibraryElementService) PatchElement(c context.Context, signedInUser *models.SignedInUser, cmd libraryelements.PatchLibraryElementCommand, UID string) (libraryelements.LibraryElementDTO, error) {
+	return libraryelements.LibraryElementDTO{}, nil
+}
+
 // GetElementsForDashboard gets all connected elements for a specific dashboard.
 func (l *mockLibraryElementService) GetElementsForDashboard(c context.Context, dashboardID int64) (map[string]libraryelements.LibraryElementDTO, error) {
 	return map[string]libraryelements.LibraryElementDTO{}, nil
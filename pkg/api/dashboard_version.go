@@ -0,0 +1,217 @@
+package api
+
+import (
+	"errors"
+	"fmt"
+
+	"github.com/grafana/grafana/pkg/api/apierrors"
+	"github.com/grafana/grafana/pkg/api/dtos"
+	"github.com/grafana/grafana/pkg/api/response"
+	"github.com/grafana/grafana/pkg/bus"
+	"github.com/grafana/grafana/pkg/models"
+	"github.com/grafana/grafana/pkg/services/dashboards"
+	"github.com/grafana/grafana/pkg/services/guardian"
+	"github.com/grafana/grafana/pkg/util"
+	"github.com/grafana/grafana/pkg/web"
+)
+
+// GetDashboardVersionByLabel returns the dashboard version with the given label.
+func GetDashboardVersionByLabel(c *models.ReqContext) response.Response {
+	dashID := c.ParamsInt64(":dashboardId")
+
+	guardian := guardian.New(c.Req.Context(), dashID, c.OrgId, c.SignedInUser)
+	if canSave, err := guardian.CanSave(); err != nil || !canSave {
+		return dashboardGuardianResponse(err)
+	}
+
+	query := models.GetDashboardVersionByLabelQuery{
+		OrgId:       c.OrgId,
+		DashboardId: dashID,
+		Label:       web.Params(c.Req)[":label"],
+	}
+	if err := bus.DispatchCtx(c.Req.Context(), &query); err != nil {
+		return toDashboardVersionError(err)
+	}
+
+	return dashboardVersionMetaResponse(c, query.Result)
+}
+
+// AddDashboardVersionLabel adds a label to a dashboard version.
+func AddDashboardVersionLabel(c *models.ReqContext, apiCmd dtos.AddDashboardVersionLabelCommand) response.Response {
+	dashID := c.ParamsInt64(":dashboardId")
+
+	guardian := guardian.New(c.Req.Context(), dashID, c.OrgId, c.SignedInUser)
+	if canSave, err := guardian.CanSave(); err != nil || !canSave {
+		return dashboardGuardianResponse(err)
+	}
+
+	cmd := models.AddDashboardVersionLabelCommand{
+		OrgId:       c.OrgId,
+		DashboardId: dashID,
+		Version:     int(c.ParamsInt64(":id")),
+		Label:       apiCmd.Label,
+		UserId:      c.UserId,
+	}
+	if err := bus.DispatchCtx(c.Req.Context(), &cmd); err != nil {
+		return toDashboardVersionError(err)
+	}
+
+	return response.JSON(200, util.DynMap{
+		"message": fmt.Sprintf("Label %s added to version %d", cmd.Label, cmd.Version),
+		"label":   cmd.Label,
+		"version": cmd.Version,
+	})
+}
+
+// DeleteDashboardVersionLabel removes a label from a dashboard version.
+func DeleteDashboardVersionLabel(c *models.ReqContext) response.Response {
+	dashID := c.ParamsInt64(":dashboardId")
+
+	guardian := guardian.New(c.Req.Context(), dashID, c.OrgId, c.SignedInUser)
+	if canSave, err := guardian.CanSave(); err != nil || !canSave {
+		return dashboardGuardianResponse(err)
+	}
+
+	cmd := models.DeleteDashboardVersionLabelCommand{
+		OrgId:       c.OrgId,
+		DashboardId: dashID,
+		Label:       web.Params(c.Req)[":label"],
+	}
+	if err := bus.DispatchCtx(c.Req.Context(), &cmd); err != nil {
+		return toDashboardVersionError(err)
+	}
+
+	return response.Success("Label deleted")
+}
+
+// GetDashboardVersionRetention returns the version retention policy of a dashboard.
+func GetDashboardVersionRetention(c *models.ReqContext) response.Response {
+	dashID := c.ParamsInt64(":dashboardId")
+
+	guardian := guardian.New(c.Req.Context(), dashID, c.OrgId, c.SignedInUser)
+	if canSave, err := guardian.CanSave(); err != nil || !canSave {
+		return dashboardGuardianResponse(err)
+	}
+
+	return getVersionRetention(c, dashID)
+}
+
+// SetDashboardVersionRetention sets the version retention policy of a dashboard.
+func SetDashboardVersionRetention(c *models.ReqContext, apiCmd dtos.SetDashboardVersionRetentionCommand) response.Response {
+	dashID := c.ParamsInt64(":dashboardId")
+
+	guardian := guardian.New(c.Req.Context(), dashID, c.OrgId, c.SignedInUser)
+	if canAdmin, err := guardian.CanAdmin(); err != nil || !canAdmin {
+		return dashboardGuardianResponse(err)
+	}
+
+	return setVersionRetention(c, dashID, apiCmd)
+}
+
+// DeleteDashboardVersionRetention deletes the version retention policy of a dashboard.
+func DeleteDashboardVersionRetention(c *models.ReqContext) response.Response {
+	dashID := c.ParamsInt64(":dashboardId")
+
+	guardian := guardian.New(c.Req.Context(), dashID, c.OrgId, c.SignedInUser)
+	if canAdmin, err := guardian.CanAdmin(); err != nil || !canAdmin {
+		return dashboardGuardianResponse(err)
+	}
+
+	return deleteVersionRetention(c, dashID)
+}
+
+// GetFolderVersionRetention returns the version retention policy of a folder.
+func (hs *HTTPServer) GetFolderVersionRetention(c *models.ReqContext) response.Response {
+	folder, rsp := hs.getFolderForVersionRetention(c, false)
+	if rsp != nil {
+		return rsp
+	}
+	return getVersionRetention(c, folder.Id)
+}
+
+// SetFolderVersionRetention sets the version retention policy of a folder.
+func (hs *HTTPServer) SetFolderVersionRetention(c *models.ReqContext, apiCmd dtos.SetDashboardVersionRetentionCommand) response.Response {
+	folder, rsp := hs.getFolderForVersionRetention(c, true)
+	if rsp != nil {
+		return rsp
+	}
+	return setVersionRetention(c, folder.Id, apiCmd)
+}
+
+// DeleteFolderVersionRetention deletes the version retention policy of a folder.
+func (hs *HTTPServer) DeleteFolderVersionRetention(c *models.ReqContext) response.Response {
+	folder, rsp := hs.getFolderForVersionRetention(c, true)
+	if rsp != nil {
+		return rsp
+	}
+	return deleteVersionRetention(c, folder.Id)
+}
+
+// getFolderForVersionRetention returns the folder of the request if the user
+// can edit it, or administer it when admin is true.
+func (hs *HTTPServer) getFolderForVersionRetention(c *models.ReqContext, admin bool) (*models.Folder, response.Response) {
+	s := dashboards.NewFolderService(c.OrgId, c.SignedInUser, hs.SQLStore)
+	folder, err := s.GetFolderByUID(c.Req.Context(), web.Params(c.Req)[":uid"])
+	if err != nil {
+		return nil, apierrors.ToFolderErrorResponse(err)
+	}
+
+	g := guardian.New(c.Req.Context(), folder.Id, c.OrgId, c.SignedInUser)
+	allowed, err := g.CanSave()
+	if admin {
+		allowed, err = g.CanAdmin()
+	}
+	if err != nil {
+		return nil, apierrors.ToFolderErrorResponse(err)
+	}
+	if !allowed {
+		return nil, apierrors.ToFolderErrorResponse(models.ErrFolderAccessDenied)
+	}
+	return folder, nil
+}
+
+func getVersionRetention(c *models.ReqContext, dashID int64) response.Response {
+	query := models.GetDashboardVersionRetentionQuery{OrgId: c.OrgId, DashboardId: dashID}
+	if err := bus.DispatchCtx(c.Req.Context(), &query); err != nil {
+		return toDashboardVersionError(err)
+	}
+	return response.JSON(200, query.Result)
+}
+
+func setVersionRetention(c *models.ReqContext, dashID int64, apiCmd dtos.SetDashboardVersionRetentionCommand) response.Response {
+	cmd := models.SetDashboardVersionRetentionCommand{
+		OrgId:          c.OrgId,
+		DashboardId:    dashID,
+		Policy:         apiCmd.Policy,
+		VersionsToKeep: apiCmd.VersionsToKeep,
+		KeepFor:        apiCmd.KeepFor,
+	}
+	if err := bus.DispatchCtx(c.Req.Context(), &cmd); err != nil {
+		return toDashboardVersionError(err)
+	}
+	return response.JSON(200, cmd.Result)
+}
+
+func deleteVersionRetention(c *models.ReqContext, dashID int64) response.Response {
+	cmd := models.DeleteDashboardVersionRetentionCommand{OrgId: c.OrgId, DashboardId: dashID}
+	if err := bus.DispatchCtx(c.Req.Context(), &cmd); err != nil {
+		return toDashboardVersionError(err)
+	}
+	return response.Success("Version retention policy deleted")
+}
+
+func toDashboardVersionError(err error) response.Response {
+	switch {
+	case errors.Is(err, models.ErrDashboardNotFound),
+		errors.Is(err, models.ErrDashboardVersionNotFound),
+		errors.Is(err, models.ErrDashboardVersionLabelNotFound),
+		errors.Is(err, models.ErrDashboardVersionRetentionNotFound):
+		return response.Error(404, err.Error(), nil)
+	case errors.Is(err, models.ErrDashboardVersionLabelExists):
+		return response.Error(409, err.Error(), nil)
+	case errors.Is(err, models.ErrDashboardVersionLabelInvalid),
+		errors.Is(err, models.ErrDashboardVersionRetentionInvalid):
+		return response.Error(400, err.Error(), nil)
+	}
+	return response.Error(500, "Failed to update dashboard versions", err)
+}
@@ -0,0 +1,97 @@
+package api
+
+import (
+	"errors"
+
+	"github.com/grafana/grafana/pkg/api/dtos"
+	"github.com/grafana/grafana/pkg/api/response"
+	"github.com/grafana/grafana/pkg/bus"
+	"github.com/grafana/grafana/pkg/models"
+	"github.com/grafana/grafana/pkg/services/datasources"
+)
+
+func (hs *HTTPServer) GetDataSourcePermissions(c *models.ReqContext) response.Response {
+	ds, errResp := hs.getDataSourceForPermissions(c)
+	if errResp != nil {
+		return errResp
+	}
+
+	permissions, err := hs.DatasourcePermissionsService.GetPermissions(c.Req.Context(), ds)
+	if err != nil {
+		return response.Error(500, "Failed to get data source permissions", err)
+	}
+
+	return response.JSON(200, permissions)
+}
+
+func (hs *HTTPServer) AddDataSourcePermission(c *models.ReqContext, cmd dtos.AddDataSourcePermissionCommand) response.Response {
+	if _, ok := datasources.PermissionActions[cmd.Permission]; !ok {
+		return response.Error(400, "Permission must be Query or Admin", nil)
+	}
+	if (cmd.UserID == 0) == (cmd.TeamID == 0) {
+		return response.Error(400, "Either userId or teamId must be set", nil)
+	}
+
+	return hs.setDataSourcePermission(c, cmd.UserID, cmd.TeamID, cmd.Permission)
+}
+
+func (hs *HTTPServer) RemoveDataSourceUserPermission(c *models.ReqContext) response.Response {
+	return hs.setDataSourcePermission(c, c.ParamsInt64(":userId"), 0, "")
+}
+
+func (hs *HTTPServer) RemoveDataSourceTeamPermission(c *models.ReqContext) response.Response {
+	return hs.setDataSourcePermission(c, 0, c.ParamsInt64(":teamId"), "")
+}
+
+func (hs *HTTPServer) setDataSourcePermission(c *models.ReqContext, userID int64, teamID int64, permission string) response.Response {
+	ds, errResp := hs.getDataSourceForPermissions(c)
+	if errResp != nil {
+		return errResp
+	}
+
+	if userID != 0 {
+		query := models.GetSignedInUserQuery{UserId: userID, OrgId: c.OrgId}
+		if err := bus.DispatchCtx(c.Req.Context(), &query); err != nil || query.Result.OrgId != c.OrgId {
+			if err == nil || errors.Is(err, models.ErrUserNotFound) {
+				return response.Error(404, "User not found", nil)
+			}
+			return response.Error(500, "Failed to get user", err)
+		}
+	}
+	if teamID != 0 {
+		query := models.GetTeamByIdQuery{OrgId: c.OrgId, Id: teamID}
+		if err := bus.DispatchCtx(c.Req.Context(), &query); err != nil {
+			if errors.Is(err, models.ErrTeamNotFound) {
+				return response.Error(404, "Team not found", nil)
+			}
+			return response.Error(500, "Failed to get team", err)
+		}
+	}
+
+	if err := hs.DatasourcePermissionsService.SetPermission(c.Req.Context(), ds, userID, teamID, permission); err != nil {
+		return response.Error(500, "Failed to update data source permissions", err)
+	}
+
+	if permission == "" {
+		return response.Success("Data source permission removed")
+	}
+	return response.Success("Data source permission added")
+}
+
+// getDataSourceForPermissions loads the data source from the database, the data
+// source cache would check the query permission of the user.
+func (hs *HTTPServer) getDataSourceForPermissions(c *models.ReqContext) (*models.DataSource, response.Response) {
+	if hs.AccessControl.IsDisabled() {
+		return nil, response.Error(404, "Data source permissions require access control to be enabled", nil)
+	}
+
+	query := models.GetDataSourceQuery{Id: c.ParamsInt64(":id"), OrgId: c.OrgId}
+	if err := bus.DispatchCtx(c.Req.Context(), &query); err != nil {
+		if errors.Is(err, models.ErrDataSourceNotFound) {
+			return nil, response.Error(404, "Data source not found", nil)
+		}
+		return nil, response.Error(500, "Failed to query datasources", err)
+	}
+
+	return query.Result, nil
+}
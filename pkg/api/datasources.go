@@ -29,8 +29,17 @@ func (hs *HTTPServer) GetDataSources(c *models.ReqContext) response.Response {
 		return response.Error(500, "Failed to query datasources", err)
 	}
 
+	dataSources := query.Result
+	if hs.DatasourcePermissionsService != nil {
+		filtered, err := hs.DatasourcePermissionsService.FilterByQueryPermission(c.Req.Context(), c.SignedInUser, dataSources)
+		if err != nil {
+			return response.Error(500, "Failed to query datasources", err)
+		}
+		dataSources = filtered
+	}
+
 	result := make(dtos.DataSourceList, 0)
-	for _, ds := range query.Result {
+	for _, ds := range dataSources {
 		dsItem := dtos.DataSourceListItemDTO{
 			OrgId:     ds.OrgId,
 			Id:        ds.Id,
@@ -105,6 +114,8 @@ func (hs *HTTPServer) DeleteDataSourceById(c *models.ReqContext) response.Respon
 		return response.Error(403, "Cannot delete read-only data source", nil)
 	}
 
+	warning := hs.dataSourceDependentsWarning(c.Req.Context(), c.OrgId, ds.Uid)
+
 	cmd := &models.DeleteDataSourceCommand{ID: id, OrgID: c.OrgId}
 
 	err = bus.DispatchCtx(c.Req.Context(), cmd)
@@ -114,6 +125,12 @@ func (hs *HTTPServer) DeleteDataSourceById(c *models.ReqContext) response.Respon
 
 	hs.Live.HandleDatasourceDelete(c.OrgId, ds.Uid)
 
+	if warning != "" {
+		return response.JSON(200, util.DynMap{
+			"message": "Data source deleted",
+			"warning": warning,
+		})
+	}
 	return response.Success("Data source deleted")
 }
 
@@ -152,6 +169,8 @@ func (hs *HTTPServer) DeleteDataSourceByUID(c *models.ReqContext) response.Respo
 		return response.Error(403, "Cannot delete read-only data source", nil)
 	}
 
+	warning := hs.dataSourceDependentsWarning(c.Req.Context(), c.OrgId, ds.Uid)
+
 	cmd := &models.DeleteDataSourceCommand{UID: uid, OrgID: c.OrgId}
 
 	err = bus.DispatchCtx(c.Req.Context(), cmd)
@@ -161,6 +180,12 @@ func (hs *HTTPServer) DeleteDataSourceByUID(c *models.ReqContext) response.Respo
 
 	hs.Live.HandleDatasourceDelete(c.OrgId, ds.Uid)
 
+	if warning != "" {
+		return response.JSON(200, util.DynMap{
+			"message": "Data source deleted",
+			"warning": warning,
+		})
+	}
 	return response.Success("Data source deleted")
 }
 
@@ -183,6 +208,8 @@ func (hs *HTTPServer) DeleteDataSourceByName(c *models.ReqContext) response.Resp
 		return response.Error(403, "Cannot delete read-only data source", nil)
 	}
 
+	warning := hs.dataSourceDependentsWarning(c.Req.Context(), c.OrgId, getCmd.Result.Uid)
+
 	cmd := &models.DeleteDataSourceCommand{Name: name, OrgID: c.OrgId}
 	err := bus.DispatchCtx(c.Req.Context(), cmd)
 	if err != nil {
@@ -191,10 +218,36 @@ func (hs *HTTPServer) DeleteDataSourceByName(c *models.ReqContext) response.Resp
 
 	hs.Live.HandleDatasourceDelete(c.OrgId, getCmd.Result.Uid)
 
-	return response.JSON(200, util.DynMap{
+	result := util.DynMap{
 		"message": "Data source deleted",
 		"id":      getCmd.Result.Id,
-	})
+	}
+	if warning != "" {
+		result["warning"] = warning
+	}
+	return response.JSON(200, result)
+}
+
+// dataSourceDependentsWarning describes the dashboards and alert rules still
+// using a data source about to be deleted, or returns an empty string if none do.
+func (hs *HTTPServer) dataSourceDependentsWarning(ctx context.Context, orgID int64, uid string) string {
+	if hs.DependencyService == nil {
+		return ""
+	}
+
+	dependents, err := hs.DependencyService.GetDataSourceDependents(ctx, orgID, uid)
+	if err != nil {
+		datasourcesLogger.Warn("Failed to get data source dependents", "uid", uid, "error", err)
+		return ""
+	}
+	if dependents.Empty() {
+		return ""
+	}
+
+	datasourcesLogger.Warn("Deleting data source in use", "uid", uid,
+		"dashboards", len(dependents.Dashboards), "alertRules", len(dependents.AlertRules))
+	return fmt.Sprintf("Data source was used by %d dashboard(s) and %d alert rule(s)",
+		len(dependents.Dashboards), len(dependents.AlertRules))
 }
 
 func validateURL(tp string, u string) response.Response {
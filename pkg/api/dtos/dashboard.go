@@ -4,6 +4,7 @@ import (
 	"time"
 
 	"github.com/grafana/grafana/pkg/components/simplejson"
+	"github.com/grafana/grafana/pkg/models"
 )
 
 type DashboardMeta struct {
@@ -62,3 +63,13 @@ type CalculateDiffTarget struct {
 type RestoreDashboardVersionCommand struct {
 	Version int `json:"version" binding:"Required"`
 }
+
+type AddDashboardVersionLabelCommand struct {
+	Label string `json:"label" binding:"Required"`
+}
+
+type SetDashboardVersionRetentionCommand struct {
+	Policy         models.VersionRetentionPolicy `json:"policy" binding:"Required"`
+	VersionsToKeep int                           `json:"versionsToKeep"`
+	KeepFor        string                        `json:"keepFor"`
+}
@@ -0,0 +1,14 @@
+package dtos
+
+// CreateServerSideSnapshotCommand creates a snapshot of a dashboard by
+// executing its panel queries on the server.
+type CreateServerSideSnapshotCommand struct {
+	Name string `json:"name"`
+	// Expires is the number of seconds until the snapshot expires, 0 never expires.
+	Expires int64  `json:"expires"`
+	From    string `json:"from"`
+	To      string `json:"to"`
+	// Variables are the template variable values, either a string or a list
+	// of strings. Variables that aren't set use their current value.
+	Variables map[string]interface{} `json:"variables"`
+}
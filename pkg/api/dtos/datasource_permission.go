@@ -0,0 +1,7 @@
+package dtos
+
+type AddDataSourcePermissionCommand struct {
+	UserID     int64  `json:"userId"`
+	TeamID     int64  `json:"teamId"`
+	Permission string `json:"permission"`
+}
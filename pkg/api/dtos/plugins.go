@@ -25,6 +25,7 @@ type PluginSetting struct {
 	Signature     plugins.PluginSignatureStatus `json:"signature"`
 	SignatureType plugins.PluginSignatureType   `json:"signatureType"`
 	SignatureOrg  string                        `json:"signatureOrg"`
+	SignatureKey  string                        `json:"signatureKey,omitempty"`
 }
 
 type PluginListItem struct {
@@ -42,6 +43,7 @@ type PluginListItem struct {
 	Signature     plugins.PluginSignatureStatus `json:"signature"`
 	SignatureType plugins.PluginSignatureType   `json:"signatureType"`
 	SignatureOrg  string                        `json:"signatureOrg"`
+	SignatureKey  string                        `json:"signatureKey,omitempty"`
 }
 
 type PluginList []PluginListItem
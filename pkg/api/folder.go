@@ -28,9 +28,10 @@ func (hs *HTTPServer) GetFolders(c *models.ReqContext) response.Response {
 
 	for _, f := range folders {
 		result = append(result, dtos.FolderSearchHit{
-			Id:    f.Id,
-			Uid:   f.Uid,
-			Title: f.Title,
+			Id:        f.Id,
+			Uid:       f.Uid,
+			Title:     f.Title,
+			ParentUid: f.ParentUid,
 		})
 	}
 
@@ -61,7 +62,7 @@ func (hs *HTTPServer) GetFolderByID(c *models.ReqContext) response.Response {
 
 func (hs *HTTPServer) CreateFolder(c *models.ReqContext, cmd models.CreateFolderCommand) response.Response {
 	s := dashboards.NewFolderService(c.OrgId, c.SignedInUser, hs.SQLStore)
-	folder, err := s.CreateFolder(c.Req.Context(), cmd.Title, cmd.Uid)
+	folder, err := s.CreateFolder(c.Req.Context(), cmd.Title, cmd.Uid, cmd.ParentUid)
 	if err != nil {
 		return apierrors.ToFolderErrorResponse(err)
 	}
@@ -88,6 +89,17 @@ func (hs *HTTPServer) UpdateFolder(c *models.ReqContext, cmd models.UpdateFolder
 	return response.JSON(200, toFolderDto(c.Req.Context(), g, cmd.Result))
 }
 
+func (hs *HTTPServer) MoveFolder(c *models.ReqContext, cmd models.MoveFolderCommand) response.Response {
+	s := dashboards.NewFolderService(c.OrgId, c.SignedInUser, hs.SQLStore)
+	err := s.MoveFolder(c.Req.Context(), web.Params(c.Req)[":uid"], &cmd)
+	if err != nil {
+		return apierrors.ToFolderErrorResponse(err)
+	}
+
+	g := guardian.New(c.Req.Context(), cmd.Result.Id, c.OrgId, c.SignedInUser)
+	return response.JSON(200, toFolderDto(c.Req.Context(), g, cmd.Result))
+}
+
 func (hs *HTTPServer) DeleteFolder(c *models.ReqContext) response.Response { // temporarily adding this function to HTTPServer, will be removed from HTTPServer when librarypanels featuretoggle is removed
 	s := dashboards.NewFolderService(c.OrgId, c.SignedInUser, hs.SQLStore)
 	err := hs.LibraryElementService.DeleteLibraryElementsInFolder(c.Req.Context(), c.SignedInUser, web.Params(c.Req)[":uid"])
@@ -129,6 +141,7 @@ func toFolderDto(ctx context.Context, g guardian.DashboardGuardian, folder *mode
 		Uid:       folder.Uid,
 		Title:     folder.Title,
 		Url:       folder.Url,
+		ParentUid: folder.ParentUid,
 		HasAcl:    folder.HasAcl,
 		CanSave:   canSave,
 		CanEdit:   canEdit,
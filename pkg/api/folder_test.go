@@ -230,7 +230,7 @@ func (s *fakeFolderService) GetFolderByUID(ctx context.Context, uid string) (*mo
 	return s.GetFolderByUIDResult, s.GetFolderByUIDError
 }
 
-func (s *fakeFolderService) CreateFolder(ctx context.Context, title, uid string) (*models.Folder, error) {
+func (s *fakeFolderService) CreateFolder(ctx context.Context, title, uid, parentUID string) (*models.Folder, error) {
 	return s.CreateFolderResult, s.CreateFolderError
 }
 
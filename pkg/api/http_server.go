@@ -37,6 +37,7 @@ import (
 	"github.com/grafana/grafana/pkg/services/contexthandler"
 	"github.com/grafana/grafana/pkg/services/datasourceproxy"
 	"github.com/grafana/grafana/pkg/services/datasources"
+	"github.com/grafana/grafana/pkg/services/dependencies"
 	"github.com/grafana/grafana/pkg/services/encryption"
 	"github.com/grafana/grafana/pkg/services/hooks"
 	"github.com/grafana/grafana/pkg/services/libraryelements"
@@ -48,6 +49,7 @@ import (
 	"github.com/grafana/grafana/pkg/services/notifications"
 	"github.com/grafana/grafana/pkg/services/oauthtoken"
 	"github.com/grafana/grafana/pkg/services/provisioning"
+	"github.com/grafana/grafana/pkg/services/querycache"
 	"github.com/grafana/grafana/pkg/services/quota"
 	"github.com/grafana/grafana/pkg/services/rendering"
 	"github.com/grafana/grafana/pkg/services/schemaloader"
@@ -111,6 +113,10 @@ type HTTPServer struct {
 	tracingService         *tracing.TracingService
 	internalMetricsSvc     *metrics.InternalMetricsService
 	searchUsersService     searchusers.Service
+	QueryCache             *querycache.Service
+
+	DatasourcePermissionsService *datasources.PermissionsService
+	DependencyService            *dependencies.Service
 }
 
 type ServerOptions struct {
@@ -135,7 +141,8 @@ func ProvideHTTPServer(opts ServerOptions, cfg *setting.Cfg, routeRegister routi
 	internalMetricsSvc *metrics.InternalMetricsService, quotaService *quota.QuotaService,
 	socialService social.Service, oauthTokenService oauthtoken.OAuthTokenService,
 	encryptionService encryption.Service, searchUsersService searchusers.Service,
-	dataSourcesService *datasources.Service) (*HTTPServer, error) {
+	dataSourcesService *datasources.Service, queryCache *querycache.Service,
+	datasourcePermissionsService *datasources.PermissionsService, dependencyService *dependencies.Service) (*HTTPServer, error) {
 	web.Env = cfg.Env
 	m := web.New()
 
@@ -173,6 +180,7 @@ func ProvideHTTPServer(opts ServerOptions, cfg *setting.Cfg, routeRegister routi
 		LibraryPanelService:    libraryPanelService,
 		LibraryElementService:  libraryElementService,
 		QuotaService:           quotaService,
+		QueryCache:             queryCache,
 		notificationService:    notificationService,
 		tracingService:         tracingService,
 		internalMetricsSvc:     internalMetricsSvc,
@@ -184,6 +192,9 @@ func ProvideHTTPServer(opts ServerOptions, cfg *setting.Cfg, routeRegister routi
 		EncryptionService:      encryptionService,
 		DataSourcesService:     dataSourcesService,
 		searchUsersService:     searchUsersService,
+
+		DatasourcePermissionsService: datasourcePermissionsService,
+		DependencyService:            dependencyService,
 	}
 	if hs.Listener != nil {
 		hs.log.Debug("Using provided listener")
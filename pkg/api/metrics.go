@@ -2,69 +2,173 @@ package api
 
 import (
 	"errors"
+	"fmt"
 	"net/http"
+	"sync"
 
 	"github.com/grafana/grafana/pkg/tsdb/grafanads"
 
 	"github.com/grafana/grafana-plugin-sdk-go/backend"
 	"github.com/grafana/grafana/pkg/api/dtos"
 	"github.com/grafana/grafana/pkg/api/response"
+	"github.com/grafana/grafana/pkg/components/simplejson"
 	"github.com/grafana/grafana/pkg/expr"
 	"github.com/grafana/grafana/pkg/models"
 	"github.com/grafana/grafana/pkg/plugins"
+	"github.com/grafana/grafana/pkg/services/querycache"
 )
 
 // QueryMetricsV2 returns query metrics.
 // POST /api/ds/query   DataSource query w/ expressions
+//
+// Queries targeting different data sources are split by data source and
+// executed concurrently, an error of one data source is reported only for
+// its own queries.
 func (hs *HTTPServer) QueryMetricsV2(c *models.ReqContext, reqDTO dtos.MetricRequest) response.Response {
 	if len(reqDTO.Queries) == 0 {
 		return response.Error(http.StatusBadRequest, "No queries found in query", nil)
 	}
 
-	timeRange := plugins.NewDataTimeRange(reqDTO.From, reqDTO.To)
-	request := plugins.DataQuery{
-		TimeRange: &timeRange,
-		Debug:     reqDTO.Debug,
-		User:      c.SignedInUser,
-		Queries:   make([]plugins.DataSubQuery, 0, len(reqDTO.Queries)),
-	}
-
-	// Loop to see if we have an expression.
-	prevType := ""
-	var ds *models.DataSource
+	// Loop to see if we have an expression and group queries by data source.
+	var groups []*dataSourceQueries
+	groupsByID := map[int64]*dataSourceQueries{}
+	refIDs := map[string]struct{}{}
 	for _, query := range reqDTO.Queries {
 		dsType := query.Get("datasource").MustString("")
 		if dsType == expr.DatasourceName {
 			return hs.handleExpressions(c, reqDTO)
 		}
-		if prevType != "" && prevType != dsType {
-			// For mixed datasource case, each data source is sent in a single request.
-			// So only the datasource from the first query is needed. As all requests
-			// should be the same data source.
+
+		// require ID for everything
+		dsID, err := query.Get("datasourceId").Int64()
+		if err != nil {
 			hs.log.Debug("Can't process query since it's missing data source ID")
-			return response.Error(http.StatusBadRequest, "All queries must use the same datasource", nil)
+			return response.Error(http.StatusBadRequest, "Query missing data source ID", nil)
 		}
 
-		if ds == nil {
-			// require ID for everything
-			dsID, err := query.Get("datasourceId").Int64()
-			if err != nil {
-				hs.log.Debug("Can't process query since it's missing data source ID")
-				return response.Error(http.StatusBadRequest, "Query missing data source ID", nil)
+		group, ok := groupsByID[dsID]
+		if !ok {
+			group = &dataSourceQueries{id: dsID}
+			groupsByID[dsID] = group
+			groups = append(groups, group)
+		}
+		group.queries = append(group.queries, query)
+		refIDs[query.Get("refId").MustString("A")] = struct{}{}
+	}
+
+	if len(groups) == 1 {
+		group := groups[0]
+		ds, err := hs.getQueryDataSource(c, group.id)
+		if err != nil {
+			return hs.handleGetDataSourceError(err, group.id)
+		}
+
+		err = hs.PluginRequestValidator.Validate(ds.Url, nil)
+		if err != nil {
+			return response.Error(http.StatusForbidden, "Access denied", err)
+		}
+
+		qdr, cacheStatus, err := hs.queryDataSource(c, reqDTO, ds, group.queries)
+		if err != nil {
+			if errors.Is(err, models.ErrDataSourceAccessDenied) {
+				return hs.handleGetDataSourceError(err, group.id)
 			}
-			if dsID == grafanads.DatasourceID {
-				ds = grafanads.DataSourceModel(c.OrgId)
-			} else {
-				ds, err = hs.DataSourceCache.GetDatasource(dsID, c.SignedInUser, c.SkipCache)
-				if err != nil {
-					return hs.handleGetDataSourceError(err, dsID)
-				}
+			return response.Error(http.StatusInternalServerError, "Metric request error", err)
+		}
+		c.Resp.Header().Set(querycache.HeaderName, string(cacheStatus))
+		return toMacronResponse(c, qdr)
+	}
+
+	// Results of queries of different data sources are merged by refId, so
+	// refIds have to be unique.
+	if len(refIDs) != len(reqDTO.Queries) {
+		return response.Error(http.StatusBadRequest, "Query refIds must be unique when using multiple data sources", nil)
+	}
+
+	qdr, cacheStatus := hs.queryMixedDataSources(c, reqDTO, groups)
+	c.Resp.Header().Set(querycache.HeaderName, string(cacheStatus))
+	return toMacronResponse(c, qdr)
+}
+
+// dataSourceQueries are queries of a request targeting a single data source.
+type dataSourceQueries struct {
+	id      int64
+	queries []*simplejson.Json
+}
+
+// queryMixedDataSources executes queries of every data source concurrently
+// and merges their responses. The cache status is a miss if results of any
+// data source weren't cached.
+func (hs *HTTPServer) queryMixedDataSources(c *models.ReqContext, reqDTO dtos.MetricRequest,
+	groups []*dataSourceQueries) (*backend.QueryDataResponse, querycache.Status) {
+	responses := make([]*backend.QueryDataResponse, len(groups))
+	statuses := make([]querycache.Status, len(groups))
+
+	var wg sync.WaitGroup
+	for i, group := range groups {
+		ds, err := hs.getQueryDataSource(c, group.id)
+		if err != nil {
+			hs.log.Debug("Encountered error getting data source", "err", err, "id", group.id)
+			responses[i] = errorDataResponse(group.queries, errors.New(dataSourceErrorMessage(err)))
+			continue
+		}
+
+		if err := hs.PluginRequestValidator.Validate(ds.Url, nil); err != nil {
+			hs.log.Debug("Data source request denied", "err", err, "id", group.id)
+			responses[i] = errorDataResponse(group.queries, errors.New("access denied"))
+			continue
+		}
+
+		wg.Add(1)
+		go func(i int, ds *models.DataSource, queries []*simplejson.Json) {
+			defer wg.Done()
+			qdr, cacheStatus, err := hs.queryDataSource(c, reqDTO, ds, queries)
+			if err != nil {
+				hs.log.Error("Metric request error", "err", err, "datasource", ds.Name)
+				qdr = errorDataResponse(queries, fmt.Errorf("metric request error: %w", err))
 			}
+			responses[i] = qdr
+			statuses[i] = cacheStatus
+		}(i, ds, group.queries)
+	}
+	wg.Wait()
+
+	merged := backend.NewQueryDataResponse()
+	for _, qdr := range responses {
+		for refID, res := range qdr.Responses {
+			merged.Responses[refID] = res
 		}
-		prevType = dsType
 	}
 
-	for _, query := range reqDTO.Queries {
+	cacheStatus := querycache.StatusBypass
+	for _, status := range statuses {
+		if status == querycache.StatusMiss || (status == querycache.StatusHit && cacheStatus == querycache.StatusBypass) {
+			cacheStatus = status
+		}
+	}
+	return merged, cacheStatus
+}
+
+func (hs *HTTPServer) getQueryDataSource(c *models.ReqContext, dsID int64) (*models.DataSource, error) {
+	if dsID == grafanads.DatasourceID {
+		return grafanads.DataSourceModel(c.OrgId), nil
+	}
+	return hs.DataSourceCache.GetDatasource(dsID, c.SignedInUser, c.SkipCache)
+}
+
+// queryDataSource executes queries targeting a single data source, results
+// are served from the query cache if the data source enables caching.
+func (hs *HTTPServer) queryDataSource(c *models.ReqContext, reqDTO dtos.MetricRequest, ds *models.DataSource,
+	queries []*simplejson.Json) (*backend.QueryDataResponse, querycache.Status, error) {
+	timeRange := plugins.NewDataTimeRange(reqDTO.From, reqDTO.To)
+	request := plugins.DataQuery{
+		TimeRange: &timeRange,
+		Debug:     reqDTO.Debug,
+		User:      c.SignedInUser,
+		Queries:   make([]plugins.DataSubQuery, 0, len(queries)),
+	}
+
+	for _, query := range queries {
 		hs.log.Debug("Processing metrics query", "query", query)
 
 		request.Queries = append(request.Queries, plugins.DataSubQuery{
@@ -77,32 +181,44 @@ func (hs *HTTPServer) QueryMetricsV2(c *models.ReqContext, reqDTO dtos.MetricReq
 		})
 	}
 
-	err := hs.PluginRequestValidator.Validate(ds.Url, nil)
-	if err != nil {
-		return response.Error(http.StatusForbidden, "Access denied", err)
+	query := func() (*backend.QueryDataResponse, error) {
+		resp, err := hs.DataService.HandleRequest(c.Req.Context(), ds, request)
+		if err != nil {
+			return nil, err
+		}
+
+		// This is insanity... but ¯\_(ツ)_/¯, the current query path looks like:
+		//  encodeJson( decodeBase64( encodeBase64( decodeArrow( encodeArrow(frame)) ) )
+		// this will soon change to a more direct route
+		qdr, err := resp.ToBackendDataResponse()
+		if err != nil {
+			return nil, fmt.Errorf("error converting results: %w", err)
+		}
+		return qdr, nil
 	}
 
-	resp, err := hs.DataService.HandleRequest(c.Req.Context(), ds, request)
-	if err != nil {
-		return response.Error(http.StatusInternalServerError, "Metric request error", err)
+	if hs.QueryCache == nil {
+		qdr, err := query()
+		return qdr, querycache.StatusBypass, err
 	}
+	return hs.QueryCache.Query(c.Req.Context(), ds, request, query)
+}
 
-	// This is insanity... but ¯\_(ツ)_/¯, the current query path looks like:
-	//  encodeJson( decodeBase64( encodeBase64( decodeArrow( encodeArrow(frame)) ) )
-	// this will soon change to a more direct route
-	qdr, err := resp.ToBackendDataResponse()
-	if err != nil {
-		return response.Error(http.StatusInternalServerError, "error converting results", err)
+// errorDataResponse returns a response with the same error for every query.
+func errorDataResponse(queries []*simplejson.Json, err error) *backend.QueryDataResponse {
+	qdr := backend.NewQueryDataResponse()
+	for _, query := range queries {
+		qdr.Responses[query.Get("refId").MustString("A")] = backend.DataResponse{Error: err}
 	}
-	return toMacronResponse(qdr)
+	return qdr
 }
 
-func toMacronResponse(qdr *backend.QueryDataResponse) response.Response {
-	statusCode := http.StatusOK
-	for _, res := range qdr.Responses {
-		if res.Error != nil {
-			statusCode = http.StatusBadRequest
-		}
+// toMacronResponse encodes query results as JSON, or as Arrow IPC streams
+// when the client accepts them.
+func toMacronResponse(c *models.ReqContext, qdr *backend.QueryDataResponse) response.Response {
+	statusCode := queryDataStatusCode(qdr)
+	if acceptsArrowStream(c) {
+		return arrowStreamResponse{status: statusCode, qdr: qdr}
 	}
 
 	return response.JSONStreaming(statusCode, qdr)
@@ -110,14 +226,6 @@ func toMacronResponse(qdr *backend.QueryDataResponse) response.Response {
 
 // handleExpressions handles POST /api/ds/query when there is an expression.
 func (hs *HTTPServer) handleExpressions(c *models.ReqContext, reqDTO dtos.MetricRequest) response.Response {
-	timeRange := plugins.NewDataTimeRange(reqDTO.From, reqDTO.To)
-	request := plugins.DataQuery{
-		TimeRange: &timeRange,
-		Debug:     reqDTO.Debug,
-		User:      c.SignedInUser,
-		Queries:   make([]plugins.DataSubQuery, 0, len(reqDTO.Queries)),
-	}
-
 	for _, query := range reqDTO.Queries {
 		hs.log.Debug("Processing metrics query", "query", query)
 		name := query.Get("datasource").MustString("")
@@ -135,7 +243,27 @@ func (hs *HTTPServer) handleExpressions(c *models.ReqContext, reqDTO dtos.Metric
 				return hs.handleGetDataSourceError(err, datasourceID)
 			}
 		}
+	}
+
+	qdr, err := hs.transformData(c, reqDTO)
+	if err != nil {
+		return response.Error(500, "expression request error", err)
+	}
+	return toMacronResponse(c, qdr)
+}
 
+// transformData executes queries containing expressions through the
+// expression service.
+func (hs *HTTPServer) transformData(c *models.ReqContext, reqDTO dtos.MetricRequest) (*backend.QueryDataResponse, error) {
+	timeRange := plugins.NewDataTimeRange(reqDTO.From, reqDTO.To)
+	request := plugins.DataQuery{
+		TimeRange: &timeRange,
+		Debug:     reqDTO.Debug,
+		User:      c.SignedInUser,
+		Queries:   make([]plugins.DataSubQuery, 0, len(reqDTO.Queries)),
+	}
+
+	for _, query := range reqDTO.Queries {
 		request.Queries = append(request.Queries, plugins.DataSubQuery{
 			RefID:         query.Get("refId").MustString("A"),
 			MaxDataPoints: query.Get("maxDataPoints").MustInt64(100),
@@ -149,22 +277,28 @@ func (hs *HTTPServer) handleExpressions(c *models.ReqContext, reqDTO dtos.Metric
 		Cfg:         hs.Cfg,
 		DataService: hs.DataService,
 	}
-	qdr, err := exprService.WrapTransformData(c.Req.Context(), request)
-	if err != nil {
-		return response.Error(500, "expression request error", err)
-	}
-	return toMacronResponse(qdr)
+	return exprService.WrapTransformData(c.Req.Context(), request)
 }
 
 func (hs *HTTPServer) handleGetDataSourceError(err error, datasourceID int64) *response.NormalResponse {
 	hs.log.Debug("Encountered error getting data source", "err", err, "id", datasourceID)
 	if errors.Is(err, models.ErrDataSourceAccessDenied) {
-		return response.Error(403, "Access denied to data source", err)
+		return response.Error(403, dataSourceErrorMessage(err), err)
+	}
+	if errors.Is(err, models.ErrDataSourceNotFound) {
+		return response.Error(400, dataSourceErrorMessage(err), err)
+	}
+	return response.Error(500, dataSourceErrorMessage(err), err)
+}
+
+func dataSourceErrorMessage(err error) string {
+	if errors.Is(err, models.ErrDataSourceAccessDenied) {
+		return "Access denied to data source"
 	}
 	if errors.Is(err, models.ErrDataSourceNotFound) {
-		return response.Error(400, "Invalid data source ID", err)
+		return "Invalid data source ID"
 	}
-	return response.Error(500, "Unable to load data source metadata", err)
+	return "Unable to load data source metadata"
 }
 
 // QueryMetrics returns query metrics
@@ -0,0 +1,291 @@
+package api
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"mime/multipart"
+	"net/http"
+	"net/textproto"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/apache/arrow/go/arrow"
+	"github.com/apache/arrow/go/arrow/array"
+	"github.com/apache/arrow/go/arrow/ipc"
+	"github.com/apache/arrow/go/arrow/memory"
+	"github.com/grafana/grafana-plugin-sdk-go/backend"
+	"github.com/grafana/grafana-plugin-sdk-go/data"
+	"github.com/grafana/grafana/pkg/models"
+)
+
+// arrowStreamContentType is the media type of the Arrow IPC streaming format.
+const arrowStreamContentType = "application/vnd.apache.arrow.stream"
+
+// Schema metadata keys identifying the query a frame belongs to.
+const (
+	arrowRefIDMetadataKey = "refId"
+	arrowErrorMetadataKey = "error"
+)
+
+func acceptsArrowStream(c *models.ReqContext) bool {
+	return strings.Contains(c.Req.Header.Get("Accept"), arrowStreamContentType)
+}
+
+// arrowStreamResponse writes query results as a multipart/mixed body. Every
+// part has the application/vnd.apache.arrow.stream content type and holds
+// a single Arrow IPC stream encoding one frame, since frames have different
+// schemas. Parts are ordered by refId. The schema metadata of every stream
+// holds the name, meta, refId and error of the query the frame belongs to,
+// queries failing without returning any frames are written as a stream
+// without fields.
+type arrowStreamResponse struct {
+	status int
+	qdr    *backend.QueryDataResponse
+}
+
+// Status gets the response's status.
+// Required to implement api.Response.
+func (r arrowStreamResponse) Status() int {
+	return r.status
+}
+
+// Body gets the response's body.
+// Required to implement api.Response.
+func (r arrowStreamResponse) Body() []byte {
+	return nil
+}
+
+// WriteTo writes the response to the provided context. Frames are encoded
+// into a buffer first, so encoding errors can still be reported with
+// a 500 status code.
+// Required to implement api.Response.
+func (r arrowStreamResponse) WriteTo(ctx *models.ReqContext) {
+	var buf bytes.Buffer
+	mw := multipart.NewWriter(&buf)
+	if err := writeArrowStreams(mw, r.qdr); err != nil {
+		ctx.JsonApiErr(http.StatusInternalServerError, "Failed to encode query results", err)
+		return
+	}
+
+	ctx.Resp.Header().Set("Content-Type", "multipart/mixed; boundary="+mw.Boundary())
+	ctx.Resp.WriteHeader(r.status)
+	if _, err := buf.WriteTo(ctx.Resp); err != nil {
+		ctx.Logger.Error("Error writing to response", "err", err)
+	}
+}
+
+func writeArrowStreams(mw *multipart.Writer, qdr *backend.QueryDataResponse) error {
+	refIDs := make([]string, 0, len(qdr.Responses))
+	for refID := range qdr.Responses {
+		refIDs = append(refIDs, refID)
+	}
+	sort.Strings(refIDs)
+
+	header := textproto.MIMEHeader{}
+	header.Set("Content-Type", arrowStreamContentType)
+	for _, refID := range refIDs {
+		res := qdr.Responses[refID]
+		if len(res.Frames) == 0 && res.Error == nil {
+			continue
+		}
+
+		frames := res.Frames
+		if len(frames) == 0 {
+			frames = data.Frames{data.NewFrame("")}
+		}
+		for _, frame := range frames {
+			part, err := mw.CreatePart(header)
+			if err != nil {
+				return err
+			}
+			if err := writeArrowStream(part, frame, refID, res.Error); err != nil {
+				return fmt.Errorf("failed to encode frame of query %q: %w", refID, err)
+			}
+		}
+	}
+	return mw.Close()
+}
+
+// writeArrowStream encodes a frame as an Arrow IPC stream with a single
+// record. Fields are encoded like the plugin SDK encodes frames, so the
+// stream can be decoded by Grafana's Arrow readers.
+func writeArrowStream(w io.Writer, frame *data.Frame, refID string, queryErr error) error {
+	rows, err := frame.RowLen()
+	if err != nil {
+		return err
+	}
+
+	schema, err := arrowSchema(frame, refID, queryErr)
+	if err != nil {
+		return err
+	}
+
+	writer := ipc.NewWriter(w, ipc.WithSchema(schema))
+	if len(frame.Fields) > 0 {
+		record, err := arrowRecord(schema, frame, rows)
+		if err != nil {
+			return err
+		}
+		err = writer.Write(record)
+		record.Release()
+		if err != nil {
+			return err
+		}
+	}
+	return writer.Close()
+}
+
+func arrowSchema(frame *data.Frame, refID string, queryErr error) (*arrow.Schema, error) {
+	fields := make([]arrow.Field, len(frame.Fields))
+	for i, field := range frame.Fields {
+		dataType, err := arrowDataType(field.Type())
+		if err != nil {
+			return nil, fmt.Errorf("field %q: %w", field.Name, err)
+		}
+
+		fieldMeta := map[string]string{"name": field.Name}
+		if field.Labels != nil {
+			if fieldMeta["labels"], err = arrowJSONMetadata(field.Labels); err != nil {
+				return nil, err
+			}
+		}
+		if field.Config != nil {
+			if fieldMeta["config"], err = arrowJSONMetadata(field.Config); err != nil {
+				return nil, err
+			}
+		}
+
+		fields[i] = arrow.Field{
+			Name:     field.Name,
+			Type:     dataType,
+			Nullable: field.Type().Nullable(),
+			Metadata: arrow.MetadataFrom(fieldMeta),
+		}
+	}
+
+	keys := []string{"name", arrowRefIDMetadataKey}
+	values := []string{frame.Name, refID}
+	if frame.Meta != nil {
+		meta, err := arrowJSONMetadata(frame.Meta)
+		if err != nil {
+			return nil, err
+		}
+		keys = append(keys, "meta")
+		values = append(values, meta)
+	}
+	if queryErr != nil {
+		keys = append(keys, arrowErrorMetadataKey)
+		values = append(values, queryErr.Error())
+	}
+	metadata := arrow.NewMetadata(keys, values)
+	return arrow.NewSchema(fields, &metadata), nil
+}
+
+func arrowDataType(fieldType data.FieldType) (arrow.DataType, error) {
+	switch fieldType.NonNullableType() {
+	case data.FieldTypeInt8:
+		return arrow.PrimitiveTypes.Int8, nil
+	case data.FieldTypeInt16:
+		return arrow.PrimitiveTypes.Int16, nil
+	case data.FieldTypeInt32:
+		return arrow.PrimitiveTypes.Int32, nil
+	case data.FieldTypeInt64:
+		return arrow.PrimitiveTypes.Int64, nil
+	case data.FieldTypeUint8:
+		return arrow.PrimitiveTypes.Uint8, nil
+	case data.FieldTypeUint16:
+		return arrow.PrimitiveTypes.Uint16, nil
+	case data.FieldTypeUint32:
+		return arrow.PrimitiveTypes.Uint32, nil
+	case data.FieldTypeUint64:
+		return arrow.PrimitiveTypes.Uint64, nil
+	case data.FieldTypeFloat32:
+		return arrow.PrimitiveTypes.Float32, nil
+	case data.FieldTypeFloat64:
+		return arrow.PrimitiveTypes.Float64, nil
+	case data.FieldTypeString:
+		return arrow.BinaryTypes.String, nil
+	case data.FieldTypeBool:
+		return arrow.FixedWidthTypes.Boolean, nil
+	case data.FieldTypeTime:
+		return &arrow.TimestampType{Unit: arrow.Nanosecond}, nil
+	default:
+		return nil, fmt.Errorf("unsupported field type %s", fieldType)
+	}
+}
+
+// arrowRecord builds a record holding all rows of the frame.
+func arrowRecord(schema *arrow.Schema, frame *data.Frame, rows int) (array.Record, error) {
+	builder := array.NewRecordBuilder(memory.NewGoAllocator(), schema)
+	defer builder.Release()
+
+	for i, field := range frame.Fields {
+		fb := builder.Field(i)
+		fb.Reserve(rows)
+		for row := 0; row < rows; row++ {
+			value, ok := field.ConcreteAt(row)
+			if !ok {
+				fb.AppendNull()
+				continue
+			}
+			if err := appendArrowValue(fb, value); err != nil {
+				return nil, fmt.Errorf("field %q: %w", field.Name, err)
+			}
+		}
+	}
+	return builder.NewRecord(), nil
+}
+
+func appendArrowValue(b array.Builder, value interface{}) error {
+	switch b := b.(type) {
+	case *array.Int8Builder:
+		b.Append(value.(int8))
+	case *array.Int16Builder:
+		b.Append(value.(int16))
+	case *array.Int32Builder:
+		b.Append(value.(int32))
+	case *array.Int64Builder:
+		b.Append(value.(int64))
+	case *array.Uint8Builder:
+		b.Append(value.(uint8))
+	case *array.Uint16Builder:
+		b.Append(value.(uint16))
+	case *array.Uint32Builder:
+		b.Append(value.(uint32))
+	case *array.Uint64Builder:
+		b.Append(value.(uint64))
+	case *array.Float32Builder:
+		b.Append(value.(float32))
+	case *array.Float64Builder:
+		b.Append(value.(float64))
+	case *array.StringBuilder:
+		b.Append(value.(string))
+	case *array.BooleanBuilder:
+		b.Append(value.(bool))
+	case *array.TimestampBuilder:
+		b.Append(arrow.Timestamp(value.(time.Time).UnixNano()))
+	default:
+		return fmt.Errorf("unsupported arrow builder %T", b)
+	}
+	return nil
+}
+
+func arrowJSONMetadata(v interface{}) (string, error) {
+	b, err := json.Marshal(v)
+	if err != nil {
+		return "", err
+	}
+	return string(b), nil
+}
+
+func queryDataStatusCode(qdr *backend.QueryDataResponse) int {
+	for _, res := range qdr.Responses {
+		if res.Error != nil {
+			return http.StatusBadRequest
+		}
+	}
+	return http.StatusOK
+}
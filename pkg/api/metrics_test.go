@@ -0,0 +1,306 @@
+package api
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"io"
+	"mime"
+	"mime/multipart"
+	"net/http"
+	"net/http/httptest"
+	"sort"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/apache/arrow/go/arrow"
+	"github.com/apache/arrow/go/arrow/array"
+	"github.com/apache/arrow/go/arrow/ipc"
+	"github.com/grafana/grafana-plugin-sdk-go/backend"
+	"github.com/grafana/grafana-plugin-sdk-go/data"
+	"github.com/grafana/grafana/pkg/api/dtos"
+	"github.com/grafana/grafana/pkg/bus"
+	"github.com/grafana/grafana/pkg/components/simplejson"
+	"github.com/grafana/grafana/pkg/infra/log"
+	"github.com/grafana/grafana/pkg/models"
+	"github.com/grafana/grafana/pkg/plugins/backendplugin"
+	"github.com/grafana/grafana/pkg/services/datasources"
+	"github.com/grafana/grafana/pkg/services/encryption/ossencryption"
+	"github.com/grafana/grafana/pkg/setting"
+	"github.com/grafana/grafana/pkg/tsdb"
+	"github.com/grafana/grafana/pkg/web"
+	"github.com/stretchr/testify/require"
+	"golang.org/x/oauth2"
+)
+
+type fakeDataSourceCache struct {
+	dataSources map[int64]*models.DataSource
+}
+
+func (c *fakeDataSourceCache) GetDatasource(id int64, user *models.SignedInUser, skipCache bool) (*models.DataSource, error) {
+	if id == 403 {
+		return nil, models.ErrDataSourceAccessDenied
+	}
+	if ds, ok := c.dataSources[id]; ok {
+		return ds, nil
+	}
+	return nil, models.ErrDataSourceNotFound
+}
+
+func (c *fakeDataSourceCache) GetDatasourceByUID(uid string, user *models.SignedInUser, skipCache bool) (*models.DataSource, error) {
+	return nil, models.ErrDataSourceNotFound
+}
+
+type fakeQueryPluginManager struct {
+	backendplugin.Manager
+
+	mu       sync.Mutex
+	requests map[int64]*backend.QueryDataRequest
+}
+
+func (m *fakeQueryPluginManager) QueryData(ctx context.Context, req *backend.QueryDataRequest) (*backend.QueryDataResponse, error) {
+	m.mu.Lock()
+	m.requests[req.PluginContext.DataSourceInstanceSettings.ID] = req
+	m.mu.Unlock()
+
+	if req.PluginContext.PluginID == "failing" {
+		return nil, errors.New("plugin failed")
+	}
+	resp := backend.NewQueryDataResponse()
+	for _, q := range req.Queries {
+		frame := data.NewFrame(req.PluginContext.DataSourceInstanceSettings.Name,
+			data.NewField("value", nil, []float64{1, 2, 3}))
+		frame.RefID = q.RefID
+		resp.Responses[q.RefID] = backend.DataResponse{Frames: data.Frames{frame}}
+	}
+	return resp, nil
+}
+
+type fakeQueryOAuthTokenService struct{}
+
+func (s *fakeQueryOAuthTokenService) GetCurrentOAuthToken(context.Context, *models.SignedInUser) *oauth2.Token {
+	return nil
+}
+
+func (s *fakeQueryOAuthTokenService) IsOAuthPassThruEnabled(*models.DataSource) bool {
+	return false
+}
+
+type fakeQueryRequestValidator struct{}
+
+func (v *fakeQueryRequestValidator) Validate(dsURL string, req *http.Request) error {
+	if dsURL == "http://denied" {
+		return errors.New("denied")
+	}
+	return nil
+}
+
+func TestQueryMetricsV2(t *testing.T) {
+	pm := &fakeQueryPluginManager{}
+	hs := &HTTPServer{
+		Cfg: setting.NewCfg(),
+		log: log.New("test"),
+		DataSourceCache: &fakeDataSourceCache{dataSources: map[int64]*models.DataSource{
+			1: {Id: 1, Name: "prometheus", Type: "prometheus", JsonData: simplejson.New()},
+			2: {Id: 2, Name: "loki", Type: "loki", JsonData: simplejson.New()},
+			3: {Id: 3, Name: "failing", Type: "failing", JsonData: simplejson.New()},
+			4: {Id: 4, Name: "denied", Type: "prometheus", Url: "http://denied", JsonData: simplejson.New()},
+		}},
+		PluginRequestValidator: &fakeQueryRequestValidator{},
+		DataService: &tsdb.Service{
+			Cfg:                  setting.NewCfg(),
+			BackendPluginManager: pm,
+			OAuthTokenService:    &fakeQueryOAuthTokenService{},
+			DataSourcesService:   datasources.ProvideService(bus.New(), nil, ossencryption.ProvideService()),
+		},
+	}
+
+	query := func(t *testing.T, queries ...*simplejson.Json) (int, map[string]map[string]interface{}) {
+		t.Helper()
+		pm.requests = map[int64]*backend.QueryDataRequest{}
+
+		httpReq, err := http.NewRequest(http.MethodPost, "/api/ds/query", nil)
+		require.NoError(t, err)
+		recorder := httptest.NewRecorder()
+		c := &models.ReqContext{
+			Context:      &web.Context{Req: httpReq, Resp: web.NewResponseWriter(http.MethodPost, recorder)},
+			SignedInUser: &models.SignedInUser{OrgId: 1},
+			Logger:       log.New("test"),
+		}
+		hs.QueryMetricsV2(c, dtos.MetricRequest{From: "now-1h", To: "now", Queries: queries}).WriteTo(c)
+
+		var body struct {
+			Results map[string]map[string]interface{} `json:"results"`
+		}
+		require.NoError(t, json.Unmarshal(recorder.Body.Bytes(), &body))
+		return recorder.Code, body.Results
+	}
+
+	newQuery := func(refID string, dsID int64) *simplejson.Json {
+		return simplejson.NewFromAny(map[string]interface{}{"refId": refID, "datasourceId": dsID})
+	}
+
+	frameNames := func(results map[string]map[string]interface{}) map[string]string {
+		names := map[string]string{}
+		for refID, res := range results {
+			frames, ok := res["frames"].([]interface{})
+			if !ok || len(frames) == 0 {
+				continue
+			}
+			schema := frames[0].(map[string]interface{})["schema"].(map[string]interface{})
+			names[refID] = schema["name"].(string)
+		}
+		return names
+	}
+
+	t.Run("Queries of a single data source", func(t *testing.T) {
+		code, results := query(t, newQuery("A", 1), newQuery("B", 1))
+		require.Equal(t, http.StatusOK, code)
+		require.Equal(t, map[string]string{"A": "prometheus", "B": "prometheus"}, frameNames(results))
+		require.Len(t, pm.requests, 1)
+		require.Len(t, pm.requests[1].Queries, 2)
+	})
+
+	t.Run("Queries of mixed data sources are split by data source", func(t *testing.T) {
+		code, results := query(t, newQuery("A", 1), newQuery("B", 2), newQuery("C", 1))
+		require.Equal(t, http.StatusOK, code)
+		require.Equal(t, map[string]string{"A": "prometheus", "B": "loki", "C": "prometheus"}, frameNames(results))
+		require.Len(t, pm.requests, 2)
+
+		var refIDs []string
+		for _, q := range pm.requests[1].Queries {
+			refIDs = append(refIDs, q.RefID)
+		}
+		sort.Strings(refIDs)
+		require.Equal(t, []string{"A", "C"}, refIDs)
+		require.Len(t, pm.requests[2].Queries, 1)
+	})
+
+	t.Run("Errors of a data source are isolated to its queries", func(t *testing.T) {
+		code, results := query(t, newQuery("A", 1), newQuery("B", 3), newQuery("C", 403), newQuery("D", 4), newQuery("E", 99))
+		require.Equal(t, http.StatusBadRequest, code)
+		require.Equal(t, map[string]string{"A": "prometheus"}, frameNames(results))
+		require.Equal(t, "metric request error: plugin failed", results["B"]["error"])
+		require.Equal(t, "Access denied to data source", results["C"]["error"])
+		require.Equal(t, "access denied", results["D"]["error"])
+		require.Equal(t, "Invalid data source ID", results["E"]["error"])
+		require.Len(t, pm.requests, 2)
+	})
+
+	t.Run("Mixed queries with duplicate refIds are rejected", func(t *testing.T) {
+		code, _ := query(t, newQuery("A", 1), newQuery("A", 2))
+		require.Equal(t, http.StatusBadRequest, code)
+		require.Empty(t, pm.requests)
+	})
+
+	t.Run("Results are streamed as Arrow when accepted", func(t *testing.T) {
+		pm.requests = map[int64]*backend.QueryDataRequest{}
+		httpReq, err := http.NewRequest(http.MethodPost, "/api/ds/query", nil)
+		require.NoError(t, err)
+		httpReq.Header.Set("Accept", "application/vnd.apache.arrow.stream, application/json")
+		recorder := httptest.NewRecorder()
+		c := &models.ReqContext{
+			Context:      &web.Context{Req: httpReq, Resp: web.NewResponseWriter(http.MethodPost, recorder)},
+			SignedInUser: &models.SignedInUser{OrgId: 1},
+			Logger:       log.New("test"),
+		}
+		queries := []*simplejson.Json{newQuery("A", 1), newQuery("B", 3), newQuery("C", 2)}
+		hs.QueryMetricsV2(c, dtos.MetricRequest{From: "now-1h", To: "now", Queries: queries}).WriteTo(c)
+		require.Equal(t, http.StatusBadRequest, recorder.Code)
+		mediaType, params, err := mime.ParseMediaType(recorder.Header().Get("Content-Type"))
+		require.NoError(t, err)
+		require.Equal(t, "multipart/mixed", mediaType)
+
+		type stream struct {
+			refID, name, err string
+			rows             int64
+		}
+		var streams []stream
+		mr := multipart.NewReader(recorder.Body, params["boundary"])
+		for {
+			part, err := mr.NextPart()
+			if errors.Is(err, io.EOF) {
+				break
+			}
+			require.NoError(t, err)
+			require.Equal(t, "application/vnd.apache.arrow.stream", part.Header.Get("Content-Type"))
+			reader, err := ipc.NewReader(part)
+			require.NoError(t, err)
+			var rows int64
+			for reader.Next() {
+				rows += reader.Record().NumRows()
+			}
+			metadata := reader.Schema().Metadata()
+			value := func(key string) string {
+				if i := metadata.FindKey(key); i >= 0 {
+					return metadata.Values()[i]
+				}
+				return ""
+			}
+			streams = append(streams, stream{refID: value("refId"), name: value("name"), err: value("error"), rows: rows})
+			reader.Release()
+		}
+		require.Equal(t, []stream{
+			{refID: "A", name: "prometheus", rows: 3},
+			{refID: "B", err: "metric request error: plugin failed"},
+			{refID: "C", name: "loki", rows: 3},
+		}, streams)
+	})
+
+	t.Run("Denied single data source fails the request", func(t *testing.T) {
+		code, _ := query(t, newQuery("A", 403))
+		require.Equal(t, http.StatusForbidden, code)
+	})
+}
+
+func TestArrowStreamResponse_EncodeError(t *testing.T) {
+	httpReq, err := http.NewRequest(http.MethodPost, "/api/ds/query", nil)
+	require.NoError(t, err)
+	recorder := httptest.NewRecorder()
+	c := &models.ReqContext{
+		Context:      &web.Context{Req: httpReq, Resp: web.NewResponseWriter(http.MethodPost, recorder)},
+		SignedInUser: &models.SignedInUser{OrgId: 1},
+		Logger:       log.New("test"),
+	}
+
+	// Fields of different lengths can't be encoded as a record.
+	frame := data.NewFrame("cpu",
+		data.NewField("time", nil, []time.Time{time.Unix(100, 0)}),
+		data.NewField("value", nil, []float64{1, 2}),
+	)
+	qdr := &backend.QueryDataResponse{Responses: backend.Responses{"A": {Frames: data.Frames{frame}}}}
+	arrowStreamResponse{status: http.StatusOK, qdr: qdr}.WriteTo(c)
+
+	require.Equal(t, http.StatusInternalServerError, recorder.Code)
+	require.Equal(t, "application/json; charset=UTF-8", recorder.Header().Get("Content-Type"))
+}
+
+func TestWriteArrowStream(t *testing.T) {
+	ts := time.Unix(100, 0)
+	value := 1.5
+	frame := data.NewFrame("cpu",
+		data.NewField("time", nil, []time.Time{ts, ts.Add(time.Second)}),
+		data.NewField("value", data.Labels{"host": "a"}, []*float64{&value, nil}),
+	)
+
+	var buf bytes.Buffer
+	require.NoError(t, writeArrowStream(&buf, frame, "A", nil))
+
+	reader, err := ipc.NewReader(&buf)
+	require.NoError(t, err)
+	defer reader.Release()
+	require.True(t, reader.Next())
+	record := reader.Record()
+	require.Equal(t, int64(2), record.NumRows())
+
+	times := record.Column(0).(*array.Timestamp)
+	require.Equal(t, arrow.Timestamp(ts.UnixNano()), times.Value(0))
+	values := record.Column(1).(*array.Float64)
+	require.Equal(t, 1.5, values.Value(0))
+	require.True(t, values.IsNull(1))
+	metadata := reader.Schema().Field(1).Metadata
+	require.Equal(t, `{"host":"a"}`, metadata.Values()[metadata.FindKey("labels")])
+	require.False(t, reader.Next())
+}
@@ -15,6 +15,7 @@ import (
 
 	"github.com/grafana/grafana/pkg/api/datasource"
 	"github.com/grafana/grafana/pkg/infra/httpclient"
+	"github.com/grafana/grafana/pkg/infra/httpclient/httpclientprovider"
 	glog "github.com/grafana/grafana/pkg/infra/log"
 	"github.com/grafana/grafana/pkg/models"
 	"github.com/grafana/grafana/pkg/plugins"
@@ -50,6 +51,17 @@ type handleResponseTransport struct {
 
 func (t *handleResponseTransport) RoundTrip(req *http.Request) (*http.Response, error) {
 	res, err := t.transport.RoundTrip(req)
+	if errors.Is(err, httpclientprovider.ErrRequestLimitExceeded) {
+		msg := err.Error()
+		return &http.Response{
+			StatusCode:    http.StatusTooManyRequests,
+			Status:        http.StatusText(http.StatusTooManyRequests),
+			Header:        http.Header{"Content-Type": []string{"text/plain; charset=utf-8"}},
+			Body:          ioutil.NopCloser(strings.NewReader(msg)),
+			ContentLength: int64(len(msg)),
+			Request:       req,
+		}, nil
+	}
 	if err != nil {
 		return nil, err
 	}
@@ -12,10 +12,12 @@ import (
 	"testing"
 	"time"
 
+	sdkhttpclient "github.com/grafana/grafana-plugin-sdk-go/backend/httpclient"
 	"github.com/grafana/grafana/pkg/api/datasource"
 	"github.com/grafana/grafana/pkg/bus"
 	"github.com/grafana/grafana/pkg/components/simplejson"
 	"github.com/grafana/grafana/pkg/infra/httpclient"
+	"github.com/grafana/grafana/pkg/infra/httpclient/httpclientprovider"
 	"github.com/grafana/grafana/pkg/models"
 	"github.com/grafana/grafana/pkg/plugins"
 	"github.com/grafana/grafana/pkg/services/datasources"
@@ -668,6 +670,27 @@ func TestDataSourceProxy_requestHandling(t *testing.T) {
 		assert.Empty(t, proxy.ctx.Resp.Header().Get("www-authenticate"))
 	})
 
+	t.Run("Requests over the data source request limit fail with status code 429", func(t *testing.T) {
+		limitedProvider := httpclient.NewProvider(sdkhttpclient.ProviderOptions{
+			Middlewares: []sdkhttpclient.Middleware{httpclientprovider.RequestLimitMiddleware()},
+		})
+		dsService := datasources.ProvideService(bus.New(), nil, ossencryption.ProvideService())
+
+		statuses := []int{}
+		for i := 0; i < 2; i++ {
+			ctx, ds := setUp(t)
+			ds.Uid = "limited"
+			ds.JsonData = simplejson.NewFromAny(map[string]interface{}{"maxRequestsPerSecond": 1, "requestQueueTimeout": 0.01})
+			proxy, err := NewDataSourceProxy(ds, plugin, ctx, "/render", &setting.Cfg{}, limitedProvider, &oauthtoken.Service{}, dsService)
+			require.NoError(t, err)
+
+			proxy.HandleRequest()
+			statuses = append(statuses, proxy.ctx.Resp.Status())
+		}
+
+		require.Equal(t, []int{http.StatusOK, http.StatusTooManyRequests}, statuses)
+	})
+
 	t.Run("Data source should handle proxy path url encoding correctly", func(t *testing.T) {
 		var req *http.Request
 		ctx, ds := setUp(t, setUpCfg{
@@ -70,6 +70,7 @@ func (hs *HTTPServer) GetPluginList(c *models.ReqContext) response.Response {
 			Signature:     pluginDef.Signature,
 			SignatureType: pluginDef.SignatureType,
 			SignatureOrg:  pluginDef.SignatureOrg,
+			SignatureKey:  pluginDef.SignatureKey,
 		}
 
 		if pluginSetting, exists := pluginSettingsMap[pluginDef.Id]; exists {
@@ -124,6 +125,7 @@ func (hs *HTTPServer) GetPluginSettingByID(c *models.ReqContext) response.Respon
 		Signature:     def.Signature,
 		SignatureType: def.SignatureType,
 		SignatureOrg:  def.SignatureOrg,
+		SignatureKey:  def.SignatureKey,
 	}
 
 	if app := hs.PluginManager.GetApp(def.Id); app != nil {
@@ -443,3 +445,21 @@ func translatePluginRequestErrorToAPIError(err error) response.Response {
 
 	return response.Error(500, "Plugin request failed", err)
 }
+
+func (hs *HTTPServer) GetBackendPluginStatuses(_ *models.ReqContext) response.Response {
+	return response.JSON(http.StatusOK, hs.BackendPluginManager.PluginStatuses())
+}
+
+func (hs *HTTPServer) RestartBackendPlugin(c *models.ReqContext) response.Response {
+	pluginID := web.Params(c.Req)[":pluginId"]
+
+	err := hs.BackendPluginManager.RestartPlugin(c.Req.Context(), pluginID)
+	if err != nil {
+		if errors.Is(err, backendplugin.ErrPluginNotRegistered) {
+			return response.Error(http.StatusNotFound, "Backend plugin not registered", err)
+		}
+
+		return response.Error(http.StatusInternalServerError, "Failed to restart backend plugin", err)
+	}
+	return response.Success("Backend plugin restarted")
+}
@@ -8,11 +8,12 @@ import (
 // API related actions
 const (
 	ActionProvisioningReload = "provisioning:reload"
+	ActionProvisioningRead   = "provisioning:read"
 
-	ActionDatasourcesRead   = "datasources:read"
-	ActionDatasourcesQuery  = "datasources:query"
+	ActionDatasourcesRead   = accesscontrol.ActionDatasourcesRead
+	ActionDatasourcesQuery  = accesscontrol.ActionDatasourcesQuery
 	ActionDatasourcesCreate = "datasources:create"
-	ActionDatasourcesWrite  = "datasources:write"
+	ActionDatasourcesWrite  = accesscontrol.ActionDatasourcesWrite
 	ActionDatasourcesDelete = "datasources:delete"
 	ActionDatasourcesIDRead = "datasources.id:read"
 )
@@ -25,7 +26,7 @@ var (
 	ScopeProvisionersDatasources   = accesscontrol.Scope("provisioners", "datasources")
 	ScopeProvisionersNotifications = accesscontrol.Scope("provisioners", "notifications")
 
-	ScopeDatasourcesAll = accesscontrol.Scope("datasources", "*")
+	ScopeDatasourcesAll = accesscontrol.ScopeDatasourcesAll
 	ScopeDatasourceID   = accesscontrol.Scope("datasources", "id", accesscontrol.Parameter(":id"))
 	ScopeDatasourceUID  = accesscontrol.Scope("datasources", "uid", accesscontrol.Parameter(":uid"))
 	ScopeDatasourceName = accesscontrol.Scope("datasources", "name", accesscontrol.Parameter(":name"))
@@ -38,14 +39,18 @@ func (hs *HTTPServer) declareFixedRoles() error {
 	registrations := []accesscontrol.RoleRegistration{
 		{
 			Role: accesscontrol.RoleDTO{
-				Version:     1,
+				Version:     2,
 				Name:        "fixed:provisioning:admin",
-				Description: "Reload provisioning configurations",
+				Description: "Reload provisioning configurations and read their status",
 				Permissions: []accesscontrol.Permission{
 					{
 						Action: ActionProvisioningReload,
 						Scope:  ScopeProvisionersAll,
 					},
+					{
+						Action: ActionProvisioningRead,
+						Scope:  ScopeProvisionersAll,
+					},
 				},
 			},
 			Grants: []string{accesscontrol.RoleGrafanaAdmin},
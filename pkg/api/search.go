@@ -15,6 +15,9 @@ import (
 
 func Search(c *models.ReqContext) response.Response {
 	query := c.Query("query")
+	content := c.Query("content")
+	contentKinds := c.QueryStrings("contentKind")
+	datasourceUIDs := c.QueryStrings("datasourceUid")
 	tags := c.QueryStrings("tag")
 	starred := c.Query("starred")
 	limit := c.QueryInt64("limit")
@@ -48,18 +51,22 @@ func Search(c *models.ReqContext) response.Response {
 	}
 
 	searchQuery := search.Query{
-		Title:        query,
-		Tags:         tags,
-		SignedInUser: c.SignedInUser,
-		Limit:        limit,
-		Page:         page,
-		IsStarred:    starred == "true",
-		OrgId:        c.OrgId,
-		DashboardIds: dbIDs,
-		Type:         dashboardType,
-		FolderIds:    folderIDs,
-		Permission:   permission,
-		Sort:         sort,
+		Title:          query,
+		Content:        content,
+		ContentKinds:   contentKinds,
+		DatasourceUIDs: datasourceUIDs,
+		Tags:           tags,
+		SignedInUser:   c.SignedInUser,
+		Limit:          limit,
+		Page:           page,
+		IsStarred:      starred == "true",
+		OrgId:          c.OrgId,
+		DashboardIds:   dbIDs,
+		Type:           dashboardType,
+		FolderIds:      folderIDs,
+		FolderSubtree:  c.QueryBool("folderSubtree"),
+		Permission:     permission,
+		Sort:           sort,
 	}
 
 	err := bus.Dispatch(&searchQuery)
@@ -45,11 +45,21 @@ var datasourceRequestsInFlight = prometheus.NewGaugeVec(
 	[]string{"datasource"},
 )
 
+var datasourceRequestRetriesCounter = prometheus.NewCounterVec(
+	prometheus.CounterOpts{
+		Namespace: "grafana",
+		Name:      "datasource_request_retries_total",
+		Help:      "A counter for retries of outgoing requests for a datasource, by the code of the retried response",
+	},
+	[]string{"datasource", "code"},
+)
+
 func init() {
 	prometheus.MustRegister(datasourceRequestSummary,
 		datasourceRequestCounter,
 		datasourceRequestsInFlight,
-		datasourceResponseSummary)
+		datasourceResponseSummary,
+		datasourceRequestRetriesCounter)
 }
 
 const DataSourceMetricsMiddlewareName = "metrics"
@@ -58,28 +68,37 @@ var executeMiddlewareFunc = executeMiddleware
 
 func DataSourceMetricsMiddleware() httpclient.Middleware {
 	return httpclient.NamedMiddlewareFunc(DataSourceMetricsMiddlewareName, func(opts httpclient.Options, next http.RoundTripper) http.RoundTripper {
-		if opts.Labels == nil {
-			return next
-		}
-
-		datasourceName, exists := opts.Labels["datasource_name"]
-		if !exists {
+		datasourceLabel := datasourceLabelFromOptions(opts)
+		if datasourceLabel == nil {
 			return next
 		}
 
-		datasourceLabelName, err := metricutil.SanitizeLabelName(datasourceName)
-		// if the datasource named cannot be turned into a prometheus
-		// label we will skip instrumenting these metrics.
-		if err != nil {
-			return next
-		}
-
-		datasourceLabel := prometheus.Labels{"datasource": datasourceLabelName}
-
 		return executeMiddlewareFunc(next, datasourceLabel)
 	})
 }
 
+// datasourceLabelFromOptions returns the datasource label of metrics, or nil
+// when the client isn't created for a datasource.
+func datasourceLabelFromOptions(opts httpclient.Options) prometheus.Labels {
+	if opts.Labels == nil {
+		return nil
+	}
+
+	datasourceName, exists := opts.Labels["datasource_name"]
+	if !exists {
+		return nil
+	}
+
+	datasourceLabelName, err := metricutil.SanitizeLabelName(datasourceName)
+	// if the datasource named cannot be turned into a prometheus
+	// label we will skip instrumenting these metrics.
+	if err != nil {
+		return nil
+	}
+
+	return prometheus.Labels{"datasource": datasourceLabelName}
+}
+
 func executeMiddleware(next http.RoundTripper, datasourceLabel prometheus.Labels) http.RoundTripper {
 	return httpclient.RoundTripperFunc(func(r *http.Request) (*http.Response, error) {
 		requestCounter := datasourceRequestCounter.MustCurryWith(datasourceLabel)
@@ -26,6 +26,9 @@ func New(cfg *setting.Cfg) *sdkhttpclient.Provider {
 		sdkhttpclient.BasicAuthenticationMiddleware(),
 		sdkhttpclient.CustomHeadersMiddleware(),
 		ResponseLimitMiddleware(cfg.ResponseLimit),
+		// Retries wrap the request limits, every attempt waits for the limits.
+		RetryMiddleware(),
+		RequestLimitMiddleware(),
 	}
 
 	if cfg.SigV4AuthEnabled {
@@ -0,0 +1,207 @@
+package httpclientprovider
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/grafana/grafana-plugin-sdk-go/backend"
+	sdkhttpclient "github.com/grafana/grafana-plugin-sdk-go/backend/httpclient"
+	"golang.org/x/sync/semaphore"
+	"golang.org/x/time/rate"
+)
+
+// RequestLimitMiddlewareName is the middleware name used by RequestLimitMiddleware.
+const RequestLimitMiddlewareName = "request-limit"
+
+// Data source settings (jsonData) limiting outgoing requests.
+const (
+	maxConcurrentRequestsKey = "maxConcurrentRequests"
+	maxRequestsPerSecondKey  = "maxRequestsPerSecond"
+	requestQueueTimeoutKey   = "requestQueueTimeout"
+)
+
+const defaultRequestQueueTimeout = 30 * time.Second
+
+// ErrRequestLimitExceeded is returned when a request waits for longer than
+// the queue timeout of the data source.
+var ErrRequestLimitExceeded = errors.New("data source request limit exceeded")
+
+// requestLimits are limits of outgoing requests of a data source.
+type requestLimits struct {
+	maxConcurrent int64
+	perSecond     float64
+	queueTimeout  time.Duration
+}
+
+func (l requestLimits) enabled() bool {
+	return l.maxConcurrent > 0 || l.perSecond > 0
+}
+
+// requestLimiter enforces limits of a data source, it's shared by all clients
+// of the data source, such as the data source proxy and the query client.
+type requestLimiter struct {
+	limits      requestLimits
+	concurrency *semaphore.Weighted
+	rate        *rate.Limiter
+}
+
+func newRequestLimiter(limits requestLimits) *requestLimiter {
+	l := &requestLimiter{limits: limits}
+	if limits.maxConcurrent > 0 {
+		l.concurrency = semaphore.NewWeighted(limits.maxConcurrent)
+	}
+	if limits.perSecond > 0 {
+		burst := int(limits.perSecond)
+		if burst < 1 {
+			burst = 1
+		}
+		l.rate = rate.NewLimiter(rate.Limit(limits.perSecond), burst)
+	}
+	return l
+}
+
+// acquire waits until the request is allowed and returns a function releasing
+// its concurrency slot.
+func (l *requestLimiter) acquire(ctx context.Context) (func(), error) {
+	ctx, cancel := context.WithTimeout(ctx, l.limits.queueTimeout)
+	defer cancel()
+
+	release := func() {}
+	if l.concurrency != nil {
+		if err := l.concurrency.Acquire(ctx, 1); err != nil {
+			return nil, l.limitError(ctx, err, fmt.Sprintf("%d concurrent requests", l.limits.maxConcurrent))
+		}
+		var once sync.Once
+		release = func() {
+			once.Do(func() { l.concurrency.Release(1) })
+		}
+	}
+
+	if l.rate != nil {
+		if err := l.rate.Wait(ctx); err != nil {
+			release()
+			return nil, l.limitError(ctx, err, fmt.Sprintf("%g requests per second", l.limits.perSecond))
+		}
+	}
+	return release, nil
+}
+
+func (l *requestLimiter) limitError(ctx context.Context, err error, limit string) error {
+	// Canceled requests aren't limited, report the original error.
+	if errors.Is(ctx.Err(), context.Canceled) {
+		return err
+	}
+	return fmt.Errorf("%w: request could not be sent within %s because of the limit of %s", ErrRequestLimitExceeded, l.limits.queueTimeout, limit)
+}
+
+// RequestLimitMiddleware limits the number of concurrent requests and the rate
+// of requests to a data source as configured in its settings. Requests over
+// the limits wait in a queue and fail with ErrRequestLimitExceeded after the
+// queue timeout.
+func RequestLimitMiddleware() sdkhttpclient.Middleware {
+	var mu sync.Mutex
+	limiters := map[string]*requestLimiter{}
+
+	return sdkhttpclient.NamedMiddlewareFunc(RequestLimitMiddlewareName, func(opts sdkhttpclient.Options, next http.RoundTripper) http.RoundTripper {
+		limits := requestLimitsFromOptions(opts)
+		if !limits.enabled() {
+			return next
+		}
+
+		key := requestLimiterKey(opts)
+		mu.Lock()
+		limiter, ok := limiters[key]
+		if key == "" || !ok || limiter.limits != limits {
+			limiter = newRequestLimiter(limits)
+			if key != "" {
+				limiters[key] = limiter
+			}
+		}
+		mu.Unlock()
+
+		return sdkhttpclient.RoundTripperFunc(func(req *http.Request) (*http.Response, error) {
+			release, err := limiter.acquire(req.Context())
+			if err != nil {
+				return nil, err
+			}
+
+			res, err := next.RoundTrip(req)
+			if err != nil || res.Body == nil {
+				release()
+				return res, err
+			}
+
+			// Keep the concurrency slot until the response is read.
+			res.Body = &releasingBody{ReadCloser: res.Body, release: release}
+			return res, nil
+		})
+	})
+}
+
+// requestLimiterKey identifies the data source of the client. Data source
+// UIDs are only unique within an organization, so the key includes the org.
+func requestLimiterKey(opts sdkhttpclient.Options) string {
+	uid := opts.Labels["datasource_uid"]
+	if uid == "" {
+		return ""
+	}
+	return opts.Labels["datasource_org_id"] + "/" + uid
+}
+
+type releasingBody struct {
+	io.ReadCloser
+	release func()
+}
+
+func (b *releasingBody) Close() error {
+	err := b.ReadCloser.Close()
+	b.release()
+	return err
+}
+
+// jsonDataFromOptions returns data source settings, which are either custom
+// options themselves or are nested in them by the plugin SDK.
+func jsonDataFromOptions(opts sdkhttpclient.Options) map[string]interface{} {
+	if jsonData := backend.JSONDataFromHTTPClientOptions(opts); jsonData != nil {
+		return jsonData
+	}
+	return opts.CustomOptions
+}
+
+func requestLimitsFromOptions(opts sdkhttpclient.Options) requestLimits {
+	jsonData := jsonDataFromOptions(opts)
+	limits := requestLimits{
+		maxConcurrent: int64(numberOption(jsonData, maxConcurrentRequestsKey)),
+		perSecond:     numberOption(jsonData, maxRequestsPerSecondKey),
+		queueTimeout:  time.Duration(numberOption(jsonData, requestQueueTimeoutKey) * float64(time.Second)),
+	}
+	if limits.queueTimeout <= 0 {
+		limits.queueTimeout = defaultRequestQueueTimeout
+	}
+	return limits
+}
+
+func numberOption(jsonData map[string]interface{}, key string) float64 {
+	switch value := jsonData[key].(type) {
+	case float64:
+		return value
+	case int:
+		return float64(value)
+	case int64:
+		return float64(value)
+	case json.Number:
+		number, _ := value.Float64()
+		return number
+	case string:
+		number, _ := strconv.ParseFloat(value, 64)
+		return number
+	}
+	return 0
+}
@@ -0,0 +1,128 @@
+package httpclientprovider
+
+import (
+	"context"
+	"encoding/json"
+	"io/ioutil"
+	"net/http"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/grafana/grafana-plugin-sdk-go/backend"
+	"github.com/grafana/grafana-plugin-sdk-go/backend/httpclient"
+	"github.com/stretchr/testify/require"
+)
+
+func TestRequestLimitMiddleware(t *testing.T) {
+	newRequest := func(t *testing.T) *http.Request {
+		req, err := http.NewRequestWithContext(context.Background(), http.MethodGet, "http://test.com/query", nil)
+		require.NoError(t, err)
+		return req
+	}
+	finalRoundTripper := httpclient.RoundTripperFunc(func(req *http.Request) (*http.Response, error) {
+		return &http.Response{StatusCode: http.StatusOK, Request: req, Body: ioutil.NopCloser(strings.NewReader("dummy"))}, nil
+	})
+	options := func(uid string, jsonData map[string]interface{}) httpclient.Options {
+		return httpclient.Options{Labels: map[string]string{"datasource_uid": uid, "datasource_org_id": "1"}, CustomOptions: jsonData}
+	}
+
+	t.Run("Without limits should return next round tripper", func(t *testing.T) {
+		mw := RequestLimitMiddleware()
+		rt := mw.CreateMiddleware(options("uid", map[string]interface{}{}), finalRoundTripper)
+		middlewareName, ok := mw.(httpclient.MiddlewareName)
+		require.True(t, ok)
+		require.Equal(t, RequestLimitMiddlewareName, middlewareName.MiddlewareName())
+
+		for i := 0; i < 10; i++ {
+			res, err := rt.RoundTrip(newRequest(t))
+			require.NoError(t, err)
+			require.NoError(t, res.Body.Close())
+		}
+	})
+
+	t.Run("Concurrent requests over the limit wait until a response is closed", func(t *testing.T) {
+		mw := RequestLimitMiddleware()
+		jsonData := map[string]interface{}{"maxConcurrentRequests": json.Number("1"), "requestQueueTimeout": 0.05}
+		// Clients created for the same data source share limits.
+		rt1 := mw.CreateMiddleware(options("uid", jsonData), finalRoundTripper)
+		rt2 := mw.CreateMiddleware(options("uid", jsonData), finalRoundTripper)
+
+		res, err := rt1.RoundTrip(newRequest(t))
+		require.NoError(t, err)
+
+		_, err = rt2.RoundTrip(newRequest(t))
+		require.ErrorIs(t, err, ErrRequestLimitExceeded)
+		require.EqualError(t, err, "data source request limit exceeded: request could not be sent within 50ms because of the limit of 1 concurrent requests")
+
+		other := mw.CreateMiddleware(options("other", jsonData), finalRoundTripper)
+		otherRes, err := other.RoundTrip(newRequest(t))
+		require.NoError(t, err)
+		require.NoError(t, otherRes.Body.Close())
+
+		done := make(chan error)
+		go func() {
+			res, err := rt2.RoundTrip(newRequest(t))
+			if err == nil {
+				err = res.Body.Close()
+			}
+			done <- err
+		}()
+		time.Sleep(10 * time.Millisecond)
+		require.NoError(t, res.Body.Close())
+		require.NoError(t, <-done)
+	})
+
+	t.Run("Data sources with the same UID in different orgs don't share limits", func(t *testing.T) {
+		mw := RequestLimitMiddleware()
+		jsonData := map[string]interface{}{"maxConcurrentRequests": 1, "requestQueueTimeout": 0.05}
+		rt1 := mw.CreateMiddleware(options("uid", jsonData), finalRoundTripper)
+		otherOrgOpts := options("uid", jsonData)
+		otherOrgOpts.Labels["datasource_org_id"] = "2"
+		rt2 := mw.CreateMiddleware(otherOrgOpts, finalRoundTripper)
+
+		res, err := rt1.RoundTrip(newRequest(t))
+		require.NoError(t, err)
+		defer func() { require.NoError(t, res.Body.Close()) }()
+
+		otherRes, err := rt2.RoundTrip(newRequest(t))
+		require.NoError(t, err)
+		require.NoError(t, otherRes.Body.Close())
+	})
+
+	t.Run("Requests over the rate limit fail after queue timeout", func(t *testing.T) {
+		mw := RequestLimitMiddleware()
+		rt := mw.CreateMiddleware(options("uid", map[string]interface{}{"maxRequestsPerSecond": 1, "requestQueueTimeout": "0.1"}), finalRoundTripper)
+
+		res, err := rt.RoundTrip(newRequest(t))
+		require.NoError(t, err)
+		require.NoError(t, res.Body.Close())
+
+		_, err = rt.RoundTrip(newRequest(t))
+		require.ErrorIs(t, err, ErrRequestLimitExceeded)
+	})
+
+	t.Run("Canceled request is not reported as limited", func(t *testing.T) {
+		mw := RequestLimitMiddleware()
+		rt := mw.CreateMiddleware(options("uid", map[string]interface{}{"maxConcurrentRequests": 1}), finalRoundTripper)
+		res, err := rt.RoundTrip(newRequest(t))
+		require.NoError(t, err)
+		defer func() { require.NoError(t, res.Body.Close()) }()
+
+		ctx, cancel := context.WithCancel(context.Background())
+		cancel()
+		req, err := http.NewRequestWithContext(ctx, http.MethodGet, "http://test.com/query", nil)
+		require.NoError(t, err)
+		_, err = rt.RoundTrip(req)
+		require.ErrorIs(t, err, context.Canceled)
+	})
+
+	t.Run("Limits are read from settings nested by the plugin SDK", func(t *testing.T) {
+		settings := backend.DataSourceInstanceSettings{UID: "uid", JSONData: []byte(`{"maxConcurrentRequests": 2, "maxRequestsPerSecond": 5}`)}
+		opts, err := settings.HTTPClientOptions()
+		require.NoError(t, err)
+
+		limits := requestLimitsFromOptions(opts)
+		require.Equal(t, requestLimits{maxConcurrent: 2, perSecond: 5, queueTimeout: defaultRequestQueueTimeout}, limits)
+	})
+}
@@ -0,0 +1,202 @@
+package httpclientprovider
+
+import (
+	"errors"
+	"io"
+	"io/ioutil"
+	"net/http"
+	"strconv"
+	"time"
+
+	sdkhttpclient "github.com/grafana/grafana-plugin-sdk-go/backend/httpclient"
+)
+
+// RetryMiddlewareName is the middleware name used by RetryMiddleware.
+const RetryMiddlewareName = "retry"
+
+// Data source settings (jsonData) configuring retries of failed requests.
+const (
+	maxRetriesKey          = "maxRetries"
+	retryBackoffInitialKey = "retryBackoffInitial"
+	retryBackoffMaxKey     = "retryBackoffMax"
+	retryPostKey           = "retryPost"
+)
+
+const (
+	defaultRetryBackoffInitial = 500 * time.Millisecond
+	defaultRetryBackoffMax     = 10 * time.Second
+)
+
+// retryPolicy controls retries of requests to a data source.
+type retryPolicy struct {
+	maxRetries     int
+	backoffInitial time.Duration
+	backoffMax     time.Duration
+	// retryPost marks POST requests to the data source as safe to retry,
+	// for example because they only query data.
+	retryPost bool
+}
+
+func retryPolicyFromOptions(opts sdkhttpclient.Options) retryPolicy {
+	jsonData := jsonDataFromOptions(opts)
+	policy := retryPolicy{
+		maxRetries:     int(numberOption(jsonData, maxRetriesKey)),
+		backoffInitial: time.Duration(numberOption(jsonData, retryBackoffInitialKey) * float64(time.Second)),
+		backoffMax:     time.Duration(numberOption(jsonData, retryBackoffMaxKey) * float64(time.Second)),
+	}
+	if retryPost, ok := jsonData[retryPostKey].(bool); ok {
+		policy.retryPost = retryPost
+	}
+	if policy.backoffInitial <= 0 {
+		policy.backoffInitial = defaultRetryBackoffInitial
+	}
+	if policy.backoffMax <= 0 {
+		policy.backoffMax = defaultRetryBackoffMax
+	}
+	if policy.backoffMax < policy.backoffInitial {
+		policy.backoffMax = policy.backoffInitial
+	}
+	return policy
+}
+
+func (p retryPolicy) backoff(retry int) time.Duration {
+	backoff := p.backoffInitial
+	for i := 1; i < retry && backoff < p.backoffMax; i++ {
+		backoff *= 2
+	}
+	if backoff > p.backoffMax {
+		return p.backoffMax
+	}
+	return backoff
+}
+
+// retryable returns whether the request is idempotent, or explicitly safe to
+// retry, and can be sent again.
+func (p retryPolicy) retryable(req *http.Request) bool {
+	switch req.Method {
+	case http.MethodGet, http.MethodHead, http.MethodOptions:
+	case http.MethodPost:
+		if !p.retryPost {
+			return false
+		}
+	default:
+		return false
+	}
+	return req.Body == nil || req.Body == http.NoBody || req.GetBody != nil
+}
+
+func retryableStatus(code int) bool {
+	return code == http.StatusTooManyRequests || code == http.StatusBadGateway || code == http.StatusServiceUnavailable
+}
+
+// retryAfter returns the delay requested by the Retry-After header, which is
+// either a number of seconds or a date.
+func retryAfter(res *http.Response, now time.Time) (time.Duration, bool) {
+	value := res.Header.Get("Retry-After")
+	if value == "" {
+		return 0, false
+	}
+	if seconds, err := strconv.Atoi(value); err == nil && seconds >= 0 {
+		return time.Duration(seconds) * time.Second, true
+	}
+	if date, err := http.ParseTime(value); err == nil {
+		if delay := date.Sub(now); delay > 0 {
+			return delay, true
+		}
+		return 0, true
+	}
+	return 0, false
+}
+
+// RetryMiddleware retries requests failing with a transient error, or with the
+// status 429, 502 or 503, as configured in settings of the data source. Only
+// idempotent requests, and POST requests to data sources marking them as safe,
+// are retried. The delay between retries grows exponentially unless the data
+// source asks for a delay with the Retry-After header, a response asking to
+// wait for longer than the maximum backoff is returned without retrying.
+// Requests rejected by the request limits aren't retried, since they already
+// waited for the queue timeout.
+func RetryMiddleware() sdkhttpclient.Middleware {
+	return sdkhttpclient.NamedMiddlewareFunc(RetryMiddlewareName, func(opts sdkhttpclient.Options, next http.RoundTripper) http.RoundTripper {
+		policy := retryPolicyFromOptions(opts)
+		if policy.maxRetries <= 0 {
+			return next
+		}
+		datasourceLabel := datasourceLabelFromOptions(opts)
+
+		return sdkhttpclient.RoundTripperFunc(func(req *http.Request) (*http.Response, error) {
+			if !policy.retryable(req) {
+				return next.RoundTrip(req)
+			}
+
+			for retry := 1; ; retry++ {
+				res, err := next.RoundTrip(req)
+				if retry > policy.maxRetries || req.Context().Err() != nil || errors.Is(err, ErrRequestLimitExceeded) {
+					return res, err
+				}
+
+				var delay time.Duration
+				code := "error"
+				switch {
+				case err != nil:
+					delay = policy.backoff(retry)
+				case retryableStatus(res.StatusCode):
+					code = strconv.Itoa(res.StatusCode)
+					delay = policy.backoff(retry)
+					if requested, ok := retryAfter(res, time.Now()); ok {
+						if requested > policy.backoffMax {
+							return res, nil
+						}
+						delay = requested
+					}
+				default:
+					return res, nil
+				}
+
+				retryReq, rewindErr := rewindRequest(req)
+				if rewindErr != nil {
+					return res, err
+				}
+				if res != nil {
+					drainBody(res.Body)
+				}
+
+				timer := time.NewTimer(delay)
+				select {
+				case <-req.Context().Done():
+					timer.Stop()
+					return nil, req.Context().Err()
+				case <-timer.C:
+				}
+
+				if datasourceLabel != nil {
+					datasourceRequestRetriesCounter.MustCurryWith(datasourceLabel).WithLabelValues(code).Inc()
+				}
+				req = retryReq
+			}
+		})
+	})
+}
+
+// rewindRequest returns a copy of the request with a fresh body.
+func rewindRequest(req *http.Request) (*http.Request, error) {
+	if req.Body == nil || req.Body == http.NoBody {
+		return req, nil
+	}
+	body, err := req.GetBody()
+	if err != nil {
+		return nil, err
+	}
+	retryReq := req.Clone(req.Context())
+	retryReq.Body = body
+	return retryReq, nil
+}
+
+// drainBody reads a bit of the body so the connection can be reused.
+func drainBody(body io.ReadCloser) {
+	if body == nil {
+		return
+	}
+	_, _ = io.CopyN(ioutil.Discard, body, 4096)
+	_ = body.Close()
+}
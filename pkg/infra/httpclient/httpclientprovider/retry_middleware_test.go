@@ -0,0 +1,191 @@
+package httpclientprovider
+
+import (
+	"context"
+	"errors"
+	"io/ioutil"
+	"net/http"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/grafana/grafana-plugin-sdk-go/backend/httpclient"
+	"github.com/prometheus/client_golang/prometheus/testutil"
+	"github.com/stretchr/testify/require"
+)
+
+func TestRetryMiddleware(t *testing.T) {
+	type attempt struct {
+		body string
+	}
+	newRoundTripper := func(attempts *[]attempt, responses ...*http.Response) http.RoundTripper {
+		return httpclient.RoundTripperFunc(func(req *http.Request) (*http.Response, error) {
+			var body string
+			if req.Body != nil {
+				b, err := ioutil.ReadAll(req.Body)
+				if err != nil {
+					return nil, err
+				}
+				body = string(b)
+			}
+			*attempts = append(*attempts, attempt{body: body})
+			res := responses[len(*attempts)-1]
+			if res == nil {
+				return nil, errors.New("connection reset")
+			}
+			res.Request = req
+			return res, nil
+		})
+	}
+	newResponse := func(code int, header http.Header) *http.Response {
+		if header == nil {
+			header = http.Header{}
+		}
+		return &http.Response{StatusCode: code, Header: header, Body: ioutil.NopCloser(strings.NewReader("dummy"))}
+	}
+	newRequest := func(t *testing.T, method string, body string) *http.Request {
+		req, err := http.NewRequestWithContext(context.Background(), method, "http://test.com/query", strings.NewReader(body))
+		require.NoError(t, err)
+		return req
+	}
+	options := func(jsonData map[string]interface{}) httpclient.Options {
+		return httpclient.Options{Labels: map[string]string{"datasource_name": "retry test"}, CustomOptions: jsonData}
+	}
+	retryOptions := func(maxRetries int) map[string]interface{} {
+		return map[string]interface{}{"maxRetries": maxRetries, "retryBackoffInitial": 0.001, "retryBackoffMax": 0.01}
+	}
+
+	t.Run("Without retries should return next round tripper", func(t *testing.T) {
+		var attempts []attempt
+		next := newRoundTripper(&attempts, newResponse(http.StatusServiceUnavailable, nil))
+		mw := RetryMiddleware()
+		rt := mw.CreateMiddleware(options(map[string]interface{}{}), next)
+		middlewareName, ok := mw.(httpclient.MiddlewareName)
+		require.True(t, ok)
+		require.Equal(t, RetryMiddlewareName, middlewareName.MiddlewareName())
+
+		res, err := rt.RoundTrip(newRequest(t, http.MethodGet, ""))
+		require.NoError(t, err)
+		require.Equal(t, http.StatusServiceUnavailable, res.StatusCode)
+		require.Len(t, attempts, 1)
+	})
+
+	t.Run("GET request is retried after errors and retryable responses", func(t *testing.T) {
+		retries := datasourceRequestRetriesCounter.WithLabelValues("retry_test", "503")
+		before := testutil.ToFloat64(retries)
+
+		var attempts []attempt
+		next := newRoundTripper(&attempts, nil, newResponse(http.StatusServiceUnavailable, nil), newResponse(http.StatusOK, nil))
+		rt := RetryMiddleware().CreateMiddleware(options(retryOptions(3)), next)
+
+		res, err := rt.RoundTrip(newRequest(t, http.MethodGet, ""))
+		require.NoError(t, err)
+		require.Equal(t, http.StatusOK, res.StatusCode)
+		require.Len(t, attempts, 3)
+		require.Equal(t, before+1, testutil.ToFloat64(retries))
+	})
+
+	t.Run("Last response is returned when retries are exhausted", func(t *testing.T) {
+		var attempts []attempt
+		next := newRoundTripper(&attempts,
+			newResponse(http.StatusBadGateway, nil),
+			newResponse(http.StatusTooManyRequests, nil),
+			newResponse(http.StatusServiceUnavailable, nil))
+		rt := RetryMiddleware().CreateMiddleware(options(retryOptions(2)), next)
+
+		res, err := rt.RoundTrip(newRequest(t, http.MethodGet, ""))
+		require.NoError(t, err)
+		require.Equal(t, http.StatusServiceUnavailable, res.StatusCode)
+		require.Len(t, attempts, 3)
+	})
+
+	t.Run("Other responses are not retried", func(t *testing.T) {
+		var attempts []attempt
+		next := newRoundTripper(&attempts, newResponse(http.StatusInternalServerError, nil))
+		rt := RetryMiddleware().CreateMiddleware(options(retryOptions(2)), next)
+
+		res, err := rt.RoundTrip(newRequest(t, http.MethodGet, ""))
+		require.NoError(t, err)
+		require.Equal(t, http.StatusInternalServerError, res.StatusCode)
+		require.Len(t, attempts, 1)
+	})
+
+	t.Run("Response asking to wait longer than the maximum backoff is returned", func(t *testing.T) {
+		var attempts []attempt
+		next := newRoundTripper(&attempts, newResponse(http.StatusTooManyRequests, http.Header{"Retry-After": []string{"120"}}))
+		rt := RetryMiddleware().CreateMiddleware(options(retryOptions(2)), next)
+
+		res, err := rt.RoundTrip(newRequest(t, http.MethodGet, ""))
+		require.NoError(t, err)
+		require.Equal(t, http.StatusTooManyRequests, res.StatusCode)
+		require.Len(t, attempts, 1)
+	})
+
+	t.Run("POST request is only retried when marked as safe", func(t *testing.T) {
+		var attempts []attempt
+		next := newRoundTripper(&attempts, newResponse(http.StatusServiceUnavailable, nil))
+		rt := RetryMiddleware().CreateMiddleware(options(retryOptions(2)), next)
+
+		res, err := rt.RoundTrip(newRequest(t, http.MethodPost, "query"))
+		require.NoError(t, err)
+		require.Equal(t, http.StatusServiceUnavailable, res.StatusCode)
+		require.Len(t, attempts, 1)
+
+		attempts = nil
+		next = newRoundTripper(&attempts, newResponse(http.StatusServiceUnavailable, nil), newResponse(http.StatusOK, nil))
+		jsonData := retryOptions(2)
+		jsonData["retryPost"] = true
+		rt = RetryMiddleware().CreateMiddleware(options(jsonData), next)
+
+		res, err = rt.RoundTrip(newRequest(t, http.MethodPost, "query"))
+		require.NoError(t, err)
+		require.Equal(t, http.StatusOK, res.StatusCode)
+		require.Equal(t, []attempt{{body: "query"}, {body: "query"}}, attempts)
+	})
+
+	t.Run("Canceled request stops retrying", func(t *testing.T) {
+		var attempts []attempt
+		next := newRoundTripper(&attempts, newResponse(http.StatusServiceUnavailable, nil))
+		jsonData := map[string]interface{}{"maxRetries": 2, "retryBackoffInitial": 10}
+		rt := RetryMiddleware().CreateMiddleware(options(jsonData), next)
+
+		ctx, cancel := context.WithCancel(context.Background())
+		req, err := http.NewRequestWithContext(ctx, http.MethodGet, "http://test.com/query", nil)
+		require.NoError(t, err)
+		time.AfterFunc(10*time.Millisecond, cancel)
+
+		_, err = rt.RoundTrip(req)
+		require.ErrorIs(t, err, context.Canceled)
+		require.Len(t, attempts, 1)
+	})
+
+	t.Run("Every attempt waits for the request limits", func(t *testing.T) {
+		var attempts []attempt
+		next := newRoundTripper(&attempts, newResponse(http.StatusServiceUnavailable, nil), newResponse(http.StatusOK, nil))
+		jsonData := retryOptions(2)
+		jsonData["maxRequestsPerSecond"] = 1
+		jsonData["requestQueueTimeout"] = 0.05
+		opts := options(jsonData)
+		rt := RetryMiddleware().CreateMiddleware(opts, RequestLimitMiddleware().CreateMiddleware(opts, next))
+
+		_, err := rt.RoundTrip(newRequest(t, http.MethodGet, ""))
+		require.ErrorIs(t, err, ErrRequestLimitExceeded)
+		require.Len(t, attempts, 1)
+	})
+
+	t.Run("Backoff grows exponentially up to the maximum", func(t *testing.T) {
+		policy := retryPolicyFromOptions(options(map[string]interface{}{"maxRetries": 5, "retryBackoffMax": 3}))
+		require.Equal(t, defaultRetryBackoffInitial, policy.backoff(1))
+		require.Equal(t, 2*defaultRetryBackoffInitial, policy.backoff(2))
+		require.Equal(t, 4*defaultRetryBackoffInitial, policy.backoff(3))
+		require.Equal(t, 3*time.Second, policy.backoff(4))
+	})
+
+	t.Run("Retry-After header can be a date", func(t *testing.T) {
+		now := time.Date(2021, 10, 1, 12, 0, 0, 0, time.UTC)
+		res := newResponse(http.StatusServiceUnavailable, http.Header{"Retry-After": []string{now.Add(5 * time.Second).Format(http.TimeFormat)}})
+		delay, ok := retryAfter(res, now)
+		require.True(t, ok)
+		require.Equal(t, 5*time.Second, delay)
+	})
+}
@@ -66,10 +66,23 @@ func RequestTracing() web.Handler {
 		status := rw.Status()
 
 		ext.HTTPStatusCode.Set(span, uint16(status))
-		ext.HTTPUrl.Set(span, req.RequestURI)
+		ext.HTTPUrl.Set(span, redactedRequestURI(req))
 		ext.HTTPMethod.Set(span, req.Method)
 		if status >= 400 {
 			ext.Error.Set(span, true)
 		}
 	}
 }
+
+// redactedRequestURI returns the request URI with the value of the token
+// query parameter, which authenticates annotation webhook payloads, redacted.
+func redactedRequestURI(req *http.Request) string {
+	query := req.URL.Query()
+	if _, ok := query["token"]; !ok {
+		return req.RequestURI
+	}
+	query.Set("token", "redacted")
+	redacted := *req.URL
+	redacted.RawQuery = query.Encode()
+	return redacted.RequestURI()
+}
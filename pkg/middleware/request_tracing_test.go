@@ -0,0 +1,19 @@
+package middleware
+
+import (
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestRedactedRequestURI(t *testing.T) {
+	for uri, expected := range map[string]string{
+		"/api/dashboards/uid/abc?orgId=1":                   "/api/dashboards/uid/abc?orgId=1",
+		"/api/annotation-webhooks/abc/receive?token=s3cr3t": "/api/annotation-webhooks/abc/receive?token=redacted",
+		"/api/annotation-webhooks/abc/receive?a=1&token=":   "/api/annotation-webhooks/abc/receive?a=1&token=redacted",
+	} {
+		req := httptest.NewRequest("POST", uri, nil)
+		assert.Equal(t, expected, redactedRequestURI(req), uri)
+	}
+}
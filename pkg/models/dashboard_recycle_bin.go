@@ -0,0 +1,51 @@
+package models
+
+import "time"
+
+var ErrDeletedDashboardNotFound = DashboardErr{
+	Reason:     "Deleted dashboard not found",
+	StatusCode: 404,
+}
+
+// DeletedDashboardDTO is a dashboard or folder in the recycle bin. Folders
+// are listed with the number of dashboards and subfolders deleted with them.
+type DeletedDashboardDTO struct {
+	Id        int64     `json:"id"`
+	Uid       string    `json:"uid"`
+	Title     string    `json:"title"`
+	IsFolder  bool      `json:"isFolder"`
+	FolderId  int64     `json:"folderId"`
+	ItemCount int64     `json:"itemCount"`
+	Deleted   time.Time `json:"deleted"`
+	Expires   time.Time `json:"expires"`
+}
+
+//
+// Queries
+//
+
+type GetDeletedDashboardsQuery struct {
+	OrgId int64
+
+	Result []*DeletedDashboardDTO
+}
+
+//
+// Commands
+//
+
+// RestoreDeletedDashboardCommand restores a dashboard or folder from the
+// recycle bin, along with its versions and permissions and, for a folder,
+// everything deleted with it.
+type RestoreDeletedDashboardCommand struct {
+	Id    int64
+	OrgId int64
+
+	Result *Dashboard
+}
+
+type DeleteExpiredDeletedDashboardsCommand struct {
+	OlderThan time.Time
+
+	DeletedRows int64
+}
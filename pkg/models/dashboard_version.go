@@ -8,8 +8,13 @@ import (
 )
 
 var (
-	ErrDashboardVersionNotFound = errors.New("dashboard version not found")
-	ErrNoVersionsForDashboardId = errors.New("no dashboard versions found for the given DashboardId")
+	ErrDashboardVersionNotFound          = errors.New("dashboard version not found")
+	ErrNoVersionsForDashboardId          = errors.New("no dashboard versions found for the given DashboardId")
+	ErrDashboardVersionLabelNotFound     = errors.New("dashboard version label not found")
+	ErrDashboardVersionLabelExists       = errors.New("a version of the dashboard already has this label")
+	ErrDashboardVersionLabelInvalid      = errors.New("dashboard version label must be between 1 and 190 characters")
+	ErrDashboardVersionRetentionNotFound = errors.New("dashboard version retention policy not found")
+	ErrDashboardVersionRetentionInvalid  = errors.New("invalid dashboard version retention policy")
 )
 
 // A DashboardVersion represents the comparable data in a dashboard, allowing
@@ -41,6 +46,7 @@ type DashboardVersionMeta struct {
 	Message       string           `json:"message"`
 	Data          *simplejson.Json `json:"data"`
 	CreatedBy     string           `json:"createdBy"`
+	Labels        []string         `json:"labels"`
 }
 
 // DashboardVersionDTO represents a dashboard version, without the dashboard
@@ -54,6 +60,46 @@ type DashboardVersionDTO struct {
 	Created       time.Time `json:"created"`
 	CreatedBy     string    `json:"createdBy"`
 	Message       string    `json:"message"`
+	Labels        []string  `json:"labels" xorm:"-"`
+}
+
+// DashboardVersionLabel is a name given to a version of a dashboard. Labeled
+// versions are never deleted by the cleanup of old versions.
+type DashboardVersionLabel struct {
+	Id          int64
+	DashboardId int64
+	Version     int
+	Label       string
+	Created     time.Time
+	CreatedBy   int64
+}
+
+// VersionRetentionPolicy is how long the versions of a dashboard are kept.
+type VersionRetentionPolicy string
+
+const (
+	// VersionRetentionCount keeps a number of the most recent versions.
+	VersionRetentionCount VersionRetentionPolicy = "count"
+	// VersionRetentionDuration keeps the versions created in a period of
+	// time, and the current version.
+	VersionRetentionDuration VersionRetentionPolicy = "duration"
+	// VersionRetentionForever keeps all versions.
+	VersionRetentionForever VersionRetentionPolicy = "forever"
+)
+
+// DashboardVersionRetention is the version retention policy of a dashboard
+// or a folder. A folder's policy applies to the dashboards and subfolders in
+// it that don't have their own policy. Dashboards without a policy keep the
+// number of versions set by the versions_to_keep setting.
+type DashboardVersionRetention struct {
+	Id             int64                  `json:"-"`
+	OrgId          int64                  `json:"-"`
+	DashboardId    int64                  `json:"dashboardId"`
+	Policy         VersionRetentionPolicy `json:"policy"`
+	VersionsToKeep int                    `json:"versionsToKeep,omitempty"`
+	KeepFor        string                 `json:"keepFor,omitempty"`
+	Created        time.Time              `json:"created"`
+	Updated        time.Time              `json:"updated"`
 }
 
 //
@@ -77,6 +123,28 @@ type GetDashboardVersionsQuery struct {
 	Result []*DashboardVersionDTO
 }
 
+type GetDashboardVersionByLabelQuery struct {
+	DashboardId int64
+	OrgId       int64
+	Label       string
+
+	Result *DashboardVersion
+}
+
+type GetDashboardVersionLabelsQuery struct {
+	DashboardId int64
+	Version     int
+
+	Result []string
+}
+
+type GetDashboardVersionRetentionQuery struct {
+	DashboardId int64
+	OrgId       int64
+
+	Result *DashboardVersionRetention
+}
+
 //
 // Commands
 //
@@ -84,3 +152,34 @@ type GetDashboardVersionsQuery struct {
 type DeleteExpiredVersionsCommand struct {
 	DeletedRows int64
 }
+
+type AddDashboardVersionLabelCommand struct {
+	DashboardId int64
+	OrgId       int64
+	Version     int
+	Label       string
+	UserId      int64
+
+	Result *DashboardVersionLabel
+}
+
+type DeleteDashboardVersionLabelCommand struct {
+	DashboardId int64
+	OrgId       int64
+	Label       string
+}
+
+type SetDashboardVersionRetentionCommand struct {
+	DashboardId    int64
+	OrgId          int64
+	Policy         VersionRetentionPolicy
+	VersionsToKeep int
+	KeepFor        string
+
+	Result *DashboardVersionRetention
+}
+
+type DeleteDashboardVersionRetentionCommand struct {
+	DashboardId int64
+	OrgId       int64
+}
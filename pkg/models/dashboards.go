@@ -14,6 +14,10 @@ import (
 
 const RootFolderName = "General"
 
+// MaxNestedFolderDepth is the maximum number of levels of nested folders,
+// including the top-level folder.
+const MaxNestedFolderDepth = 4
+
 // Typed errors
 var (
 	ErrDashboardNotFound = DashboardErr{
@@ -47,8 +51,12 @@ var (
 		Reason:     "Dashboard title cannot be empty",
 		StatusCode: 400,
 	}
-	ErrDashboardFolderCannotHaveParent = DashboardErr{
-		Reason:     "A Dashboard Folder cannot be added to another folder",
+	ErrDashboardFolderTooDeep = DashboardErr{
+		Reason:     fmt.Sprintf("Folders cannot be nested more than %d levels deep", MaxNestedFolderDepth),
+		StatusCode: 400,
+	}
+	ErrDashboardFolderCannotBeOwnAncestor = DashboardErr{
+		Reason:     "A folder cannot be moved into itself or one of its subfolders",
 		StatusCode: 400,
 	}
 	ErrDashboardsWithSameSlugExists = DashboardErr{
@@ -445,3 +453,12 @@ type GetDashboardRefByIdQuery struct {
 type UnprovisionDashboardCommand struct {
 	Id int64
 }
+
+// UpdateDashboardProvisioningCommand updates the checksum of the file a
+// dashboard was provisioned from, after the file was written by Grafana.
+type UpdateDashboardProvisioningCommand struct {
+	DashboardId int64
+	Name        string
+	CheckSum    string
+	Updated     int64
+}
@@ -19,11 +19,13 @@ var (
 )
 
 type Folder struct {
-	Id      int64
-	Uid     string
-	Title   string
-	Url     string
-	Version int
+	Id        int64
+	Uid       string
+	Title     string
+	Url       string
+	Version   int
+	ParentId  int64
+	ParentUid string
 
 	Created time.Time
 	Updated time.Time
@@ -59,8 +61,9 @@ func (cmd *UpdateFolderCommand) UpdateDashboardModel(dashFolder *Dashboard, orgI
 //
 
 type CreateFolderCommand struct {
-	Uid   string `json:"uid"`
-	Title string `json:"title"`
+	Uid       string `json:"uid"`
+	Title     string `json:"title"`
+	ParentUid string `json:"parentUid"`
 
 	Result *Folder
 }
@@ -74,6 +77,16 @@ type UpdateFolderCommand struct {
 	Result *Folder
 }
 
+// MoveFolderCommand moves a folder, with its dashboards and subfolders, into
+// another folder. An empty ParentUid moves it to the top level.
+type MoveFolderCommand struct {
+	ParentUid string `json:"parentUid"`
+	Version   int    `json:"version"`
+	Overwrite bool   `json:"overwrite"`
+
+	Result *Folder
+}
+
 //
 // QUERIES
 //
@@ -30,6 +30,10 @@ type Manager interface {
 	CallResource(pCtx backend.PluginContext, reqCtx *models.ReqContext, path string)
 	// Get plugin by its ID.
 	Get(pluginID string) (Plugin, bool)
+	// PluginStatuses returns process statuses of registered backend plugins.
+	PluginStatuses() []PluginStatus
+	// RestartPlugin restarts a backend plugin process, also one disabled after crashing.
+	RestartPlugin(ctx context.Context, pluginID string) error
 }
 
 // Plugin is the backend plugin interface.
@@ -6,12 +6,15 @@ import (
 	"time"
 
 	"github.com/grafana/grafana-plugin-sdk-go/backend"
+	"github.com/grafana/grafana/pkg/plugins/backendplugin"
 	"github.com/prometheus/client_golang/prometheus"
 )
 
 var (
 	pluginRequestCounter  *prometheus.CounterVec
 	pluginRequestDuration *prometheus.SummaryVec
+	pluginProcessState    *prometheus.GaugeVec
+	pluginProcessRestarts *prometheus.CounterVec
 )
 
 func init() {
@@ -28,7 +31,42 @@ func init() {
 		Objectives: map[float64]float64{0.5: 0.05, 0.9: 0.01, 0.99: 0.001},
 	}, []string{"plugin_id", "endpoint"})
 
-	prometheus.MustRegister(pluginRequestCounter, pluginRequestDuration)
+	pluginProcessState = prometheus.NewGaugeVec(prometheus.GaugeOpts{
+		Namespace: "grafana",
+		Name:      "plugin_process_state",
+		Help:      "Backend plugin process lifecycle state, 1 for the current state and 0 for the rest",
+	}, []string{"plugin_id", "state"})
+
+	pluginProcessRestarts = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Namespace: "grafana",
+		Name:      "plugin_process_restarts_total",
+		Help:      "The total amount of backend plugin process restarts",
+	}, []string{"plugin_id"})
+
+	prometheus.MustRegister(pluginRequestCounter, pluginRequestDuration, pluginProcessState, pluginProcessRestarts)
+}
+
+// SetPluginProcessState sets the current lifecycle state of a plugin process.
+func SetPluginProcessState(pluginID string, state backendplugin.PluginState) {
+	for _, s := range backendplugin.PluginStates {
+		value := 0.0
+		if s == state {
+			value = 1
+		}
+		pluginProcessState.WithLabelValues(pluginID, string(s)).Set(value)
+	}
+}
+
+// DeletePluginProcessState removes process state series of an unregistered plugin.
+func DeletePluginProcessState(pluginID string) {
+	for _, s := range backendplugin.PluginStates {
+		pluginProcessState.DeleteLabelValues(pluginID, string(s))
+	}
+}
+
+// IncPluginProcessRestarts counts a plugin process restart.
+func IncPluginProcessRestarts(pluginID string) {
+	pluginProcessRestarts.WithLabelValues(pluginID).Inc()
 }
 
 // instrumentPluginRequest instruments success rate and latency of `fn`
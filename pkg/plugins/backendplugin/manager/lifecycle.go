@@ -0,0 +1,366 @@
+package manager
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/grafana/grafana/pkg/plugins/backendplugin"
+	"github.com/grafana/grafana/pkg/plugins/backendplugin/instrumentation"
+)
+
+const (
+	defaultRestartBackoffInitial = time.Second
+	defaultRestartBackoffMax     = 5 * time.Minute
+	// A plugin process running at least this long is considered stable and
+	// its consecutive restarts counter is reset.
+	stableRunDuration = time.Minute
+	// How often plugin processes are checked for being exited.
+	processCheckInterval = time.Second
+)
+
+// restartPolicy controls how exited plugin processes are restarted.
+type restartPolicy struct {
+	backoffInitial time.Duration
+	backoffMax     time.Duration
+	// maxConsecutiveRestarts is a number of restarts after which the plugin
+	// is disabled, 0 means the plugin is never disabled.
+	maxConsecutiveRestarts int
+}
+
+func (p restartPolicy) backoff(consecutiveRestarts int) time.Duration {
+	initial := p.backoffInitial
+	if initial <= 0 {
+		initial = defaultRestartBackoffInitial
+	}
+	max := p.backoffMax
+	if max <= 0 {
+		max = defaultRestartBackoffMax
+	}
+	backoff := initial
+	for i := 1; i < consecutiveRestarts; i++ {
+		backoff *= 2
+		if backoff >= max {
+			return max
+		}
+	}
+	if backoff > max {
+		return max
+	}
+	return backoff
+}
+
+// pluginLifecycle tracks the state of a plugin process.
+type pluginLifecycle struct {
+	policy restartPolicy
+
+	// procMu serializes starting of the plugin process.
+	procMu sync.Mutex
+
+	mu         sync.RWMutex
+	status     backendplugin.PluginStatus
+	startedAt  time.Time
+	monitoring bool
+	// ctx is a context the plugin process was started with, it's used to
+	// restart the process.
+	ctx context.Context
+}
+
+func newPluginLifecycle(pluginID string, managed bool, policy restartPolicy) *pluginLifecycle {
+	lc := &pluginLifecycle{
+		policy: policy,
+		status: backendplugin.PluginStatus{
+			PluginID: pluginID,
+			Managed:  managed,
+			State:    backendplugin.PluginStateNotStarted,
+		},
+	}
+	instrumentation.SetPluginProcessState(pluginID, lc.status.State)
+	return lc
+}
+
+func (lc *pluginLifecycle) getStatus() backendplugin.PluginStatus {
+	lc.mu.RLock()
+	defer lc.mu.RUnlock()
+	return lc.status
+}
+
+func (lc *pluginLifecycle) state() backendplugin.PluginState {
+	lc.mu.RLock()
+	defer lc.mu.RUnlock()
+	return lc.status.State
+}
+
+// setState must be called with mu locked.
+func (lc *pluginLifecycle) setState(state backendplugin.PluginState) {
+	lc.status.State = state
+	instrumentation.SetPluginProcessState(lc.status.PluginID, state)
+}
+
+func (lc *pluginLifecycle) starting() {
+	lc.mu.Lock()
+	defer lc.mu.Unlock()
+	lc.setState(backendplugin.PluginStateStarting)
+}
+
+// started records a successful start of the plugin process.
+func (lc *pluginLifecycle) started(now time.Time, restart bool) {
+	lc.mu.Lock()
+	defer lc.mu.Unlock()
+	lc.setState(backendplugin.PluginStateRunning)
+	lc.startedAt = now
+	lc.status.NextRestartTime = nil
+	if restart {
+		lc.status.Restarts++
+		instrumentation.IncPluginProcessRestarts(lc.status.PluginID)
+	}
+}
+
+// exited records an exit or a failed start of the plugin process and decides
+// whether and when it should be restarted.
+func (lc *pluginLifecycle) exited(now time.Time, reason string) {
+	lc.mu.Lock()
+	defer lc.mu.Unlock()
+	lc.status.LastExitReason = reason
+	lc.status.LastExitTime = &now
+	lc.status.ConsecutiveRestarts++
+
+	if max := lc.policy.maxConsecutiveRestarts; max > 0 && lc.status.ConsecutiveRestarts > max {
+		lc.status.NextRestartTime = nil
+		lc.setState(backendplugin.PluginStateDisabled)
+		return
+	}
+
+	next := now.Add(lc.policy.backoff(lc.status.ConsecutiveRestarts))
+	lc.status.NextRestartTime = &next
+	lc.setState(backendplugin.PluginStateCrashLooping)
+}
+
+// checkStable resets consecutive restarts once the process runs long enough.
+func (lc *pluginLifecycle) checkStable(now time.Time) {
+	lc.mu.Lock()
+	defer lc.mu.Unlock()
+	if lc.status.State == backendplugin.PluginStateRunning && lc.status.ConsecutiveRestarts > 0 &&
+		now.Sub(lc.startedAt) >= stableRunDuration {
+		lc.status.ConsecutiveRestarts = 0
+	}
+}
+
+func (lc *pluginLifecycle) restartDue(now time.Time) bool {
+	lc.mu.RLock()
+	defer lc.mu.RUnlock()
+	return lc.status.State == backendplugin.PluginStateCrashLooping &&
+		(lc.status.NextRestartTime == nil || !now.Before(*lc.status.NextRestartTime))
+}
+
+func (lc *pluginLifecycle) reset() {
+	lc.mu.Lock()
+	defer lc.mu.Unlock()
+	lc.status.ConsecutiveRestarts = 0
+	lc.status.NextRestartTime = nil
+}
+
+func (m *Manager) restartPolicy() restartPolicy {
+	return restartPolicy{
+		backoffInitial:         m.Cfg.PluginRestartBackoffInitial,
+		backoffMax:             m.Cfg.PluginRestartBackoffMax,
+		maxConsecutiveRestarts: m.Cfg.PluginMaxConsecutiveRestarts,
+	}
+}
+
+// lifecycle returns the lifecycle of a plugin, creating it if needed.
+func (m *Manager) lifecycle(p backendplugin.Plugin) *pluginLifecycle {
+	m.lifecyclesMu.Lock()
+	defer m.lifecyclesMu.Unlock()
+	if m.lifecycles == nil {
+		m.lifecycles = map[string]*pluginLifecycle{}
+	}
+	lc, ok := m.lifecycles[p.PluginID()]
+	if !ok {
+		lc = newPluginLifecycle(p.PluginID(), p.IsManaged(), m.restartPolicy())
+		m.lifecycles[p.PluginID()] = lc
+	}
+	return lc
+}
+
+func (m *Manager) removeLifecycle(pluginID string) {
+	m.lifecyclesMu.Lock()
+	defer m.lifecyclesMu.Unlock()
+	delete(m.lifecycles, pluginID)
+	instrumentation.DeletePluginProcessState(pluginID)
+}
+
+// startPluginAndRestartKilledProcesses starts the plugin process and keeps
+// restarting it with an exponential backoff when it exits.
+func (m *Manager) startPluginAndRestartKilledProcesses(ctx context.Context, p backendplugin.Plugin) error {
+	lc := m.lifecycle(p)
+	lc.mu.Lock()
+	lc.ctx = ctx
+	lc.mu.Unlock()
+
+	lc.procMu.Lock()
+	err := m.startProcess(ctx, p, lc, false)
+	lc.procMu.Unlock()
+
+	m.monitorProcess(lc, p)
+	return err
+}
+
+func (m *Manager) startProcess(ctx context.Context, p backendplugin.Plugin, lc *pluginLifecycle, restart bool) error {
+	lc.starting()
+	if err := p.Start(ctx); err != nil {
+		lc.exited(time.Now(), fmt.Sprintf("failed to start: %v", err))
+		return err
+	}
+	lc.started(time.Now(), restart)
+	return nil
+}
+
+// monitorProcess starts watching the plugin process unless it's already watched.
+func (m *Manager) monitorProcess(lc *pluginLifecycle, p backendplugin.Plugin) {
+	lc.mu.Lock()
+	if lc.monitoring {
+		lc.mu.Unlock()
+		return
+	}
+	lc.monitoring = true
+	ctx := lc.ctx
+	lc.mu.Unlock()
+
+	go func() {
+		if err := m.restartKilledProcess(ctx, p, lc); err != nil {
+			p.Logger().Error("Attempt to restart killed plugin process failed", "error", err)
+		}
+	}()
+}
+
+// stopMonitoring marks the process as not watched, unless it was restarted
+// after being disabled, in which case watching should continue.
+func (lc *pluginLifecycle) stopMonitoring(force bool) bool {
+	lc.mu.Lock()
+	defer lc.mu.Unlock()
+	if !force && lc.status.State != backendplugin.PluginStateDisabled {
+		return false
+	}
+	lc.monitoring = false
+	return true
+}
+
+func (m *Manager) restartKilledProcess(ctx context.Context, p backendplugin.Plugin, lc *pluginLifecycle) error {
+	ticker := time.NewTicker(processCheckInterval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			lc.stopMonitoring(true)
+			if err := ctx.Err(); err != nil && !errors.Is(err, context.Canceled) {
+				return err
+			}
+			return nil
+		case <-ticker.C:
+			if p.IsDecommissioned() {
+				lc.stopMonitoring(true)
+				p.Logger().Debug("Plugin decommissioned")
+				return nil
+			}
+
+			if done := m.checkProcess(ctx, p, lc, time.Now()); done && lc.stopMonitoring(false) {
+				return nil
+			}
+		}
+	}
+}
+
+// checkProcess moves the plugin process through its lifecycle, it returns true
+// when the process shouldn't be watched anymore.
+func (m *Manager) checkProcess(ctx context.Context, p backendplugin.Plugin, lc *pluginLifecycle, now time.Time) bool {
+	lc.procMu.Lock()
+	defer lc.procMu.Unlock()
+
+	switch lc.state() {
+	case backendplugin.PluginStateRunning:
+		if !p.Exited() {
+			lc.checkStable(now)
+			return false
+		}
+		lc.exited(now, "plugin process exited")
+		status := lc.getStatus()
+		if status.State == backendplugin.PluginStateDisabled {
+			p.Logger().Error("Plugin process keeps exiting, disabling it", "restarts", status.ConsecutiveRestarts-1)
+			return true
+		}
+		p.Logger().Warn("Plugin process exited, restarting", "restartIn", status.NextRestartTime.Sub(now),
+			"consecutiveRestarts", status.ConsecutiveRestarts)
+		return false
+	case backendplugin.PluginStateCrashLooping:
+		if !lc.restartDue(now) {
+			return false
+		}
+		p.Logger().Debug("Restarting plugin")
+		if err := m.startProcess(ctx, p, lc, true); err != nil {
+			p.Logger().Error("Failed to restart plugin", "error", err)
+			return lc.state() == backendplugin.PluginStateDisabled
+		}
+		p.Logger().Debug("Plugin restarted")
+		return false
+	case backendplugin.PluginStateDisabled:
+		return true
+	}
+	return false
+}
+
+// PluginStatuses returns process statuses of registered backend plugins.
+func (m *Manager) PluginStatuses() []backendplugin.PluginStatus {
+	m.pluginsMu.RLock()
+	registered := make([]backendplugin.Plugin, 0, len(m.plugins))
+	for _, p := range m.plugins {
+		registered = append(registered, p)
+	}
+	m.pluginsMu.RUnlock()
+
+	statuses := make([]backendplugin.PluginStatus, 0, len(registered))
+	for _, p := range registered {
+		if p.IsDecommissioned() {
+			continue
+		}
+		statuses = append(statuses, m.lifecycle(p).getStatus())
+	}
+	sort.Slice(statuses, func(i, j int) bool {
+		return statuses[i].PluginID < statuses[j].PluginID
+	})
+	return statuses
+}
+
+// RestartPlugin restarts a backend plugin process, also one disabled after crashing.
+func (m *Manager) RestartPlugin(ctx context.Context, pluginID string) error {
+	p, registered := m.Get(pluginID)
+	if !registered {
+		return backendplugin.ErrPluginNotRegistered
+	}
+
+	lc := m.lifecycle(p)
+	lc.mu.Lock()
+	if lc.ctx == nil {
+		lc.ctx = context.Background()
+	}
+	procCtx := lc.ctx
+	lc.mu.Unlock()
+
+	lc.procMu.Lock()
+	if !p.Exited() {
+		if err := p.Stop(ctx); err != nil {
+			lc.procMu.Unlock()
+			return err
+		}
+	}
+	lc.reset()
+	err := m.startProcess(procCtx, p, lc, true)
+	lc.procMu.Unlock()
+
+	m.monitorProcess(lc, p)
+	return err
+}
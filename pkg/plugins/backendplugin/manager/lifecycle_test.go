@@ -0,0 +1,126 @@
+package manager
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/grafana/grafana/pkg/infra/log"
+	"github.com/grafana/grafana/pkg/plugins/backendplugin"
+	"github.com/grafana/grafana/pkg/setting"
+	"github.com/stretchr/testify/require"
+)
+
+func TestRestartPolicy_backoff(t *testing.T) {
+	policy := restartPolicy{backoffInitial: time.Second, backoffMax: 10 * time.Second}
+	require.Equal(t, time.Second, policy.backoff(1))
+	require.Equal(t, 2*time.Second, policy.backoff(2))
+	require.Equal(t, 8*time.Second, policy.backoff(4))
+	require.Equal(t, 10*time.Second, policy.backoff(5))
+	require.Equal(t, 10*time.Second, policy.backoff(100))
+
+	require.Equal(t, defaultRestartBackoffInitial, restartPolicy{}.backoff(1))
+}
+
+func TestManager_pluginLifecycle(t *testing.T) {
+	cfg := setting.NewCfg()
+	cfg.PluginRestartBackoffInitial = time.Second
+	cfg.PluginRestartBackoffMax = 4 * time.Second
+	cfg.PluginMaxConsecutiveRestarts = 3
+
+	m := &Manager{
+		Cfg:     cfg,
+		logger:  log.New("test"),
+		plugins: map[string]backendplugin.Plugin{},
+	}
+	plugin := &testPlugin{pluginID: testPluginID, logger: log.New("test"), managed: true}
+	m.plugins[testPluginID] = plugin
+
+	ctx, cancel := context.WithCancel(context.Background())
+	t.Cleanup(cancel)
+
+	statusOf := func() backendplugin.PluginStatus {
+		statuses := m.PluginStatuses()
+		require.Len(t, statuses, 1)
+		return statuses[0]
+	}
+
+	require.Equal(t, backendplugin.PluginStateNotStarted, statusOf().State)
+
+	lc := m.lifecycle(plugin)
+	lc.ctx = ctx
+	require.NoError(t, m.startProcess(ctx, plugin, lc, false))
+	require.Equal(t, backendplugin.PluginStateRunning, statusOf().State)
+
+	now := time.Now()
+	require.False(t, m.checkProcess(ctx, plugin, lc, now))
+	require.Equal(t, 1, plugin.startCount)
+
+	t.Run("Exited process is restarted with backoff", func(t *testing.T) {
+		plugin.kill()
+		require.False(t, m.checkProcess(ctx, plugin, lc, now))
+		status := statusOf()
+		require.Equal(t, backendplugin.PluginStateCrashLooping, status.State)
+		require.Equal(t, "plugin process exited", status.LastExitReason)
+		require.Equal(t, now.Add(time.Second), *status.NextRestartTime)
+
+		// not restarted before backoff elapses
+		require.False(t, m.checkProcess(ctx, plugin, lc, now.Add(500*time.Millisecond)))
+		require.Equal(t, 1, plugin.startCount)
+
+		now = now.Add(time.Second)
+		require.False(t, m.checkProcess(ctx, plugin, lc, now))
+		require.Equal(t, 2, plugin.startCount)
+		status = statusOf()
+		require.Equal(t, backendplugin.PluginStateRunning, status.State)
+		require.Equal(t, 1, status.Restarts)
+
+		plugin.kill()
+		require.False(t, m.checkProcess(ctx, plugin, lc, now))
+		require.Equal(t, now.Add(2*time.Second), *statusOf().NextRestartTime)
+		now = now.Add(2 * time.Second)
+		require.False(t, m.checkProcess(ctx, plugin, lc, now))
+		require.Equal(t, 3, plugin.startCount)
+	})
+
+	t.Run("Stable process resets consecutive restarts", func(t *testing.T) {
+		require.Equal(t, 2, statusOf().ConsecutiveRestarts)
+		now = now.Add(stableRunDuration)
+		require.False(t, m.checkProcess(ctx, plugin, lc, now))
+		require.Equal(t, 0, statusOf().ConsecutiveRestarts)
+	})
+
+	t.Run("Process exiting too many times is disabled", func(t *testing.T) {
+		for i := 0; i < 3; i++ {
+			plugin.kill()
+			require.False(t, m.checkProcess(ctx, plugin, lc, now))
+			now = now.Add(time.Minute)
+			require.False(t, m.checkProcess(ctx, plugin, lc, now))
+		}
+		plugin.kill()
+		require.True(t, m.checkProcess(ctx, plugin, lc, now))
+
+		status := statusOf()
+		require.Equal(t, backendplugin.PluginStateDisabled, status.State)
+		require.Nil(t, status.NextRestartTime)
+		require.Equal(t, 5, status.Restarts)
+
+		now = now.Add(time.Hour)
+		require.True(t, m.checkProcess(ctx, plugin, lc, now))
+		require.Equal(t, 6, plugin.startCount)
+	})
+
+	t.Run("Disabled process can be restarted manually", func(t *testing.T) {
+		require.NoError(t, m.RestartPlugin(context.Background(), testPluginID))
+		require.Equal(t, 7, plugin.startCount)
+
+		status := statusOf()
+		require.Equal(t, backendplugin.PluginStateRunning, status.State)
+		require.Equal(t, 0, status.ConsecutiveRestarts)
+		require.Equal(t, 6, status.Restarts)
+	})
+
+	t.Run("Restarting unregistered plugin fails", func(t *testing.T) {
+		require.Equal(t, backendplugin.ErrPluginNotRegistered, m.RestartPlugin(context.Background(), "unknown"))
+	})
+}
@@ -11,7 +11,6 @@ import (
 	"net/url"
 	"strings"
 	"sync"
-	"time"
 
 	"github.com/grafana/grafana-aws-sdk/pkg/awsds"
 	"github.com/grafana/grafana-plugin-sdk-go/backend"
@@ -32,6 +31,7 @@ func ProvideService(cfg *setting.Cfg, licensing models.Licensing,
 		PluginRequestValidator: pluginRequestValidator,
 		logger:                 log.New("plugins.backend"),
 		plugins:                map[string]backendplugin.Plugin{},
+		lifecycles:             map[string]*pluginLifecycle{},
 	}
 	return s
 }
@@ -43,6 +43,8 @@ type Manager struct {
 	pluginsMu              sync.RWMutex
 	plugins                map[string]backendplugin.Plugin
 	logger                 log.Logger
+	lifecyclesMu           sync.Mutex
+	lifecycles             map[string]*pluginLifecycle
 }
 
 func (m *Manager) Run(ctx context.Context) error {
@@ -134,6 +136,7 @@ func (m *Manager) UnregisterAndStop(ctx context.Context, pluginID string) error
 	}
 
 	delete(m.plugins, pluginID)
+	m.removeLifecycle(pluginID)
 
 	m.logger.Debug("Backend plugin unregistered", "pluginId", pluginID)
 	return nil
@@ -190,7 +193,7 @@ func (m *Manager) start(ctx context.Context, p backendplugin.Plugin) {
 		return
 	}
 
-	if err := startPluginAndRestartKilledProcesses(ctx, p); err != nil {
+	if err := m.startPluginAndRestartKilledProcesses(ctx, p); err != nil {
 		p.Logger().Error("Failed to start plugin", "error", err)
 	}
 }
@@ -208,7 +211,7 @@ func (m *Manager) StartPlugin(ctx context.Context, pluginID string) error {
 		return errors.New("backend plugin is managed and cannot be manually started")
 	}
 
-	return startPluginAndRestartKilledProcesses(ctx, p)
+	return m.startPluginAndRestartKilledProcesses(ctx, p)
 }
 
 // stop stops all managed backend plugins
@@ -480,50 +483,6 @@ func flushStream(plugin backendplugin.Plugin, stream callResourceClientResponseS
 	}
 }
 
-func startPluginAndRestartKilledProcesses(ctx context.Context, p backendplugin.Plugin) error {
-	if err := p.Start(ctx); err != nil {
-		return err
-	}
-
-	go func(ctx context.Context, p backendplugin.Plugin) {
-		if err := restartKilledProcess(ctx, p); err != nil {
-			p.Logger().Error("Attempt to restart killed plugin process failed", "error", err)
-		}
-	}(ctx, p)
-
-	return nil
-}
-
-func restartKilledProcess(ctx context.Context, p backendplugin.Plugin) error {
-	ticker := time.NewTicker(time.Second * 1)
-
-	for {
-		select {
-		case <-ctx.Done():
-			if err := ctx.Err(); err != nil && !errors.Is(err, context.Canceled) {
-				return err
-			}
-			return nil
-		case <-ticker.C:
-			if p.IsDecommissioned() {
-				p.Logger().Debug("Plugin decommissioned")
-				return nil
-			}
-
-			if !p.Exited() {
-				continue
-			}
-
-			p.Logger().Debug("Restarting plugin")
-			if err := p.Start(ctx); err != nil {
-				p.Logger().Error("Failed to restart plugin", "error", err)
-				continue
-			}
-			p.Logger().Debug("Plugin restarted")
-		}
-	}
-}
-
 // callResourceClientResponseStream is used for receiving resource call responses.
 type callResourceClientResponseStream interface {
 	Recv() (*backend.CallResourceResponse, error)
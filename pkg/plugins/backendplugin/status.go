@@ -0,0 +1,43 @@
+package backendplugin
+
+import "time"
+
+// PluginState is a lifecycle state of a backend plugin process.
+type PluginState string
+
+const (
+	// PluginStateNotStarted means the plugin is registered but its process was never started.
+	PluginStateNotStarted PluginState = "notStarted"
+	// PluginStateStarting means the plugin process is being started.
+	PluginStateStarting PluginState = "starting"
+	// PluginStateRunning means the plugin process is running.
+	PluginStateRunning PluginState = "running"
+	// PluginStateCrashLooping means the plugin process exited and waits to be restarted.
+	PluginStateCrashLooping PluginState = "crashLooping"
+	// PluginStateDisabled means the plugin process exited too many times in a row
+	// and will not be restarted until it's restarted manually.
+	PluginStateDisabled PluginState = "disabled"
+)
+
+// PluginStates lists all plugin lifecycle states.
+var PluginStates = []PluginState{
+	PluginStateNotStarted,
+	PluginStateStarting,
+	PluginStateRunning,
+	PluginStateCrashLooping,
+	PluginStateDisabled,
+}
+
+// PluginStatus describes the state of a backend plugin process.
+type PluginStatus struct {
+	PluginID string      `json:"pluginId"`
+	Managed  bool        `json:"managed"`
+	State    PluginState `json:"state"`
+	// Restarts is a total number of plugin process restarts.
+	Restarts int `json:"restarts"`
+	// ConsecutiveRestarts is a number of restarts since the plugin process last ran stable.
+	ConsecutiveRestarts int        `json:"consecutiveRestarts"`
+	LastExitReason      string     `json:"lastExitReason,omitempty"`
+	LastExitTime        *time.Time `json:"lastExitTime,omitempty"`
+	NextRestartTime     *time.Time `json:"nextRestartTime,omitempty"`
+}
@@ -1,6 +1,7 @@
 package manager
 
 import (
+	"context"
 	"encoding/json"
 	"fmt"
 	"regexp"
@@ -60,7 +61,7 @@ func (pm *PluginManager) ImportDashboard(pluginID, path string, orgID, folderID
 		User:      user,
 	}
 
-	savedDash, err := dashboards.NewService(pm.SQLStore).ImportDashboard(dto)
+	savedDash, err := dashboards.NewService(pm.SQLStore).ImportDashboard(context.TODO(), dto)
 	if err != nil {
 		return plugins.PluginDashboardInfoDTO{}, &models.Dashboard{}, err
 	}
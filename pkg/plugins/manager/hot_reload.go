@@ -0,0 +1,160 @@
+package manager
+
+import (
+	"context"
+	"fmt"
+	"hash/fnv"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"github.com/grafana/grafana/pkg/infra/log"
+	"github.com/grafana/grafana/pkg/plugins"
+)
+
+// pluginDirWatcher detects changes of external plugin directories by
+// comparing fingerprints of their files between checks.
+type pluginDirWatcher struct {
+	log          log.Logger
+	fingerprints map[string]uint64
+}
+
+func newPluginDirWatcher(logger log.Logger) *pluginDirWatcher {
+	return &pluginDirWatcher{log: logger, fingerprints: map[string]uint64{}}
+}
+
+// changedDirs returns directories which were added, removed or had any of
+// their files changed since the previous call.
+func (w *pluginDirWatcher) changedDirs(dirs []string) []string {
+	var changed []string
+	current := make(map[string]uint64, len(dirs))
+	for _, dir := range dirs {
+		fingerprint, err := fingerprintDir(dir)
+		if err != nil {
+			w.log.Debug("Failed to fingerprint plugin directory", "dir", dir, "err", err)
+			continue
+		}
+		current[dir] = fingerprint
+		if previous, ok := w.fingerprints[dir]; !ok || previous != fingerprint {
+			changed = append(changed, dir)
+		}
+	}
+	for dir := range w.fingerprints {
+		if _, ok := current[dir]; !ok {
+			changed = append(changed, dir)
+		}
+	}
+	w.fingerprints = current
+	sort.Strings(changed)
+	return changed
+}
+
+// fingerprintDir hashes paths, sizes and modification times of all files
+// in a plugin directory.
+func fingerprintDir(dir string) (uint64, error) {
+	resolved, err := filepath.EvalSymlinks(dir)
+	if err != nil {
+		return 0, err
+	}
+	h := fnv.New64a()
+	err = filepath.Walk(resolved, func(path string, info os.FileInfo, err error) error {
+		if err != nil {
+			return err
+		}
+		if info.IsDir() {
+			if info.Name() == "node_modules" || info.Name() == ".git" {
+				return filepath.SkipDir
+			}
+			return nil
+		}
+		_, err = fmt.Fprintf(h, "%s:%d:%d\n", path, info.Size(), info.ModTime().UnixNano())
+		return err
+	})
+	if err != nil {
+		return 0, err
+	}
+	return h.Sum64(), nil
+}
+
+// externalPluginDirs lists directories of external plugins, that is every
+// directory in the plugins path and paths configured for plugins.
+func (pm *PluginManager) externalPluginDirs() []string {
+	var dirs []string
+	entries, err := ioutil.ReadDir(pm.Cfg.PluginsPath)
+	if err != nil && !os.IsNotExist(err) {
+		pm.log.Warn("Failed to read plugins directory", "dir", pm.Cfg.PluginsPath, "err", err)
+	}
+	for _, entry := range entries {
+		path := filepath.Join(pm.Cfg.PluginsPath, entry.Name())
+		if fi, err := os.Stat(path); err == nil && fi.IsDir() {
+			dirs = append(dirs, path)
+		}
+	}
+	for _, settings := range pm.Cfg.PluginSettings {
+		if path := settings["path"]; path != "" {
+			dirs = append(dirs, path)
+		}
+	}
+	return dirs
+}
+
+func isSubPath(path, dir string) bool {
+	rel, err := filepath.Rel(dir, path)
+	return err == nil && rel != ".." && !strings.HasPrefix(rel, ".."+string(filepath.Separator))
+}
+
+// reloadChangedPlugins unloads plugins from changed directories and scans
+// external plugins again so changed plugins are validated and registered anew.
+func (pm *PluginManager) reloadChangedPlugins(ctx context.Context, watcher *pluginDirWatcher) error {
+	changed := watcher.changedDirs(pm.externalPluginDirs())
+	if len(changed) == 0 {
+		return nil
+	}
+
+	var unloaded []string
+	for _, p := range pm.Plugins() {
+		if p.IsCorePlugin {
+			continue
+		}
+		for _, dir := range changed {
+			if !isSubPath(p.PluginDir, dir) {
+				continue
+			}
+			if err := pm.unloadPlugin(ctx, p); err != nil {
+				return err
+			}
+			unloaded = append(unloaded, p.Id)
+			break
+		}
+	}
+
+	// Core and bundled plugins are scanned without signature requirements, so
+	// scanning errors only come from external plugins which are scanned again.
+	pm.pluginsMu.Lock()
+	pm.scanningErrors = nil
+	pm.pluginScanningErrors = map[string]plugins.PluginError{}
+	pm.pluginsMu.Unlock()
+	if err := pm.initExternalPlugins(); err != nil {
+		return err
+	}
+
+	for _, id := range unloaded {
+		if pm.GetPlugin(id) == nil {
+			pm.log.Warn("Plugin removed by hot reload", "id", id)
+			continue
+		}
+		pm.log.Info("Plugin reloaded", "id", id)
+	}
+	return nil
+}
+
+func (pm *PluginManager) unloadPlugin(ctx context.Context, p *plugins.PluginBase) error {
+	if pm.BackendPluginManager.IsRegistered(p.Id) {
+		if err := pm.BackendPluginManager.UnregisterAndStop(ctx, p.Id); err != nil {
+			return err
+		}
+	}
+	return pm.unregister(p)
+}
@@ -0,0 +1,105 @@
+package manager
+
+import (
+	"context"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/grafana/grafana/pkg/setting"
+	"github.com/stretchr/testify/require"
+)
+
+func writeTestPlugin(t *testing.T, dir, pluginJSON string) {
+	t.Helper()
+
+	require.NoError(t, os.MkdirAll(dir, 0750))
+	require.NoError(t, ioutil.WriteFile(filepath.Join(dir, "plugin.json"), []byte(pluginJSON), 0600))
+	require.NoError(t, ioutil.WriteFile(filepath.Join(dir, "module.js"), []byte("define([], function() {});"), 0600))
+
+	// make sure the modification time differs from any previous write
+	future := time.Now().Add(time.Duration(time.Now().UnixNano()%1000+1) * time.Second)
+	require.NoError(t, os.Chtimes(filepath.Join(dir, "plugin.json"), future, future))
+}
+
+func testPanelJSON(id, version string) string {
+	return fmt.Sprintf(`{"type": "panel", "name": "Test", "id": %q, "info": {"version": %q}}`, id, version)
+}
+
+func TestPluginManager_reloadChangedPlugins(t *testing.T) {
+	pluginsPath := t.TempDir()
+	writeTestPlugin(t, filepath.Join(pluginsPath, "test-panel"), testPanelJSON("test-panel", "1.0.0"))
+	writeTestPlugin(t, filepath.Join(pluginsPath, "test-datasource"),
+		`{"type": "datasource", "name": "Test", "id": "test-datasource", "backend": true, "executable": "gpx_test", "info": {"version": "1.0.0"}}`)
+
+	fm := &fakeBackendPluginManager{}
+	pm := createManager(t, func(pm *PluginManager) {
+		pm.Cfg.PluginsPath = pluginsPath
+		pm.Cfg.Env = setting.Dev
+		pm.BackendPluginManager = fm
+	})
+	require.NoError(t, pm.init())
+	require.Equal(t, "1.0.0", pm.GetPlugin("test-panel").Info.Version)
+	require.Equal(t, []string{"test-datasource"}, fm.registeredPlugins)
+
+	watcher := newPluginDirWatcher(pm.log)
+	watcher.changedDirs(pm.externalPluginDirs())
+	ctx := context.Background()
+
+	t.Run("Nothing changed", func(t *testing.T) {
+		require.NoError(t, pm.reloadChangedPlugins(ctx, watcher))
+		require.Equal(t, "1.0.0", pm.GetPlugin("test-panel").Info.Version)
+	})
+
+	t.Run("Changed plugin is reloaded", func(t *testing.T) {
+		writeTestPlugin(t, filepath.Join(pluginsPath, "test-panel"), testPanelJSON("test-panel", "1.1.0"))
+		require.NoError(t, pm.reloadChangedPlugins(ctx, watcher))
+		require.Equal(t, "1.1.0", pm.GetPlugin("test-panel").Info.Version)
+		require.Equal(t, "1.1.0", pm.panels["test-panel"].Info.Version)
+	})
+
+	t.Run("Changed backend plugin is registered again", func(t *testing.T) {
+		require.NoError(t, ioutil.WriteFile(filepath.Join(pluginsPath, "test-datasource", "gpx_test_linux_amd64"), []byte("binary"), 0600))
+		require.NoError(t, pm.reloadChangedPlugins(ctx, watcher))
+		require.NotNil(t, pm.GetDataSource("test-datasource"))
+		require.Equal(t, []string{"test-datasource"}, fm.registeredPlugins)
+	})
+
+	t.Run("Added plugin is loaded", func(t *testing.T) {
+		writeTestPlugin(t, filepath.Join(pluginsPath, "new-panel"), testPanelJSON("new-panel", "1.0.0"))
+		require.NoError(t, pm.reloadChangedPlugins(ctx, watcher))
+		require.NotNil(t, pm.GetPlugin("new-panel"))
+
+		var routes []string
+		for _, route := range pm.StaticRoutes() {
+			routes = append(routes, route.PluginId)
+		}
+		require.Contains(t, routes, "new-panel")
+	})
+
+	t.Run("Removed plugin is unloaded", func(t *testing.T) {
+		require.NoError(t, os.RemoveAll(filepath.Join(pluginsPath, "new-panel")))
+		require.NoError(t, pm.reloadChangedPlugins(ctx, watcher))
+		require.Nil(t, pm.GetPlugin("new-panel"))
+		require.NotNil(t, pm.GetPlugin("test-panel"))
+	})
+
+	t.Run("Plugins can be read while reloading", func(t *testing.T) {
+		done := make(chan struct{})
+		go func() {
+			defer close(done)
+			for i := 0; i < 10; i++ {
+				_ = pm.StaticRoutes()
+				_ = pm.ScanningErrors()
+				_ = pm.Plugins()
+			}
+		}()
+		writeTestPlugin(t, filepath.Join(pluginsPath, "other-panel"), testPanelJSON("other-panel", "1.0.0"))
+		require.NoError(t, pm.reloadChangedPlugins(ctx, watcher))
+		<-done
+		require.NotNil(t, pm.GetPlugin("other-panel"))
+	})
+}
@@ -23,6 +23,7 @@ import (
 	"strings"
 	"time"
 
+	"github.com/Masterminds/semver"
 	"github.com/grafana/grafana/pkg/plugins"
 	"github.com/grafana/grafana/pkg/util/errutil"
 )
@@ -80,6 +81,16 @@ func (e ErrVersionNotFound) Error() string {
 	return fmt.Sprintf("%s v%s either does not exist or is not supported on your system (%s)", e.PluginID, e.RequestedVersion, e.SystemInfo)
 }
 
+type ErrVersionMismatch struct {
+	PluginID         string
+	RequestedVersion string
+	InstalledVersion string
+}
+
+func (e ErrVersionMismatch) Error() string {
+	return fmt.Sprintf("%s archive contains v%s while v%s was requested", e.PluginID, e.InstalledVersion, e.RequestedVersion)
+}
+
 func New(skipTLSVerify bool, grafanaVersion string, logger plugins.PluginInstallerLogger) *Installer {
 	return &Installer{
 		httpClient:          makeHttpClient(skipTLSVerify, 10*time.Second),
@@ -132,12 +143,217 @@ func (i *Installer) Install(ctx context.Context, pluginID, version, pluginsDir,
 		}
 	}
 
+	res, err := i.installArchive(pluginID, pluginsDir, pluginZipURL, checksum, isInternal)
+	if err != nil {
+		return err
+	}
+
+	i.log.Successf("Downloaded %s v%s zip successfully", res.ID, res.Info.Version)
+
+	// download dependency plugins
+	for _, dep := range res.Dependencies.Plugins {
+		i.log.Infof("Fetching %s dependencies...", res.ID)
+		if err := i.Install(ctx, dep.ID, NormalizeVersion(dep.Version), pluginsDir, "", pluginRepoURL); err != nil {
+			return errutil.Wrapf(err, "failed to install plugin %s", dep.ID)
+		}
+	}
+
+	return nil
+}
+
+// InstallPinned installs exactly the requested version of a plugin from the source described by
+// plugin. The archive checksum, either plugin.Checksum or the one published by the repository,
+// is required and verified. It is recorded next to the installed plugins so InstalledChecksum can
+// tell whether the installed plugin comes from the expected archive. Dependencies are not
+// installed, they are returned as a part of the installed plugin so the caller can decide where
+// to take them from.
+func (i *Installer) InstallPinned(ctx context.Context, plugin PinnedPlugin, pluginsDir string) (InstalledPlugin, error) {
+	archiveURL, checksum, err := i.pinnedArchive(plugin)
+	if err != nil {
+		return InstalledPlugin{}, err
+	}
+
+	res, err := i.installArchive(plugin.ID, pluginsDir, archiveURL, checksum, false)
+	if err != nil {
+		return InstalledPlugin{}, err
+	}
+	if res.Info.Version != plugin.Version {
+		if err := os.RemoveAll(filepath.Join(pluginsDir, plugin.ID)); err != nil {
+			i.log.Warn("Failed to remove plugin with mismatching version", "pluginId", plugin.ID, "err", err)
+		}
+		return InstalledPlugin{}, ErrVersionMismatch{
+			PluginID:         plugin.ID,
+			RequestedVersion: plugin.Version,
+			InstalledVersion: res.Info.Version,
+		}
+	}
+
+	if err := writeInstalledChecksum(pluginsDir, plugin.ID, checksum); err != nil {
+		return InstalledPlugin{}, errutil.Wrap("failed to record plugin archive checksum", err)
+	}
+
+	i.log.Successf("Installed %s v%s", res.ID, res.Info.Version)
+	return res, nil
+}
+
+// PinnedChecksum returns the expected SHA256 checksum of the archive of a pinned plugin, either
+// plugin.Checksum or the one published by the repository.
+func (i *Installer) PinnedChecksum(plugin PinnedPlugin) (string, error) {
+	_, checksum, err := i.pinnedArchive(plugin)
+	return checksum, err
+}
+
+// pinnedArchive returns the archive location and the expected checksum of a pinned plugin.
+func (i *Installer) pinnedArchive(plugin PinnedPlugin) (string, string, error) {
+	if plugin.Version == "" {
+		return "", "", fmt.Errorf("plugin %s has no pinned version", plugin.ID)
+	}
+
+	archiveURL := plugin.URL
+	if plugin.Path != "" {
+		archiveURL = plugin.Path
+	}
+	checksum := strings.ToLower(plugin.Checksum)
+	if archiveURL == "" {
+		repoURL := plugin.Repository
+		if repoURL == "" {
+			repoURL = DefaultRepositoryURL
+		}
+		meta, err := i.getPluginMetadataFromPluginRepo(plugin.ID, repoURL)
+		if err != nil {
+			return "", "", err
+		}
+		v, err := i.selectVersion(&meta, plugin.Version)
+		if err != nil {
+			return "", "", err
+		}
+		archiveURL = fmt.Sprintf("%s/%s/versions/%s/download", repoURL, plugin.ID, v.Version)
+		if checksum == "" && v.Arch != nil {
+			archMeta, exists := v.Arch[osAndArchString()]
+			if !exists {
+				archMeta = v.Arch["any"]
+			}
+			checksum = strings.ToLower(archMeta.SHA256)
+		}
+	}
+	if checksum == "" {
+		return "", "", fmt.Errorf("no checksum is known for %s v%s archive", plugin.ID, plugin.Version)
+	}
+	return archiveURL, checksum, nil
+}
+
+// InstalledChecksum returns the checksum of the archive a plugin was installed from by
+// InstallPinned, or an empty string if the plugin was installed in some other way.
+func InstalledChecksum(pluginsDir, pluginID string) string {
+	// nolint:gosec
+	// We can ignore the gosec G304 warning on this one because the path is built from the
+	// plugins directory and the ID of a plugin installed there.
+	b, err := ioutil.ReadFile(installedChecksumPath(pluginsDir, pluginID))
+	if err != nil {
+		return ""
+	}
+	return strings.TrimSpace(string(b))
+}
+
+// installedChecksumPath returns the path of a file with a recorded archive checksum. The files
+// are kept outside of plugin directories, where they would break plugin signatures.
+func installedChecksumPath(pluginsDir, pluginID string) string {
+	return filepath.Join(pluginsDir, ".checksums", pluginID+".sha256")
+}
+
+func writeInstalledChecksum(pluginsDir, pluginID, checksum string) error {
+	path := installedChecksumPath(pluginsDir, pluginID)
+	if err := os.MkdirAll(filepath.Dir(path), 0750); err != nil {
+		return err
+	}
+	return ioutil.WriteFile(path, []byte(checksum+"\n"), 0600)
+}
+
+func removeInstalledChecksum(pluginsDir, pluginID string) error {
+	if err := os.Remove(installedChecksumPath(pluginsDir, pluginID)); err != nil && !os.IsNotExist(err) {
+		return err
+	}
+	return nil
+}
+
+// InstalledVersion returns the version of a plugin installed in the provided plugins directory,
+// or an empty string if the plugin is not installed.
+func InstalledVersion(pluginsDir, pluginID string) string {
+	res, err := toPluginDTO(pluginsDir, pluginID)
+	if err != nil {
+		return ""
+	}
+	return res.Info.Version
+}
+
+// VersionSatisfies returns true when a plugin version satisfies a version constraint, such as
+// the ">=7.0.0" or "^1.2.0" ranges used by plugin dependencies. An empty constraint allows any
+// version.
+func VersionSatisfies(version, constraint string) bool {
+	c, err := versionConstraint(constraint)
+	if err != nil {
+		return false
+	}
+	v, err := semver.NewVersion(version)
+	if err != nil {
+		return false
+	}
+	return c.Check(v)
+}
+
+// ResolveVersion returns the latest version of a plugin in the repository which satisfies a
+// version constraint and is supported on the current system.
+func (i *Installer) ResolveVersion(pluginID, constraint, pluginRepoURL string) (string, error) {
+	c, err := versionConstraint(constraint)
+	if err != nil {
+		return "", fmt.Errorf("invalid version constraint %q of plugin %s: %w", constraint, pluginID, err)
+	}
+	if pluginRepoURL == "" {
+		pluginRepoURL = DefaultRepositoryURL
+	}
+	plugin, err := i.getPluginMetadataFromPluginRepo(pluginID, pluginRepoURL)
+	if err != nil {
+		return "", err
+	}
+
+	// Versions are listed from the latest one.
+	for _, v := range plugin.Versions {
+		ver := v
+		sv, err := semver.NewVersion(ver.Version)
+		if err != nil || !c.Check(sv) || !supportsCurrentArch(&ver) {
+			continue
+		}
+		return ver.Version, nil
+	}
+	return "", ErrVersionNotFound{
+		PluginID:         pluginID,
+		RequestedVersion: constraint,
+		SystemInfo:       i.fullSystemInfoString(),
+	}
+}
+
+func versionConstraint(constraint string) (*semver.Constraints, error) {
+	constraint = strings.TrimSpace(constraint)
+	if constraint == "" {
+		constraint = "*"
+	}
+	return semver.NewConstraint(constraint)
+}
+
+// installArchive downloads a plugin archive from the URL or local file path, verifies its checksum
+// if provided and extracts it into the plugins directory.
+func (i *Installer) installArchive(pluginID, pluginsDir, pluginZipURL, checksum string, isInternal bool) (InstalledPlugin, error) {
 	i.log.Debugf("Installing plugin\nfrom: %s\ninto: %s", pluginZipURL, pluginsDir)
 
+	// Files of the plugin are replaced, so the recorded checksum no longer applies.
+	if err := removeInstalledChecksum(pluginsDir, pluginID); err != nil {
+		return InstalledPlugin{}, errutil.Wrap("failed to remove recorded plugin archive checksum", err)
+	}
+
 	// Create temp file for downloading zip file
 	tmpFile, err := ioutil.TempFile("", "*.zip")
 	if err != nil {
-		return errutil.Wrap("failed to create temporary file", err)
+		return InstalledPlugin{}, errutil.Wrap("failed to create temporary file", err)
 	}
 	defer func() {
 		if err := os.Remove(tmpFile.Name()); err != nil {
@@ -150,31 +366,20 @@ func (i *Installer) Install(ctx context.Context, pluginID, version, pluginsDir,
 		if err := tmpFile.Close(); err != nil {
 			i.log.Warn("Failed to close file", "err", err)
 		}
-		return errutil.Wrap("failed to download plugin archive", err)
+		return InstalledPlugin{}, errutil.Wrap("failed to download plugin archive", err)
 	}
 	err = tmpFile.Close()
 	if err != nil {
-		return errutil.Wrap("failed to close tmp file", err)
+		return InstalledPlugin{}, errutil.Wrap("failed to close tmp file", err)
 	}
 
 	err = i.extractFiles(tmpFile.Name(), pluginID, pluginsDir, isInternal)
 	if err != nil {
-		return errutil.Wrap("failed to extract plugin archive", err)
+		return InstalledPlugin{}, errutil.Wrap("failed to extract plugin archive", err)
 	}
 
 	res, _ := toPluginDTO(pluginsDir, pluginID)
-
-	i.log.Successf("Downloaded %s v%s zip successfully", res.ID, res.Info.Version)
-
-	// download dependency plugins
-	for _, dep := range res.Dependencies.Plugins {
-		i.log.Infof("Fetching %s dependencies...", res.ID)
-		if err := i.Install(ctx, dep.ID, normalizeVersion(dep.Version), pluginsDir, "", pluginRepoURL); err != nil {
-			return errutil.Wrapf(err, "failed to install plugin %s", dep.ID)
-		}
-	}
-
-	return err
+	return res, nil
 }
 
 // Uninstall removes the specified plugin from the provided plugin directory.
@@ -192,6 +397,9 @@ func (i *Installer) Uninstall(ctx context.Context, pluginDir string) error {
 
 	i.log.Infof("Uninstalling plugin %v", pluginDir)
 
+	if err := removeInstalledChecksum(filepath.Dir(pluginDir), filepath.Base(pluginDir)); err != nil {
+		return err
+	}
 	return os.RemoveAll(pluginDir)
 }
 
@@ -210,10 +418,14 @@ func (i *Installer) DownloadFile(pluginID string, tmpFile *os.File, url string,
 				i.log.Warn("Failed to close file", "err", err)
 			}
 		}()
-		_, err = io.Copy(tmpFile, f)
+		h := sha256.New()
+		_, err = io.Copy(tmpFile, io.TeeReader(f, h))
 		if err != nil {
 			return errutil.Wrap("Failed to copy plugin archive", err)
 		}
+		if len(checksum) > 0 && checksum != fmt.Sprintf("%x", h.Sum(nil)) {
+			return fmt.Errorf("expected SHA256 checksum does not match the plugin archive %q", url)
+		}
 		return nil
 	}
 
@@ -401,7 +613,8 @@ func makeHttpClient(skipTLSVerify bool, timeout time.Duration) http.Client {
 	}
 }
 
-func normalizeVersion(version string) string {
+// NormalizeVersion strips a version range prefix from a plugin dependency version.
+func NormalizeVersion(version string) string {
 	normalized := strings.ReplaceAll(version, " ", "")
 	if strings.HasPrefix(normalized, "^") || strings.HasPrefix(normalized, "v") {
 		return normalized[1:]
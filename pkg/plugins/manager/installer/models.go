@@ -1,5 +1,24 @@
 package installer
 
+// DefaultRepositoryURL is the grafana.com plugin repository API.
+const DefaultRepositoryURL = "https://grafana.com/api/plugins"
+
+// PinnedPlugin is a plugin with an exact version and an archive source. Only one of
+// URL, Path and Repository is expected to be set, Repository is used when neither
+// URL nor Path is set and defaults to DefaultRepositoryURL.
+type PinnedPlugin struct {
+	ID      string
+	Version string
+	// URL of a plugin zip archive.
+	URL string
+	// Path to a local plugin zip archive.
+	Path string
+	// Repository is a URL of a repository implementing the grafana.com plugins API.
+	Repository string
+	// Checksum is an expected SHA256 checksum of the archive, hex encoded.
+	Checksum string
+}
+
 type InstalledPlugin struct {
 	ID           string       `json:"id"`
 	Name         string       `json:"name"`
@@ -48,6 +48,7 @@ type PluginScanner struct {
 	log                           log.Logger
 	plugins                       map[string]*plugins.PluginBase
 	allowUnsignedPluginsCondition unsignedPluginConditionFunc
+	signingKeyring                *signingKeyring
 }
 
 type PluginManager struct {
@@ -64,6 +65,7 @@ type PluginManager struct {
 	grafanaLatestVersion          string
 	grafanaHasUpdate              bool
 	pluginScanningErrors          map[string]plugins.PluginError
+	signingKeyring                *signingKeyring
 
 	renderer     *plugins.RendererPlugin
 	dataSources  map[string]*plugins.DataSourcePlugin
@@ -98,7 +100,18 @@ func newManager(cfg *setting.Cfg, sqlStore *sqlstore.SQLStore, backendPM backend
 
 func (pm *PluginManager) init() error {
 	plog = log.New("plugins")
-	pm.pluginInstaller = installer.New(false, pm.Cfg.BuildVersion, installerLog)
+	inst := installer.New(false, pm.Cfg.BuildVersion, installerLog)
+	pm.pluginInstaller = inst
+
+	keyring, err := newSigningKeyring(pm.Cfg.PluginsTrustedSigningKeys)
+	if err != nil {
+		return err
+	}
+	pm.signingKeyring = keyring
+
+	if err := pm.installProvisionedPlugins(context.Background(), inst); err != nil {
+		return err
+	}
 
 	pm.log.Info("Starting plugin search")
 
@@ -159,16 +172,20 @@ func (pm *PluginManager) initExternalPlugins() error {
 		staticRoutesList = append(staticRoutesList, staticRoutes...)
 	}
 
-	for _, app := range pm.Apps() {
+	pm.pluginsMu.RLock()
+	for _, app := range pm.apps {
 		staticRoutes := app.InitApp(pm.panels, pm.dataSources, pm.Cfg)
 		staticRoutesList = append(staticRoutesList, staticRoutes...)
 	}
+	pm.pluginsMu.RUnlock()
 
 	if pm.Renderer() != nil {
 		staticRoutes := pm.renderer.InitFrontendPlugin(pm.Cfg)
 		staticRoutesList = append(staticRoutesList, staticRoutes...)
 	}
+	pm.pluginsMu.Lock()
 	pm.staticRoutes = staticRoutesList
+	pm.pluginsMu.Unlock()
 
 	for _, p := range pm.Plugins() {
 		if p.IsCorePlugin {
@@ -187,10 +204,27 @@ func (pm *PluginManager) Run(ctx context.Context) error {
 	ticker := time.NewTicker(time.Minute * 10)
 	run := true
 
+	var hotReloadC <-chan time.Time
+	var watcher *pluginDirWatcher
+	if pm.Cfg.PluginsHotReload && pm.Cfg.Env != setting.Dev {
+		pm.log.Warn("Plugin hot reload is only available in development mode, ignoring it")
+	} else if pm.Cfg.PluginsHotReload {
+		pm.log.Info("Plugin hot reload enabled", "interval", pm.Cfg.PluginsHotReloadInterval)
+		watcher = newPluginDirWatcher(pm.log)
+		watcher.changedDirs(pm.externalPluginDirs())
+		hotReloadTicker := time.NewTicker(pm.Cfg.PluginsHotReloadInterval)
+		defer hotReloadTicker.Stop()
+		hotReloadC = hotReloadTicker.C
+	}
+
 	for run {
 		select {
 		case <-ticker.C:
 			pm.checkForUpdates()
+		case <-hotReloadC:
+			if err := pm.reloadChangedPlugins(ctx, watcher); err != nil {
+				pm.log.Error("Failed to reload changed plugins", "error", err)
+			}
 		case <-ctx.Done():
 			run = false
 		}
@@ -330,6 +364,7 @@ func (pm *PluginManager) scan(pluginDir string, requireSigned bool) error {
 		log:                           pm.log,
 		plugins:                       map[string]*plugins.PluginBase{},
 		allowUnsignedPluginsCondition: pm.AllowUnsignedPluginsCondition,
+		signingKeyring:                pm.signingKeyring,
 	}
 
 	// 1st pass: Scan plugins, also mapping plugins to their respective directories
@@ -398,7 +433,9 @@ func (pm *PluginManager) scan(pluginDir string, requireSigned bool) error {
 		if signingError != nil {
 			pm.log.Debug("Failed to validate plugin signature. Will skip loading", "id", plugin.Id,
 				"signature", plugin.Signature, "status", signingError.ErrorCode)
+			pm.pluginsMu.Lock()
 			pm.pluginScanningErrors[plugin.Id] = *signingError
+			pm.pluginsMu.Unlock()
 			continue
 		}
 
@@ -455,7 +492,9 @@ func (pm *PluginManager) scan(pluginDir string, requireSigned bool) error {
 			errStr = append(errStr, err.Error())
 		}
 		pm.log.Warn("Some plugin scanning errors were found", "errors", strings.Join(errStr, ", "))
+		pm.pluginsMu.Lock()
 		pm.scanningErrors = scanner.errors
+		pm.pluginsMu.Unlock()
 	}
 
 	return nil
@@ -512,6 +551,7 @@ func (pm *PluginManager) loadPlugin(jsonParser *json.Decoder, pluginBase *plugin
 	pb.Signature = pluginBase.Signature
 	pb.SignatureType = pluginBase.SignatureType
 	pb.SignatureOrg = pluginBase.SignatureOrg
+	pb.SignatureKey = pluginBase.SignatureKey
 	pb.SignedFiles = pluginBase.SignedFiles
 
 	pm.plugins[pb.Id] = pb
@@ -574,7 +614,7 @@ func (s *PluginScanner) loadPlugin(pluginJSONFilePath string) error {
 	}
 
 	pluginCommon.PluginDir = filepath.Dir(pluginJSONFilePath)
-	signatureState, err := getPluginSignatureState(s.log, &pluginCommon)
+	signatureState, err := getPluginSignatureState(s.log, s.signingKeyring, &pluginCommon)
 	if err != nil {
 		s.log.Warn("Could not get plugin signature state", "pluginID", pluginCommon.Id, "err", err)
 		return err
@@ -582,6 +622,7 @@ func (s *PluginScanner) loadPlugin(pluginJSONFilePath string) error {
 	pluginCommon.Signature = signatureState.Status
 	pluginCommon.SignatureType = signatureState.Type
 	pluginCommon.SignatureOrg = signatureState.SigningOrg
+	pluginCommon.SignatureKey = signatureState.SigningKey
 	pluginCommon.SignedFiles = signatureState.Files
 
 	s.plugins[currentDir] = &pluginCommon
@@ -596,7 +637,10 @@ func (*PluginScanner) IsBackendOnlyPlugin(pluginType string) bool {
 // validateSignature validates a plugin's signature.
 func (s *PluginScanner) validateSignature(plugin *plugins.PluginBase) *plugins.PluginError {
 	if plugin.Signature == plugins.PluginSignatureValid {
-		s.log.Debug("Plugin has valid signature", "id", plugin.Id)
+		s.log.Debug("Plugin has valid signature", "id", plugin.Id, "signingKey", plugin.SignatureKey)
+		if plugin.SignatureKey != "" && plugin.SignatureKey != grafanaSigningKeyName {
+			s.log.Info("Plugin signed by trusted custom key", "id", plugin.Id, "signingKey", plugin.SignatureKey)
+		}
 		return nil
 	}
 
@@ -609,6 +653,7 @@ func (s *PluginScanner) validateSignature(plugin *plugins.PluginBase) *plugins.P
 			s.log.Debug("Setting descendant plugin's signature to that of root", "plugin", plugin.Id,
 				"root", plugin.Root.Id, "signature", plugin.Signature, "rootSignature", plugin.Root.Signature)
 			plugin.Signature = plugin.Root.Signature
+			plugin.SignatureKey = plugin.Root.SignatureKey
 			if plugin.Signature == plugins.PluginSignatureValid {
 				s.log.Debug("Plugin has valid signature (inherited from root)", "id", plugin.Id)
 				return nil
@@ -672,6 +717,9 @@ func (s *PluginScanner) allowUnsigned(plugin *plugins.PluginBase) bool {
 
 // ScanningErrors returns plugin scanning errors encountered.
 func (pm *PluginManager) ScanningErrors() []plugins.PluginError {
+	pm.pluginsMu.RLock()
+	defer pm.pluginsMu.RUnlock()
+
 	scanningErrs := make([]plugins.PluginError, 0)
 	for id, e := range pm.pluginScanningErrors {
 		scanningErrs = append(scanningErrs, plugins.PluginError{
@@ -683,8 +731,8 @@ func (pm *PluginManager) ScanningErrors() []plugins.PluginError {
 }
 
 func (pm *PluginManager) GetPluginMarkdown(pluginId string, name string) ([]byte, error) {
-	plug, exists := pm.plugins[pluginId]
-	if !exists {
+	plug := pm.GetPlugin(pluginId)
+	if plug == nil {
 		return nil, plugins.PluginNotFoundError{PluginID: pluginId}
 	}
 
@@ -719,7 +767,10 @@ func (pm *PluginManager) GetPluginMarkdown(pluginId string, name string) ([]byte
 }
 
 func (pm *PluginManager) StaticRoutes() []*plugins.PluginStaticRoute {
-	return pm.staticRoutes
+	pm.pluginsMu.RLock()
+	defer pm.pluginsMu.RUnlock()
+
+	return append([]*plugins.PluginStaticRoute{}, pm.staticRoutes...)
 }
 
 func (pm *PluginManager) Install(ctx context.Context, pluginID, version string) error {
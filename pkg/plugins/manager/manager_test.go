@@ -232,6 +232,7 @@ func TestPluginManager_Init(t *testing.T) {
 				Signature:     plugins.PluginSignatureValid,
 				SignatureType: plugins.GrafanaType,
 				SignatureOrg:  "Grafana Labs",
+				SignatureKey:  "grafana",
 				SignedFiles:   plugins.PluginFiles{"plugin.json": {}},
 				Dependencies: plugins.PluginDependencies{
 					GrafanaVersion: "*",
@@ -498,6 +499,7 @@ func TestPluginManager_Installer(t *testing.T) {
 			Signature:     plugins.PluginSignatureValid,
 			SignatureType: plugins.GrafanaType,
 			SignatureOrg:  "Grafana Labs",
+			SignatureKey:  "grafana",
 			SignedFiles:   plugins.PluginFiles{"plugin.json": {}},
 			Dependencies: plugins.PluginDependencies{
 				GrafanaVersion: "*",
@@ -673,6 +675,14 @@ func (f *fakeBackendPluginManager) StartPlugin(ctx context.Context, pluginID str
 	return nil
 }
 
+func (f *fakeBackendPluginManager) PluginStatuses() []backendplugin.PluginStatus {
+	return nil
+}
+
+func (f *fakeBackendPluginManager) RestartPlugin(ctx context.Context, pluginID string) error {
+	return nil
+}
+
 func (f *fakeBackendPluginManager) CollectMetrics(ctx context.Context, pluginID string) (*backend.CollectMetricsResult, error) {
 	return nil, nil
 }
@@ -1,7 +1,6 @@
 package manager
 
 import (
-	"bytes"
 	"crypto/sha256"
 	"encoding/hex"
 	"encoding/json"
@@ -20,7 +19,6 @@ import (
 	"github.com/grafana/grafana/pkg/setting"
 	"github.com/grafana/grafana/pkg/util/errutil"
 
-	"golang.org/x/crypto/openpgp"
 	"golang.org/x/crypto/openpgp/clearsign"
 )
 
@@ -70,37 +68,37 @@ func (m *pluginManifest) isV2() bool {
 	return strings.HasPrefix(m.ManifestVersion, "2.")
 }
 
-// readPluginManifest attempts to read and verify the plugin manifest
-// if any error occurs or the manifest is not valid, this will return an error
-func readPluginManifest(body []byte) (*pluginManifest, error) {
+// readPluginManifest attempts to read and verify the plugin manifest against
+// the trusted keyring, if any error occurs or the manifest is not valid, this
+// will return an error. On success the signing key is returned.
+func readPluginManifest(body []byte, keyring *signingKeyring) (*pluginManifest, signingKey, error) {
 	block, _ := clearsign.Decode(body)
 	if block == nil {
-		return nil, errors.New("unable to decode manifest")
+		return nil, signingKey{}, errors.New("unable to decode manifest")
 	}
 
 	// Convert to a well typed object
 	manifest := &pluginManifest{}
 	err := json.Unmarshal(block.Plaintext, &manifest)
 	if err != nil {
-		return nil, errutil.Wrap("Error parsing manifest JSON", err)
+		return nil, signingKey{}, errutil.Wrap("Error parsing manifest JSON", err)
 	}
 
-	keyring, err := openpgp.ReadArmoredKeyRing(bytes.NewBufferString(publicKeyText))
+	signature, err := ioutil.ReadAll(block.ArmoredSignature.Body)
 	if err != nil {
-		return nil, errutil.Wrap("failed to parse public key", err)
+		return nil, signingKey{}, errutil.Wrap("failed to read signature", err)
 	}
 
-	if _, err := openpgp.CheckDetachedSignature(keyring,
-		bytes.NewBuffer(block.Bytes),
-		block.ArmoredSignature.Body); err != nil {
-		return nil, errutil.Wrap("failed to check signature", err)
+	key, err := keyring.verify(manifest.Plugin, block.Bytes, signature)
+	if err != nil {
+		return nil, signingKey{}, errutil.Wrap("failed to check signature", err)
 	}
 
-	return manifest, nil
+	return manifest, key, nil
 }
 
 // getPluginSignatureState returns the signature state for a plugin.
-func getPluginSignatureState(log log.Logger, plugin *plugins.PluginBase) (plugins.PluginSignatureState, error) {
+func getPluginSignatureState(log log.Logger, keyring *signingKeyring, plugin *plugins.PluginBase) (plugins.PluginSignatureState, error) {
 	log.Debug("Getting signature state of plugin", "plugin", plugin.Id, "isBackend", plugin.Backend)
 	manifestPath := filepath.Join(plugin.PluginDir, "MANIFEST.txt")
 
@@ -115,14 +113,28 @@ func getPluginSignatureState(log log.Logger, plugin *plugins.PluginBase) (plugin
 		}, nil
 	}
 
-	manifest, err := readPluginManifest(byteValue)
+	manifest, signingKey, err := readPluginManifest(byteValue, keyring)
 	if err != nil {
-		log.Debug("Plugin signature invalid", "id", plugin.Id)
+		log.Debug("Plugin signature invalid", "id", plugin.Id, "err", err)
 		return plugins.PluginSignatureState{
 			Status: plugins.PluginSignatureInvalid,
 		}, nil
 	}
 
+	// The signature type is derived from the key, plugins signed by custom
+	// keys are private and can't claim to be signed by Grafana.
+	signatureType := manifest.SignatureType
+	if !signingKey.builtIn {
+		if signatureType == plugins.GrafanaType {
+			log.Warn("Plugin signed by custom key claims the grafana signature type", "id", plugin.Id,
+				"signingKey", signingKey.name)
+			return plugins.PluginSignatureState{
+				Status: plugins.PluginSignatureInvalid,
+			}, nil
+		}
+		signatureType = plugins.PrivateType
+	}
+
 	// Make sure the versions all match
 	if manifest.Plugin != plugin.Id || manifest.Version != plugin.Info.Version {
 		return plugins.PluginSignatureState{
@@ -131,7 +143,7 @@ func getPluginSignatureState(log log.Logger, plugin *plugins.PluginBase) (plugin
 	}
 
 	// Validate that private is running within defined root URLs
-	if manifest.SignatureType == plugins.PrivateType {
+	if signatureType == plugins.PrivateType {
 		appURL, err := url.Parse(setting.AppUrl)
 		if err != nil {
 			return plugins.PluginSignatureState{}, err
@@ -210,11 +222,12 @@ func getPluginSignatureState(log log.Logger, plugin *plugins.PluginBase) (plugin
 	}
 
 	// Everything OK
-	log.Debug("Plugin signature valid", "id", plugin.Id)
+	log.Debug("Plugin signature valid", "id", plugin.Id, "signingKey", signingKey.name)
 	return plugins.PluginSignatureState{
 		Status:     plugins.PluginSignatureValid,
-		Type:       manifest.SignatureType,
+		Type:       signatureType,
 		SigningOrg: manifest.SignedByOrgName,
+		SigningKey: signingKey.name,
 		Files:      manifestFiles,
 	}, nil
 }
@@ -1,16 +1,23 @@
 package manager
 
 import (
+	"io/ioutil"
+	"path/filepath"
 	"sort"
 	"strings"
 	"testing"
 
+	"github.com/grafana/grafana/pkg/infra/log"
 	"github.com/grafana/grafana/pkg/plugins"
+	"github.com/grafana/grafana/pkg/setting"
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
 )
 
 func TestReadPluginManifest(t *testing.T) {
+	keyring, err := newSigningKeyring(nil)
+	require.NoError(t, err)
+
 	txt := `-----BEGIN PGP SIGNED MESSAGE-----
 Hash: SHA512
 
@@ -43,9 +50,11 @@ NR7DnB0CCQHO+4FlSPtXFTzNepoc+CytQyDAeOLMLmf2Tqhk2YShk+G/YlVX
 -----END PGP SIGNATURE-----`
 
 	t.Run("valid manifest", func(t *testing.T) {
-		manifest, err := readPluginManifest([]byte(txt))
+		manifest, key, err := readPluginManifest([]byte(txt), keyring)
 
 		require.NoError(t, err)
+		require.Equal(t, grafanaSigningKeyName, key.name)
+		require.True(t, key.builtIn)
 		require.NotNil(t, manifest)
 		assert.Equal(t, "grafana-googlesheets-datasource", manifest.Plugin)
 		assert.Equal(t, "1.0.0-dev", manifest.Version)
@@ -59,12 +68,15 @@ NR7DnB0CCQHO+4FlSPtXFTzNepoc+CytQyDAeOLMLmf2Tqhk2YShk+G/YlVX
 
 	t.Run("invalid manifest", func(t *testing.T) {
 		modified := strings.ReplaceAll(txt, "README.md", "xxxxxxxxxx")
-		_, err := readPluginManifest([]byte(modified))
+		_, _, err := readPluginManifest([]byte(modified), keyring)
 		require.Error(t, err)
 	})
 }
 
 func TestReadPluginManifestV2(t *testing.T) {
+	keyring, err := newSigningKeyring(nil)
+	require.NoError(t, err)
+
 	txt := `-----BEGIN PGP SIGNED MESSAGE-----
 Hash: SHA512
 
@@ -96,9 +108,11 @@ khdr/tZ1PDgRxMqB/u+Vtbpl0xSxgblnrDOYMSI=
 -----END PGP SIGNATURE-----`
 
 	t.Run("valid manifest", func(t *testing.T) {
-		manifest, err := readPluginManifest([]byte(txt))
+		manifest, key, err := readPluginManifest([]byte(txt), keyring)
 
 		require.NoError(t, err)
+		require.Equal(t, grafanaSigningKeyName, key.name)
+		require.True(t, key.builtIn)
 		require.NotNil(t, manifest)
 		assert.Equal(t, "test", manifest.Plugin)
 		assert.Equal(t, "1.0.0", manifest.Version)
@@ -113,6 +127,45 @@ khdr/tZ1PDgRxMqB/u+Vtbpl0xSxgblnrDOYMSI=
 	})
 }
 
+func TestGetPluginSignatureState_CustomKeyRootURLs(t *testing.T) {
+	entity, keyPath := generateSigningKey(t)
+	keyring, err := newSigningKeyring([]setting.PluginSigningKey{
+		{Name: "myorg", PublicKeyPath: keyPath},
+	})
+	require.NoError(t, err)
+
+	origAppURL, origAppSubURL := setting.AppUrl, setting.AppSubUrl
+	t.Cleanup(func() {
+		setting.AppUrl, setting.AppSubUrl = origAppURL, origAppSubURL
+	})
+	setting.AppUrl, setting.AppSubUrl = "http://localhost:3000/", ""
+
+	signatureState := func(t *testing.T, manifest string) plugins.PluginSignatureState {
+		t.Helper()
+		dir := t.TempDir()
+		require.NoError(t, ioutil.WriteFile(filepath.Join(dir, "MANIFEST.txt"), signManifest(t, entity, manifest), 0600))
+		plugin := &plugins.PluginBase{Id: "myorg-panel", PluginDir: dir}
+		plugin.Info.Version = "1.0.0"
+		state, err := getPluginSignatureState(log.New("test"), keyring, plugin)
+		require.NoError(t, err)
+		return state
+	}
+
+	t.Run("community manifest signed by custom key must match root URLs", func(t *testing.T) {
+		state := signatureState(t, `{"plugin": "myorg-panel", "version": "1.0.0", "files": {}, "signatureType": "community", "rootUrls": ["http://example.com/"]}`)
+		require.Equal(t, plugins.PluginSignatureInvalid, state.Status)
+
+		state = signatureState(t, `{"plugin": "myorg-panel", "version": "1.0.0", "files": {}, "signatureType": "community"}`)
+		require.Equal(t, plugins.PluginSignatureInvalid, state.Status)
+	})
+
+	t.Run("community manifest signed by custom key with matching root URL", func(t *testing.T) {
+		state := signatureState(t, `{"plugin": "myorg-panel", "version": "1.0.0", "files": {}, "signatureType": "community", "rootUrls": ["http://localhost:3000/"]}`)
+		require.Equal(t, plugins.PluginSignatureValid, state.Status)
+		require.Equal(t, plugins.PrivateType, state.Type)
+	})
+}
+
 func fileList(manifest *pluginManifest) []string {
 	var keys []string
 	for k := range manifest.Files {
@@ -0,0 +1,194 @@
+package manager
+
+import (
+	"context"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/grafana/grafana/pkg/plugins/manager/installer"
+	"github.com/grafana/grafana/pkg/services/provisioning/values"
+	"gopkg.in/yaml.v2"
+)
+
+// provisionedPluginV1 is a plugin listed in the install section of a plugin provisioning file.
+type provisionedPluginV1 struct {
+	ID         values.StringValue `json:"id" yaml:"id"`
+	Version    values.StringValue `json:"version" yaml:"version"`
+	URL        values.StringValue `json:"url" yaml:"url"`
+	Path       values.StringValue `json:"path" yaml:"path"`
+	Repository values.StringValue `json:"repository" yaml:"repository"`
+	Checksum   values.StringValue `json:"checksum" yaml:"checksum"`
+	// InstallMissingDependencies allows installing dependencies which are not provisioned
+	// from the repository of the plugin, grafana.com when not set.
+	InstallMissingDependencies values.BoolValue `json:"installMissingDependencies" yaml:"installMissingDependencies"`
+}
+
+type provisionedPluginsV1 struct {
+	Install []*provisionedPluginV1 `json:"install" yaml:"install"`
+}
+
+// provisionedPlugin is a plugin to install read from provisioning files.
+type provisionedPlugin struct {
+	installer.PinnedPlugin
+	installMissingDependencies bool
+}
+
+// readProvisionedPlugins reads plugins to install from the install section of plugin
+// provisioning files. These files are shared with app provisioning which reads the apps
+// section.
+func readProvisionedPlugins(path string) ([]provisionedPlugin, error) {
+	files, err := ioutil.ReadDir(path)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return nil, nil
+		}
+		return nil, err
+	}
+
+	var result []provisionedPlugin
+	seen := map[string]string{}
+	for _, file := range files {
+		if !strings.HasSuffix(file.Name(), ".yaml") && !strings.HasSuffix(file.Name(), ".yml") {
+			continue
+		}
+
+		filename := filepath.Join(path, file.Name())
+		// nolint:gosec
+		// We can ignore the gosec G304 warning on this one because `filename` comes from ps.Cfg.ProvisioningPath
+		yamlFile, err := ioutil.ReadFile(filename)
+		if err != nil {
+			return nil, err
+		}
+
+		var cfg provisionedPluginsV1
+		if err := yaml.Unmarshal(yamlFile, &cfg); err != nil {
+			return nil, fmt.Errorf("failed to parse plugin provisioning file %q: %w", filename, err)
+		}
+
+		for index, p := range cfg.Install {
+			plugin := installer.PinnedPlugin{
+				ID:         p.ID.Value(),
+				Version:    p.Version.Value(),
+				URL:        p.URL.Value(),
+				Path:       p.Path.Value(),
+				Repository: p.Repository.Value(),
+				Checksum:   p.Checksum.Value(),
+			}
+			if plugin.ID == "" || plugin.Version == "" {
+				return nil, fmt.Errorf("install item %d in %q must have id and version", index+1, filename)
+			}
+			if plugin.URL != "" && plugin.Path != "" {
+				return nil, fmt.Errorf("plugin %s in %q can have only one of url and path", plugin.ID, filename)
+			}
+			if (plugin.URL != "" || plugin.Path != "") && plugin.Checksum == "" {
+				return nil, fmt.Errorf("plugin %s in %q must have a checksum when installed from url or path", plugin.ID, filename)
+			}
+			if other, ok := seen[plugin.ID]; ok {
+				return nil, fmt.Errorf("plugin %s is provisioned in both %q and %q", plugin.ID, other, filename)
+			}
+			seen[plugin.ID] = filename
+			result = append(result, provisionedPlugin{
+				PinnedPlugin:               plugin,
+				installMissingDependencies: p.InstallMissingDependencies.Value(),
+			})
+		}
+	}
+	return result, nil
+}
+
+// installProvisionedPlugins makes sure plugins listed in provisioning files are installed
+// with the pinned versions from archives with the expected checksums. Dependencies of installed
+// plugins are taken from the provisioning files when listed there or kept when an installed
+// version satisfies the dependency. Other dependencies fail the installation unless the dependant
+// plugin allows installing them from its repository, then version ranges are resolved to the
+// latest matching version of the repository.
+func (pm *PluginManager) installProvisionedPlugins(ctx context.Context, inst *installer.Installer) error {
+	path := filepath.Join(pm.Cfg.ProvisioningPath, "plugins")
+	provisioned, err := readProvisionedPlugins(path)
+	if err != nil {
+		return err
+	}
+	if len(provisioned) == 0 {
+		return nil
+	}
+
+	byID := make(map[string]provisionedPlugin, len(provisioned))
+	for _, p := range provisioned {
+		byID[p.ID] = p
+	}
+
+	queue := append([]provisionedPlugin{}, provisioned...)
+	processed := map[string]bool{}
+	for len(queue) > 0 {
+		p := queue[0]
+		queue = queue[1:]
+		if processed[p.ID] {
+			continue
+		}
+		processed[p.ID] = true
+
+		if installed := installer.InstalledVersion(pm.Cfg.PluginsPath, p.ID); installed == p.Version {
+			checksum, err := inst.PinnedChecksum(p.PinnedPlugin)
+			if err != nil {
+				return fmt.Errorf("failed to get checksum of provisioned plugin %s: %w", p.ID, err)
+			}
+			if installer.InstalledChecksum(pm.Cfg.PluginsPath, p.ID) == checksum {
+				pm.log.Debug("Provisioned plugin is already installed", "pluginId", p.ID, "version", p.Version)
+				continue
+			}
+			pm.log.Info("Installed plugin is not installed from the provisioned archive, replacing it", "pluginId", p.ID,
+				"version", p.Version)
+		} else if installed != "" {
+			pm.log.Info("Replacing installed plugin version with provisioned one", "pluginId", p.ID,
+				"installed", installed, "version", p.Version)
+		}
+
+		res, err := inst.InstallPinned(ctx, p.PinnedPlugin, pm.Cfg.PluginsPath)
+		if err != nil {
+			return fmt.Errorf("failed to install provisioned plugin %s: %w", p.ID, err)
+		}
+		pm.log.Info("Installed provisioned plugin", "pluginId", p.ID, "version", p.Version)
+
+		for _, dep := range res.Dependencies.Plugins {
+			if depProvisioned, ok := byID[dep.ID]; ok {
+				if !installer.VersionSatisfies(depProvisioned.Version, dep.Version) {
+					pm.log.Warn("Provisioned version of plugin dependency does not satisfy required version",
+						"pluginId", p.ID, "dependency", dep.ID, "version", depProvisioned.Version, "required", dep.Version)
+				}
+				queue = append(queue, depProvisioned)
+				continue
+			}
+			if processed[dep.ID] {
+				continue
+			}
+			if installed := installer.InstalledVersion(pm.Cfg.PluginsPath, dep.ID); installed != "" {
+				if !installer.VersionSatisfies(installed, dep.Version) {
+					pm.log.Warn("Installed version of plugin dependency does not satisfy required version, keeping it",
+						"pluginId", p.ID, "dependency", dep.ID, "installed", installed, "required", dep.Version)
+				}
+				continue
+			}
+			if !p.installMissingDependencies {
+				return fmt.Errorf("dependency %s of provisioned plugin %s is neither provisioned nor installed", dep.ID, p.ID)
+			}
+			version, err := inst.ResolveVersion(dep.ID, dep.Version, p.Repository)
+			if err != nil {
+				return fmt.Errorf("failed to resolve version %q of dependency %s of plugin %s: %w", dep.Version, dep.ID, p.ID, err)
+			}
+			pm.log.Info("Plugin dependency is not provisioned, installing it from the repository", "pluginId", p.ID,
+				"dependency", dep.ID, "required", dep.Version, "version", version)
+			queue = append(queue, provisionedPlugin{
+				PinnedPlugin: installer.PinnedPlugin{
+					ID:         dep.ID,
+					Version:    version,
+					Repository: p.Repository,
+				},
+				installMissingDependencies: true,
+			})
+		}
+	}
+	return nil
+}
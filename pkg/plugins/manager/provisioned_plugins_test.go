@@ -0,0 +1,297 @@
+package manager
+
+import (
+	"archive/zip"
+	"bytes"
+	"context"
+	"crypto/sha256"
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/grafana/grafana/pkg/plugins/manager/installer"
+	"github.com/grafana/grafana/pkg/setting"
+	"github.com/stretchr/testify/require"
+)
+
+func pluginArchive(t *testing.T, pluginID, version string, deps ...string) []byte {
+	t.Helper()
+
+	var depItems []map[string]string
+	for _, dep := range deps {
+		depItems = append(depItems, map[string]string{"id": dep, "type": "panel", "version": "^1.0.0"})
+	}
+	pluginJSON, err := json.Marshal(map[string]interface{}{
+		"id":           pluginID,
+		"type":         "panel",
+		"name":         pluginID,
+		"info":         map[string]string{"version": version},
+		"dependencies": map[string]interface{}{"plugins": depItems},
+	})
+	require.NoError(t, err)
+
+	var buf bytes.Buffer
+	w := zip.NewWriter(&buf)
+	f, err := w.Create(pluginID + "/plugin.json")
+	require.NoError(t, err)
+	_, err = f.Write(pluginJSON)
+	require.NoError(t, err)
+	require.NoError(t, w.Close())
+	return buf.Bytes()
+}
+
+func sha256Hex(b []byte) string {
+	return fmt.Sprintf("%x", sha256.Sum256(b))
+}
+
+func setupProvisionedPluginsTest(t *testing.T, provisioning string) *PluginManager {
+	t.Helper()
+
+	cfg := setting.NewCfg()
+	cfg.PluginsPath = t.TempDir()
+	cfg.ProvisioningPath = t.TempDir()
+	require.NoError(t, os.MkdirAll(filepath.Join(cfg.ProvisioningPath, "plugins"), 0750))
+	require.NoError(t, ioutil.WriteFile(filepath.Join(cfg.ProvisioningPath, "plugins", "install.yaml"), []byte(provisioning), 0600))
+	return newManager(cfg, nil, nil)
+}
+
+func TestPluginManager_installProvisionedPlugins(t *testing.T) {
+	inst := installer.New(false, "8.0.0", installerLog)
+
+	t.Run("Installs plugins from archive path and repository with dependencies", func(t *testing.T) {
+		panelArchive := pluginArchive(t, "myorg-panel", "1.2.3", "myorg-dep-panel")
+		archivePath := filepath.Join(t.TempDir(), "myorg-panel.zip")
+		require.NoError(t, ioutil.WriteFile(archivePath, panelArchive, 0600))
+
+		depArchive := pluginArchive(t, "myorg-dep-panel", "1.0.0")
+		var requests []string
+		repo := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			requests = append(requests, r.URL.Path)
+			switch r.URL.Path {
+			case "/api/plugins/repo/myorg-dep-panel":
+				_ = json.NewEncoder(w).Encode(installer.Plugin{
+					ID: "myorg-dep-panel",
+					Versions: []installer.Version{
+						{Version: "1.1.0"},
+						{Version: "1.0.0", Arch: map[string]installer.ArchMeta{"any": {SHA256: sha256Hex(depArchive)}}},
+					},
+				})
+			case "/api/plugins/myorg-dep-panel/versions/1.0.0/download":
+				_, _ = w.Write(depArchive)
+			default:
+				w.WriteHeader(http.StatusNotFound)
+			}
+		}))
+		t.Cleanup(repo.Close)
+
+		pm := setupProvisionedPluginsTest(t, fmt.Sprintf(`
+apiVersion: 1
+install:
+  - id: myorg-panel
+    version: 1.2.3
+    path: %s
+    checksum: %s
+  - id: myorg-dep-panel
+    version: 1.0.0
+    repository: %s/api/plugins
+`, archivePath, sha256Hex(panelArchive), repo.URL))
+
+		require.NoError(t, pm.installProvisionedPlugins(context.Background(), inst))
+		require.Equal(t, "1.2.3", installer.InstalledVersion(pm.Cfg.PluginsPath, "myorg-panel"))
+		require.Equal(t, "1.0.0", installer.InstalledVersion(pm.Cfg.PluginsPath, "myorg-dep-panel"))
+		require.Len(t, requests, 2)
+
+		require.Equal(t, sha256Hex(panelArchive), installer.InstalledChecksum(pm.Cfg.PluginsPath, "myorg-panel"))
+		require.Equal(t, sha256Hex(depArchive), installer.InstalledChecksum(pm.Cfg.PluginsPath, "myorg-dep-panel"))
+
+		// Installed plugins matching the pinned versions and checksums are left as is, only
+		// the checksum published by the repository is fetched again.
+		require.NoError(t, os.Remove(archivePath))
+		require.NoError(t, pm.installProvisionedPlugins(context.Background(), inst))
+		require.Len(t, requests, 3)
+		require.Equal(t, "/api/plugins/repo/myorg-dep-panel", requests[2])
+	})
+
+	t.Run("Replaces installed plugin of the pinned version with a different checksum", func(t *testing.T) {
+		archive := pluginArchive(t, "myorg-panel", "1.2.3")
+		archivePath := filepath.Join(t.TempDir(), "myorg-panel.zip")
+		require.NoError(t, ioutil.WriteFile(archivePath, archive, 0600))
+
+		pm := setupProvisionedPluginsTest(t, fmt.Sprintf(`
+install:
+  - id: myorg-panel
+    version: 1.2.3
+    path: %s
+    checksum: %s
+`, archivePath, sha256Hex(archive)))
+
+		// Plugin of the same version installed in some other way, without a recorded checksum.
+		pluginDir := filepath.Join(pm.Cfg.PluginsPath, "myorg-panel")
+		require.NoError(t, os.MkdirAll(pluginDir, 0750))
+		require.NoError(t, ioutil.WriteFile(filepath.Join(pluginDir, "plugin.json"),
+			[]byte(`{"id": "myorg-panel", "type": "panel", "info": {"version": "1.2.3"}}`), 0600))
+		require.NoError(t, ioutil.WriteFile(filepath.Join(pluginDir, "module.js"), []byte("tampered"), 0600))
+
+		require.NoError(t, pm.installProvisionedPlugins(context.Background(), inst))
+		require.Equal(t, sha256Hex(archive), installer.InstalledChecksum(pm.Cfg.PluginsPath, "myorg-panel"))
+		require.NoFileExists(t, filepath.Join(pluginDir, "module.js"))
+	})
+
+	t.Run("Fails when dependency is not provisioned", func(t *testing.T) {
+		panelArchive := pluginArchive(t, "myorg-panel", "1.2.3", "myorg-dep-panel")
+		archivePath := filepath.Join(t.TempDir(), "myorg-panel.zip")
+		require.NoError(t, ioutil.WriteFile(archivePath, panelArchive, 0600))
+
+		pm := setupProvisionedPluginsTest(t, fmt.Sprintf(`
+install:
+  - id: myorg-panel
+    version: 1.2.3
+    path: %s
+    checksum: %s
+`, archivePath, sha256Hex(panelArchive)))
+
+		err := pm.installProvisionedPlugins(context.Background(), inst)
+		require.Error(t, err)
+		require.Contains(t, err.Error(), "myorg-dep-panel")
+		require.Empty(t, installer.InstalledVersion(pm.Cfg.PluginsPath, "myorg-dep-panel"))
+	})
+
+	t.Run("Resolves version ranges of dependencies which are not provisioned", func(t *testing.T) {
+		panelArchive := pluginArchive(t, "myorg-panel", "1.2.3", "myorg-dep-panel")
+		archivePath := filepath.Join(t.TempDir(), "myorg-panel.zip")
+		require.NoError(t, ioutil.WriteFile(archivePath, panelArchive, 0600))
+
+		depArchive := pluginArchive(t, "myorg-dep-panel", "1.1.0")
+		var downloads []string
+		repo := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			switch r.URL.Path {
+			case "/api/plugins/repo/myorg-dep-panel":
+				_ = json.NewEncoder(w).Encode(installer.Plugin{
+					ID: "myorg-dep-panel",
+					Versions: []installer.Version{
+						{Version: "2.0.0"},
+						{Version: "1.1.0", Arch: map[string]installer.ArchMeta{"any": {SHA256: sha256Hex(depArchive)}}},
+						{Version: "1.0.0"},
+					},
+				})
+			case "/api/plugins/myorg-dep-panel/versions/1.1.0/download":
+				downloads = append(downloads, r.URL.Path)
+				_, _ = w.Write(depArchive)
+			default:
+				w.WriteHeader(http.StatusNotFound)
+			}
+		}))
+		t.Cleanup(repo.Close)
+
+		provisioning := fmt.Sprintf(`
+install:
+  - id: myorg-panel
+    version: 1.2.3
+    path: %s
+    checksum: %s
+    repository: %s/api/plugins
+    installMissingDependencies: true
+`, archivePath, sha256Hex(panelArchive), repo.URL)
+		pm := setupProvisionedPluginsTest(t, provisioning)
+		require.NoError(t, pm.installProvisionedPlugins(context.Background(), inst))
+		require.Equal(t, "1.1.0", installer.InstalledVersion(pm.Cfg.PluginsPath, "myorg-dep-panel"))
+		require.Len(t, downloads, 1)
+
+		// Installed dependencies satisfying the range are kept.
+		pluginsPath := pm.Cfg.PluginsPath
+		pm = setupProvisionedPluginsTest(t, provisioning)
+		pm.Cfg.PluginsPath = pluginsPath
+		require.NoError(t, os.RemoveAll(filepath.Join(pluginsPath, "myorg-panel")))
+		require.NoError(t, pm.installProvisionedPlugins(context.Background(), inst))
+		require.Equal(t, "1.1.0", installer.InstalledVersion(pm.Cfg.PluginsPath, "myorg-dep-panel"))
+		require.Len(t, downloads, 1)
+	})
+
+	t.Run("Installs plugin from archive URL", func(t *testing.T) {
+		archive := pluginArchive(t, "myorg-app", "2.0.0")
+		server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			_, _ = w.Write(archive)
+		}))
+		t.Cleanup(server.Close)
+
+		pm := setupProvisionedPluginsTest(t, fmt.Sprintf(`
+install:
+  - id: myorg-app
+    version: 2.0.0
+    url: %s/myorg-app.zip
+    checksum: %s
+`, server.URL, sha256Hex(archive)))
+
+		require.NoError(t, pm.installProvisionedPlugins(context.Background(), inst))
+		require.Equal(t, "2.0.0", installer.InstalledVersion(pm.Cfg.PluginsPath, "myorg-app"))
+	})
+
+	t.Run("Fails on checksum mismatch", func(t *testing.T) {
+		archivePath := filepath.Join(t.TempDir(), "myorg-panel.zip")
+		require.NoError(t, ioutil.WriteFile(archivePath, pluginArchive(t, "myorg-panel", "1.2.3"), 0600))
+
+		pm := setupProvisionedPluginsTest(t, fmt.Sprintf(`
+install:
+  - id: myorg-panel
+    version: 1.2.3
+    path: %s
+    checksum: %s
+`, archivePath, sha256Hex([]byte("other"))))
+
+		err := pm.installProvisionedPlugins(context.Background(), inst)
+		require.Error(t, err)
+		require.Contains(t, err.Error(), "checksum")
+		require.Empty(t, installer.InstalledVersion(pm.Cfg.PluginsPath, "myorg-panel"))
+	})
+
+	t.Run("Fails when archive version does not match pinned version", func(t *testing.T) {
+		archivePath := filepath.Join(t.TempDir(), "myorg-panel.zip")
+		archive := pluginArchive(t, "myorg-panel", "1.2.4")
+		require.NoError(t, ioutil.WriteFile(archivePath, archive, 0600))
+
+		pm := setupProvisionedPluginsTest(t, fmt.Sprintf(`
+install:
+  - id: myorg-panel
+    version: 1.2.3
+    path: %s
+    checksum: %s
+`, archivePath, sha256Hex(archive)))
+
+		err := pm.installProvisionedPlugins(context.Background(), inst)
+		require.Error(t, err)
+		require.ErrorAs(t, err, &installer.ErrVersionMismatch{})
+		require.Empty(t, installer.InstalledVersion(pm.Cfg.PluginsPath, "myorg-panel"))
+	})
+
+	t.Run("Fails when archive checksum is not known", func(t *testing.T) {
+		archivePath := filepath.Join(t.TempDir(), "myorg-panel.zip")
+		require.NoError(t, ioutil.WriteFile(archivePath, pluginArchive(t, "myorg-panel", "1.2.3"), 0600))
+
+		pm := setupProvisionedPluginsTest(t, fmt.Sprintf(`
+install:
+  - id: myorg-panel
+    version: 1.2.3
+    path: %s
+`, archivePath))
+
+		err := pm.installProvisionedPlugins(context.Background(), inst)
+		require.Error(t, err)
+		require.Contains(t, err.Error(), "checksum")
+		require.Empty(t, installer.InstalledVersion(pm.Cfg.PluginsPath, "myorg-panel"))
+	})
+
+	t.Run("Fails when version is not pinned", func(t *testing.T) {
+		pm := setupProvisionedPluginsTest(t, `
+install:
+  - id: myorg-panel
+`)
+
+		err := pm.installProvisionedPlugins(context.Background(), inst)
+		require.Error(t, err)
+	})
+}
@@ -0,0 +1,94 @@
+package manager
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"io/ioutil"
+	"strings"
+
+	"github.com/grafana/grafana/pkg/setting"
+	"github.com/grafana/grafana/pkg/util/errutil"
+
+	"golang.org/x/crypto/openpgp"
+)
+
+// grafanaSigningKeyName is the name of the built-in Grafana public key.
+const grafanaSigningKeyName = "grafana"
+
+var errNoTrustedSigningKey = errors.New("manifest is not signed by any trusted key")
+
+// signingKey is a public key trusted to sign plugin manifests.
+type signingKey struct {
+	name string
+	// builtIn is set for the Grafana key, the only key trusted to sign
+	// plugins with the grafana signature type.
+	builtIn bool
+	// pluginIDPrefixes limits plugins the key may sign, empty means any plugin.
+	pluginIDPrefixes []string
+	entities         openpgp.EntityList
+}
+
+func (k signingKey) canSign(pluginID string) bool {
+	if len(k.pluginIDPrefixes) == 0 {
+		return true
+	}
+	for _, prefix := range k.pluginIDPrefixes {
+		if strings.HasPrefix(pluginID, prefix) {
+			return true
+		}
+	}
+	return false
+}
+
+// signingKeyring is a list of public keys trusted to sign plugin manifests.
+// The built-in Grafana key always comes first, followed by operator
+// configured keys.
+type signingKeyring struct {
+	keys []signingKey
+}
+
+func newSigningKeyring(trustedKeys []setting.PluginSigningKey) (*signingKeyring, error) {
+	grafanaKey, err := openpgp.ReadArmoredKeyRing(bytes.NewBufferString(publicKeyText))
+	if err != nil {
+		return nil, errutil.Wrap("failed to parse public key", err)
+	}
+	keyring := &signingKeyring{
+		keys: []signingKey{{name: grafanaSigningKeyName, builtIn: true, entities: grafanaKey}},
+	}
+
+	for _, trustedKey := range trustedKeys {
+		// nolint:gosec
+		// We can ignore the gosec G304 warning on this one because the path comes from
+		// operator configuration.
+		keyText, err := ioutil.ReadFile(trustedKey.PublicKeyPath)
+		if err != nil {
+			return nil, fmt.Errorf("failed to read plugin signing key %q: %w", trustedKey.Name, err)
+		}
+		entities, err := openpgp.ReadArmoredKeyRing(bytes.NewReader(keyText))
+		if err != nil {
+			return nil, fmt.Errorf("failed to parse plugin signing key %q: %w", trustedKey.Name, err)
+		}
+		keyring.keys = append(keyring.keys, signingKey{
+			name:             trustedKey.Name,
+			pluginIDPrefixes: trustedKey.PluginIDPrefixes,
+			entities:         entities,
+		})
+	}
+	return keyring, nil
+}
+
+// verify checks a detached signature of a manifest for pluginID and returns
+// the key that made the signature.
+func (kr *signingKeyring) verify(pluginID string, signed []byte, signature []byte) (signingKey, error) {
+	for _, key := range kr.keys {
+		if !key.canSign(pluginID) {
+			continue
+		}
+		_, err := openpgp.CheckDetachedSignature(key.entities, bytes.NewReader(signed), bytes.NewReader(signature))
+		if err == nil {
+			return key, nil
+		}
+	}
+	return signingKey{}, errNoTrustedSigningKey
+}
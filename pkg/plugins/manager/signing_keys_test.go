@@ -0,0 +1,128 @@
+package manager
+
+import (
+	"bytes"
+	"io/ioutil"
+	"path/filepath"
+	"testing"
+
+	"github.com/grafana/grafana/pkg/infra/log"
+	"github.com/grafana/grafana/pkg/plugins"
+	"github.com/grafana/grafana/pkg/setting"
+	"github.com/stretchr/testify/require"
+	"golang.org/x/crypto/openpgp"
+	"golang.org/x/crypto/openpgp/armor"
+	"golang.org/x/crypto/openpgp/clearsign"
+)
+
+func generateSigningKey(t *testing.T) (*openpgp.Entity, string) {
+	t.Helper()
+
+	entity, err := openpgp.NewEntity("Test", "", "test@example.com", nil)
+	require.NoError(t, err)
+
+	var buf bytes.Buffer
+	w, err := armor.Encode(&buf, openpgp.PublicKeyType, nil)
+	require.NoError(t, err)
+	require.NoError(t, entity.Serialize(w))
+	require.NoError(t, w.Close())
+
+	keyPath := filepath.Join(t.TempDir(), "key.asc")
+	require.NoError(t, ioutil.WriteFile(keyPath, buf.Bytes(), 0600))
+	return entity, keyPath
+}
+
+func signManifest(t *testing.T, entity *openpgp.Entity, manifest string) []byte {
+	t.Helper()
+
+	var buf bytes.Buffer
+	w, err := clearsign.Encode(&buf, entity.PrivateKey, nil)
+	require.NoError(t, err)
+	_, err = w.Write([]byte(manifest))
+	require.NoError(t, err)
+	require.NoError(t, w.Close())
+	return buf.Bytes()
+}
+
+func TestSigningKeyring(t *testing.T) {
+	entity, keyPath := generateSigningKey(t)
+	manifest := signManifest(t, entity, `{"plugin": "myorg-panel", "version": "1.0.0", "files": {}}`)
+
+	t.Run("manifest signed by unknown key is rejected", func(t *testing.T) {
+		keyring, err := newSigningKeyring(nil)
+		require.NoError(t, err)
+
+		_, _, err = readPluginManifest(manifest, keyring)
+		require.Error(t, err)
+	})
+
+	t.Run("manifest signed by trusted key is accepted", func(t *testing.T) {
+		keyring, err := newSigningKeyring([]setting.PluginSigningKey{
+			{Name: "myorg", PublicKeyPath: keyPath},
+		})
+		require.NoError(t, err)
+
+		m, key, err := readPluginManifest(manifest, keyring)
+		require.NoError(t, err)
+		require.Equal(t, "myorg", key.name)
+		require.Equal(t, "myorg-panel", m.Plugin)
+	})
+
+	t.Run("trusted key is scoped to plugin ID prefixes", func(t *testing.T) {
+		keyring, err := newSigningKeyring([]setting.PluginSigningKey{
+			{Name: "myorg", PublicKeyPath: keyPath, PluginIDPrefixes: []string{"otherorg-"}},
+		})
+		require.NoError(t, err)
+
+		_, _, err = readPluginManifest(manifest, keyring)
+		require.Error(t, err)
+
+		keyring, err = newSigningKeyring([]setting.PluginSigningKey{
+			{Name: "myorg", PublicKeyPath: keyPath, PluginIDPrefixes: []string{"otherorg-", "myorg-"}},
+		})
+		require.NoError(t, err)
+
+		_, key, err := readPluginManifest(manifest, keyring)
+		require.NoError(t, err)
+		require.Equal(t, "myorg", key.name)
+	})
+
+	t.Run("signature type is derived from the signing key", func(t *testing.T) {
+		keyring, err := newSigningKeyring([]setting.PluginSigningKey{
+			{Name: "myorg", PublicKeyPath: keyPath},
+		})
+		require.NoError(t, err)
+
+		signatureState := func(t *testing.T, manifest string) plugins.PluginSignatureState {
+			t.Helper()
+			dir := t.TempDir()
+			require.NoError(t, ioutil.WriteFile(filepath.Join(dir, "MANIFEST.txt"), signManifest(t, entity, manifest), 0600))
+			plugin := &plugins.PluginBase{Id: "myorg-panel", PluginDir: dir}
+			plugin.Info.Version = "1.0.0"
+			state, err := getPluginSignatureState(log.New("test"), keyring, plugin)
+			require.NoError(t, err)
+			return state
+		}
+
+		origAppURL, origAppSubURL := setting.AppUrl, setting.AppSubUrl
+		t.Cleanup(func() {
+			setting.AppUrl, setting.AppSubUrl = origAppURL, origAppSubURL
+		})
+		setting.AppUrl, setting.AppSubUrl = "http://localhost:3000/", ""
+
+		state := signatureState(t, `{"plugin": "myorg-panel", "version": "1.0.0", "files": {}, "signatureType": "community", "rootUrls": ["http://localhost:3000/"]}`)
+		require.Equal(t, plugins.PluginSignatureValid, state.Status)
+		require.Equal(t, plugins.PrivateType, state.Type)
+		require.Equal(t, "myorg", state.SigningKey)
+
+		state = signatureState(t, `{"plugin": "myorg-panel", "version": "1.0.0", "files": {}, "signatureType": "grafana"}`)
+		require.Equal(t, plugins.PluginSignatureInvalid, state.Status)
+	})
+
+	t.Run("missing key file", func(t *testing.T) {
+		_, err := newSigningKeyring([]setting.PluginSigningKey{
+			{Name: "missing", PublicKeyPath: filepath.Join(t.TempDir(), "missing.asc")},
+		})
+		require.Error(t, err)
+	})
+}
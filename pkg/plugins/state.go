@@ -37,5 +37,6 @@ type PluginSignatureState struct {
 	Status     PluginSignatureStatus
 	Type       PluginSignatureType
 	SigningOrg string
+	SigningKey string
 	Files      PluginFiles
 }
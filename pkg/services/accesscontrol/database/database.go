@@ -0,0 +1,106 @@
+package database
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"github.com/grafana/grafana/pkg/models"
+	"github.com/grafana/grafana/pkg/services/accesscontrol"
+	"github.com/grafana/grafana/pkg/services/sqlstore"
+)
+
+var errInvalidAssignee = errors.New("managed permissions are assigned to either a user or a team")
+
+func ProvideService(sqlStore *sqlstore.SQLStore) *AccessControlStore {
+	return &AccessControlStore{
+		SQLStore: sqlStore,
+	}
+}
+
+// AccessControlStore stores permissions managed for users and teams.
+type AccessControlStore struct {
+	SQLStore *sqlstore.SQLStore
+}
+
+// GetUserPermissions returns permissions managed for the user and the teams of
+// the user in the current organization of the user.
+func (s *AccessControlStore) GetUserPermissions(ctx context.Context, user *models.SignedInUser) ([]*accesscontrol.Permission, error) {
+	permissions := make([]*accesscontrol.Permission, 0)
+	// Anonymous users and API keys don't have managed permissions, while
+	// permissions of teams are stored without a user.
+	if user.UserId == 0 {
+		return permissions, nil
+	}
+
+	err := s.SQLStore.WithDbSession(ctx, func(sess *sqlstore.DBSession) error {
+		rawSQL := `SELECT action, scope FROM managed_permission
+			WHERE org_id = ? AND (user_id = ? OR team_id IN (SELECT team_id FROM team_member WHERE org_id = ? AND user_id = ?))`
+
+		return sess.SQL(rawSQL, user.OrgId, user.UserId, user.OrgId, user.UserId).Find(&permissions)
+	})
+
+	return permissions, err
+}
+
+// GetManagedPermissions returns permissions managed for users and teams on the scopes.
+func (s *AccessControlStore) GetManagedPermissions(ctx context.Context, orgID int64, scopes ...string) ([]accesscontrol.ManagedPermission, error) {
+	permissions := make([]accesscontrol.ManagedPermission, 0)
+	err := s.SQLStore.WithDbSession(ctx, func(sess *sqlstore.DBSession) error {
+		return sess.Where("org_id = ?", orgID).In("scope", scopes).OrderBy("id").Find(&permissions)
+	})
+
+	return permissions, err
+}
+
+// HasManagedPermissions returns whether any user or team has a managed
+// permission for the action on the scope.
+func (s *AccessControlStore) HasManagedPermissions(ctx context.Context, orgID int64, action string, scope string) (bool, error) {
+	var exists bool
+	err := s.SQLStore.WithDbSession(ctx, func(sess *sqlstore.DBSession) error {
+		var err error
+		exists, err = sess.Where("org_id = ? AND action = ? AND scope = ?", orgID, action, scope).Exist(&accesscontrol.ManagedPermission{})
+		return err
+	})
+
+	return exists, err
+}
+
+// SetManagedPermissions replaces the permissions managed for a user or a team
+// on the scopes.
+func (s *AccessControlStore) SetManagedPermissions(ctx context.Context, cmd accesscontrol.SetManagedPermissionsCommand) error {
+	if (cmd.UserID == 0) == (cmd.TeamID == 0) {
+		return errInvalidAssignee
+	}
+
+	return s.SQLStore.WithTransactionalDbSession(ctx, func(sess *sqlstore.DBSession) error {
+		_, err := sess.Where("org_id = ? AND user_id = ? AND team_id = ?", cmd.OrgID, cmd.UserID, cmd.TeamID).
+			In("scope", cmd.Scopes).
+			Delete(&accesscontrol.ManagedPermission{})
+		if err != nil {
+			return err
+		}
+
+		now := time.Now()
+		permissions := make([]*accesscontrol.ManagedPermission, 0, len(cmd.Actions)*len(cmd.Scopes))
+		for _, action := range cmd.Actions {
+			for _, scope := range cmd.Scopes {
+				permissions = append(permissions, &accesscontrol.ManagedPermission{
+					OrgID:   cmd.OrgID,
+					UserID:  cmd.UserID,
+					TeamID:  cmd.TeamID,
+					Action:  action,
+					Scope:   scope,
+					Created: now,
+					Updated: now,
+				})
+			}
+		}
+		if len(permissions) == 0 {
+			return nil
+		}
+
+		_, err = sess.InsertMulti(permissions)
+		return err
+	})
+}
@@ -0,0 +1,85 @@
+package database
+
+import (
+	"context"
+	"testing"
+
+	"github.com/grafana/grafana/pkg/models"
+	"github.com/grafana/grafana/pkg/services/accesscontrol"
+	"github.com/grafana/grafana/pkg/services/sqlstore"
+	"github.com/stretchr/testify/require"
+)
+
+func TestAccessControlStore(t *testing.T) {
+	ctx := context.Background()
+	sqlStore := sqlstore.InitTestDB(t)
+	store := ProvideService(sqlStore)
+
+	user, err := sqlStore.CreateUser(ctx, models.CreateUserCommand{Login: "viewer", OrgId: 1})
+	require.NoError(t, err)
+	team, err := sqlStore.CreateTeam("finance", "", 1)
+	require.NoError(t, err)
+	require.NoError(t, sqlStore.AddTeamMember(user.Id, 1, team.Id, false, 0))
+
+	signedInUser := &models.SignedInUser{UserId: user.Id, OrgId: 1}
+
+	t.Run("permissions must be assigned to either a user or a team", func(t *testing.T) {
+		err := store.SetManagedPermissions(ctx, accesscontrol.SetManagedPermissionsCommand{OrgID: 1, UserID: user.Id, TeamID: team.Id})
+		require.ErrorIs(t, err, errInvalidAssignee)
+		err = store.SetManagedPermissions(ctx, accesscontrol.SetManagedPermissionsCommand{OrgID: 1})
+		require.ErrorIs(t, err, errInvalidAssignee)
+	})
+
+	t.Run("user permissions include permissions of the teams of the user", func(t *testing.T) {
+		require.NoError(t, store.SetManagedPermissions(ctx, accesscontrol.SetManagedPermissionsCommand{
+			OrgID:   1,
+			TeamID:  team.Id,
+			Actions: []string{accesscontrol.ActionDatasourcesQuery},
+			Scopes:  []string{"datasources:uid:finance"},
+		}))
+		require.NoError(t, store.SetManagedPermissions(ctx, accesscontrol.SetManagedPermissionsCommand{
+			OrgID:   1,
+			UserID:  user.Id,
+			Actions: []string{accesscontrol.ActionDatasourcesQuery, accesscontrol.ActionDatasourcesWrite},
+			Scopes:  []string{"datasources:uid:sales"},
+		}))
+
+		permissions, err := store.GetUserPermissions(ctx, signedInUser)
+		require.NoError(t, err)
+		require.Len(t, permissions, 3)
+
+		permissions, err = store.GetUserPermissions(ctx, &models.SignedInUser{UserId: user.Id, OrgId: 2})
+		require.NoError(t, err)
+		require.Empty(t, permissions)
+	})
+
+	t.Run("setting permissions replaces the permissions on the scopes", func(t *testing.T) {
+		require.NoError(t, store.SetManagedPermissions(ctx, accesscontrol.SetManagedPermissionsCommand{
+			OrgID:   1,
+			UserID:  user.Id,
+			Actions: []string{accesscontrol.ActionDatasourcesQuery},
+			Scopes:  []string{"datasources:uid:sales"},
+		}))
+
+		managed, err := store.GetManagedPermissions(ctx, 1, "datasources:uid:sales")
+		require.NoError(t, err)
+		require.Len(t, managed, 1)
+		require.Equal(t, accesscontrol.ActionDatasourcesQuery, managed[0].Action)
+
+		has, err := store.HasManagedPermissions(ctx, 1, accesscontrol.ActionDatasourcesWrite, "datasources:uid:sales")
+		require.NoError(t, err)
+		require.False(t, has)
+	})
+
+	t.Run("setting no actions removes the permissions", func(t *testing.T) {
+		require.NoError(t, store.SetManagedPermissions(ctx, accesscontrol.SetManagedPermissionsCommand{
+			OrgID:  1,
+			TeamID: team.Id,
+			Scopes: []string{"datasources:uid:finance"},
+		}))
+
+		has, err := store.HasManagedPermissions(ctx, 1, accesscontrol.ActionDatasourcesQuery, "datasources:uid:finance")
+		require.NoError(t, err)
+		require.False(t, has)
+	})
+}
@@ -143,6 +143,30 @@ func (p Permission) OSSPermission() Permission {
 	}
 }
 
+// ManagedPermission is a permission managed for a user or a team of an
+// organization, e.g. the permission to query a data source.
+type ManagedPermission struct {
+	ID     int64  `json:"id" xorm:"pk autoincr 'id'"`
+	OrgID  int64  `json:"orgId" xorm:"org_id"`
+	UserID int64  `json:"userId" xorm:"user_id"`
+	TeamID int64  `json:"teamId" xorm:"team_id"`
+	Action string `json:"action"`
+	Scope  string `json:"scope"`
+
+	Updated time.Time `json:"updated"`
+	Created time.Time `json:"created"`
+}
+
+// SetManagedPermissionsCommand replaces the permissions managed for a user or
+// a team on the scopes, permissions are removed when no actions are given.
+type SetManagedPermissionsCommand struct {
+	OrgID   int64
+	UserID  int64
+	TeamID  int64
+	Actions []string
+	Scopes  []string
+}
+
 // ScopeParams holds the parameters used to fill in scope templates
 type ScopeParams struct {
 	OrgID     int64
@@ -195,6 +219,9 @@ const (
 
 	// Datasources actions
 	ActionDatasourcesExplore = "datasources:explore"
+	ActionDatasourcesRead    = "datasources:read"
+	ActionDatasourcesQuery   = "datasources:query"
+	ActionDatasourcesWrite   = "datasources:write"
 
 	// Plugin actions
 	ActionPluginsManage = "plugins:manage"
@@ -208,6 +235,10 @@ const (
 	// Settings scope
 	ScopeSettingsAll = "settings:*"
 
+	// Datasources scopes
+	ScopeDatasourcesAll    = "datasources:*"
+	ScopeDatasourcesUIDAll = "datasources:uid:*"
+
 	// Licensing related actions
 	ActionLicensingRead        = "licensing:read"
 	ActionLicensingUpdate      = "licensing:update"
@@ -9,14 +9,16 @@ import (
 	"github.com/grafana/grafana/pkg/infra/usagestats"
 	"github.com/grafana/grafana/pkg/models"
 	"github.com/grafana/grafana/pkg/services/accesscontrol"
+	"github.com/grafana/grafana/pkg/services/accesscontrol/database"
 	"github.com/grafana/grafana/pkg/setting"
 	"github.com/prometheus/client_golang/prometheus"
 )
 
-func ProvideService(cfg *setting.Cfg, usageStats usagestats.Service) *OSSAccessControlService {
+func ProvideService(cfg *setting.Cfg, usageStats usagestats.Service, store *database.AccessControlStore) *OSSAccessControlService {
 	s := &OSSAccessControlService{
 		Cfg:           cfg,
 		UsageStats:    usageStats,
+		Store:         store,
 		Log:           log.New("accesscontrol"),
 		scopeResolver: accesscontrol.NewScopeResolver(),
 	}
@@ -28,6 +30,7 @@ func ProvideService(cfg *setting.Cfg, usageStats usagestats.Service) *OSSAccessC
 type OSSAccessControlService struct {
 	Cfg           *setting.Cfg
 	UsageStats    usagestats.Service
+	Store         *database.AccessControlStore
 	Log           log.Logger
 	registrations accesscontrol.RegistrationList
 	scopeResolver accesscontrol.ScopeResolver
@@ -87,7 +90,8 @@ func (ac *OSSAccessControlService) LinkAPIKeyToServiceAccount(context.Context, *
 	return errors.New("link SA not implemented yet in service accounts") //Please switch on Enterprise to test this
 }
 
-// GetUserPermissions returns user permissions based on built-in roles
+// GetUserPermissions returns user permissions based on built-in roles and
+// permissions managed for the user and the teams of the user
 func (ac *OSSAccessControlService) GetUserPermissions(ctx context.Context, user *models.SignedInUser) ([]*accesscontrol.Permission, error) {
 	timer := prometheus.NewTimer(metrics.MAccessPermissionsSummary)
 	defer timer.ObserveDuration()
@@ -113,6 +117,14 @@ func (ac *OSSAccessControlService) GetUserPermissions(ctx context.Context, user
 		}
 	}
 
+	if ac.Store != nil {
+		managed, err := ac.Store.GetUserPermissions(ctx, user)
+		if err != nil {
+			return nil, err
+		}
+		permissions = append(permissions, managed...)
+	}
+
 	return permissions, nil
 }
 
@@ -19,7 +19,7 @@ func setupTestEnv(t testing.TB) *OSSAccessControlService {
 
 	cfg := setting.NewCfg()
 	cfg.FeatureToggles = map[string]bool{"accesscontrol": true}
-	ac := ProvideService(cfg, &usagestats.UsageStatsMock{T: t})
+	ac := ProvideService(cfg, &usagestats.UsageStatsMock{T: t}, nil)
 	return ac
 }
 
@@ -145,7 +145,7 @@ func TestUsageMetrics(t *testing.T) {
 				cfg.FeatureToggles = map[string]bool{"accesscontrol": true}
 			}
 
-			s := ProvideService(cfg, &usagestats.UsageStatsMock{T: t})
+			s := ProvideService(cfg, &usagestats.UsageStatsMock{T: t}, nil)
 			report, err := s.UsageStats.GetUsageReport(context.Background())
 			assert.Nil(t, err)
 
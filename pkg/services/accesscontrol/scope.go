@@ -2,6 +2,7 @@ package accesscontrol
 
 import (
 	"fmt"
+	"strconv"
 	"strings"
 
 	"github.com/grafana/grafana/pkg/models"
@@ -32,6 +33,18 @@ func Field(key string) string {
 	return fmt.Sprintf(`{{ .%s }}`, key)
 }
 
+// DatasourceUIDScope returns the scope of a data source by its uid,
+// e.g. DatasourceUIDScope("abc") returns "datasources:uid:abc"
+func DatasourceUIDScope(uid string) string {
+	return Scope("datasources", "uid", uid)
+}
+
+// DatasourceIDScope returns the scope of a data source by its id,
+// e.g. DatasourceIDScope(1) returns "datasources:id:1"
+func DatasourceIDScope(id int64) string {
+	return Scope("datasources", "id", strconv.FormatInt(id, 10))
+}
+
 type KeywordScopeResolveFunc func(*models.SignedInUser) (string, error)
 
 // ScopeResolver contains a map of functions to resolve scope keywords such as `self` or `current` into `id` based scopes
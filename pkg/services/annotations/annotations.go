@@ -2,7 +2,9 @@ package annotations
 
 import (
 	"context"
+	"encoding/base64"
 	"errors"
+	"fmt"
 
 	"github.com/grafana/grafana/pkg/components/simplejson"
 	"github.com/grafana/grafana/pkg/setting"
@@ -10,6 +12,7 @@ import (
 
 var (
 	ErrTimerangeMissing = errors.New("missing timerange")
+	ErrInvalidCursor    = errors.New("invalid cursor")
 )
 
 type Repository interface {
@@ -37,8 +40,51 @@ type ItemQuery struct {
 	Tags         []string `json:"tags"`
 	Type         string   `json:"type"`
 	MatchAny     bool     `json:"matchAny"`
+	// Query matches annotations whose text contains all of its words.
+	Query string `json:"query"`
 
-	Limit int64 `json:"limit"`
+	// Cursor is the cursor of the last annotation of the previous page,
+	// results start after it.
+	Cursor string `json:"cursor"`
+	Limit  int64  `json:"limit"`
+}
+
+// Cursor is the position of an annotation in the results of a query, which
+// are ordered by end time, time and id, all descending.
+type Cursor struct {
+	EpochEnd int64
+	Epoch    int64
+	Id       int64
+}
+
+// NewCursor returns the cursor of an annotation.
+func NewCursor(item *ItemDTO) Cursor {
+	return Cursor{EpochEnd: item.TimeEnd, Epoch: item.Time, Id: item.Id}
+}
+
+// String returns the opaque string form of the cursor.
+func (c Cursor) String() string {
+	return base64.RawURLEncoding.EncodeToString([]byte(fmt.Sprintf("%d:%d:%d", c.EpochEnd, c.Epoch, c.Id)))
+}
+
+// ParseCursor parses the string form of a cursor.
+func ParseCursor(s string) (Cursor, error) {
+	c := Cursor{}
+	raw, err := base64.RawURLEncoding.DecodeString(s)
+	if err != nil {
+		return c, ErrInvalidCursor
+	}
+	if _, err := fmt.Sscanf(string(raw), "%d:%d:%d", &c.EpochEnd, &c.Epoch, &c.Id); err != nil {
+		return c, ErrInvalidCursor
+	}
+	return c, nil
+}
+
+// SearchResult is a page of annotation search results.
+type SearchResult struct {
+	Annotations []*ItemDTO `json:"annotations"`
+	// NextCursor is the cursor of the next page, empty on the last page.
+	NextCursor string `json:"nextCursor,omitempty"`
 }
 
 // TagsQuery is the query for a tags search.
@@ -0,0 +1,407 @@
+package annotationwebhooks
+
+import (
+	"context"
+	"crypto/subtle"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/grafana/grafana/pkg/api/routing"
+	"github.com/grafana/grafana/pkg/infra/log"
+	"github.com/grafana/grafana/pkg/models"
+	"github.com/grafana/grafana/pkg/services/annotations"
+	"github.com/grafana/grafana/pkg/services/encryption"
+	"github.com/grafana/grafana/pkg/services/sqlstore"
+	"github.com/grafana/grafana/pkg/setting"
+	"github.com/grafana/grafana/pkg/util"
+)
+
+// tokenLength is the length of generated webhook tokens.
+const tokenLength = 32
+
+func ProvideService(cfg *setting.Cfg, sqlStore *sqlstore.SQLStore, routeRegister routing.RouteRegister,
+	encryptionService encryption.Service) *Service {
+	s := &Service{
+		Cfg:               cfg,
+		SQLStore:          sqlStore,
+		RouteRegister:     routeRegister,
+		EncryptionService: encryptionService,
+		log:               log.New("annotation-webhooks"),
+	}
+	s.registerAPIEndpoints()
+	return s
+}
+
+// Service manages inbound webhooks creating annotations from the payloads of
+// CI/CD and incident systems.
+type Service struct {
+	Cfg               *setting.Cfg
+	SQLStore          *sqlstore.SQLStore
+	RouteRegister     routing.RouteRegister
+	EncryptionService encryption.Service
+	log               log.Logger
+}
+
+// GetWebhooks returns the webhooks of an organization.
+func (s *Service) GetWebhooks(ctx context.Context, orgID int64) ([]WebhookDTO, error) {
+	var webhooks []*Webhook
+	err := s.SQLStore.WithDbSession(ctx, func(sess *sqlstore.DBSession) error {
+		return sess.Where("org_id = ?", orgID).Asc("name").Find(&webhooks)
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	result := make([]WebhookDTO, 0, len(webhooks))
+	for _, webhook := range webhooks {
+		result = append(result, s.toDTO(webhook, ""))
+	}
+	return result, nil
+}
+
+// GetWebhook returns a webhook of an organization.
+func (s *Service) GetWebhook(ctx context.Context, orgID int64, uid string) (WebhookDTO, error) {
+	webhook, err := s.getWebhook(ctx, uid)
+	if err != nil {
+		return WebhookDTO{}, err
+	}
+	if webhook.OrgID != orgID {
+		return WebhookDTO{}, ErrWebhookNotFound
+	}
+	return s.toDTO(webhook, ""), nil
+}
+
+// CreateWebhook creates a webhook with a new token, which is only returned
+// here.
+func (s *Service) CreateWebhook(ctx context.Context, user *models.SignedInUser, cmd CreateWebhookCommand) (WebhookDTO, error) {
+	token, err := util.GetRandomString(tokenLength)
+	if err != nil {
+		return WebhookDTO{}, err
+	}
+	encryptedToken, err := s.EncryptionService.Encrypt(ctx, []byte(token), setting.SecretKey)
+	if err != nil {
+		return WebhookDTO{}, err
+	}
+
+	now := time.Now()
+	webhook := &Webhook{
+		UID:            util.GenerateShortUID(),
+		OrgID:          user.OrgId,
+		Name:           strings.TrimSpace(cmd.Name),
+		Source:         cmd.Source,
+		DashboardUID:   cmd.DashboardUID,
+		PanelID:        cmd.PanelID,
+		Tags:           cmd.Tags,
+		Mapping:        cmd.Mapping,
+		EncryptedToken: encryptedToken,
+		CreatedBy:      user.UserId,
+		Created:        now,
+		Updated:        now,
+	}
+	if err := s.validate(ctx, webhook); err != nil {
+		return WebhookDTO{}, err
+	}
+
+	err = s.SQLStore.WithTransactionalDbSession(ctx, func(sess *sqlstore.DBSession) error {
+		if err := checkNameAvailable(sess, webhook); err != nil {
+			return err
+		}
+		_, err := sess.Insert(webhook)
+		return err
+	})
+	if err != nil {
+		return WebhookDTO{}, err
+	}
+	return s.toDTO(webhook, token), nil
+}
+
+// UpdateWebhook updates a webhook of an organization, its token is kept.
+func (s *Service) UpdateWebhook(ctx context.Context, orgID int64, uid string, cmd UpdateWebhookCommand) (WebhookDTO, error) {
+	webhook, err := s.getWebhook(ctx, uid)
+	if err != nil {
+		return WebhookDTO{}, err
+	}
+	if webhook.OrgID != orgID {
+		return WebhookDTO{}, ErrWebhookNotFound
+	}
+
+	webhook.Name = strings.TrimSpace(cmd.Name)
+	webhook.Source = cmd.Source
+	webhook.DashboardUID = cmd.DashboardUID
+	webhook.PanelID = cmd.PanelID
+	webhook.Tags = cmd.Tags
+	webhook.Mapping = cmd.Mapping
+	webhook.Updated = time.Now()
+	if err := s.validate(ctx, webhook); err != nil {
+		return WebhookDTO{}, err
+	}
+
+	err = s.SQLStore.WithTransactionalDbSession(ctx, func(sess *sqlstore.DBSession) error {
+		if err := checkNameAvailable(sess, webhook); err != nil {
+			return err
+		}
+		_, err := sess.ID(webhook.ID).AllCols().Update(webhook)
+		return err
+	})
+	if err != nil {
+		return WebhookDTO{}, err
+	}
+	return s.toDTO(webhook, ""), nil
+}
+
+// RegenerateToken replaces the token of a webhook, payloads authenticated
+// with the previous token are rejected from then on.
+func (s *Service) RegenerateToken(ctx context.Context, orgID int64, uid string) (WebhookDTO, error) {
+	webhook, err := s.getWebhook(ctx, uid)
+	if err != nil {
+		return WebhookDTO{}, err
+	}
+	if webhook.OrgID != orgID {
+		return WebhookDTO{}, ErrWebhookNotFound
+	}
+
+	token, err := util.GetRandomString(tokenLength)
+	if err != nil {
+		return WebhookDTO{}, err
+	}
+	webhook.EncryptedToken, err = s.EncryptionService.Encrypt(ctx, []byte(token), setting.SecretKey)
+	if err != nil {
+		return WebhookDTO{}, err
+	}
+	webhook.Updated = time.Now()
+
+	err = s.SQLStore.WithDbSession(ctx, func(sess *sqlstore.DBSession) error {
+		_, err := sess.ID(webhook.ID).Cols("encrypted_token", "updated").Update(webhook)
+		return err
+	})
+	if err != nil {
+		return WebhookDTO{}, err
+	}
+	return s.toDTO(webhook, token), nil
+}
+
+// DeleteWebhook deletes a webhook of an organization, the annotations it
+// created are kept.
+func (s *Service) DeleteWebhook(ctx context.Context, orgID int64, uid string) error {
+	return s.SQLStore.WithDbSession(ctx, func(sess *sqlstore.DBSession) error {
+		deleted, err := sess.Where("org_id = ? AND uid = ?", orgID, uid).Delete(&Webhook{})
+		if err != nil {
+			return err
+		}
+		if deleted == 0 {
+			return ErrWebhookNotFound
+		}
+		return nil
+	})
+}
+
+// Receive authenticates a payload sent to a webhook and creates the
+// annotations it maps to. Annotations that were already created for an
+// earlier payload, such as a firing alert that is now resolved, are updated
+// instead.
+func (s *Service) Receive(ctx context.Context, uid string, req *Request) (ReceiveResult, error) {
+	webhook, err := s.getWebhook(ctx, uid)
+	if err != nil {
+		return ReceiveResult{}, err
+	}
+	if err := s.authenticate(ctx, webhook, req); err != nil {
+		return ReceiveResult{}, err
+	}
+
+	mapped, message, err := mapPayload(webhook, req)
+	if err != nil {
+		return ReceiveResult{}, err
+	}
+	result := ReceiveResult{Message: message, AnnotationIDs: []int64{}}
+	if len(mapped) == 0 {
+		return result, nil
+	}
+
+	var dashboardID int64
+	if webhook.DashboardUID != "" {
+		dash, err := s.SQLStore.GetDashboard(0, webhook.OrgID, webhook.DashboardUID, "")
+		if err != nil {
+			return ReceiveResult{}, err
+		}
+		dashboardID = dash.Id
+	}
+
+	repo := annotations.GetRepository()
+	for _, a := range mapped {
+		item := annotations.Item{
+			OrgId:       webhook.OrgID,
+			DashboardId: dashboardID,
+			PanelId:     webhook.PanelID,
+			Epoch:       a.time.UnixNano() / int64(time.Millisecond),
+			Text:        a.text,
+			Tags:        mergeTags(a.tags, []string{a.key}, webhook.Tags),
+		}
+		if !a.timeEnd.IsZero() {
+			item.EpochEnd = a.timeEnd.UnixNano() / int64(time.Millisecond)
+		}
+
+		existing, err := findAnnotation(repo, &item, a.key)
+		if err != nil {
+			return ReceiveResult{}, err
+		}
+		if existing != nil {
+			item.Id = existing.Id
+			err = repo.Update(&item)
+		} else {
+			err = repo.Save(&item)
+		}
+		if err != nil {
+			return ReceiveResult{}, err
+		}
+		result.AnnotationIDs = append(result.AnnotationIDs, item.Id)
+	}
+	return result, nil
+}
+
+// authenticate checks the token of a payload, sent either as a GitHub
+// signature, in the X-Gitlab-Token or X-Grafana-Webhook-Token headers, or in
+// the token query parameter.
+func (s *Service) authenticate(ctx context.Context, webhook *Webhook, req *Request) error {
+	token, err := s.EncryptionService.Decrypt(ctx, webhook.EncryptedToken, setting.SecretKey)
+	if err != nil {
+		return err
+	}
+
+	if signature := req.Header.Get("X-Hub-Signature-256"); signature != "" {
+		if !validGitHubSignature(token, req.Body, signature) {
+			return ErrInvalidToken
+		}
+		return nil
+	}
+
+	for _, received := range []string{req.Header.Get("X-Gitlab-Token"), req.Header.Get("X-Grafana-Webhook-Token"), req.Token} {
+		if received != "" && subtle.ConstantTimeCompare([]byte(received), token) == 1 {
+			return nil
+		}
+	}
+	return ErrInvalidToken
+}
+
+func (s *Service) validate(ctx context.Context, webhook *Webhook) error {
+	if webhook.Name == "" {
+		return errNameRequired
+	}
+	switch webhook.Source {
+	case SourceGeneric:
+		for key := range webhook.Mapping {
+			switch key {
+			case MappingText, MappingTags, MappingTime, MappingTimeEnd:
+			default:
+				return errInvalidMapping
+			}
+		}
+	case SourceGitHub, SourceGitLab, SourceAlertmanager:
+		webhook.Mapping = nil
+	default:
+		return errInvalidSource
+	}
+
+	if webhook.DashboardUID == "" {
+		webhook.PanelID = 0
+		return nil
+	}
+	if _, err := s.SQLStore.GetDashboard(0, webhook.OrgID, webhook.DashboardUID, ""); err != nil {
+		if errors.Is(err, models.ErrDashboardNotFound) {
+			return fmt.Errorf("%w: %q", errDashboardNotFound, webhook.DashboardUID)
+		}
+		return err
+	}
+	return nil
+}
+
+func (s *Service) getWebhook(ctx context.Context, uid string) (*Webhook, error) {
+	webhook := &Webhook{}
+	err := s.SQLStore.WithDbSession(ctx, func(sess *sqlstore.DBSession) error {
+		has, err := sess.Where("uid = ?", uid).Get(webhook)
+		if err != nil {
+			return err
+		}
+		if !has {
+			return ErrWebhookNotFound
+		}
+		return nil
+	})
+	return webhook, err
+}
+
+func (s *Service) toDTO(webhook *Webhook, token string) WebhookDTO {
+	tags := webhook.Tags
+	if tags == nil {
+		tags = []string{}
+	}
+	return WebhookDTO{
+		UID:          webhook.UID,
+		Name:         webhook.Name,
+		Source:       webhook.Source,
+		DashboardUID: webhook.DashboardUID,
+		PanelID:      webhook.PanelID,
+		Tags:         tags,
+		Mapping:      webhook.Mapping,
+		URL:          fmt.Sprintf("%sapi/annotation-webhooks/%s/receive", s.Cfg.AppURL, webhook.UID),
+		Token:        token,
+		Created:      webhook.Created,
+		Updated:      webhook.Updated,
+	}
+}
+
+func checkNameAvailable(sess *sqlstore.DBSession, webhook *Webhook) error {
+	existing := Webhook{}
+	has, err := sess.Where("org_id = ? AND name = ?", webhook.OrgID, webhook.Name).Cols("id").Get(&existing)
+	if err != nil {
+		return err
+	}
+	if has && existing.ID != webhook.ID {
+		return ErrWebhookNameExists
+	}
+	return nil
+}
+
+// findAnnotation returns the annotation created for an earlier payload with
+// the same key and start time, if any.
+func findAnnotation(repo annotations.Repository, item *annotations.Item, key string) (*annotations.ItemDTO, error) {
+	if key == "" {
+		return nil, nil
+	}
+	items, err := repo.Find(&annotations.ItemQuery{
+		OrgId:       item.OrgId,
+		DashboardId: item.DashboardId,
+		From:        item.Epoch,
+		To:          item.Epoch,
+		Tags:        []string{key},
+		Type:        "annotation",
+	})
+	if err != nil {
+		return nil, err
+	}
+	for _, existing := range items {
+		if existing.Time == item.Epoch && existing.PanelId == item.PanelId {
+			return existing, nil
+		}
+	}
+	return nil, nil
+}
+
+// mergeTags returns the tags of the lists in order, without duplicates. The
+// lists aren't modified.
+func mergeTags(lists ...[]string) []string {
+	merged := []string{}
+	seen := map[string]bool{}
+	for _, tags := range lists {
+		for _, tag := range tags {
+			tag = strings.TrimSpace(tag)
+			if tag == "" || seen[tag] {
+				continue
+			}
+			seen[tag] = true
+			merged = append(merged, tag)
+		}
+	}
+	return merged
+}
@@ -0,0 +1,225 @@
+package annotationwebhooks
+
+import (
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"net/http"
+	"testing"
+
+	"github.com/grafana/grafana/pkg/api/routing"
+	"github.com/grafana/grafana/pkg/components/simplejson"
+	"github.com/grafana/grafana/pkg/models"
+	"github.com/grafana/grafana/pkg/services/annotations"
+	"github.com/grafana/grafana/pkg/services/encryption/ossencryption"
+	"github.com/grafana/grafana/pkg/services/sqlstore"
+	"github.com/grafana/grafana/pkg/setting"
+	"github.com/stretchr/testify/require"
+)
+
+func TestService(t *testing.T) {
+	ctx := context.Background()
+	sqlStore := sqlstore.InitTestDB(t)
+	cfg := setting.NewCfg()
+	cfg.AppURL = "http://localhost:3000/"
+	s := ProvideService(cfg, sqlStore, routing.NewRouteRegister(), ossencryption.ProvideService())
+	user := &models.SignedInUser{OrgId: 1, UserId: 1, OrgRole: models.ROLE_ADMIN}
+
+	data := simplejson.New()
+	data.Set("title", "Deployments")
+	dash, err := sqlStore.SaveDashboard(context.Background(), models.SaveDashboardCommand{OrgId: 1, Dashboard: data})
+	require.NoError(t, err)
+
+	findAnnotations := func(t *testing.T, tags ...string) []*annotations.ItemDTO {
+		t.Helper()
+		items, err := annotations.GetRepository().Find(&annotations.ItemQuery{OrgId: 1, Tags: tags})
+		require.NoError(t, err)
+		return items
+	}
+
+	t.Run("create, update, list and delete webhooks", func(t *testing.T) {
+		created, err := s.CreateWebhook(ctx, user, CreateWebhookCommand{Name: "Deploys", Source: SourceGeneric})
+		require.NoError(t, err)
+		require.Len(t, created.Token, tokenLength)
+		require.Equal(t, "http://localhost:3000/api/annotation-webhooks/"+created.UID+"/receive", created.URL)
+
+		_, err = s.CreateWebhook(ctx, user, CreateWebhookCommand{Name: "Deploys", Source: SourceGitHub})
+		require.ErrorIs(t, err, ErrWebhookNameExists)
+		_, err = s.CreateWebhook(ctx, user, CreateWebhookCommand{Name: "Other", Source: "jenkins"})
+		require.ErrorIs(t, err, errInvalidSource)
+		_, err = s.CreateWebhook(ctx, user, CreateWebhookCommand{Name: "Other", Source: SourceGeneric, Mapping: map[string]string{"title": "x"}})
+		require.ErrorIs(t, err, errInvalidMapping)
+		_, err = s.CreateWebhook(ctx, user, CreateWebhookCommand{Name: "Other", Source: SourceGeneric, DashboardUID: "missing"})
+		require.ErrorIs(t, err, errDashboardNotFound)
+
+		updated, err := s.UpdateWebhook(ctx, 1, created.UID, UpdateWebhookCommand{
+			Name:         "Deploys",
+			Source:       SourceGeneric,
+			DashboardUID: dash.Uid,
+			PanelID:      2,
+			Tags:         []string{"ci"},
+		})
+		require.NoError(t, err)
+		require.Empty(t, updated.Token)
+		require.Equal(t, dash.Uid, updated.DashboardUID)
+
+		_, err = s.GetWebhook(ctx, 2, created.UID)
+		require.ErrorIs(t, err, ErrWebhookNotFound)
+
+		webhooks, err := s.GetWebhooks(ctx, 1)
+		require.NoError(t, err)
+		require.Len(t, webhooks, 1)
+		require.Equal(t, []string{"ci"}, webhooks[0].Tags)
+
+		require.NoError(t, s.DeleteWebhook(ctx, 1, created.UID))
+		require.ErrorIs(t, s.DeleteWebhook(ctx, 1, created.UID), ErrWebhookNotFound)
+	})
+
+	t.Run("generic payloads are authenticated and mapped", func(t *testing.T) {
+		webhook, err := s.CreateWebhook(ctx, user, CreateWebhookCommand{
+			Name:         "Generic",
+			Source:       SourceGeneric,
+			DashboardUID: dash.Uid,
+			Tags:         []string{"generic"},
+			Mapping:      map[string]string{MappingText: "build.message", MappingTags: "build.labels", MappingTime: "build.finishedAt"},
+		})
+		require.NoError(t, err)
+
+		body := []byte(`{"build": {"message": "Build 42 deployed", "labels": ["release"], "finishedAt": "2021-10-01T12:00:00Z"}}`)
+		_, err = s.Receive(ctx, webhook.UID, &Request{Header: http.Header{}, Body: body, Token: "wrong"})
+		require.ErrorIs(t, err, ErrInvalidToken)
+
+		result, err := s.Receive(ctx, webhook.UID, &Request{Header: http.Header{}, Body: body, Token: webhook.Token})
+		require.NoError(t, err)
+		require.Len(t, result.AnnotationIDs, 1)
+
+		items := findAnnotations(t, "generic")
+		require.Len(t, items, 1)
+		require.Equal(t, "Build 42 deployed", items[0].Text)
+		require.Equal(t, []string{"release", "generic"}, items[0].Tags)
+		require.Equal(t, dash.Id, items[0].DashboardId)
+		require.Equal(t, int64(1633089600000), items[0].Time)
+
+		_, err = s.Receive(ctx, webhook.UID, &Request{Header: http.Header{}, Body: []byte(`{}`), Token: webhook.Token})
+		require.ErrorIs(t, err, errInvalidPayload)
+
+		regenerated, err := s.RegenerateToken(ctx, 1, webhook.UID)
+		require.NoError(t, err)
+		_, err = s.Receive(ctx, webhook.UID, &Request{Header: http.Header{}, Body: body, Token: webhook.Token})
+		require.ErrorIs(t, err, ErrInvalidToken)
+		header := http.Header{}
+		header.Set("X-Grafana-Webhook-Token", regenerated.Token)
+		_, err = s.Receive(ctx, webhook.UID, &Request{Header: header, Body: body})
+		require.NoError(t, err)
+	})
+
+	t.Run("GitHub deployment statuses are mapped to region annotations", func(t *testing.T) {
+		webhook, err := s.CreateWebhook(ctx, user, CreateWebhookCommand{Name: "GitHub", Source: SourceGitHub})
+		require.NoError(t, err)
+
+		send := func(t *testing.T, event string, body string, secret string) (ReceiveResult, error) {
+			mac := hmac.New(sha256.New, []byte(secret))
+			mac.Write([]byte(body))
+			header := http.Header{}
+			header.Set("X-GitHub-Event", event)
+			header.Set("X-Hub-Signature-256", "sha256="+hex.EncodeToString(mac.Sum(nil)))
+			return s.Receive(ctx, webhook.UID, &Request{Header: header, Body: []byte(body)})
+		}
+
+		body := `{
+			"deployment_status": {"state": "success", "created_at": "2021-10-01T12:05:00Z"},
+			"deployment": {"id": 7, "ref": "main", "sha": "0123456789abcdef", "environment": "production", "created_at": "2021-10-01T12:00:00Z"},
+			"repository": {"full_name": "grafana/app"}
+		}`
+		_, err = send(t, "deployment_status", body, "wrong")
+		require.ErrorIs(t, err, ErrInvalidToken)
+
+		result, err := send(t, "ping", `{}`, webhook.Token)
+		require.NoError(t, err)
+		require.Empty(t, result.AnnotationIDs)
+
+		result, err = send(t, "deployment_status", body, webhook.Token)
+		require.NoError(t, err)
+		require.Len(t, result.AnnotationIDs, 1)
+
+		items := findAnnotations(t, "github")
+		require.Len(t, items, 1)
+		require.Equal(t, "Deployment of grafana/app main (0123456) to production: success", items[0].Text)
+		require.Equal(t, []string{"github", "deployment", "repository:grafana/app", "environment:production", "status:success", "deployment:7"}, items[0].Tags)
+		require.Equal(t, int64(1633089600000), items[0].Time)
+		require.Equal(t, int64(1633089900000), items[0].TimeEnd)
+	})
+
+	t.Run("GitLab deployment events are mapped to annotations", func(t *testing.T) {
+		webhook, err := s.CreateWebhook(ctx, user, CreateWebhookCommand{Name: "GitLab", Source: SourceGitLab})
+		require.NoError(t, err)
+
+		header := http.Header{}
+		header.Set("X-Gitlab-Token", webhook.Token)
+		running := `{"object_kind": "deployment", "status": "running", "deployment_id": 3}`
+		result, err := s.Receive(ctx, webhook.UID, &Request{Header: header, Body: []byte(running)})
+		require.NoError(t, err)
+		require.Empty(t, result.AnnotationIDs)
+
+		body := `{
+			"object_kind": "deployment", "status": "failed", "status_changed_at": "2021-10-01 14:00:00 +0200",
+			"deployment_id": 3, "environment": "staging", "ref": "v1.2.0", "short_sha": "abcdef1",
+			"project": {"path_with_namespace": "grafana/app"}
+		}`
+		result, err = s.Receive(ctx, webhook.UID, &Request{Header: header, Body: []byte(body)})
+		require.NoError(t, err)
+		require.Len(t, result.AnnotationIDs, 1)
+
+		items := findAnnotations(t, "gitlab")
+		require.Len(t, items, 1)
+		require.Equal(t, "Deployment of grafana/app v1.2.0 (abcdef1) to staging: failed", items[0].Text)
+		require.Equal(t, int64(1633089600000), items[0].Time)
+	})
+
+	t.Run("Alertmanager alerts are updated once resolved", func(t *testing.T) {
+		webhook, err := s.CreateWebhook(ctx, user, CreateWebhookCommand{Name: "Alertmanager", Source: SourceAlertmanager})
+		require.NoError(t, err)
+
+		firing := `{"status": "firing", "alerts": [{
+			"status": "firing", "fingerprint": "abc123", "startsAt": "2021-10-01T12:00:00Z", "endsAt": "0001-01-01T00:00:00Z",
+			"labels": {"alertname": "HighLatency", "severity": "critical"}, "annotations": {"summary": "p99 above 1s"}
+		}]}`
+		first, err := s.Receive(ctx, webhook.UID, &Request{Header: http.Header{}, Body: []byte(firing), Token: webhook.Token})
+		require.NoError(t, err)
+		require.Len(t, first.AnnotationIDs, 1)
+
+		// repeated notifications don't create more annotations
+		repeated, err := s.Receive(ctx, webhook.UID, &Request{Header: http.Header{}, Body: []byte(firing), Token: webhook.Token})
+		require.NoError(t, err)
+		require.Equal(t, first.AnnotationIDs, repeated.AnnotationIDs)
+
+		resolved := `{"status": "resolved", "alerts": [{
+			"status": "resolved", "fingerprint": "abc123", "startsAt": "2021-10-01T12:00:00Z", "endsAt": "2021-10-01T12:30:00Z",
+			"labels": {"alertname": "HighLatency", "severity": "critical"}, "annotations": {"summary": "p99 above 1s"}
+		}]}`
+		result, err := s.Receive(ctx, webhook.UID, &Request{Header: http.Header{}, Body: []byte(resolved), Token: webhook.Token})
+		require.NoError(t, err)
+		require.Equal(t, first.AnnotationIDs, result.AnnotationIDs)
+
+		items := findAnnotations(t, "alertmanager")
+		require.Len(t, items, 1)
+		require.Equal(t, "HighLatency: p99 above 1s", items[0].Text)
+		require.Equal(t, []string{"alertmanager", "alertname:HighLatency", "status:resolved", "severity:critical", "fingerprint:abc123"}, items[0].Tags)
+		require.Equal(t, int64(1633089600000), items[0].Time)
+		require.Equal(t, int64(1633091400000), items[0].TimeEnd)
+	})
+
+	t.Run("payloads for unknown webhooks are rejected", func(t *testing.T) {
+		_, err := s.Receive(ctx, "unknown", &Request{Header: http.Header{}, Body: []byte(`{}`)})
+		require.ErrorIs(t, err, ErrWebhookNotFound)
+	})
+}
+
+func TestMergeTags(t *testing.T) {
+	backing := []string{"deploy", "production"}
+	tags := backing[:1]
+
+	require.Equal(t, []string{"deploy", "key", "webhook"}, mergeTags(tags, []string{"key"}, []string{" webhook ", "deploy", ""}))
+	require.Equal(t, []string{"deploy", "production"}, backing)
+}
@@ -0,0 +1,127 @@
+package annotationwebhooks
+
+import (
+	"errors"
+	"io/ioutil"
+	"net/http"
+
+	"github.com/go-macaron/binding"
+	"github.com/grafana/grafana/pkg/api/response"
+	"github.com/grafana/grafana/pkg/api/routing"
+	"github.com/grafana/grafana/pkg/middleware"
+	"github.com/grafana/grafana/pkg/models"
+	"github.com/grafana/grafana/pkg/web"
+)
+
+// maxPayloadSize is the maximum size of a payload received by a webhook.
+const maxPayloadSize = 1 << 20
+
+func (s *Service) registerAPIEndpoints() {
+	s.RouteRegister.Group("/api/annotation-webhooks", func(webhooks routing.RouteRegister) {
+		webhooks.Get("/", middleware.ReqOrgAdmin, routing.Wrap(s.getWebhooksHandler))
+		webhooks.Post("/", middleware.ReqOrgAdmin, binding.Bind(CreateWebhookCommand{}), routing.Wrap(s.createHandler))
+		webhooks.Get("/:uid", middleware.ReqOrgAdmin, routing.Wrap(s.getWebhookHandler))
+		webhooks.Put("/:uid", middleware.ReqOrgAdmin, binding.Bind(UpdateWebhookCommand{}), routing.Wrap(s.updateHandler))
+		webhooks.Delete("/:uid", middleware.ReqOrgAdmin, routing.Wrap(s.deleteHandler))
+		webhooks.Post("/:uid/token", middleware.ReqOrgAdmin, routing.Wrap(s.regenerateTokenHandler))
+		// payloads are authenticated with the token of the webhook
+		webhooks.Post("/:uid/receive", routing.Wrap(s.receiveHandler))
+	})
+}
+
+// getWebhooksHandler handles GET /api/annotation-webhooks.
+func (s *Service) getWebhooksHandler(c *models.ReqContext) response.Response {
+	webhooks, err := s.GetWebhooks(c.Req.Context(), c.OrgId)
+	if err != nil {
+		return toWebhookError(err, "Failed to get annotation webhooks")
+	}
+
+	return response.JSON(200, webhooks)
+}
+
+// createHandler handles POST /api/annotation-webhooks.
+func (s *Service) createHandler(c *models.ReqContext, cmd CreateWebhookCommand) response.Response {
+	webhook, err := s.CreateWebhook(c.Req.Context(), c.SignedInUser, cmd)
+	if err != nil {
+		return toWebhookError(err, "Failed to create annotation webhook")
+	}
+
+	return response.JSON(200, webhook)
+}
+
+// getWebhookHandler handles GET /api/annotation-webhooks/:uid.
+func (s *Service) getWebhookHandler(c *models.ReqContext) response.Response {
+	webhook, err := s.GetWebhook(c.Req.Context(), c.OrgId, web.Params(c.Req)[":uid"])
+	if err != nil {
+		return toWebhookError(err, "Failed to get annotation webhook")
+	}
+
+	return response.JSON(200, webhook)
+}
+
+// updateHandler handles PUT /api/annotation-webhooks/:uid.
+func (s *Service) updateHandler(c *models.ReqContext, cmd UpdateWebhookCommand) response.Response {
+	webhook, err := s.UpdateWebhook(c.Req.Context(), c.OrgId, web.Params(c.Req)[":uid"], cmd)
+	if err != nil {
+		return toWebhookError(err, "Failed to update annotation webhook")
+	}
+
+	return response.JSON(200, webhook)
+}
+
+// deleteHandler handles DELETE /api/annotation-webhooks/:uid.
+func (s *Service) deleteHandler(c *models.ReqContext) response.Response {
+	if err := s.DeleteWebhook(c.Req.Context(), c.OrgId, web.Params(c.Req)[":uid"]); err != nil {
+		return toWebhookError(err, "Failed to delete annotation webhook")
+	}
+
+	return response.Success("Annotation webhook deleted")
+}
+
+// regenerateTokenHandler handles POST /api/annotation-webhooks/:uid/token.
+func (s *Service) regenerateTokenHandler(c *models.ReqContext) response.Response {
+	webhook, err := s.RegenerateToken(c.Req.Context(), c.OrgId, web.Params(c.Req)[":uid"])
+	if err != nil {
+		return toWebhookError(err, "Failed to regenerate annotation webhook token")
+	}
+
+	return response.JSON(200, webhook)
+}
+
+// receiveHandler handles POST /api/annotation-webhooks/:uid/receive.
+func (s *Service) receiveHandler(c *models.ReqContext) response.Response {
+	body, err := ioutil.ReadAll(http.MaxBytesReader(c.Resp, c.Req.Body, maxPayloadSize))
+	if err != nil {
+		return response.Error(400, "Failed to read webhook payload", err)
+	}
+
+	result, err := s.Receive(c.Req.Context(), web.Params(c.Req)[":uid"], &Request{
+		Header: c.Req.Header,
+		Body:   body,
+		Token:  c.Query("token"),
+	})
+	if err != nil {
+		// unknown webhooks and invalid tokens get the same response so
+		// that webhook uids can't be probed
+		if errors.Is(err, ErrWebhookNotFound) || errors.Is(err, ErrInvalidToken) {
+			return response.Error(401, ErrInvalidToken.Error(), nil)
+		}
+		return toWebhookError(err, "Failed to save annotations of webhook payload")
+	}
+
+	return response.JSON(200, result)
+}
+
+func toWebhookError(err error, message string) response.Response {
+	if errors.Is(err, ErrWebhookNotFound) || errors.Is(err, models.ErrDashboardNotFound) {
+		return response.Error(404, err.Error(), err)
+	}
+	if errors.Is(err, ErrWebhookNameExists) {
+		return response.Error(409, err.Error(), err)
+	}
+	if errors.Is(err, errNameRequired) || errors.Is(err, errInvalidSource) ||
+		errors.Is(err, errInvalidMapping) || errors.Is(err, errDashboardNotFound) || errors.Is(err, errInvalidPayload) {
+		return response.Error(400, err.Error(), err)
+	}
+	return response.Error(500, message, err)
+}
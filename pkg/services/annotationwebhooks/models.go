@@ -0,0 +1,114 @@
+package annotationwebhooks
+
+import (
+	"errors"
+	"time"
+)
+
+var (
+	ErrWebhookNotFound   = errors.New("annotation webhook not found")
+	ErrWebhookNameExists = errors.New("an annotation webhook with the same name already exists")
+	ErrInvalidToken      = errors.New("invalid annotation webhook token")
+	errNameRequired      = errors.New("name is required")
+	errInvalidSource     = errors.New("source must be one of generic, github, gitlab or alertmanager")
+	errInvalidMapping    = errors.New("mapping keys must be one of text, tags, time or timeEnd")
+	errDashboardNotFound = errors.New("dashboard not found")
+	errInvalidPayload    = errors.New("invalid webhook payload")
+)
+
+// Sources of the payloads received by webhooks.
+const (
+	SourceGeneric      = "generic"
+	SourceGitHub       = "github"
+	SourceGitLab       = "gitlab"
+	SourceAlertmanager = "alertmanager"
+)
+
+// Mapping keys of generic webhooks, they map annotation fields to the paths of
+// the payload fields holding their values.
+const (
+	MappingText    = "text"
+	MappingTags    = "tags"
+	MappingTime    = "time"
+	MappingTimeEnd = "timeEnd"
+)
+
+// Webhook is an inbound webhook creating annotations from the payloads it
+// receives.
+type Webhook struct {
+	ID           int64  `xorm:"pk autoincr 'id'"`
+	UID          string `xorm:"uid"`
+	OrgID        int64  `xorm:"org_id"`
+	Name         string
+	Source       string
+	DashboardUID string `xorm:"dashboard_uid"`
+	PanelID      int64  `xorm:"panel_id"`
+	// Tags are added to all annotations created by the webhook.
+	Tags    []string
+	Mapping map[string]string
+	// EncryptedToken is the encrypted secret token authenticating payloads.
+	EncryptedToken []byte
+	CreatedBy      int64
+	Created        time.Time
+	Updated        time.Time
+}
+
+// TableName returns the table of webhooks.
+func (w Webhook) TableName() string {
+	return "annotation_webhook"
+}
+
+// CreateWebhookCommand is the command for creating a webhook.
+type CreateWebhookCommand struct {
+	Name         string            `json:"name"`
+	Source       string            `json:"source"`
+	DashboardUID string            `json:"dashboardUid"`
+	PanelID      int64             `json:"panelId"`
+	Tags         []string          `json:"tags"`
+	Mapping      map[string]string `json:"mapping"`
+}
+
+// UpdateWebhookCommand is the command for updating a webhook, the token is
+// kept.
+type UpdateWebhookCommand struct {
+	Name         string            `json:"name"`
+	Source       string            `json:"source"`
+	DashboardUID string            `json:"dashboardUid"`
+	PanelID      int64             `json:"panelId"`
+	Tags         []string          `json:"tags"`
+	Mapping      map[string]string `json:"mapping"`
+}
+
+// WebhookDTO is the frontend DTO for webhooks. The token is only set when
+// the webhook is created or its token is regenerated.
+type WebhookDTO struct {
+	UID          string            `json:"uid"`
+	Name         string            `json:"name"`
+	Source       string            `json:"source"`
+	DashboardUID string            `json:"dashboardUid"`
+	PanelID      int64             `json:"panelId"`
+	Tags         []string          `json:"tags"`
+	Mapping      map[string]string `json:"mapping"`
+	URL          string            `json:"url"`
+	Token        string            `json:"token,omitempty"`
+	Created      time.Time         `json:"created"`
+	Updated      time.Time         `json:"updated"`
+}
+
+// ReceiveResult is the result of receiving a payload.
+type ReceiveResult struct {
+	Message string `json:"message"`
+	// AnnotationIDs are the ids of the created or updated annotations.
+	AnnotationIDs []int64 `json:"annotationIds"`
+}
+
+// annotation is an annotation mapped from a payload. Annotations with a key
+// are only created once, later payloads with the same key and time update
+// them. The key is added to the tags of the annotation.
+type annotation struct {
+	key     string
+	text    string
+	tags    []string
+	time    time.Time
+	timeEnd time.Time
+}
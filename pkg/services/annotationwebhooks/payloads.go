@@ -0,0 +1,234 @@
+package annotationwebhooks
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/grafana/grafana/pkg/components/simplejson"
+)
+
+// Request is a payload received by a webhook.
+type Request struct {
+	Header http.Header
+	Body   []byte
+	// Token is the token query parameter.
+	Token string
+}
+
+// gitLabTimeLayout is the layout of times in GitLab deployment events.
+const gitLabTimeLayout = "2006-01-02 15:04:05 -0700"
+
+// mapPayload returns the annotations a payload maps to and a message
+// describing the result, payloads of events that aren't annotated map to no
+// annotations.
+func mapPayload(webhook *Webhook, req *Request) ([]annotation, string, error) {
+	payload, err := simplejson.NewJson(req.Body)
+	if err != nil {
+		return nil, "", fmt.Errorf("%w: %s", errInvalidPayload, err)
+	}
+
+	var mapped []annotation
+	switch webhook.Source {
+	case SourceGitHub:
+		mapped, err = mapGitHubPayload(req.Header.Get("X-GitHub-Event"), payload)
+	case SourceGitLab:
+		mapped, err = mapGitLabPayload(payload)
+	case SourceAlertmanager:
+		mapped, err = mapAlertmanagerPayload(payload)
+	default:
+		mapped, err = mapGenericPayload(webhook.Mapping, payload)
+	}
+	if err != nil {
+		return nil, "", err
+	}
+
+	if len(mapped) == 0 {
+		return nil, "Event ignored", nil
+	}
+	return mapped, fmt.Sprintf("%d annotation(s) saved", len(mapped)), nil
+}
+
+// mapGenericPayload maps the fields of a payload to an annotation, using the
+// paths of the mapping or the text, tags, time and timeEnd fields by default.
+// Paths are separated by dots.
+func mapGenericPayload(mapping map[string]string, payload *simplejson.Json) ([]annotation, error) {
+	get := func(key string) *simplejson.Json {
+		path := key
+		if p, ok := mapping[key]; ok && p != "" {
+			path = p
+		}
+		return payload.GetPath(strings.Split(path, ".")...)
+	}
+
+	text := get(MappingText).MustString()
+	if text == "" {
+		return nil, fmt.Errorf("%w: text is required", errInvalidPayload)
+	}
+
+	a := annotation{text: text, time: time.Now()}
+	switch tags := get(MappingTags).Interface().(type) {
+	case string:
+		a.tags = []string{tags}
+	case []interface{}:
+		for _, tag := range tags {
+			if s, ok := tag.(string); ok {
+				a.tags = append(a.tags, s)
+			}
+		}
+	}
+
+	var err error
+	if field := get(MappingTime); field.Interface() != nil {
+		if a.time, err = parseTime(field); err != nil {
+			return nil, err
+		}
+	}
+	if field := get(MappingTimeEnd); field.Interface() != nil {
+		if a.timeEnd, err = parseTime(field); err != nil {
+			return nil, err
+		}
+	}
+	return []annotation{a}, nil
+}
+
+// mapGitHubPayload maps deployment_status events of finished deployments to
+// region annotations, from the creation of the deployment to its status.
+func mapGitHubPayload(event string, payload *simplejson.Json) ([]annotation, error) {
+	if event != "deployment_status" {
+		return nil, nil
+	}
+
+	state := payload.GetPath("deployment_status", "state").MustString()
+	switch state {
+	case "success", "failure", "error":
+	default:
+		return nil, nil
+	}
+
+	deployment := payload.Get("deployment")
+	environment := deployment.Get("environment").MustString()
+	repository := payload.GetPath("repository", "full_name").MustString()
+	start, err := parseTime(deployment.Get("created_at"))
+	if err != nil {
+		return nil, err
+	}
+	end, err := parseTime(payload.GetPath("deployment_status", "created_at"))
+	if err != nil {
+		return nil, err
+	}
+
+	return []annotation{{
+		key:     fmt.Sprintf("deployment:%d", deployment.Get("id").MustInt64()),
+		text:    deploymentText(repository, deployment.Get("ref").MustString(), deployment.Get("sha").MustString(), environment, state),
+		tags:    []string{"github", "deployment", "repository:" + repository, "environment:" + environment, "status:" + state},
+		time:    start,
+		timeEnd: end,
+	}}, nil
+}
+
+// mapGitLabPayload maps deployment events of finished deployments to
+// annotations at the time of their status.
+func mapGitLabPayload(payload *simplejson.Json) ([]annotation, error) {
+	if payload.Get("object_kind").MustString() != "deployment" {
+		return nil, nil
+	}
+
+	status := payload.Get("status").MustString()
+	switch status {
+	case "success", "failed", "canceled":
+	default:
+		return nil, nil
+	}
+
+	environment := payload.Get("environment").MustString()
+	project := payload.GetPath("project", "path_with_namespace").MustString()
+	at, err := time.Parse(gitLabTimeLayout, payload.Get("status_changed_at").MustString())
+	if err != nil {
+		return nil, fmt.Errorf("%w: invalid status_changed_at: %s", errInvalidPayload, err)
+	}
+
+	return []annotation{{
+		key:  fmt.Sprintf("deployment:%d", payload.Get("deployment_id").MustInt64()),
+		text: deploymentText(project, payload.Get("ref").MustString(), payload.Get("short_sha").MustString(), environment, status),
+		tags: []string{"gitlab", "deployment", "project:" + project, "environment:" + environment, "status:" + status},
+		time: at,
+	}}, nil
+}
+
+// mapAlertmanagerPayload maps each alert of a notification to an
+// annotation, which becomes a region annotation once the alert is resolved.
+func mapAlertmanagerPayload(payload *simplejson.Json) ([]annotation, error) {
+	alerts, err := payload.Get("alerts").Array()
+	if err != nil {
+		return nil, fmt.Errorf("%w: alerts are required", errInvalidPayload)
+	}
+
+	mapped := make([]annotation, 0, len(alerts))
+	for i := range alerts {
+		alert := payload.Get("alerts").GetIndex(i)
+		status := alert.Get("status").MustString()
+		labels := alert.Get("labels")
+		name := labels.Get("alertname").MustString()
+
+		a := annotation{
+			text: name,
+			tags: []string{"alertmanager", "alertname:" + name, "status:" + status},
+		}
+		if fingerprint := alert.Get("fingerprint").MustString(); fingerprint != "" {
+			a.key = "fingerprint:" + fingerprint
+		}
+		if severity := labels.Get("severity").MustString(); severity != "" {
+			a.tags = append(a.tags, "severity:"+severity)
+		}
+		if summary := alert.GetPath("annotations", "summary").MustString(); summary != "" {
+			a.text += ": " + summary
+		}
+
+		if a.time, err = parseTime(alert.Get("startsAt")); err != nil {
+			return nil, err
+		}
+		if status == "resolved" {
+			if a.timeEnd, err = parseTime(alert.Get("endsAt")); err != nil {
+				return nil, err
+			}
+		}
+		mapped = append(mapped, a)
+	}
+	return mapped, nil
+}
+
+func deploymentText(project, ref, sha, environment, status string) string {
+	if len(sha) > 7 {
+		sha = sha[:7]
+	}
+	return fmt.Sprintf("Deployment of %s %s (%s) to %s: %s", project, ref, sha, environment, status)
+}
+
+// parseTime parses times given as RFC 3339 strings or as Unix milliseconds.
+func parseTime(field *simplejson.Json) (time.Time, error) {
+	if s, err := field.String(); err == nil {
+		t, err := time.Parse(time.RFC3339, s)
+		if err != nil {
+			return time.Time{}, fmt.Errorf("%w: invalid time %q", errInvalidPayload, s)
+		}
+		return t, nil
+	}
+	if ms, err := field.Int64(); err == nil {
+		return time.Unix(0, ms*int64(time.Millisecond)), nil
+	}
+	return time.Time{}, fmt.Errorf("%w: time must be an RFC 3339 string or Unix milliseconds", errInvalidPayload)
+}
+
+// validGitHubSignature checks the X-Hub-Signature-256 header GitHub sends
+// with payloads, an HMAC of the body using the token as secret.
+func validGitHubSignature(token []byte, body []byte, signature string) bool {
+	mac := hmac.New(sha256.New, token)
+	mac.Write(body)
+	expected := "sha256=" + hex.EncodeToString(mac.Sum(nil))
+	return hmac.Equal([]byte(expected), []byte(signature))
+}
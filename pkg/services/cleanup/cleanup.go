@@ -8,6 +8,7 @@ import (
 	"path"
 	"time"
 
+	"github.com/grafana/grafana/pkg/services/queryhistory"
 	"github.com/grafana/grafana/pkg/services/shorturls"
 
 	"github.com/grafana/grafana/pkg/bus"
@@ -15,25 +16,31 @@ import (
 	"github.com/grafana/grafana/pkg/infra/serverlock"
 	"github.com/grafana/grafana/pkg/models"
 	"github.com/grafana/grafana/pkg/services/annotations"
+	"github.com/grafana/grafana/pkg/services/live/database"
 	"github.com/grafana/grafana/pkg/setting"
 )
 
 func ProvideService(cfg *setting.Cfg, serverLockService *serverlock.ServerLockService,
-	shortURLService shorturls.Service) *CleanUpService {
+	shortURLService shorturls.Service, liveMessageStore *database.Storage,
+	queryHistoryService queryhistory.Service) *CleanUpService {
 	s := &CleanUpService{
-		Cfg:               cfg,
-		ServerLockService: serverLockService,
-		ShortURLService:   shortURLService,
-		log:               log.New("cleanup"),
+		Cfg:                 cfg,
+		ServerLockService:   serverLockService,
+		ShortURLService:     shortURLService,
+		LiveMessageStore:    liveMessageStore,
+		QueryHistoryService: queryHistoryService,
+		log:                 log.New("cleanup"),
 	}
 	return s
 }
 
 type CleanUpService struct {
-	log               log.Logger
-	Cfg               *setting.Cfg
-	ServerLockService *serverlock.ServerLockService
-	ShortURLService   shorturls.Service
+	log                 log.Logger
+	Cfg                 *setting.Cfg
+	ServerLockService   *serverlock.ServerLockService
+	ShortURLService     shorturls.Service
+	LiveMessageStore    *database.Storage
+	QueryHistoryService queryhistory.Service
 }
 
 func (srv *CleanUpService) Run(ctx context.Context) error {
@@ -49,9 +56,12 @@ func (srv *CleanUpService) Run(ctx context.Context) error {
 			srv.cleanUpTmpFiles()
 			srv.deleteExpiredSnapshots()
 			srv.deleteExpiredDashboardVersions()
+			srv.deleteExpiredDeletedDashboards(ctxWithTimeout)
 			srv.cleanUpOldAnnotations(ctxWithTimeout)
 			srv.expireOldUserInvites()
 			srv.deleteStaleShortURLs()
+			srv.deleteExpiredLiveMessages(ctxWithTimeout)
+			srv.deleteStaleQueryHistory(ctxWithTimeout)
 			err := srv.ServerLockService.LockAndExecute(ctx, "delete old login attempts",
 				time.Minute*10, func(context.Context) {
 					srv.deleteOldLoginAttempts()
@@ -143,6 +153,17 @@ func (srv *CleanUpService) deleteExpiredDashboardVersions() {
 	}
 }
 
+func (srv *CleanUpService) deleteExpiredDeletedDashboards(ctx context.Context) {
+	cmd := models.DeleteExpiredDeletedDashboardsCommand{
+		OlderThan: time.Now().Add(-setting.DashboardRecycleBinRetention),
+	}
+	if err := bus.DispatchCtx(ctx, &cmd); err != nil {
+		srv.log.Error("Problem deleting expired dashboards from recycle bin", "error", err.Error())
+	} else {
+		srv.log.Debug("Deleted expired dashboards from recycle bin", "rows affected", cmd.DeletedRows)
+	}
+}
+
 func (srv *CleanUpService) deleteOldLoginAttempts() {
 	if srv.Cfg.DisableBruteForceLoginProtection {
 		return
@@ -181,3 +202,22 @@ func (srv *CleanUpService) deleteStaleShortURLs() {
 		srv.log.Debug("Deleted short urls", "rows affected", cmd.NumDeleted)
 	}
 }
+
+func (srv *CleanUpService) deleteExpiredLiveMessages(ctx context.Context) {
+	deleted, err := srv.LiveMessageStore.DeleteExpiredLiveMessages(ctx)
+	if err != nil {
+		srv.log.Error("Problem deleting expired live messages", "error", err.Error())
+	} else {
+		srv.log.Debug("Deleted expired live messages", "rows affected", deleted)
+	}
+}
+
+func (srv *CleanUpService) deleteStaleQueryHistory(ctx context.Context) {
+	olderThan := time.Now().Add(-srv.Cfg.QueryHistoryRetention)
+	deleted, err := srv.QueryHistoryService.DeleteStaleQueriesInQueryHistory(ctx, olderThan)
+	if err != nil {
+		srv.log.Error("Problem deleting stale query history", "error", err.Error())
+	} else {
+		srv.log.Debug("Deleted stale query history", "rows affected", deleted)
+	}
+}
@@ -1,6 +1,7 @@
 package dashboards
 
 import (
+	"context"
 	"fmt"
 	"testing"
 
@@ -40,11 +41,13 @@ func TestDashboardService(t *testing.T) {
 				}
 			})
 
-			Convey("Should return validation error if it's a folder and have a folder id", func() {
+			Convey("Should return validation error if it's a folder nested too deep", func() {
+				fakeStore.validationError = models.ErrDashboardFolderTooDeep
+				defer func() { fakeStore.validationError = nil }()
 				dto.Dashboard = models.NewDashboardFolder("Folder")
 				dto.Dashboard.FolderId = 1
 				_, err := service.SaveDashboard(dto, false)
-				So(err, ShouldEqual, models.ErrDashboardFolderCannotHaveParent)
+				So(err, ShouldEqual, models.ErrDashboardFolderTooDeep)
 			})
 
 			Convey("Should return validation error if folder is named General", func() {
@@ -80,7 +83,7 @@ func TestDashboardService(t *testing.T) {
 					dto.Dashboard.SetUid(tc.Uid)
 					dto.User = &models.SignedInUser{}
 
-					_, err := service.buildSaveDashboardCommand(dto, true, false)
+					_, err := service.buildSaveDashboardCommand(context.Background(), dto, true, false)
 					So(err, ShouldEqual, tc.Error)
 				}
 			})
@@ -226,7 +229,7 @@ func TestDashboardService(t *testing.T) {
 				dto.Dashboard = models.NewDashboard("Dash")
 				dto.Dashboard.SetId(3)
 				dto.User = &models.SignedInUser{UserId: 1}
-				_, err := service.ImportDashboard(dto)
+				_, err := service.ImportDashboard(context.Background(), dto)
 				So(err, ShouldEqual, models.ErrDashboardCannotSaveProvisionedDashboard)
 			})
 		})
@@ -301,7 +304,7 @@ type fakeDashboardStore struct {
 	provisionedData *models.DashboardProvisioning
 }
 
-func (s *fakeDashboardStore) ValidateDashboardBeforeSave(dashboard *models.Dashboard, overwrite bool) (
+func (s *fakeDashboardStore) ValidateDashboardBeforeSave(ctx context.Context, dashboard *models.Dashboard, overwrite bool) (
 	bool, error) {
 	return false, s.validationError
 }
@@ -315,7 +318,7 @@ func (s *fakeDashboardStore) SaveProvisionedDashboard(models.SaveDashboardComman
 	return nil, nil
 }
 
-func (s *fakeDashboardStore) SaveDashboard(cmd models.SaveDashboardCommand) (*models.Dashboard, error) {
+func (s *fakeDashboardStore) SaveDashboard(ctx context.Context, cmd models.SaveDashboardCommand) (*models.Dashboard, error) {
 	return cmd.GetDashboardModel(), nil
 }
 
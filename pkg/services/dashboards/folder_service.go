@@ -18,8 +18,9 @@ type FolderService interface {
 	GetFolderByID(ctx context.Context, id int64) (*models.Folder, error)
 	GetFolderByUID(ctx context.Context, uid string) (*models.Folder, error)
 	GetFolderByTitle(ctx context.Context, title string) (*models.Folder, error)
-	CreateFolder(ctx context.Context, title, uid string) (*models.Folder, error)
+	CreateFolder(ctx context.Context, title, uid, parentUID string) (*models.Folder, error)
 	UpdateFolder(ctx context.Context, uid string, cmd *models.UpdateFolderCommand) error
+	MoveFolder(ctx context.Context, uid string, cmd *models.MoveFolderCommand) error
 	DeleteFolder(ctx context.Context, uid string, forceDeleteRules bool) (*models.Folder, error)
 	MakeUserAdmin(ctx context.Context, orgID int64, userID, folderID int64, setViewAndEditPermissions bool) error
 }
@@ -53,9 +54,11 @@ func (dr *dashboardServiceImpl) GetFolders(ctx context.Context, limit int64, pag
 
 	for _, hit := range searchQuery.Result {
 		folders = append(folders, &models.Folder{
-			Id:    hit.ID,
-			Uid:   hit.UID,
-			Title: hit.Title,
+			Id:        hit.ID,
+			Uid:       hit.UID,
+			Title:     hit.Title,
+			ParentId:  hit.FolderID,
+			ParentUid: hit.FolderUID,
 		})
 	}
 
@@ -80,7 +83,7 @@ func (dr *dashboardServiceImpl) GetFolderByID(ctx context.Context, id int64) (*m
 		return nil, models.ErrFolderAccessDenied
 	}
 
-	return dashToFolder(dashFolder), nil
+	return toFolder(ctx, dashFolder)
 }
 
 func (dr *dashboardServiceImpl) GetFolderByUID(ctx context.Context, uid string) (*models.Folder, error) {
@@ -99,7 +102,7 @@ func (dr *dashboardServiceImpl) GetFolderByUID(ctx context.Context, uid string)
 		return nil, models.ErrFolderAccessDenied
 	}
 
-	return dashToFolder(dashFolder), nil
+	return toFolder(ctx, dashFolder)
 }
 
 func (dr *dashboardServiceImpl) GetFolderByTitle(ctx context.Context, title string) (*models.Folder, error) {
@@ -116,13 +119,20 @@ func (dr *dashboardServiceImpl) GetFolderByTitle(ctx context.Context, title stri
 		return nil, models.ErrFolderAccessDenied
 	}
 
-	return dashToFolder(dashFolder), nil
+	return toFolder(ctx, dashFolder)
 }
 
-func (dr *dashboardServiceImpl) CreateFolder(ctx context.Context, title, uid string) (*models.Folder, error) {
+func (dr *dashboardServiceImpl) CreateFolder(ctx context.Context, title, uid, parentUID string) (*models.Folder, error) {
 	dashFolder := models.NewDashboardFolder(title)
 	dashFolder.OrgId = dr.orgId
 	dashFolder.SetUid(strings.TrimSpace(uid))
+	if parentUID != "" {
+		parent, err := getFolder(ctx, models.GetDashboardQuery{OrgId: dr.orgId, Uid: parentUID})
+		if err != nil {
+			return nil, toFolderError(err)
+		}
+		dashFolder.FolderId = parent.Id
+	}
 	userID := dr.user.UserId
 	if userID == 0 {
 		userID = -1
@@ -137,12 +147,12 @@ func (dr *dashboardServiceImpl) CreateFolder(ctx context.Context, title, uid str
 		User:      dr.user,
 	}
 
-	saveDashboardCmd, err := dr.buildSaveDashboardCommand(dto, false, false)
+	saveDashboardCmd, err := dr.buildSaveDashboardCommand(ctx, dto, false, false)
 	if err != nil {
 		return nil, toFolderError(err)
 	}
 
-	dash, err := dr.dashboardStore.SaveDashboard(*saveDashboardCmd)
+	dash, err := dr.dashboardStore.SaveDashboard(ctx, *saveDashboardCmd)
 	if err != nil {
 		return nil, toFolderError(err)
 	}
@@ -153,7 +163,7 @@ func (dr *dashboardServiceImpl) CreateFolder(ctx context.Context, title, uid str
 		return nil, toFolderError(err)
 	}
 
-	return dashToFolder(dashFolder), nil
+	return toFolder(ctx, dashFolder)
 }
 
 func (dr *dashboardServiceImpl) UpdateFolder(ctx context.Context, existingUid string, cmd *models.UpdateFolderCommand) error {
@@ -172,12 +182,12 @@ func (dr *dashboardServiceImpl) UpdateFolder(ctx context.Context, existingUid st
 		Overwrite: cmd.Overwrite,
 	}
 
-	saveDashboardCmd, err := dr.buildSaveDashboardCommand(dto, false, false)
+	saveDashboardCmd, err := dr.buildSaveDashboardCommand(ctx, dto, false, false)
 	if err != nil {
 		return toFolderError(err)
 	}
 
-	dash, err := dr.dashboardStore.SaveDashboard(*saveDashboardCmd)
+	dash, err := dr.dashboardStore.SaveDashboard(ctx, *saveDashboardCmd)
 	if err != nil {
 		return toFolderError(err)
 	}
@@ -188,9 +198,61 @@ func (dr *dashboardServiceImpl) UpdateFolder(ctx context.Context, existingUid st
 		return toFolderError(err)
 	}
 
-	cmd.Result = dashToFolder(dashFolder)
+	cmd.Result, err = toFolder(ctx, dashFolder)
+	return err
+}
 
-	return nil
+// MoveFolder moves a folder, with its dashboards and subfolders, into another
+// folder or to the top level.
+func (dr *dashboardServiceImpl) MoveFolder(ctx context.Context, uid string, cmd *models.MoveFolderCommand) error {
+	query := models.GetDashboardQuery{OrgId: dr.orgId, Uid: uid}
+	dashFolder, err := getFolder(ctx, query)
+	if err != nil {
+		return toFolderError(err)
+	}
+
+	var parentID int64
+	if cmd.ParentUid != "" {
+		parent, err := getFolder(ctx, models.GetDashboardQuery{OrgId: dr.orgId, Uid: cmd.ParentUid})
+		if err != nil {
+			return toFolderError(err)
+		}
+		parentID = parent.Id
+	}
+
+	dashFolder.FolderId = parentID
+	dashFolder.SetVersion(cmd.Version)
+	userID := dr.user.UserId
+	if userID == 0 {
+		userID = -1
+	}
+	dashFolder.UpdatedBy = userID
+
+	dto := &SaveDashboardDTO{
+		Dashboard: dashFolder,
+		OrgId:     dr.orgId,
+		User:      dr.user,
+		Overwrite: cmd.Overwrite,
+	}
+
+	saveDashboardCmd, err := dr.buildSaveDashboardCommand(ctx, dto, false, false)
+	if err != nil {
+		return toFolderError(err)
+	}
+
+	dash, err := dr.dashboardStore.SaveDashboard(ctx, *saveDashboardCmd)
+	if err != nil {
+		return toFolderError(err)
+	}
+
+	query = models.GetDashboardQuery{OrgId: dr.orgId, Id: dash.Id}
+	dashFolder, err = getFolder(ctx, query)
+	if err != nil {
+		return toFolderError(err)
+	}
+
+	cmd.Result, err = toFolder(ctx, dashFolder)
+	return err
 }
 
 func (dr *dashboardServiceImpl) DeleteFolder(ctx context.Context, uid string, forceDeleteRules bool) (*models.Folder, error) {
@@ -216,6 +278,19 @@ func (dr *dashboardServiceImpl) DeleteFolder(ctx context.Context, uid string, fo
 	return dashToFolder(dashFolder), nil
 }
 
+// toFolder converts the folder dashboard to a folder, looking up the UID of its parent folder.
+func toFolder(ctx context.Context, dash *models.Dashboard) (*models.Folder, error) {
+	folder := dashToFolder(dash)
+	if dash.FolderId > 0 {
+		parent, err := getFolder(ctx, models.GetDashboardQuery{OrgId: dash.OrgId, Id: dash.FolderId})
+		if err != nil {
+			return nil, err
+		}
+		folder.ParentUid = parent.Uid
+	}
+	return folder, nil
+}
+
 func getFolder(ctx context.Context, query models.GetDashboardQuery) (*models.Dashboard, error) {
 	if err := bus.DispatchCtx(ctx, &query); err != nil {
 		return nil, toFolderError(err)
@@ -236,6 +311,7 @@ func dashToFolder(dash *models.Dashboard) *models.Folder {
 		HasAcl:    dash.HasAcl,
 		Url:       dash.GetUrl(),
 		Version:   dash.Version,
+		ParentId:  dash.FolderId,
 		Created:   dash.Created,
 		CreatedBy: dash.CreatedBy,
 		Updated:   dash.Updated,
@@ -0,0 +1,17 @@
+package datasourcehealth
+
+import (
+	"github.com/grafana/grafana/pkg/api/response"
+	"github.com/grafana/grafana/pkg/api/routing"
+	"github.com/grafana/grafana/pkg/middleware"
+	"github.com/grafana/grafana/pkg/models"
+)
+
+func (s *Service) registerAPIEndpoints() {
+	s.RouteRegister.Get("/api/admin/datasources/health", middleware.ReqGrafanaAdmin, routing.Wrap(s.getResultsHandler))
+}
+
+// getResultsHandler handles GET /api/admin/datasources/health.
+func (s *Service) getResultsHandler(c *models.ReqContext) response.Response {
+	return response.JSON(200, s.GetResults())
+}
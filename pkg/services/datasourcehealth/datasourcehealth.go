@@ -0,0 +1,283 @@
+package datasourcehealth
+
+import (
+	"context"
+	"errors"
+	"sort"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/grafana/grafana-plugin-sdk-go/backend"
+	"github.com/grafana/grafana/pkg/api/routing"
+	"github.com/grafana/grafana/pkg/bus"
+	"github.com/grafana/grafana/pkg/events"
+	"github.com/grafana/grafana/pkg/infra/log"
+	"github.com/grafana/grafana/pkg/models"
+	"github.com/grafana/grafana/pkg/plugins"
+	"github.com/grafana/grafana/pkg/plugins/adapters"
+	"github.com/grafana/grafana/pkg/plugins/backendplugin"
+	"github.com/grafana/grafana/pkg/services/encryption"
+	"github.com/grafana/grafana/pkg/services/sqlstore"
+	"github.com/grafana/grafana/pkg/setting"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/promauto"
+)
+
+// StatusError is the status of data sources whose health check failed
+// without a result from the plugin, e.g. because it timed out.
+const StatusError = "ERROR"
+
+var healthLabels = []string{"org_id", "datasource_uid", "datasource", "datasource_type"}
+
+var (
+	healthStatusGauge = promauto.NewGaugeVec(prometheus.GaugeOpts{
+		Namespace: "grafana",
+		Name:      "datasource_health_status",
+		Help:      "Result of the last scheduled health check of the data source, 1 if healthy and 0 otherwise",
+	}, healthLabels)
+
+	healthLatencyGauge = promauto.NewGaugeVec(prometheus.GaugeOpts{
+		Namespace: "grafana",
+		Name:      "datasource_health_check_duration_seconds",
+		Help:      "Duration of the last scheduled health check of the data source",
+	}, healthLabels)
+)
+
+// Result is the result of the last health check of a data source.
+type Result struct {
+	OrgID          int64     `json:"orgId"`
+	DatasourceID   int64     `json:"datasourceId"`
+	DatasourceUID  string    `json:"datasourceUid"`
+	DatasourceName string    `json:"datasourceName"`
+	Type           string    `json:"type"`
+	Status         string    `json:"status"`
+	Message        string    `json:"message"`
+	LatencyMs      int64     `json:"latencyMs"`
+	CheckedAt      time.Time `json:"checkedAt"`
+}
+
+func (r *Result) healthy() bool {
+	return r.Status == backend.HealthStatusOk.String()
+}
+
+func (r *Result) labels() prometheus.Labels {
+	return prometheus.Labels{
+		"org_id":          strconv.FormatInt(r.OrgID, 10),
+		"datasource_uid":  r.DatasourceUID,
+		"datasource":      r.DatasourceName,
+		"datasource_type": r.Type,
+	}
+}
+
+func ProvideService(cfg *setting.Cfg, bus bus.Bus, sqlStore *sqlstore.SQLStore, pluginManager plugins.Manager,
+	backendPluginManager backendplugin.Manager, encryptionService encryption.Service,
+	routeRegister routing.RouteRegister) *Service {
+	s := &Service{
+		Cfg:                  cfg,
+		Bus:                  bus,
+		SQLStore:             sqlStore,
+		PluginManager:        pluginManager,
+		BackendPluginManager: backendPluginManager,
+		EncryptionService:    encryptionService,
+		RouteRegister:        routeRegister,
+		log:                  log.New("datasourcehealth"),
+		results:              map[int64]*Result{},
+	}
+
+	if !s.IsDisabled() {
+		s.registerAPIEndpoints()
+	}
+
+	return s
+}
+
+// Service periodically checks the health of data sources with a backend plugin.
+type Service struct {
+	Cfg                  *setting.Cfg
+	Bus                  bus.Bus
+	SQLStore             *sqlstore.SQLStore
+	PluginManager        plugins.Manager
+	BackendPluginManager backendplugin.Manager
+	EncryptionService    encryption.Service
+	RouteRegister        routing.RouteRegister
+	log                  log.Logger
+
+	mu      sync.RWMutex
+	results map[int64]*Result
+}
+
+func (s *Service) IsDisabled() bool {
+	return !s.Cfg.DataSourceHealthCheckEnabled
+}
+
+func (s *Service) Run(ctx context.Context) error {
+	s.checkAll(ctx)
+
+	ticker := time.NewTicker(s.Cfg.DataSourceHealthCheckInterval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ticker.C:
+			s.checkAll(ctx)
+		case <-ctx.Done():
+			return ctx.Err()
+		}
+	}
+}
+
+// GetResults returns the results of the last health checks, ordered by
+// organization and data source name.
+func (s *Service) GetResults() []*Result {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	results := make([]*Result, 0, len(s.results))
+	for _, r := range s.results {
+		results = append(results, r)
+	}
+	sort.Slice(results, func(i, j int) bool {
+		if results[i].OrgID != results[j].OrgID {
+			return results[i].OrgID < results[j].OrgID
+		}
+		return results[i].DatasourceName < results[j].DatasourceName
+	})
+
+	return results
+}
+
+func (s *Service) checkAll(ctx context.Context) {
+	var dataSources []*models.DataSource
+	err := s.SQLStore.WithDbSession(ctx, func(sess *sqlstore.DBSession) error {
+		return sess.Asc("org_id", "name").Find(&dataSources)
+	})
+	if err != nil {
+		s.log.Error("Failed to get data sources", "error", err)
+		return
+	}
+
+	checked := map[int64]struct{}{}
+	for _, ds := range dataSources {
+		if ctx.Err() != nil {
+			return
+		}
+
+		result := s.check(ctx, ds)
+		if result == nil {
+			continue
+		}
+		checked[ds.Id] = struct{}{}
+		s.record(result)
+	}
+
+	// Forget data sources which have been deleted, or can't be checked anymore.
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	for id, r := range s.results {
+		if _, ok := checked[id]; !ok {
+			healthStatusGauge.Delete(r.labels())
+			healthLatencyGauge.Delete(r.labels())
+			delete(s.results, id)
+		}
+	}
+}
+
+// check returns the health of the data source, or nil if the plugin of the
+// data source doesn't support health checks.
+func (s *Service) check(ctx context.Context, ds *models.DataSource) *Result {
+	plugin := s.PluginManager.GetDataSource(ds.Type)
+	if plugin == nil || !plugin.Backend {
+		return nil
+	}
+
+	result := &Result{
+		OrgID:          ds.OrgId,
+		DatasourceID:   ds.Id,
+		DatasourceUID:  ds.Uid,
+		DatasourceName: ds.Name,
+		Type:           ds.Type,
+		Status:         StatusError,
+	}
+
+	settings, err := adapters.ModelToInstanceSettings(ds, s.decryptSecureJsonDataFn(ctx))
+	if err != nil {
+		result.Message = "Unable to get data source settings"
+		result.CheckedAt = time.Now()
+		return result
+	}
+
+	ctx, cancel := context.WithTimeout(ctx, s.Cfg.DataSourceHealthCheckTimeout)
+	defer cancel()
+
+	start := time.Now()
+	resp, err := s.BackendPluginManager.CheckHealth(ctx, backend.PluginContext{
+		OrgID:                      ds.OrgId,
+		PluginID:                   plugin.Id,
+		DataSourceInstanceSettings: settings,
+	})
+	result.LatencyMs = time.Since(start).Milliseconds()
+	result.CheckedAt = time.Now()
+
+	switch {
+	case errors.Is(err, backendplugin.ErrPluginNotRegistered), errors.Is(err, backendplugin.ErrMethodNotImplemented):
+		return nil
+	case err != nil:
+		result.Message = err.Error()
+	default:
+		result.Status = resp.Status.String()
+		result.Message = resp.Message
+	}
+
+	return result
+}
+
+func (s *Service) record(result *Result) {
+	s.mu.Lock()
+	previous := s.results[result.DatasourceID]
+	s.results[result.DatasourceID] = result
+	s.mu.Unlock()
+
+	// The name of the data source is part of the labels.
+	if previous != nil && previous.DatasourceName != result.DatasourceName {
+		healthStatusGauge.Delete(previous.labels())
+		healthLatencyGauge.Delete(previous.labels())
+	}
+
+	status := 0.0
+	if result.healthy() {
+		status = 1
+	}
+	healthStatusGauge.With(result.labels()).Set(status)
+	healthLatencyGauge.With(result.labels()).Set(float64(result.LatencyMs) / 1000)
+
+	if result.healthy() {
+		return
+	}
+	s.log.Warn("Data source is unhealthy", "orgId", result.OrgID, "uid", result.DatasourceUID,
+		"name", result.DatasourceName, "status", result.Status, "message", result.Message)
+
+	if !s.Cfg.DataSourceHealthCheckEvents || (previous != nil && !previous.healthy()) {
+		return
+	}
+	if err := s.Bus.Publish(&events.DataSourceUnhealthy{
+		Timestamp: result.CheckedAt,
+		Name:      result.DatasourceName,
+		ID:        result.DatasourceID,
+		UID:       result.DatasourceUID,
+		OrgID:     result.OrgID,
+		Status:    result.Status,
+		Message:   result.Message,
+	}); err != nil {
+		s.log.Error("Failed to publish data source unhealthy event", "error", err)
+	}
+}
+
+func (s *Service) decryptSecureJsonDataFn(ctx context.Context) func(map[string][]byte) map[string]string {
+	return func(m map[string][]byte) map[string]string {
+		decryptedJsonData, err := s.EncryptionService.DecryptJsonData(ctx, m, setting.SecretKey)
+		if err != nil {
+			s.log.Error("Failed to decrypt secure json data", "error", err)
+		}
+		return decryptedJsonData
+	}
+}
@@ -0,0 +1,124 @@
+package datasourcehealth
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/grafana/grafana-plugin-sdk-go/backend"
+	"github.com/grafana/grafana/pkg/api/routing"
+	"github.com/grafana/grafana/pkg/bus"
+	"github.com/grafana/grafana/pkg/events"
+	"github.com/grafana/grafana/pkg/models"
+	"github.com/grafana/grafana/pkg/plugins"
+	"github.com/grafana/grafana/pkg/plugins/backendplugin"
+	"github.com/grafana/grafana/pkg/services/encryption/ossencryption"
+	"github.com/grafana/grafana/pkg/services/sqlstore"
+	"github.com/grafana/grafana/pkg/setting"
+	"github.com/prometheus/client_golang/prometheus/testutil"
+	"github.com/stretchr/testify/require"
+)
+
+func TestService(t *testing.T) {
+	ctx := context.Background()
+	sqlStore := sqlstore.InitTestDB(t)
+
+	cfg := setting.NewCfg()
+	cfg.DataSourceHealthCheckEnabled = true
+	cfg.DataSourceHealthCheckEvents = true
+	cfg.DataSourceHealthCheckTimeout = time.Second
+
+	backendPM := &fakeBackendPluginManager{results: map[string]*backend.CheckHealthResult{}}
+	pluginManager := &fakePluginManager{dataSources: map[string]*plugins.DataSourcePlugin{
+		"prometheus": {FrontendPluginBase: plugins.FrontendPluginBase{PluginBase: plugins.PluginBase{Id: "prometheus"}}, Backend: true},
+		"frontend":   {FrontendPluginBase: plugins.FrontendPluginBase{PluginBase: plugins.PluginBase{Id: "frontend"}}},
+	}}
+
+	var published []*events.DataSourceUnhealthy
+	b := bus.New()
+	b.AddEventListener(func(e *events.DataSourceUnhealthy) error {
+		published = append(published, e)
+		return nil
+	})
+
+	s := ProvideService(cfg, b, sqlStore, pluginManager, backendPM, ossencryption.ProvideService(), routing.NewRouteRegister())
+
+	addDataSource := func(name, dsType string) *models.DataSource {
+		cmd := &models.AddDataSourceCommand{OrgId: 1, Name: name, Type: dsType, Access: models.DS_ACCESS_PROXY, Uid: name}
+		require.NoError(t, sqlStore.AddDataSource(ctx, cmd))
+		return cmd.Result
+	}
+	prod := addDataSource("prod", "prometheus")
+	addDataSource("browser", "frontend")
+	staging := addDataSource("staging", "prometheus")
+
+	t.Run("data sources with a backend plugin are checked", func(t *testing.T) {
+		backendPM.results[prod.Uid] = &backend.CheckHealthResult{Status: backend.HealthStatusOk, Message: "Data source is working"}
+		backendPM.results[staging.Uid] = &backend.CheckHealthResult{Status: backend.HealthStatusError, Message: "401 Unauthorized"}
+
+		s.checkAll(ctx)
+
+		results := s.GetResults()
+		require.Len(t, results, 2)
+		require.Equal(t, "prod", results[0].DatasourceName)
+		require.Equal(t, "OK", results[0].Status)
+		require.Equal(t, "staging", results[1].DatasourceName)
+		require.Equal(t, "ERROR", results[1].Status)
+		require.Equal(t, "401 Unauthorized", results[1].Message)
+
+		require.Equal(t, 1.0, testutil.ToFloat64(healthStatusGauge.With(results[0].labels())))
+		require.Equal(t, 0.0, testutil.ToFloat64(healthStatusGauge.With(results[1].labels())))
+
+		require.Len(t, published, 1)
+		require.Equal(t, staging.Uid, published[0].UID)
+	})
+
+	t.Run("events are only published when a data source becomes unhealthy", func(t *testing.T) {
+		backendPM.errs = map[string]error{prod.Uid: context.DeadlineExceeded}
+
+		s.checkAll(ctx)
+
+		results := s.GetResults()
+		require.Equal(t, StatusError, results[0].Status)
+		require.Equal(t, context.DeadlineExceeded.Error(), results[0].Message)
+		require.Len(t, published, 2)
+		require.Equal(t, prod.Uid, published[1].UID)
+	})
+
+	t.Run("results of deleted data sources are removed", func(t *testing.T) {
+		require.NoError(t, sqlStore.DeleteDataSource(ctx, &models.DeleteDataSourceCommand{OrgID: 1, UID: staging.Uid}))
+
+		s.checkAll(ctx)
+
+		results := s.GetResults()
+		require.Len(t, results, 1)
+		require.Equal(t, "prod", results[0].DatasourceName)
+	})
+}
+
+type fakePluginManager struct {
+	plugins.Manager
+	dataSources map[string]*plugins.DataSourcePlugin
+}
+
+func (m *fakePluginManager) GetDataSource(id string) *plugins.DataSourcePlugin {
+	return m.dataSources[id]
+}
+
+type fakeBackendPluginManager struct {
+	backendplugin.Manager
+	results map[string]*backend.CheckHealthResult
+	errs    map[string]error
+}
+
+func (m *fakeBackendPluginManager) CheckHealth(ctx context.Context, pCtx backend.PluginContext) (*backend.CheckHealthResult, error) {
+	uid := pCtx.DataSourceInstanceSettings.UID
+	if err := m.errs[uid]; err != nil {
+		return nil, err
+	}
+	if result, ok := m.results[uid]; ok {
+		return result, nil
+	}
+	return nil, errors.New("unexpected health check")
+}
@@ -10,10 +10,11 @@ import (
 	"github.com/grafana/grafana/pkg/services/sqlstore"
 )
 
-func ProvideCacheService(cacheService *localcache.CacheService, sqlStore *sqlstore.SQLStore) *CacheServiceImpl {
+func ProvideCacheService(cacheService *localcache.CacheService, sqlStore *sqlstore.SQLStore, permissions *PermissionsService) *CacheServiceImpl {
 	return &CacheServiceImpl{
 		CacheService: cacheService,
 		SQLStore:     sqlStore,
+		Permissions:  permissions,
 	}
 }
 
@@ -25,6 +26,7 @@ type CacheService interface {
 type CacheServiceImpl struct {
 	CacheService *localcache.CacheService
 	SQLStore     *sqlstore.SQLStore
+	Permissions  *PermissionsService
 }
 
 func (dc *CacheServiceImpl) GetDatasource(
@@ -38,7 +40,7 @@ func (dc *CacheServiceImpl) GetDatasource(
 		if cached, found := dc.CacheService.Get(cacheKey); found {
 			ds := cached.(*models.DataSource)
 			if ds.OrgId == user.OrgId {
-				return ds, nil
+				return dc.checkQueryPermission(user, ds)
 			}
 		}
 	}
@@ -57,7 +59,7 @@ func (dc *CacheServiceImpl) GetDatasource(
 		dc.CacheService.Set(uidKey(ds.OrgId, ds.Uid), ds, time.Second*5)
 	}
 	dc.CacheService.Set(cacheKey, ds, time.Second*5)
-	return ds, nil
+	return dc.checkQueryPermission(user, ds)
 }
 
 func (dc *CacheServiceImpl) GetDatasourceByUID(
@@ -77,7 +79,7 @@ func (dc *CacheServiceImpl) GetDatasourceByUID(
 		if cached, found := dc.CacheService.Get(uidCacheKey); found {
 			ds := cached.(*models.DataSource)
 			if ds.OrgId == user.OrgId {
-				return ds, nil
+				return dc.checkQueryPermission(user, ds)
 			}
 		}
 	}
@@ -93,6 +95,24 @@ func (dc *CacheServiceImpl) GetDatasourceByUID(
 
 	dc.CacheService.Set(uidCacheKey, ds, time.Second*5)
 	dc.CacheService.Set(idKey(ds.Id), ds, time.Second*5)
+	return dc.checkQueryPermission(user, ds)
+}
+
+// checkQueryPermission returns the data source if the user is allowed to
+// query it.
+func (dc *CacheServiceImpl) checkQueryPermission(user *models.SignedInUser, ds *models.DataSource) (*models.DataSource, error) {
+	if dc.Permissions == nil {
+		return ds, nil
+	}
+
+	allowed, err := dc.Permissions.CanQuery(context.TODO(), user, ds)
+	if err != nil {
+		return nil, err
+	}
+	if !allowed {
+		return nil, models.ErrDataSourceAccessDenied
+	}
+
 	return ds, nil
 }
 
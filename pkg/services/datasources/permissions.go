@@ -0,0 +1,164 @@
+package datasources
+
+import (
+	"context"
+	"time"
+
+	"github.com/grafana/grafana/pkg/bus"
+	"github.com/grafana/grafana/pkg/models"
+	"github.com/grafana/grafana/pkg/services/accesscontrol"
+	"github.com/grafana/grafana/pkg/services/accesscontrol/database"
+)
+
+// Data source permissions that can be managed for users and teams.
+const (
+	PermissionQuery = "Query"
+	PermissionAdmin = "Admin"
+)
+
+// PermissionActions are the actions granted by each data source permission.
+var PermissionActions = map[string][]string{
+	PermissionQuery: {accesscontrol.ActionDatasourcesQuery},
+	PermissionAdmin: {accesscontrol.ActionDatasourcesQuery, accesscontrol.ActionDatasourcesRead, accesscontrol.ActionDatasourcesWrite},
+}
+
+func ProvidePermissionsService(bus bus.Bus, ac accesscontrol.AccessControl, store *database.AccessControlStore) *PermissionsService {
+	s := &PermissionsService{
+		AccessControl: ac,
+		Store:         store,
+	}
+	bus.AddHandlerCtx(s.filterDatasourcesByQueryPermission)
+	return s
+}
+
+// PermissionsService evaluates data source permissions of users when access
+// control is enabled. A data source can be queried by every viewer of the
+// organization until a user or a team is given the permission to query it.
+type PermissionsService struct {
+	AccessControl accesscontrol.AccessControl
+	Store         *database.AccessControlStore
+}
+
+// CanQuery returns whether the user is allowed to query the data source.
+// Data sources loaded without a user, by background services, aren't checked.
+func (s *PermissionsService) CanQuery(ctx context.Context, user *models.SignedInUser, ds *models.DataSource) (bool, error) {
+	if s.AccessControl.IsDisabled() || user == nil {
+		return true, nil
+	}
+
+	permissions, err := s.AccessControl.GetUserPermissions(ctx, user)
+	if err != nil {
+		return false, err
+	}
+
+	return s.canQuery(ctx, accesscontrol.GroupScopesByAction(permissions), ds)
+}
+
+// FilterByQueryPermission returns the data sources the user is allowed to query.
+func (s *PermissionsService) FilterByQueryPermission(ctx context.Context, user *models.SignedInUser, dataSources []*models.DataSource) ([]*models.DataSource, error) {
+	if s.AccessControl.IsDisabled() || user == nil {
+		return dataSources, nil
+	}
+
+	permissions, err := s.AccessControl.GetUserPermissions(ctx, user)
+	if err != nil {
+		return nil, err
+	}
+	grouped := accesscontrol.GroupScopesByAction(permissions)
+
+	filtered := make([]*models.DataSource, 0, len(dataSources))
+	for _, ds := range dataSources {
+		allowed, err := s.canQuery(ctx, grouped, ds)
+		if err != nil {
+			return nil, err
+		}
+		if allowed {
+			filtered = append(filtered, ds)
+		}
+	}
+
+	return filtered, nil
+}
+
+func (s *PermissionsService) canQuery(ctx context.Context, permissions map[string]map[string]struct{}, ds *models.DataSource) (bool, error) {
+	scope := accesscontrol.DatasourceUIDScope(ds.Uid)
+	allowed, err := accesscontrol.EvalPermission(accesscontrol.ActionDatasourcesQuery, scope).Evaluate(permissions)
+	if err != nil || allowed {
+		return allowed, err
+	}
+
+	restricted, err := s.Store.HasManagedPermissions(ctx, ds.OrgId, accesscontrol.ActionDatasourcesQuery, scope)
+	if err != nil || restricted {
+		return false, err
+	}
+
+	// Viewers can query data sources without managed permissions.
+	return accesscontrol.EvalPermission(accesscontrol.ActionDatasourcesQuery).Evaluate(permissions)
+}
+
+// Permission is the permission of a user or a team on a data source.
+type Permission struct {
+	DatasourceID int64     `json:"datasourceId"`
+	UserID       int64     `json:"userId"`
+	TeamID       int64     `json:"teamId"`
+	Permission   string    `json:"permission"`
+	Created      time.Time `json:"created"`
+	Updated      time.Time `json:"updated"`
+}
+
+// GetPermissions returns the permissions managed for users and teams on the data source.
+func (s *PermissionsService) GetPermissions(ctx context.Context, ds *models.DataSource) ([]*Permission, error) {
+	managed, err := s.Store.GetManagedPermissions(ctx, ds.OrgId, accesscontrol.DatasourceUIDScope(ds.Uid))
+	if err != nil {
+		return nil, err
+	}
+
+	type assignee struct{ userID, teamID int64 }
+	byAssignee := map[assignee]*Permission{}
+	permissions := make([]*Permission, 0)
+	for _, m := range managed {
+		key := assignee{userID: m.UserID, teamID: m.TeamID}
+		p, ok := byAssignee[key]
+		if !ok {
+			p = &Permission{
+				DatasourceID: ds.Id,
+				UserID:       m.UserID,
+				TeamID:       m.TeamID,
+				Permission:   PermissionQuery,
+				Created:      m.Created,
+				Updated:      m.Updated,
+			}
+			byAssignee[key] = p
+			permissions = append(permissions, p)
+		}
+		if m.Action == accesscontrol.ActionDatasourcesWrite {
+			p.Permission = PermissionAdmin
+		}
+	}
+
+	return permissions, nil
+}
+
+// SetPermission replaces the permission of a user or a team on the data source,
+// the permission is removed when it's empty.
+func (s *PermissionsService) SetPermission(ctx context.Context, ds *models.DataSource, userID int64, teamID int64, permission string) error {
+	return s.Store.SetManagedPermissions(ctx, accesscontrol.SetManagedPermissionsCommand{
+		OrgID:   ds.OrgId,
+		UserID:  userID,
+		TeamID:  teamID,
+		Actions: PermissionActions[permission],
+		// Data source API routes are scoped by id, while permissions are
+		// evaluated by uid elsewhere.
+		Scopes: []string{accesscontrol.DatasourceUIDScope(ds.Uid), accesscontrol.DatasourceIDScope(ds.Id)},
+	})
+}
+
+func (s *PermissionsService) filterDatasourcesByQueryPermission(ctx context.Context, query *models.DatasourcesPermissionFilterQuery) error {
+	filtered, err := s.FilterByQueryPermission(ctx, query.User, query.Datasources)
+	if err != nil {
+		return err
+	}
+
+	query.Result = filtered
+	return nil
+}
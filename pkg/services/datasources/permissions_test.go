@@ -0,0 +1,86 @@
+package datasources
+
+import (
+	"context"
+	"testing"
+
+	"github.com/grafana/grafana/pkg/bus"
+	"github.com/grafana/grafana/pkg/models"
+	"github.com/grafana/grafana/pkg/services/accesscontrol"
+	"github.com/grafana/grafana/pkg/services/accesscontrol/database"
+	"github.com/grafana/grafana/pkg/services/accesscontrol/mock"
+	"github.com/grafana/grafana/pkg/services/sqlstore"
+	"github.com/stretchr/testify/require"
+)
+
+func TestPermissionsService(t *testing.T) {
+	ctx := context.Background()
+	store := database.ProvideService(sqlstore.InitTestDB(t))
+
+	finance := &models.DataSource{Id: 1, Uid: "finance", OrgId: 1}
+	sales := &models.DataSource{Id: 2, Uid: "sales", OrgId: 1}
+	viewer := &models.SignedInUser{UserId: 2, OrgId: 1, OrgRole: models.ROLE_VIEWER}
+	viewerPermissions := []*accesscontrol.Permission{{Action: accesscontrol.ActionDatasourcesQuery}}
+
+	newService := func(ac accesscontrol.AccessControl) *PermissionsService {
+		return ProvidePermissionsService(bus.New(), ac, store)
+	}
+
+	t.Run("viewers can query data sources without managed permissions", func(t *testing.T) {
+		s := newService(mock.New().WithPermissions(viewerPermissions))
+
+		allowed, err := s.CanQuery(ctx, viewer, finance)
+		require.NoError(t, err)
+		require.True(t, allowed)
+	})
+
+	require.NoError(t, newService(mock.New()).SetPermission(ctx, finance, 3, 0, PermissionQuery))
+
+	t.Run("viewers can't query data sources with managed permissions of others", func(t *testing.T) {
+		s := newService(mock.New().WithPermissions(viewerPermissions))
+
+		allowed, err := s.CanQuery(ctx, viewer, finance)
+		require.NoError(t, err)
+		require.False(t, allowed)
+
+		filtered, err := s.FilterByQueryPermission(ctx, viewer, []*models.DataSource{finance, sales})
+		require.NoError(t, err)
+		require.Equal(t, []*models.DataSource{sales}, filtered)
+	})
+
+	t.Run("users with the query permission on the data source can query it", func(t *testing.T) {
+		s := newService(mock.New().WithPermissions([]*accesscontrol.Permission{
+			{Action: accesscontrol.ActionDatasourcesQuery, Scope: accesscontrol.DatasourceUIDScope("finance")},
+		}))
+
+		allowed, err := s.CanQuery(ctx, viewer, finance)
+		require.NoError(t, err)
+		require.True(t, allowed)
+	})
+
+	t.Run("data sources aren't filtered when access control is disabled", func(t *testing.T) {
+		s := newService(mock.New().WithDisabled())
+
+		allowed, err := s.CanQuery(ctx, viewer, finance)
+		require.NoError(t, err)
+		require.True(t, allowed)
+	})
+
+	t.Run("permissions are listed per user and team", func(t *testing.T) {
+		s := newService(mock.New())
+		require.NoError(t, s.SetPermission(ctx, finance, 0, 1, PermissionAdmin))
+
+		permissions, err := s.GetPermissions(ctx, finance)
+		require.NoError(t, err)
+		require.Len(t, permissions, 2)
+		require.Equal(t, int64(3), permissions[0].UserID)
+		require.Equal(t, PermissionQuery, permissions[0].Permission)
+		require.Equal(t, int64(1), permissions[1].TeamID)
+		require.Equal(t, PermissionAdmin, permissions[1].Permission)
+
+		require.NoError(t, s.SetPermission(ctx, finance, 3, 0, ""))
+		permissions, err = s.GetPermissions(ctx, finance)
+		require.NoError(t, err)
+		require.Len(t, permissions, 1)
+	})
+}
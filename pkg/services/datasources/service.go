@@ -221,8 +221,9 @@ func (s *Service) httpClientOptions(ds *models.DataSource) (*sdkhttpclient.Optio
 		Timeouts: timeouts,
 		Headers:  s.getCustomHeaders(ds.JsonData, s.DecryptedValues(ds)),
 		Labels: map[string]string{
-			"datasource_name": ds.Name,
-			"datasource_uid":  ds.Uid,
+			"datasource_name":   ds.Name,
+			"datasource_uid":    ds.Uid,
+			"datasource_org_id": strconv.FormatInt(ds.OrgId, 10),
 		},
 		TLS: &tlsOptions,
 	}
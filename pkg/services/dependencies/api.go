@@ -0,0 +1,56 @@
+package dependencies
+
+import (
+	"errors"
+
+	"github.com/grafana/grafana/pkg/api/response"
+	"github.com/grafana/grafana/pkg/api/routing"
+	"github.com/grafana/grafana/pkg/middleware"
+	"github.com/grafana/grafana/pkg/models"
+	"github.com/grafana/grafana/pkg/web"
+)
+
+func (s *Service) registerAPIEndpoints() {
+	s.RouteRegister.Group("/api/dependencies", func(entities routing.RouteRegister) {
+		entities.Get("/datasources/:uid", middleware.ReqOrgAdmin, routing.Wrap(s.getDataSourceDependentsHandler))
+		entities.Get("/panel-plugins/:pluginId", middleware.ReqOrgAdmin, routing.Wrap(s.getPanelPluginDependentsHandler))
+		entities.Get("/library-panels/:uid", middleware.ReqOrgAdmin, routing.Wrap(s.getLibraryPanelDependentsHandler))
+	})
+}
+
+// getDataSourceDependentsHandler handles GET /api/dependencies/datasources/:uid.
+func (s *Service) getDataSourceDependentsHandler(c *models.ReqContext) response.Response {
+	dependents, err := s.GetDataSourceDependents(c.Req.Context(), c.OrgId, web.Params(c.Req)[":uid"])
+	if err != nil {
+		return toDependenciesError(err)
+	}
+
+	return response.JSON(200, dependents)
+}
+
+// getPanelPluginDependentsHandler handles GET /api/dependencies/panel-plugins/:pluginId.
+func (s *Service) getPanelPluginDependentsHandler(c *models.ReqContext) response.Response {
+	dependents, err := s.GetPanelPluginDependents(c.Req.Context(), c.OrgId, web.Params(c.Req)[":pluginId"])
+	if err != nil {
+		return toDependenciesError(err)
+	}
+
+	return response.JSON(200, dependents)
+}
+
+// getLibraryPanelDependentsHandler handles GET /api/dependencies/library-panels/:uid.
+func (s *Service) getLibraryPanelDependentsHandler(c *models.ReqContext) response.Response {
+	dependents, err := s.GetLibraryPanelDependents(c.Req.Context(), c.OrgId, web.Params(c.Req)[":uid"])
+	if err != nil {
+		return toDependenciesError(err)
+	}
+
+	return response.JSON(200, dependents)
+}
+
+func toDependenciesError(err error) response.Response {
+	if errors.Is(err, errDataSourceNotFound) {
+		return response.Error(404, err.Error(), err)
+	}
+	return response.Error(500, "Failed to get dependents", err)
+}
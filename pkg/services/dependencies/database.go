@@ -0,0 +1,195 @@
+package dependencies
+
+import (
+	"context"
+	"strings"
+
+	"github.com/grafana/grafana/pkg/components/simplejson"
+	"github.com/grafana/grafana/pkg/models"
+	ngmodels "github.com/grafana/grafana/pkg/services/ngalert/models"
+	"github.com/grafana/grafana/pkg/services/sqlstore"
+)
+
+// backfillBatchSize is the number of dashboards or alert rules loaded at once
+// when the dependency index is rebuilt.
+const backfillBatchSize = 100
+
+// SetAlertRuleDependencies replaces the indexed dependencies of the alert
+// rule, in the transaction saving the rule.
+func SetAlertRuleDependencies(sess *sqlstore.DBSession, rule *ngmodels.AlertRule) error {
+	return setTargets(sess, rule.OrgID, SourceAlertRule, rule.UID, AlertRuleTargets(rule))
+}
+
+func setTargets(sess *sqlstore.DBSession, orgID int64, sourceType, sourceUID string, targets []Target) error {
+	_, err := sess.Exec("DELETE FROM dependency WHERE org_id = ? AND source_type = ? AND source_uid = ?", orgID, sourceType, sourceUID)
+	if err != nil {
+		return err
+	}
+	if len(targets) == 0 {
+		return nil
+	}
+
+	dependencies := make([]*Dependency, 0, len(targets))
+	for _, t := range targets {
+		dependencies = append(dependencies, &Dependency{
+			OrgID:      orgID,
+			SourceType: sourceType,
+			SourceUID:  sourceUID,
+			TargetType: t.Type,
+			TargetUID:  t.UID,
+		})
+	}
+	_, err = sess.InsertMulti(dependencies)
+	return err
+}
+
+func (s *Service) setDashboardDependencies(ctx context.Context, dash *models.Dashboard) error {
+	defaultDatasource, err := s.getDefaultDatasourceUID(ctx, dash.OrgId)
+	if err != nil {
+		return err
+	}
+	return s.setDashboardTargets(ctx, dash, defaultDatasource)
+}
+
+func (s *Service) setDashboardTargets(ctx context.Context, dash *models.Dashboard, defaultDatasource string) error {
+	return s.SQLStore.WithTransactionalDbSession(ctx, func(sess *sqlstore.DBSession) error {
+		return setTargets(sess, dash.OrgId, SourceDashboard, dash.Uid, DashboardTargets(dash.Data, defaultDatasource))
+	})
+}
+
+// getDefaultDatasourceUID returns the UID of the default data source of the
+// org, used by panels without a data source, or an empty string if the org
+// has no default data source.
+func (s *Service) getDefaultDatasourceUID(ctx context.Context, orgID int64) (string, error) {
+	var uid string
+	err := s.SQLStore.WithDbSession(ctx, func(sess *sqlstore.DBSession) error {
+		var ds models.DataSource
+		exists, err := sess.Where("org_id = ? AND is_default = ?", orgID, true).Cols("uid").Get(&ds)
+		if exists {
+			uid = ds.Uid
+		}
+		return err
+	})
+	return uid, err
+}
+
+// getDependents returns the dashboards and alert rules using any of the
+// targets. Dependencies of deleted dashboards and alert rules are left
+// out until the index is rebuilt.
+func (s *Service) getDependents(ctx context.Context, orgID int64, targetType string, targetUIDs ...string) (Dependents, error) {
+	result := Dependents{
+		Dashboards: make([]DependentDashboard, 0),
+		AlertRules: make([]DependentAlertRule, 0),
+	}
+
+	params := []interface{}{orgID, targetType}
+	for _, uid := range targetUIDs {
+		params = append(params, uid)
+	}
+	where := `dependency.org_id = ? AND dependency.target_type = ? AND dependency.target_uid IN (?` + strings.Repeat(",?", len(targetUIDs)-1) + `)`
+
+	err := s.SQLStore.WithDbSession(ctx, func(sess *sqlstore.DBSession) error {
+		var dashboards []struct {
+			UID      string `xorm:"uid"`
+			Title    string `xorm:"title"`
+			Slug     string `xorm:"slug"`
+			IsFolder bool   `xorm:"is_folder"`
+		}
+		err := sess.SQL(`SELECT DISTINCT dashboard.uid, dashboard.title, dashboard.slug, dashboard.is_folder FROM dependency
+			INNER JOIN dashboard ON dashboard.org_id = dependency.org_id AND dashboard.uid = dependency.source_uid
+			WHERE dependency.source_type = '`+SourceDashboard+`' AND `+where+`
+			ORDER BY dashboard.title`, params...).Find(&dashboards)
+		if err != nil {
+			return err
+		}
+		for _, d := range dashboards {
+			result.Dashboards = append(result.Dashboards, DependentDashboard{
+				UID:   d.UID,
+				Title: d.Title,
+				URL:   models.GetDashboardFolderUrl(d.IsFolder, d.UID, d.Slug),
+			})
+		}
+
+		return sess.SQL(`SELECT DISTINCT alert_rule.uid, alert_rule.title, alert_rule.namespace_uid, alert_rule.rule_group FROM dependency
+			INNER JOIN alert_rule ON alert_rule.org_id = dependency.org_id AND alert_rule.uid = dependency.source_uid
+			WHERE dependency.source_type = '`+SourceAlertRule+`' AND `+where+`
+			ORDER BY alert_rule.title`, params...).Find(&result.AlertRules)
+	})
+
+	return result, err
+}
+
+// backfill rebuilds the dependency index from all dashboards and alert rules.
+func (s *Service) backfill(ctx context.Context) error {
+	defaultDatasources := map[int64]string{}
+	var lastID int64
+	for {
+		var dashboards []*models.Dashboard
+		err := s.SQLStore.WithDbSession(ctx, func(sess *sqlstore.DBSession) error {
+			return sess.Where("id > ? AND is_folder = "+s.SQLStore.Dialect.BooleanStr(false), lastID).
+				Asc("id").Limit(backfillBatchSize).Find(&dashboards)
+		})
+		if err != nil {
+			return err
+		}
+		if len(dashboards) == 0 {
+			break
+		}
+
+		for _, dash := range dashboards {
+			if dash.Data == nil {
+				dash.Data = simplejson.New()
+			}
+			defaultDatasource, ok := defaultDatasources[dash.OrgId]
+			if !ok {
+				if defaultDatasource, err = s.getDefaultDatasourceUID(ctx, dash.OrgId); err != nil {
+					return err
+				}
+				defaultDatasources[dash.OrgId] = defaultDatasource
+			}
+			if err := s.setDashboardTargets(ctx, dash, defaultDatasource); err != nil {
+				return err
+			}
+		}
+		lastID = dashboards[len(dashboards)-1].Id
+	}
+
+	lastID = 0
+	for {
+		var rules []*ngmodels.AlertRule
+		err := s.SQLStore.WithDbSession(ctx, func(sess *sqlstore.DBSession) error {
+			return sess.Where("id > ?", lastID).Asc("id").Limit(backfillBatchSize).Find(&rules)
+		})
+		if err != nil {
+			return err
+		}
+		if len(rules) == 0 {
+			break
+		}
+
+		err = s.SQLStore.WithTransactionalDbSession(ctx, func(sess *sqlstore.DBSession) error {
+			for _, rule := range rules {
+				if err := SetAlertRuleDependencies(sess, rule); err != nil {
+					return err
+				}
+			}
+			return nil
+		})
+		if err != nil {
+			return err
+		}
+		lastID = rules[len(rules)-1].ID
+	}
+
+	// Remove dependencies of deleted dashboards and alert rules.
+	return s.SQLStore.WithDbSession(ctx, func(sess *sqlstore.DBSession) error {
+		_, err := sess.Exec(`DELETE FROM dependency WHERE source_type = ? AND NOT EXISTS
+			(SELECT 1 FROM dashboard WHERE dashboard.org_id = dependency.org_id AND dashboard.uid = dependency.source_uid)`, SourceDashboard)
+		if err != nil {
+			return err
+		}
+		_, err = sess.Exec(`DELETE FROM dependency WHERE source_type = ? AND NOT EXISTS
+			(SELECT 1 FROM alert_rule WHERE alert_rule.org_id = dependency.org_id AND alert_rule.uid = dependency.source_uid)`, SourceAlertRule)
+		return err
+	})
+}
@@ -0,0 +1,115 @@
+package dependencies
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"github.com/grafana/grafana/pkg/api/routing"
+	"github.com/grafana/grafana/pkg/bus"
+	"github.com/grafana/grafana/pkg/events"
+	"github.com/grafana/grafana/pkg/infra/log"
+	"github.com/grafana/grafana/pkg/infra/serverlock"
+	"github.com/grafana/grafana/pkg/models"
+	"github.com/grafana/grafana/pkg/services/sqlstore"
+	"github.com/grafana/grafana/pkg/setting"
+)
+
+// backfillInterval is how often the dependency index is rebuilt, to remove
+// dependencies of deleted dashboards and alert rules and to include changes
+// of the default data source.
+const backfillInterval = time.Hour
+
+func ProvideService(cfg *setting.Cfg, sqlStore *sqlstore.SQLStore, serverLockService *serverlock.ServerLockService,
+	routeRegister routing.RouteRegister) *Service {
+	s := &Service{
+		Cfg:               cfg,
+		SQLStore:          sqlStore,
+		ServerLockService: serverLockService,
+		RouteRegister:     routeRegister,
+		log:               log.New("dependencies"),
+	}
+	s.registerAPIEndpoints()
+	bus.AddEventListener(s.handleDashboardSaved)
+	return s
+}
+
+// Service indexes the data sources, panel plugins and library panels used by
+// dashboards and alert rules.
+type Service struct {
+	Cfg               *setting.Cfg
+	SQLStore          *sqlstore.SQLStore
+	ServerLockService *serverlock.ServerLockService
+	RouteRegister     routing.RouteRegister
+	log               log.Logger
+}
+
+func (s *Service) Run(ctx context.Context) error {
+	ticker := time.NewTicker(backfillInterval)
+	defer ticker.Stop()
+	for {
+		err := s.ServerLockService.LockAndExecute(ctx, "rebuild dependency index", backfillInterval, func(ctx context.Context) {
+			start := time.Now()
+			if err := s.backfill(ctx); err != nil && !errors.Is(err, context.Canceled) {
+				s.log.Error("Failed to rebuild dependency index", "error", err)
+				return
+			}
+			s.log.Debug("Rebuilt dependency index", "duration", time.Since(start))
+		})
+		if err != nil {
+			s.log.Error("Failed to lock and execute rebuild of dependency index", "error", err)
+		}
+
+		select {
+		case <-ticker.C:
+		case <-ctx.Done():
+			return ctx.Err()
+		}
+	}
+}
+
+// handleDashboardSaved indexes dependencies of every saved dashboard, whether
+// it's saved by the API, provisioning or imports. Failures are only logged
+// since the index is rebuilt periodically.
+func (s *Service) handleDashboardSaved(e *events.DashboardSaved) error {
+	ctx := context.Background()
+	var dash models.Dashboard
+	err := s.SQLStore.WithDbSession(ctx, func(sess *sqlstore.DBSession) error {
+		exists, err := sess.Where("id = ? AND org_id = ?", e.ID, e.OrgID).Get(&dash)
+		if err == nil && !exists {
+			err = models.ErrDashboardNotFound
+		}
+		return err
+	})
+	if err == nil {
+		err = s.setDashboardDependencies(ctx, &dash)
+	}
+	if err != nil {
+		s.log.Warn("Failed to update dashboard dependencies", "uid", e.UID, "error", err)
+	}
+	return nil
+}
+
+// GetDataSourceDependents returns the dashboards and alert rules using the data
+// source, including dashboards referencing it by name.
+func (s *Service) GetDataSourceDependents(ctx context.Context, orgID int64, uid string) (Dependents, error) {
+	query := models.GetDataSourceQuery{OrgId: orgID, Uid: uid}
+	if err := s.SQLStore.GetDataSource(ctx, &query); err != nil {
+		if errors.Is(err, models.ErrDataSourceNotFound) {
+			return Dependents{}, errDataSourceNotFound
+		}
+		return Dependents{}, err
+	}
+
+	return s.getDependents(ctx, orgID, TargetDatasource, query.Result.Uid, query.Result.Name)
+}
+
+// GetPanelPluginDependents returns the dashboards using the panel plugin.
+func (s *Service) GetPanelPluginDependents(ctx context.Context, orgID int64, pluginID string) (Dependents, error) {
+	return s.getDependents(ctx, orgID, TargetPanelPlugin, pluginID)
+}
+
+// GetLibraryPanelDependents returns the dashboards using the library panel.
+func (s *Service) GetLibraryPanelDependents(ctx context.Context, orgID int64, uid string) (Dependents, error) {
+	return s.getDependents(ctx, orgID, TargetLibraryPanel, uid)
+}
@@ -0,0 +1,122 @@
+package dependencies
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/grafana/grafana/pkg/api/routing"
+	"github.com/grafana/grafana/pkg/components/simplejson"
+	"github.com/grafana/grafana/pkg/infra/serverlock"
+	"github.com/grafana/grafana/pkg/models"
+	ngmodels "github.com/grafana/grafana/pkg/services/ngalert/models"
+	"github.com/grafana/grafana/pkg/services/sqlstore"
+	"github.com/grafana/grafana/pkg/setting"
+	"github.com/stretchr/testify/require"
+)
+
+func TestService(t *testing.T) {
+	ctx := context.Background()
+	sqlStore := sqlstore.InitTestDB(t)
+	s := ProvideService(setting.NewCfg(), sqlStore, serverlock.ProvideService(sqlStore), routing.NewRouteRegister())
+
+	ds := &models.AddDataSourceCommand{OrgId: 1, Name: "Prometheus", Type: "prometheus", Uid: "prom", Access: models.DS_ACCESS_PROXY, IsDefault: true}
+	require.NoError(t, sqlStore.AddDataSource(ctx, ds))
+
+	saveDashboard := func(t *testing.T, title string, json string) *models.Dashboard {
+		t.Helper()
+		data, err := simplejson.NewJson([]byte(json))
+		require.NoError(t, err)
+		data.Set("title", title)
+		dash, err := sqlStore.SaveDashboard(context.Background(), models.SaveDashboardCommand{OrgId: 1, Dashboard: data})
+		require.NoError(t, err)
+		return dash
+	}
+
+	byUID := saveDashboard(t, "By uid", `{"panels": [{"type": "graph", "datasource": {"uid": "prom"}, "libraryPanel": {"uid": "lib-1"}}]}`)
+	byName := saveDashboard(t, "By name", `{"panels": [{"type": "table", "datasource": "Prometheus"}]}`)
+	unrelated := saveDashboard(t, "Unrelated", `{"panels": [{"type": "graph", "datasource": {"uid": "loki"}}]}`)
+
+	err := sqlStore.WithDbSession(ctx, func(sess *sqlstore.DBSession) error {
+		_, err := sess.Insert(&ngmodels.AlertRule{
+			OrgID:        1,
+			UID:          "rule-1",
+			Title:        "High load",
+			NamespaceUID: "folder",
+			RuleGroup:    "group",
+			Updated:      time.Now(),
+			Data:         []ngmodels.AlertQuery{{RefID: "A", DatasourceUID: "prom"}},
+		})
+		return err
+	})
+	require.NoError(t, err)
+
+	t.Run("backfill indexes existing dashboards and alert rules", func(t *testing.T) {
+		require.NoError(t, s.backfill(ctx))
+
+		dependents, err := s.GetDataSourceDependents(ctx, 1, "prom")
+		require.NoError(t, err)
+		require.Len(t, dependents.Dashboards, 2)
+		require.Equal(t, byName.Uid, dependents.Dashboards[0].UID)
+		require.Equal(t, byUID.Uid, dependents.Dashboards[1].UID)
+		require.Equal(t, byUID.GetUrl(), dependents.Dashboards[1].URL)
+		require.Equal(t, []DependentAlertRule{{UID: "rule-1", Title: "High load", NamespaceUID: "folder", RuleGroup: "group"}}, dependents.AlertRules)
+
+		dependents, err = s.GetPanelPluginDependents(ctx, 1, "graph")
+		require.NoError(t, err)
+		require.Len(t, dependents.Dashboards, 2)
+		require.Empty(t, dependents.AlertRules)
+
+		dependents, err = s.GetLibraryPanelDependents(ctx, 1, "lib-1")
+		require.NoError(t, err)
+		require.Len(t, dependents.Dashboards, 1)
+		require.Equal(t, byUID.Uid, dependents.Dashboards[0].UID)
+	})
+
+	t.Run("saving a dashboard replaces its dependencies", func(t *testing.T) {
+		unrelated.Data.Set("panels", []interface{}{map[string]interface{}{"type": "graph", "datasource": map[string]interface{}{"uid": "prom"}}})
+		require.NoError(t, s.setDashboardDependencies(ctx, unrelated))
+
+		dependents, err := s.GetDataSourceDependents(ctx, 1, "prom")
+		require.NoError(t, err)
+		require.Len(t, dependents.Dashboards, 3)
+
+		dependents, err = s.GetLibraryPanelDependents(ctx, 1, "lib-1")
+		require.NoError(t, err)
+		require.Len(t, dependents.Dashboards, 1)
+	})
+
+	t.Run("deleted dashboards are not returned", func(t *testing.T) {
+		require.NoError(t, sqlstore.DeleteDashboard(ctx, &models.DeleteDashboardCommand{Id: byUID.Id, OrgId: 1}))
+
+		dependents, err := s.GetLibraryPanelDependents(ctx, 1, "lib-1")
+		require.NoError(t, err)
+		require.Empty(t, dependents.Dashboards)
+
+		require.NoError(t, s.backfill(ctx))
+		var count int64
+		err = sqlStore.WithDbSession(ctx, func(sess *sqlstore.DBSession) error {
+			count, err = sess.Where("source_uid = ?", byUID.Uid).Count(&Dependency{})
+			return err
+		})
+		require.NoError(t, err)
+		require.Zero(t, count)
+	})
+
+	t.Run("dashboards saved to the store are indexed with the default data source", func(t *testing.T) {
+		dash := saveDashboard(t, "Default data source", `{"panels": [{"type": "graph", "datasource": null, "targets": [{"refId": "A"}]}]}`)
+
+		dependents, err := s.GetDataSourceDependents(ctx, 1, "prom")
+		require.NoError(t, err)
+		uids := make([]string, 0, len(dependents.Dashboards))
+		for _, d := range dependents.Dashboards {
+			uids = append(uids, d.UID)
+		}
+		require.Contains(t, uids, dash.Uid)
+	})
+
+	t.Run("unknown data source returns an error", func(t *testing.T) {
+		_, err := s.GetDataSourceDependents(ctx, 1, "missing")
+		require.ErrorIs(t, err, errDataSourceNotFound)
+	})
+}
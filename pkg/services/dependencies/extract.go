@@ -0,0 +1,100 @@
+package dependencies
+
+import (
+	"strings"
+
+	"github.com/grafana/grafana/pkg/components/simplejson"
+	"github.com/grafana/grafana/pkg/expr"
+	ngmodels "github.com/grafana/grafana/pkg/services/ngalert/models"
+)
+
+// DashboardTargets returns the data sources, panel plugins and library panels
+// used by the dashboard JSON model. Panels without a data source query the
+// default data source, which is left out if it's empty.
+func DashboardTargets(data *simplejson.Json, defaultDatasource string) []Target {
+	e := targetExtractor{seen: map[Target]struct{}{}, defaultDatasource: defaultDatasource}
+
+	for _, panel := range data.Get("panels").MustArray() {
+		e.addPanel(simplejson.NewFromAny(panel))
+	}
+	// Dashboards created before the panels property store panels in rows.
+	for _, row := range data.Get("rows").MustArray() {
+		for _, panel := range simplejson.NewFromAny(row).Get("panels").MustArray() {
+			e.addPanel(simplejson.NewFromAny(panel))
+		}
+	}
+
+	for _, variable := range data.Get("templating").Get("list").MustArray() {
+		e.addDatasource(simplejson.NewFromAny(variable).Get("datasource"))
+	}
+	for _, annotation := range data.Get("annotations").Get("list").MustArray() {
+		e.addDatasource(simplejson.NewFromAny(annotation).Get("datasource"))
+	}
+
+	return e.targets
+}
+
+// AlertRuleTargets returns the data sources queried by the alert rule.
+func AlertRuleTargets(rule *ngmodels.AlertRule) []Target {
+	e := targetExtractor{seen: map[Target]struct{}{}}
+	for _, query := range rule.Data {
+		if query.DatasourceUID != expr.DatasourceUID {
+			e.add(TargetDatasource, query.DatasourceUID)
+		}
+	}
+
+	return e.targets
+}
+
+type targetExtractor struct {
+	targets           []Target
+	seen              map[Target]struct{}
+	defaultDatasource string
+}
+
+func (e *targetExtractor) add(targetType, uid string) {
+	// Template variables are resolved when the dashboard is displayed.
+	if uid == "" || strings.HasPrefix(uid, "$") {
+		return
+	}
+
+	t := Target{Type: targetType, UID: uid}
+	if _, ok := e.seen[t]; ok {
+		return
+	}
+	e.seen[t] = struct{}{}
+	e.targets = append(e.targets, t)
+}
+
+// addDatasource adds a data source reference, older dashboards reference data
+// sources by name.
+func (e *targetExtractor) addDatasource(ref *simplejson.Json) {
+	if uid, err := ref.Get("uid").String(); err == nil {
+		e.add(TargetDatasource, uid)
+		return
+	}
+	e.add(TargetDatasource, ref.MustString())
+}
+
+func (e *targetExtractor) addPanel(panel *simplejson.Json) {
+	if panelType := panel.Get("type").MustString(); panelType != "row" {
+		e.add(TargetPanelPlugin, panelType)
+	}
+	e.add(TargetLibraryPanel, panel.Get("libraryPanel").Get("uid").MustString())
+
+	targets := panel.Get("targets").MustArray()
+	if ref := panel.Get("datasource"); ref.Interface() == nil && len(targets) > 0 {
+		e.add(TargetDatasource, e.defaultDatasource)
+	} else {
+		e.addDatasource(ref)
+	}
+
+	for _, target := range targets {
+		e.addDatasource(simplejson.NewFromAny(target).Get("datasource"))
+	}
+
+	// Collapsed rows hold their panels.
+	for _, nested := range panel.Get("panels").MustArray() {
+		e.addPanel(simplejson.NewFromAny(nested))
+	}
+}
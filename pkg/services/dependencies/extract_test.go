@@ -0,0 +1,55 @@
+package dependencies
+
+import (
+	"testing"
+
+	"github.com/grafana/grafana/pkg/components/simplejson"
+	"github.com/grafana/grafana/pkg/expr"
+	ngmodels "github.com/grafana/grafana/pkg/services/ngalert/models"
+	"github.com/stretchr/testify/require"
+)
+
+func TestDashboardTargets(t *testing.T) {
+	data, err := simplejson.NewJson([]byte(`{
+		"panels": [
+			{"type": "graph", "datasource": {"uid": "prom", "type": "prometheus"}, "targets": [{"datasource": {"uid": "loki"}}]},
+			{"type": "row", "panels": [
+				{"type": "table", "datasource": "Old MySQL", "libraryPanel": {"uid": "lib-1"}}
+			]},
+			{"type": "graph", "datasource": {"uid": "$ds"}},
+			{"type": "graph", "datasource": null, "targets": [{"refId": "A"}]},
+			{"type": "text"}
+		],
+		"rows": [
+			{"panels": [{"type": "singlestat", "datasource": "prom"}]}
+		],
+		"templating": {"list": [{"datasource": {"uid": "prom"}}, {"datasource": "${ds}"}]},
+		"annotations": {"list": [{"datasource": {"uid": "grafana"}}]}
+	}`))
+	require.NoError(t, err)
+
+	require.Equal(t, []Target{
+		{Type: TargetPanelPlugin, UID: "graph"},
+		{Type: TargetDatasource, UID: "prom"},
+		{Type: TargetDatasource, UID: "loki"},
+		{Type: TargetPanelPlugin, UID: "table"},
+		{Type: TargetLibraryPanel, UID: "lib-1"},
+		{Type: TargetDatasource, UID: "Old MySQL"},
+		{Type: TargetDatasource, UID: "default"},
+		{Type: TargetPanelPlugin, UID: "text"},
+		{Type: TargetPanelPlugin, UID: "singlestat"},
+		{Type: TargetDatasource, UID: "grafana"},
+	}, DashboardTargets(data, "default"))
+
+	require.Empty(t, DashboardTargets(simplejson.New(), "default"))
+}
+
+func TestAlertRuleTargets(t *testing.T) {
+	rule := &ngmodels.AlertRule{Data: []ngmodels.AlertQuery{
+		{RefID: "A", DatasourceUID: "prom"},
+		{RefID: "B", DatasourceUID: "prom"},
+		{RefID: "C", DatasourceUID: expr.DatasourceUID},
+	}}
+
+	require.Equal(t, []Target{{Type: TargetDatasource, UID: "prom"}}, AlertRuleTargets(rule))
+}
@@ -0,0 +1,60 @@
+package dependencies
+
+import "errors"
+
+// Types of the dashboards and alert rules depending on other resources.
+const (
+	SourceDashboard = "dashboard"
+	SourceAlertRule = "alert_rule"
+)
+
+// Types of the resources dashboards and alert rules depend on.
+const (
+	TargetDatasource   = "datasource"
+	TargetPanelPlugin  = "panel_plugin"
+	TargetLibraryPanel = "library_panel"
+)
+
+var errDataSourceNotFound = errors.New("data source not found")
+
+// Dependency is a resource used by a dashboard or an alert rule.
+type Dependency struct {
+	ID         int64  `xorm:"pk autoincr 'id'"`
+	OrgID      int64  `xorm:"org_id"`
+	SourceType string `xorm:"source_type"`
+	SourceUID  string `xorm:"source_uid"`
+	TargetType string `xorm:"target_type"`
+	TargetUID  string `xorm:"target_uid"`
+}
+
+// Target is a resource a dashboard or an alert rule depends on.
+type Target struct {
+	Type string
+	UID  string
+}
+
+// DependentDashboard is a dashboard using a resource.
+type DependentDashboard struct {
+	UID   string `json:"uid"`
+	Title string `json:"title"`
+	URL   string `json:"url"`
+}
+
+// DependentAlertRule is an alert rule using a resource.
+type DependentAlertRule struct {
+	UID          string `json:"uid" xorm:"uid"`
+	Title        string `json:"title" xorm:"title"`
+	NamespaceUID string `json:"namespaceUid" xorm:"namespace_uid"`
+	RuleGroup    string `json:"ruleGroup" xorm:"rule_group"`
+}
+
+// Dependents are the dashboards and alert rules using a resource.
+type Dependents struct {
+	Dashboards []DependentDashboard `json:"dashboards"`
+	AlertRules []DependentAlertRule `json:"alertRules"`
+}
+
+// Empty returns whether no dashboard or alert rule uses the resource.
+func (d Dependents) Empty() bool {
+	return len(d.Dashboards) == 0 && len(d.AlertRules) == 0
+}
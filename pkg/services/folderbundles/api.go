@@ -0,0 +1,102 @@
+package folderbundles
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+
+	"github.com/grafana/grafana/pkg/api/response"
+	"github.com/grafana/grafana/pkg/api/routing"
+	"github.com/grafana/grafana/pkg/middleware"
+	"github.com/grafana/grafana/pkg/models"
+	"github.com/grafana/grafana/pkg/web"
+)
+
+// maxBundleSize is the maximum size of an imported bundle archive, and of
+// the request importing it.
+const maxBundleSize = 64 << 20
+
+func (s *Service) registerAPIEndpoints() {
+	s.RouteRegister.Group("/api/folder-bundles", func(bundles routing.RouteRegister) {
+		bundles.Get("/export/:uid", middleware.ReqOrgAdmin, routing.Wrap(s.exportHandler))
+		bundles.Post("/import", middleware.ReqOrgAdmin, routing.Wrap(s.importHandler))
+	})
+}
+
+// exportHandler handles GET /api/folder-bundles/export/:uid.
+func (s *Service) exportHandler(c *models.ReqContext) response.Response {
+	uid := web.Params(c.Req)[":uid"]
+	content, err := s.Export(c.Req.Context(), c.OrgId, uid)
+	if err != nil {
+		return toFolderBundleError(err)
+	}
+
+	return response.Respond(200, content).
+		SetHeader("Content-Type", "application/zip").
+		SetHeader("Content-Disposition", fmt.Sprintf(`attachment; filename="folder-%s.zip"`, uid))
+}
+
+// importHandler handles POST /api/folder-bundles/import. The bundle archive
+// is sent in the bundle field of a multipart form, the import options as JSON
+// in the options field.
+func (s *Service) importHandler(c *models.ReqContext) response.Response {
+	c.Req.Body = http.MaxBytesReader(c.Resp, c.Req.Body, maxBundleSize)
+	if err := c.Req.ParseMultipartForm(maxBundleSize); err != nil {
+		return response.Error(400, "Failed to parse multipart form", err)
+	}
+	file, _, err := c.Req.FormFile("bundle")
+	if err != nil {
+		return response.Error(400, "Bundle file is required", err)
+	}
+	defer func() {
+		if err := file.Close(); err != nil {
+			s.log.Warn("Failed to close bundle file", "error", err)
+		}
+	}()
+	content, err := ioutil.ReadAll(file)
+	if err != nil {
+		return response.Error(400, "Failed to read bundle file", err)
+	}
+
+	options := ImportOptions{}
+	if raw := c.Req.FormValue("options"); raw != "" {
+		if err := json.Unmarshal([]byte(raw), &options); err != nil {
+			return response.Error(400, "Invalid import options", err)
+		}
+	}
+
+	result, err := s.Import(c.Req.Context(), c.SignedInUser, content, options)
+	if err != nil {
+		return toFolderBundleError(err)
+	}
+
+	return response.JSON(200, result)
+}
+
+func toFolderBundleError(err error) response.Response {
+	var conflictErr *ConflictError
+	if errors.As(err, &conflictErr) {
+		return response.JSON(409, map[string]interface{}{
+			"message":   err.Error(),
+			"conflicts": conflictErr.Conflicts,
+		})
+	}
+
+	var dataSourcesErr *UnresolvedDataSourcesError
+	if errors.As(err, &dataSourcesErr) {
+		return response.JSON(400, map[string]interface{}{
+			"message":     err.Error(),
+			"dataSources": dataSourcesErr.DataSources,
+		})
+	}
+
+	if errors.Is(err, errFolderNotFound) {
+		return response.Error(404, err.Error(), err)
+	}
+	if isBadRequest(err) {
+		return response.Error(400, err.Error(), err)
+	}
+	return response.Error(500, "Failed to process folder bundle", err)
+}
@@ -0,0 +1,177 @@
+package folderbundles
+
+import (
+	"sort"
+	"strings"
+
+	"github.com/grafana/grafana/pkg/models"
+)
+
+// builtInDataSources are data source references that don't refer to a data
+// source of the org.
+var builtInDataSources = map[string]bool{
+	"-- Mixed --":     true,
+	"-- Grafana --":   true,
+	"-- Dashboard --": true,
+	"grafana":         true,
+	"__expr__":        true,
+	"-100":            true,
+}
+
+// dataSourceRef is a reference to a data source, either by uid or by name.
+type dataSourceRef struct {
+	uid  string
+	name string
+}
+
+func isDataSourceRef(ref string) bool {
+	return ref != "" && !strings.HasPrefix(ref, "$") && !builtInDataSources[ref]
+}
+
+// walkDataSourceRefs calls fn for every data source reference of a dashboard
+// or panel model, which is a name or an object with a uid, and replaces the
+// reference with the result. Template variables aren't data source references.
+func walkDataSourceRefs(value interface{}, fn func(ref dataSourceRef) string) {
+	switch value := value.(type) {
+	case map[string]interface{}:
+		for key, child := range value {
+			if key != "datasource" {
+				walkDataSourceRefs(child, fn)
+				continue
+			}
+
+			switch ref := child.(type) {
+			case string:
+				if isDataSourceRef(ref) {
+					value[key] = fn(dataSourceRef{name: ref})
+				}
+			case map[string]interface{}:
+				if uid, ok := ref["uid"].(string); ok && isDataSourceRef(uid) {
+					ref["uid"] = fn(dataSourceRef{uid: uid})
+				}
+			}
+		}
+	case []interface{}:
+		for _, child := range value {
+			walkDataSourceRefs(child, fn)
+		}
+	}
+}
+
+// collectDataSourceRefs returns a function collecting the data source
+// references passed to walkDataSourceRefs.
+func collectDataSourceRefs(refs map[dataSourceRef]bool) func(ref dataSourceRef) string {
+	return func(ref dataSourceRef) string {
+		refs[ref] = true
+		if ref.uid != "" {
+			return ref.uid
+		}
+		return ref.name
+	}
+}
+
+// getDataSourceDescriptors returns the descriptors of the referenced data
+// sources. References to data sources that don't exist are ignored.
+func (s *Service) getDataSourceDescriptors(orgID int64, refs map[dataSourceRef]bool) ([]DataSourceDescriptor, error) {
+	query := models.GetDataSourcesQuery{OrgId: orgID}
+	if err := s.SQLStore.GetDataSources(&query); err != nil {
+		return nil, err
+	}
+
+	descriptors := []DataSourceDescriptor{}
+	for _, ds := range query.Result {
+		if !refs[dataSourceRef{uid: ds.Uid}] && !refs[dataSourceRef{name: ds.Name}] {
+			continue
+		}
+		descriptors = append(descriptors, DataSourceDescriptor{
+			UID:       ds.Uid,
+			Name:      ds.Name,
+			Type:      ds.Type,
+			Access:    ds.Access,
+			URL:       ds.Url,
+			Database:  ds.Database,
+			User:      ds.User,
+			BasicAuth: ds.BasicAuth,
+			JSONData:  ds.JsonData,
+		})
+	}
+	sort.Slice(descriptors, func(i, j int) bool {
+		return descriptors[i].Name < descriptors[j].Name
+	})
+	return descriptors, nil
+}
+
+// dataSourceMapping maps the data source references of a bundle to the data
+// sources of the org.
+type dataSourceMapping struct {
+	uids  map[string]string
+	names map[string]string
+}
+
+// resolveDataSources maps the data sources of the bundle to the data sources
+// given in the options, or else to the data sources of the org with the same
+// uid or name and type.
+func (s *Service) resolveDataSources(orgID int64, descriptors []DataSourceDescriptor,
+	options map[string]string) (*dataSourceMapping, error) {
+	query := models.GetDataSourcesQuery{OrgId: orgID}
+	if err := s.SQLStore.GetDataSources(&query); err != nil {
+		return nil, err
+	}
+	byUID := make(map[string]*models.DataSource, len(query.Result))
+	for _, ds := range query.Result {
+		byUID[ds.Uid] = ds
+	}
+
+	mapping := &dataSourceMapping{uids: map[string]string{}, names: map[string]string{}}
+	var unresolved []DataSourceDescriptor
+	for _, descriptor := range descriptors {
+		var target *models.DataSource
+		if uid, ok := options[descriptor.UID]; ok {
+			target = byUID[uid]
+		} else if ds, ok := byUID[descriptor.UID]; ok && ds.Type == descriptor.Type {
+			target = ds
+		} else {
+			for _, ds := range query.Result {
+				if ds.Name == descriptor.Name && ds.Type == descriptor.Type {
+					target = ds
+					break
+				}
+			}
+		}
+
+		if target == nil {
+			unresolved = append(unresolved, descriptor)
+			continue
+		}
+		mapping.uids[descriptor.UID] = target.Uid
+		mapping.names[descriptor.Name] = target.Name
+	}
+
+	if len(unresolved) > 0 {
+		return nil, &UnresolvedDataSourcesError{DataSources: unresolved}
+	}
+	return mapping, nil
+}
+
+// rewrite returns the reference to the mapped data source, references to data
+// sources not in the bundle are kept.
+func (m *dataSourceMapping) rewrite(ref dataSourceRef) string {
+	if ref.uid != "" {
+		if uid, ok := m.uids[ref.uid]; ok {
+			return uid
+		}
+		return ref.uid
+	}
+	if name, ok := m.names[ref.name]; ok {
+		return name
+	}
+	return ref.name
+}
+
+// rewriteUID returns the uid of the mapped data source.
+func (m *dataSourceMapping) rewriteUID(uid string) string {
+	if !isDataSourceRef(uid) {
+		return uid
+	}
+	return m.rewrite(dataSourceRef{uid: uid})
+}
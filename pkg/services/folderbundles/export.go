@@ -0,0 +1,300 @@
+package folderbundles
+
+import (
+	"archive/zip"
+	"bytes"
+	"context"
+	"encoding/json"
+	"path"
+
+	"github.com/grafana/grafana/pkg/components/simplejson"
+	"github.com/grafana/grafana/pkg/models"
+	"github.com/grafana/grafana/pkg/services/libraryelements"
+	ngmodels "github.com/grafana/grafana/pkg/services/ngalert/models"
+	"github.com/grafana/grafana/pkg/services/sqlstore"
+)
+
+// bundleFile is a file of the bundle archive besides the manifest.
+type bundleFile struct {
+	path    string
+	content []byte
+}
+
+// Export returns the bundle archive of a folder, its subfolders and their
+// content.
+func (s *Service) Export(ctx context.Context, orgID int64, folderUID string) ([]byte, error) {
+	folders, err := s.getFolderTree(ctx, orgID, folderUID)
+	if err != nil {
+		return nil, err
+	}
+
+	manifest := Manifest{
+		Version:         bundleVersion,
+		FolderUID:       folderUID,
+		Folders:         []Folder{},
+		Dashboards:      []Dashboard{},
+		LibraryElements: []LibraryElement{},
+		AlertRules:      []AlertRule{},
+	}
+	folderUIDs := make(map[int64]string, len(folders))
+	folderIDs := make([]int64, 0, len(folders))
+	for _, folder := range folders {
+		folderUIDs[folder.Id] = folder.Uid
+		folderIDs = append(folderIDs, folder.Id)
+	}
+
+	for _, folder := range folders {
+		permissions, err := s.getFolderPermissions(ctx, orgID, folder.Id)
+		if err != nil {
+			return nil, err
+		}
+		manifest.Folders = append(manifest.Folders, Folder{
+			UID:         folder.Uid,
+			Title:       folder.Title,
+			ParentUID:   folderUIDs[folder.FolderId],
+			Permissions: permissions,
+		})
+	}
+
+	var files []bundleFile
+	refs := map[dataSourceRef]bool{}
+	libraryElementUIDs := map[string]bool{}
+
+	dashboards, err := s.getDashboards(ctx, orgID, folderIDs)
+	if err != nil {
+		return nil, err
+	}
+	for _, dash := range dashboards {
+		dash.Data.Del("id")
+		dash.Data.Del("version")
+		walkDataSourceRefs(dash.Data.Interface(), collectDataSourceRefs(refs))
+		for _, uid := range libraryPanelUIDs(dash.Data) {
+			libraryElementUIDs[uid] = true
+		}
+
+		content, err := marshalIndent(dash.Data)
+		if err != nil {
+			return nil, err
+		}
+		filePath := path.Join("dashboards", dash.Uid+".json")
+		files = append(files, bundleFile{path: filePath, content: content})
+		manifest.Dashboards = append(manifest.Dashboards, Dashboard{
+			UID:       dash.Uid,
+			Title:     dash.Title,
+			FolderUID: folderUIDs[dash.FolderId],
+			Path:      filePath,
+		})
+	}
+
+	elements, err := s.getLibraryElements(ctx, orgID, folderIDs, libraryElementUIDs)
+	if err != nil {
+		return nil, err
+	}
+	for _, element := range elements {
+		var model interface{}
+		if err := json.Unmarshal(element.Model, &model); err != nil {
+			return nil, err
+		}
+		walkDataSourceRefs(model, collectDataSourceRefs(refs))
+
+		content, err := marshalIndent(model)
+		if err != nil {
+			return nil, err
+		}
+		filePath := path.Join("library-elements", element.UID+".json")
+		files = append(files, bundleFile{path: filePath, content: content})
+		manifest.LibraryElements = append(manifest.LibraryElements, LibraryElement{
+			UID:       element.UID,
+			Name:      element.Name,
+			Kind:      element.Kind,
+			FolderUID: folderUIDs[element.FolderID],
+			Path:      filePath,
+		})
+	}
+
+	rules, err := s.getAlertRules(ctx, orgID, folders)
+	if err != nil {
+		return nil, err
+	}
+	for _, rule := range rules {
+		for _, query := range rule.Data {
+			if isDataSourceRef(query.DatasourceUID) {
+				refs[dataSourceRef{uid: query.DatasourceUID}] = true
+			}
+		}
+		manifest.AlertRules = append(manifest.AlertRules, AlertRule{
+			UID:             rule.UID,
+			Title:           rule.Title,
+			FolderUID:       rule.NamespaceUID,
+			RuleGroup:       rule.RuleGroup,
+			Condition:       rule.Condition,
+			Data:            rule.Data,
+			IntervalSeconds: rule.IntervalSeconds,
+			NoDataState:     rule.NoDataState,
+			ExecErrState:    rule.ExecErrState,
+			For:             rule.For.String(),
+			DashboardUID:    rule.DashboardUID,
+			PanelID:         rule.PanelID,
+			Annotations:     rule.Annotations,
+			Labels:          rule.Labels,
+		})
+	}
+
+	manifest.DataSources, err = s.getDataSourceDescriptors(orgID, refs)
+	if err != nil {
+		return nil, err
+	}
+
+	return writeBundle(manifest, files)
+}
+
+func writeBundle(manifest Manifest, files []bundleFile) ([]byte, error) {
+	content, err := marshalIndent(manifest)
+	if err != nil {
+		return nil, err
+	}
+	files = append([]bundleFile{{path: manifestPath, content: content}}, files...)
+
+	var buf bytes.Buffer
+	archive := zip.NewWriter(&buf)
+	for _, file := range files {
+		w, err := archive.Create(file.path)
+		if err != nil {
+			return nil, err
+		}
+		if _, err := w.Write(file.content); err != nil {
+			return nil, err
+		}
+	}
+	if err := archive.Close(); err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
+
+// getFolderTree returns a folder and its subfolders, parents before their
+// subfolders.
+func (s *Service) getFolderTree(ctx context.Context, orgID int64, folderUID string) ([]*models.Dashboard, error) {
+	root, err := s.getFolderByUID(ctx, orgID, folderUID)
+	if err != nil {
+		return nil, err
+	}
+
+	folders := []*models.Dashboard{root}
+	err = s.SQLStore.WithDbSession(ctx, func(sess *sqlstore.DBSession) error {
+		parentIDs := []int64{root.Id}
+		for len(parentIDs) > 0 {
+			var children []*models.Dashboard
+			err := sess.In("folder_id", parentIDs).Where("org_id=? AND is_folder=?", orgID, s.SQLStore.Dialect.BooleanStr(true)).
+				Asc("title").Find(&children)
+			if err != nil {
+				return err
+			}
+			parentIDs = parentIDs[:0]
+			for _, child := range children {
+				folders = append(folders, child)
+				parentIDs = append(parentIDs, child.Id)
+			}
+		}
+		return nil
+	})
+	return folders, err
+}
+
+// getFolderPermissions returns the permissions set on a folder, inherited and
+// default permissions aren't part of the bundle.
+func (s *Service) getFolderPermissions(ctx context.Context, orgID int64, folderID int64) ([]Permission, error) {
+	query := models.GetDashboardAclInfoListQuery{OrgID: orgID, DashboardID: folderID}
+	if err := s.SQLStore.GetDashboardAclInfoList(ctx, &query); err != nil {
+		return nil, err
+	}
+
+	permissions := []Permission{}
+	for _, item := range query.Result {
+		if item.Inherited || item.DashboardId != folderID {
+			continue
+		}
+		permissions = append(permissions, Permission{
+			Role:       item.Role,
+			UserLogin:  item.UserLogin,
+			Team:       item.Team,
+			Permission: item.Permission,
+		})
+	}
+	return permissions, nil
+}
+
+func (s *Service) getDashboards(ctx context.Context, orgID int64, folderIDs []int64) ([]*models.Dashboard, error) {
+	var dashboards []*models.Dashboard
+	err := s.SQLStore.WithDbSession(ctx, func(sess *sqlstore.DBSession) error {
+		return sess.In("folder_id", folderIDs).Where("org_id=? AND is_folder=?", orgID, s.SQLStore.Dialect.BooleanStr(false)).
+			Asc("title").Find(&dashboards)
+	})
+	return dashboards, err
+}
+
+// getLibraryElements returns the library elements stored in the folders and
+// the library elements with the given uids.
+func (s *Service) getLibraryElements(ctx context.Context, orgID int64, folderIDs []int64,
+	uids map[string]bool) ([]libraryelements.LibraryElement, error) {
+	var elements []libraryelements.LibraryElement
+	err := s.SQLStore.WithDbSession(ctx, func(sess *sqlstore.DBSession) error {
+		inFolders := []libraryelements.LibraryElement{}
+		if err := sess.In("folder_id", folderIDs).Where("org_id=?", orgID).Asc("name").Find(&inFolders); err != nil {
+			return err
+		}
+		elements = append(elements, inFolders...)
+
+		var missing []string
+		for uid := range uids {
+			found := false
+			for _, element := range inFolders {
+				if element.UID == uid {
+					found = true
+					break
+				}
+			}
+			if !found {
+				missing = append(missing, uid)
+			}
+		}
+		if len(missing) == 0 {
+			return nil
+		}
+
+		used := []libraryelements.LibraryElement{}
+		if err := sess.In("uid", missing).Where("org_id=?", orgID).Asc("name").Find(&used); err != nil {
+			return err
+		}
+		elements = append(elements, used...)
+		return nil
+	})
+	return elements, err
+}
+
+func (s *Service) getAlertRules(ctx context.Context, orgID int64, folders []*models.Dashboard) ([]ngmodels.AlertRule, error) {
+	namespaceUIDs := make([]string, 0, len(folders))
+	for _, folder := range folders {
+		namespaceUIDs = append(namespaceUIDs, folder.Uid)
+	}
+
+	var rules []ngmodels.AlertRule
+	err := s.SQLStore.WithDbSession(ctx, func(sess *sqlstore.DBSession) error {
+		return sess.Table("alert_rule").In("namespace_uid", namespaceUIDs).Where("org_id=?", orgID).
+			Asc("namespace_uid", "rule_group", "title").Find(&rules)
+	})
+	return rules, err
+}
+
+// libraryPanelUIDs returns the uids of the library panels used by a dashboard.
+func libraryPanelUIDs(data *simplejson.Json) []string {
+	var uids []string
+	for _, panel := range data.Get("panels").MustArray() {
+		panel := simplejson.NewFromAny(panel)
+		if uid := panel.GetPath("libraryPanel", "uid").MustString(); uid != "" {
+			uids = append(uids, uid)
+		}
+		uids = append(uids, libraryPanelUIDs(panel)...)
+	}
+	return uids
+}
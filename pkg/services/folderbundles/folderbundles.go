@@ -0,0 +1,58 @@
+package folderbundles
+
+import (
+	"time"
+
+	"github.com/grafana/grafana/pkg/api/routing"
+	"github.com/grafana/grafana/pkg/infra/log"
+	"github.com/grafana/grafana/pkg/services/libraryelements"
+	"github.com/grafana/grafana/pkg/services/librarypanels"
+	"github.com/grafana/grafana/pkg/services/ngalert/store"
+	"github.com/grafana/grafana/pkg/services/sqlstore"
+	"github.com/grafana/grafana/pkg/setting"
+)
+
+// defaultAlertingBaseInterval is the base interval of the alert scheduler
+// used when it isn't configured.
+const defaultAlertingBaseInterval = 10 * time.Second
+
+func ProvideService(cfg *setting.Cfg, sqlStore *sqlstore.SQLStore, routeRegister routing.RouteRegister,
+	libraryElementService libraryelements.Service, libraryPanelService librarypanels.Service) *Service {
+	s := &Service{
+		Cfg:                   cfg,
+		SQLStore:              sqlStore,
+		RouteRegister:         routeRegister,
+		LibraryElementService: libraryElementService,
+		LibraryPanelService:   libraryPanelService,
+		log:                   log.New("folder-bundles"),
+	}
+	s.registerAPIEndpoints()
+	return s
+}
+
+// Service exports folders, with their subfolders, dashboards, library
+// elements and alert rules, as bundles that can be imported into another
+// org or Grafana instance.
+type Service struct {
+	Cfg                   *setting.Cfg
+	SQLStore              *sqlstore.SQLStore
+	RouteRegister         routing.RouteRegister
+	LibraryElementService libraryelements.Service
+	LibraryPanelService   librarypanels.Service
+	log                   log.Logger
+}
+
+// alertRuleStore returns the store used to save the alert rules of bundles,
+// which validates them like the alerting API.
+func (s *Service) alertRuleStore() store.DBstore {
+	baseInterval := s.Cfg.AlertingBaseInterval * time.Second
+	if baseInterval <= 0 {
+		baseInterval = defaultAlertingBaseInterval
+	}
+	return store.DBstore{
+		BaseInterval:    baseInterval,
+		DefaultInterval: baseInterval,
+		SQLStore:        s.SQLStore,
+		Logger:          s.log,
+	}
+}
@@ -0,0 +1,307 @@
+package folderbundles
+
+import (
+	"archive/zip"
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/grafana/grafana/pkg/api/routing"
+	"github.com/grafana/grafana/pkg/components/simplejson"
+	"github.com/grafana/grafana/pkg/models"
+	"github.com/grafana/grafana/pkg/services/dashboards"
+	"github.com/grafana/grafana/pkg/services/libraryelements"
+	"github.com/grafana/grafana/pkg/services/librarypanels"
+	ngmodels "github.com/grafana/grafana/pkg/services/ngalert/models"
+	"github.com/grafana/grafana/pkg/services/sqlstore"
+	"github.com/grafana/grafana/pkg/setting"
+	"github.com/stretchr/testify/require"
+)
+
+func TestFolderBundles(t *testing.T) {
+	ctx := context.Background()
+	sqlStore := sqlstore.InitTestDB(t)
+	cfg := setting.NewCfg()
+	routeRegister := routing.NewRouteRegister()
+	libraryElementService := libraryelements.ProvideService(cfg, sqlStore, routeRegister)
+	s := ProvideService(cfg, sqlStore, routeRegister, libraryElementService,
+		librarypanels.ProvideService(cfg, sqlStore, routeRegister, libraryElementService))
+
+	alice, err := sqlStore.CreateUser(ctx, models.CreateUserCommand{Login: "alice", Email: "alice@example.com"})
+	require.NoError(t, err)
+	otherOrg, err := sqlStore.CreateOrgWithMember("Other", alice.Id)
+	require.NoError(t, err)
+	team, err := sqlStore.CreateTeam("ops", "", 1)
+	require.NoError(t, err)
+
+	source := &models.SignedInUser{UserId: alice.Id, OrgId: 1, OrgRole: models.ROLE_ADMIN}
+	target := &models.SignedInUser{UserId: alice.Id, OrgId: otherOrg.Id, OrgRole: models.ROLE_ADMIN}
+
+	require.NoError(t, sqlStore.AddDataSource(ctx, &models.AddDataSourceCommand{
+		OrgId: 1, Name: "Prometheus", Type: "prometheus", Uid: "prom", Access: models.DS_ACCESS_PROXY,
+	}))
+	require.NoError(t, sqlStore.AddDataSource(ctx, &models.AddDataSourceCommand{
+		OrgId: otherOrg.Id, Name: "Metrics", Type: "prometheus", Uid: "metrics", Access: models.DS_ACCESS_PROXY,
+	}))
+
+	folderService := dashboards.NewFolderService(1, source, sqlStore)
+	root, err := folderService.CreateFolder(ctx, "Team", "team", "")
+	require.NoError(t, err)
+	sub, err := folderService.CreateFolder(ctx, "Services", "services", "team")
+	require.NoError(t, err)
+	editor := models.ROLE_EDITOR
+	now := time.Now()
+	require.NoError(t, sqlStore.UpdateDashboardACLCtx(ctx, root.Id, []*models.DashboardAcl{
+		{OrgID: 1, DashboardID: root.Id, UserID: alice.Id, Permission: models.PERMISSION_ADMIN, Created: now, Updated: now},
+		{OrgID: 1, DashboardID: root.Id, Role: &editor, Permission: models.PERMISSION_EDIT, Created: now, Updated: now},
+	}))
+	require.NoError(t, sqlStore.UpdateDashboardACLCtx(ctx, sub.Id, []*models.DashboardAcl{
+		{OrgID: 1, DashboardID: sub.Id, TeamID: team.Id, Permission: models.PERMISSION_VIEW, Created: now, Updated: now},
+	}))
+
+	_, err = libraryElementService.CreateElement(ctx, source, libraryelements.CreateLibraryElementCommand{
+		FolderID: root.Id,
+		Name:     "Requests",
+		Kind:     int64(models.PanelElement),
+		UID:      "requests",
+		Model:    []byte(`{"type": "graph", "datasource": "Prometheus"}`),
+	})
+	require.NoError(t, err)
+
+	dashJSON := simplejson.NewFromAny(map[string]interface{}{
+		"uid":   "api",
+		"title": "API",
+		"panels": []interface{}{
+			map[string]interface{}{"id": 1, "type": "graph", "datasource": map[string]interface{}{"uid": "prom", "type": "prometheus"}},
+			map[string]interface{}{"id": 2, "libraryPanel": map[string]interface{}{"uid": "requests", "name": "Requests"}},
+		},
+	})
+	_, err = sqlStore.SaveDashboard(context.Background(), models.SaveDashboardCommand{OrgId: 1, FolderId: sub.Id, Dashboard: dashJSON})
+	require.NoError(t, err)
+
+	err = sqlStore.WithDbSession(ctx, func(sess *sqlstore.DBSession) error {
+		_, err := sess.Insert(&ngmodels.AlertRule{
+			OrgID:           1,
+			UID:             "high-latency",
+			Title:           "High latency",
+			Condition:       "A",
+			NamespaceUID:    "services",
+			RuleGroup:       "api",
+			IntervalSeconds: 60,
+			For:             5 * time.Minute,
+			Updated:         time.Now(),
+			Data: []ngmodels.AlertQuery{{
+				RefID:         "A",
+				DatasourceUID: "prom",
+				RelativeTimeRange: ngmodels.RelativeTimeRange{
+					From: ngmodels.Duration(10 * time.Minute),
+				},
+				Model: json.RawMessage(`{"expr": "latency > 1"}`),
+			}},
+		})
+		return err
+	})
+	require.NoError(t, err)
+
+	content, err := s.Export(ctx, 1, "team")
+	require.NoError(t, err)
+
+	t.Run("export writes the folder tree and its content", func(t *testing.T) {
+		b, err := readBundle(content)
+		require.NoError(t, err)
+
+		manifest := b.manifest
+		require.Equal(t, "team", manifest.FolderUID)
+		require.Len(t, manifest.Folders, 2)
+		require.Equal(t, Folder{UID: "team", Title: "Team", Permissions: []Permission{
+			{UserLogin: "alice", Permission: models.PERMISSION_ADMIN},
+			{Role: &editor, Permission: models.PERMISSION_EDIT},
+		}}, manifest.Folders[0])
+		require.Equal(t, Folder{UID: "services", Title: "Services", ParentUID: "team", Permissions: []Permission{
+			{Team: "ops", Permission: models.PERMISSION_VIEW},
+		}}, manifest.Folders[1])
+
+		require.Equal(t, []Dashboard{{UID: "api", Title: "API", FolderUID: "services", Path: "dashboards/api.json"}}, manifest.Dashboards)
+		require.Equal(t, []LibraryElement{{
+			UID: "requests", Name: "Requests", Kind: int64(models.PanelElement), FolderUID: "team", Path: "library-elements/requests.json",
+		}}, manifest.LibraryElements)
+		require.Len(t, manifest.AlertRules, 1)
+		require.Equal(t, "5m0s", manifest.AlertRules[0].For)
+		require.Len(t, manifest.DataSources, 1)
+		require.Equal(t, "prom", manifest.DataSources[0].UID)
+
+		dash, err := simplejson.NewJson(b.files["dashboards/api.json"])
+		require.NoError(t, err)
+		require.Nil(t, dash.Get("id").Interface())
+	})
+
+	t.Run("import fails with unresolved data sources", func(t *testing.T) {
+		_, err := s.Import(ctx, target, content, ImportOptions{})
+		var dataSourcesErr *UnresolvedDataSourcesError
+		require.True(t, errors.As(err, &dataSourcesErr))
+		require.Equal(t, "prom", dataSourcesErr.DataSources[0].UID)
+	})
+
+	options := ImportOptions{DataSources: map[string]string{"prom": "metrics"}}
+
+	t.Run("import creates the folder tree and remaps data sources", func(t *testing.T) {
+		result, err := s.Import(ctx, target, content, options)
+		require.NoError(t, err)
+		require.Len(t, result.Imported, 5)
+		require.Empty(t, result.Skipped)
+		require.Equal(t, []string{"team ops of the permissions of folder services not found"}, result.Warnings)
+
+		folder, err := s.getFolderByUID(ctx, otherOrg.Id, "services")
+		require.NoError(t, err)
+		parent, err := s.getFolderByUID(ctx, otherOrg.Id, "team")
+		require.NoError(t, err)
+		require.Equal(t, parent.Id, folder.FolderId)
+
+		permissions, err := s.getFolderPermissions(ctx, otherOrg.Id, parent.Id)
+		require.NoError(t, err)
+		require.Len(t, permissions, 2)
+
+		dash, err := sqlStore.GetDashboard(0, otherOrg.Id, "api", "")
+		require.NoError(t, err)
+		require.Equal(t, folder.Id, dash.FolderId)
+		panels := dash.Data.Get("panels")
+		require.Equal(t, "metrics", panels.GetIndex(0).GetPath("datasource", "uid").MustString())
+
+		element, err := libraryElementService.GetElement(ctx, target, "requests")
+		require.NoError(t, err)
+		require.Equal(t, parent.Id, element.FolderID)
+		require.JSONEq(t, `{"type": "graph", "description": "", "datasource": "Metrics"}`, string(element.Model))
+		connected, err := libraryElementService.GetElementsForDashboard(ctx, dash.Id)
+		require.NoError(t, err)
+		require.Contains(t, connected, "requests")
+
+		rules := []ngmodels.AlertRule{}
+		err = sqlStore.WithDbSession(ctx, func(sess *sqlstore.DBSession) error {
+			return sess.Table("alert_rule").Where("org_id=?", otherOrg.Id).Find(&rules)
+		})
+		require.NoError(t, err)
+		require.Len(t, rules, 1)
+		require.Equal(t, "services", rules[0].NamespaceUID)
+		require.Equal(t, "metrics", rules[0].Data[0].DatasourceUID)
+		require.Equal(t, 5*time.Minute, rules[0].For)
+	})
+
+	t.Run("import fails on conflicts by default", func(t *testing.T) {
+		_, err := s.Import(ctx, target, content, options)
+		var conflictErr *ConflictError
+		require.True(t, errors.As(err, &conflictErr))
+		require.Len(t, conflictErr.Conflicts, 5)
+	})
+
+	t.Run("import skips existing objects", func(t *testing.T) {
+		options := options
+		options.Conflict = ConflictSkip
+		result, err := s.Import(ctx, target, content, options)
+		require.NoError(t, err)
+		require.Empty(t, result.Imported)
+		require.Len(t, result.Skipped, 5)
+	})
+
+	t.Run("import overwrites existing objects", func(t *testing.T) {
+		options := options
+		options.Conflict = ConflictOverwrite
+		result, err := s.Import(ctx, target, content, options)
+		require.NoError(t, err)
+		require.Len(t, result.Imported, 5)
+
+		rules := []ngmodels.AlertRule{}
+		err = sqlStore.WithDbSession(ctx, func(sess *sqlstore.DBSession) error {
+			return sess.Table("alert_rule").Where("org_id=?", otherOrg.Id).Find(&rules)
+		})
+		require.NoError(t, err)
+		require.Len(t, rules, 1)
+		require.Equal(t, int64(2), rules[0].Version)
+	})
+
+	t.Run("import doesn't import anything if a write fails", func(t *testing.T) {
+		third, err := sqlStore.CreateOrgWithMember("Third", alice.Id)
+		require.NoError(t, err)
+		require.NoError(t, sqlStore.AddDataSource(ctx, &models.AddDataSourceCommand{
+			OrgId: third.Id, Name: "Metrics", Type: "prometheus", Uid: "metrics", Access: models.DS_ACCESS_PROXY,
+		}))
+		user := &models.SignedInUser{UserId: alice.Id, OrgId: third.Id, OrgRole: models.ROLE_ADMIN}
+
+		b, err := readBundle(content)
+		require.NoError(t, err)
+		b.manifest.AlertRules[0].IntervalSeconds = 7
+		var files []bundleFile
+		for path, data := range b.files {
+			if path != manifestPath {
+				files = append(files, bundleFile{path: path, content: data})
+			}
+		}
+		invalid, err := writeBundle(b.manifest, files)
+		require.NoError(t, err)
+
+		_, err = s.Import(ctx, user, invalid, options)
+		require.ErrorIs(t, err, ngmodels.ErrAlertRuleFailedValidation)
+
+		_, err = s.getFolderByUID(ctx, third.Id, "team")
+		require.ErrorIs(t, err, errFolderNotFound)
+		_, err = sqlStore.GetDashboard(0, third.Id, "api", "")
+		require.ErrorIs(t, err, models.ErrDashboardNotFound)
+		_, err = libraryElementService.GetElement(ctx, user, "requests")
+		require.ErrorIs(t, err, libraryelements.ErrLibraryElementNotFound)
+	})
+
+	t.Run("import rejects invalid bundles", func(t *testing.T) {
+		var buf bytes.Buffer
+		archive := zip.NewWriter(&buf)
+		w, err := archive.Create(manifestPath)
+		require.NoError(t, err)
+		_, err = w.Write([]byte(`{"version": 2}`))
+		require.NoError(t, err)
+		require.NoError(t, archive.Close())
+
+		_, err = s.Import(ctx, target, buf.Bytes(), ImportOptions{})
+		require.ErrorIs(t, err, errUnsupportedVersion)
+
+		_, err = s.Import(ctx, target, []byte("not a zip"), ImportOptions{})
+		require.ErrorIs(t, err, errInvalidBundle)
+
+		_, err = s.Import(ctx, target, content, ImportOptions{Conflict: "merge"})
+		require.ErrorIs(t, err, errInvalidConflict)
+	})
+
+	t.Run("import rejects bundles with oversized files", func(t *testing.T) {
+		large, err := writeBundle(Manifest{Version: bundleVersion}, []bundleFile{
+			{path: "dashboards/large.json", content: make([]byte, maxBundleEntrySize+1)},
+		})
+		require.NoError(t, err)
+
+		_, err = s.Import(ctx, target, large, ImportOptions{})
+		require.ErrorIs(t, err, errInvalidBundle)
+	})
+}
+
+func TestWalkDataSourceRefs(t *testing.T) {
+	var model interface{}
+	require.NoError(t, json.Unmarshal([]byte(`{
+		"datasource": "Prometheus",
+		"panels": [
+			{"datasource": {"uid": "prom"}, "targets": [{"datasource": "$ds"}]},
+			{"datasource": "-- Mixed --"}
+		]
+	}`), &model))
+
+	mapping := &dataSourceMapping{uids: map[string]string{"prom": "metrics"}, names: map[string]string{"Prometheus": "Metrics"}}
+	walkDataSourceRefs(model, mapping.rewrite)
+
+	raw, err := json.Marshal(model)
+	require.NoError(t, err)
+	require.JSONEq(t, `{
+		"datasource": "Metrics",
+		"panels": [
+			{"datasource": {"uid": "metrics"}, "targets": [{"datasource": "$ds"}]},
+			{"datasource": "-- Mixed --"}
+		]
+	}`, string(raw))
+}
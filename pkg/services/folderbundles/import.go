@@ -0,0 +1,628 @@
+package folderbundles
+
+import (
+	"archive/zip"
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"time"
+
+	"github.com/grafana/grafana/pkg/components/simplejson"
+	"github.com/grafana/grafana/pkg/models"
+	"github.com/grafana/grafana/pkg/services/dashboards"
+	"github.com/grafana/grafana/pkg/services/libraryelements"
+	ngmodels "github.com/grafana/grafana/pkg/services/ngalert/models"
+	"github.com/grafana/grafana/pkg/services/ngalert/store"
+	"github.com/grafana/grafana/pkg/services/sqlstore"
+)
+
+// Limits of the uncompressed content of an imported bundle archive, which
+// keep a small archive from expanding into more than fits in memory.
+const (
+	maxBundleEntrySize        = 32 << 20
+	maxBundleUncompressedSize = 256 << 20
+)
+
+// bundle is a bundle archive read into memory.
+type bundle struct {
+	manifest Manifest
+	files    map[string][]byte
+}
+
+func readBundle(content []byte) (*bundle, error) {
+	archive, err := zip.NewReader(bytes.NewReader(content), int64(len(content)))
+	if err != nil {
+		return nil, fmt.Errorf("%w: %v", errInvalidBundle, err)
+	}
+
+	b := &bundle{files: make(map[string][]byte, len(archive.File))}
+	var size int64
+	for _, file := range archive.File {
+		data, err := readBundleFile(file, maxBundleUncompressedSize-size)
+		if err != nil {
+			return nil, err
+		}
+		size += int64(len(data))
+		b.files[file.Name] = data
+	}
+
+	data, ok := b.files[manifestPath]
+	if !ok {
+		return nil, fmt.Errorf("%w: %s is missing", errInvalidBundle, manifestPath)
+	}
+	if err := json.Unmarshal(data, &b.manifest); err != nil {
+		return nil, fmt.Errorf("%w: %v", errInvalidBundle, err)
+	}
+	if b.manifest.Version != bundleVersion {
+		return nil, errUnsupportedVersion
+	}
+	return b, nil
+}
+
+// readBundleFile reads a file of a bundle archive, failing if it's larger
+// than maxBundleEntrySize or remaining bytes uncompressed. The sizes in the
+// archive headers aren't trusted.
+func readBundleFile(file *zip.File, remaining int64) ([]byte, error) {
+	limit := int64(maxBundleEntrySize)
+	if remaining < limit {
+		limit = remaining
+	}
+
+	r, err := file.Open()
+	if err != nil {
+		return nil, fmt.Errorf("%w: %v", errInvalidBundle, err)
+	}
+	defer func() { _ = r.Close() }()
+
+	data, err := ioutil.ReadAll(io.LimitReader(r, limit+1))
+	if err != nil {
+		return nil, fmt.Errorf("%w: %v", errInvalidBundle, err)
+	}
+	if int64(len(data)) > limit {
+		return nil, fmt.Errorf("%w: %s exceeds the maximum uncompressed size", errInvalidBundle, file.Name)
+	}
+	return data, nil
+}
+
+func (b *bundle) file(filePath string) ([]byte, error) {
+	data, ok := b.files[filePath]
+	if !ok {
+		return nil, fmt.Errorf("%w: %s is missing", errInvalidBundle, filePath)
+	}
+	return data, nil
+}
+
+// importer imports a bundle into an org.
+type importer struct {
+	s        *Service
+	user     *models.SignedInUser
+	bundle   *bundle
+	options  ImportOptions
+	mapping  *dataSourceMapping
+	result   *ImportResult
+	existing map[Item]bool
+	// folderIDs maps the uids of the imported folders to their ids.
+	folderIDs map[string]int64
+
+	// The objects of the bundle prepared for the import, by uid.
+	folderPermissions    map[string][]*models.DashboardAcl
+	libraryElementModels map[string][]byte
+	dashboards           map[string]*simplejson.Json
+	alertRules           map[string]store.UpsertRule
+}
+
+// Import imports a bundle archive into the org of the user. The bundle is
+// validated and conflicts are checked before anything is imported, and the
+// import is done in a single transaction, so a failing import doesn't import
+// anything.
+func (s *Service) Import(ctx context.Context, user *models.SignedInUser, content []byte,
+	options ImportOptions) (*ImportResult, error) {
+	if options.Conflict == "" {
+		options.Conflict = ConflictFail
+	}
+	switch options.Conflict {
+	case ConflictFail, ConflictSkip, ConflictOverwrite:
+	default:
+		return nil, errInvalidConflict
+	}
+
+	b, err := readBundle(content)
+	if err != nil {
+		return nil, err
+	}
+
+	imp := &importer{
+		s:         s,
+		user:      user,
+		bundle:    b,
+		options:   options,
+		result:    &ImportResult{FolderUID: b.manifest.FolderUID, Imported: []Item{}, Skipped: []Item{}, Warnings: []string{}},
+		folderIDs: map[string]int64{},
+
+		folderPermissions:    map[string][]*models.DashboardAcl{},
+		libraryElementModels: map[string][]byte{},
+		dashboards:           map[string]*simplejson.Json{},
+		alertRules:           map[string]store.UpsertRule{},
+	}
+
+	if options.ParentUID != "" {
+		parent, err := s.getFolderByUID(ctx, user.OrgId, options.ParentUID)
+		if err != nil {
+			return nil, err
+		}
+		imp.folderIDs[parent.Uid] = parent.Id
+	}
+
+	imp.mapping, err = s.resolveDataSources(user.OrgId, b.manifest.DataSources, options.DataSources)
+	if err != nil {
+		return nil, err
+	}
+
+	imp.existing, err = imp.findExisting(ctx)
+	if err != nil {
+		return nil, err
+	}
+	if options.Conflict == ConflictFail && len(imp.existing) > 0 {
+		conflicts := []Item{}
+		for _, item := range imp.items() {
+			if imp.existing[item] {
+				conflicts = append(conflicts, item)
+			}
+		}
+		return nil, &ConflictError{Conflicts: conflicts}
+	}
+
+	if err := imp.prepare(ctx); err != nil {
+		return nil, err
+	}
+
+	err = s.SQLStore.InTransaction(ctx, func(ctx context.Context) error {
+		if err := imp.importFolders(ctx); err != nil {
+			return err
+		}
+		if err := imp.importLibraryElements(ctx); err != nil {
+			return err
+		}
+		if err := imp.importDashboards(ctx); err != nil {
+			return err
+		}
+		return imp.importAlertRules(ctx)
+	})
+	if err != nil {
+		return nil, err
+	}
+	return imp.result, nil
+}
+
+// prepare reads and validates the objects of the bundle that are imported,
+// and rewrites their data source references.
+func (imp *importer) prepare(ctx context.Context) error {
+	manifest := imp.bundle.manifest
+	for _, folder := range manifest.Folders {
+		item := Item{Kind: kindFolder, UID: folder.UID, Title: folder.Title}
+		if imp.skip(item) || len(folder.Permissions) == 0 {
+			continue
+		}
+		permissions, err := imp.resolveFolderPermissions(ctx, folder)
+		if err != nil {
+			return err
+		}
+		imp.folderPermissions[folder.UID] = permissions
+	}
+
+	for _, element := range manifest.LibraryElements {
+		item := Item{Kind: kindLibraryElement, UID: element.UID, Title: element.Name}
+		if imp.skip(item) {
+			continue
+		}
+		model, err := imp.prepareLibraryElement(element)
+		if err != nil {
+			return err
+		}
+		imp.libraryElementModels[element.UID] = model
+	}
+
+	for _, dashboard := range manifest.Dashboards {
+		item := Item{Kind: kindDashboard, UID: dashboard.UID, Title: dashboard.Title}
+		if imp.skip(item) {
+			continue
+		}
+		dashJSON, err := imp.prepareDashboard(dashboard)
+		if err != nil {
+			return err
+		}
+		imp.dashboards[dashboard.UID] = dashJSON
+	}
+
+	for _, rule := range manifest.AlertRules {
+		item := Item{Kind: kindAlertRule, UID: rule.UID, Title: rule.Title}
+		if imp.skip(item) {
+			continue
+		}
+		upsert, err := imp.prepareAlertRule(rule)
+		if err != nil {
+			return err
+		}
+		imp.alertRules[rule.UID] = upsert
+	}
+	return nil
+}
+
+// items returns the objects of the bundle in import order.
+func (imp *importer) items() []Item {
+	manifest := imp.bundle.manifest
+	var items []Item
+	for _, folder := range manifest.Folders {
+		items = append(items, Item{Kind: kindFolder, UID: folder.UID, Title: folder.Title})
+	}
+	for _, element := range manifest.LibraryElements {
+		items = append(items, Item{Kind: kindLibraryElement, UID: element.UID, Title: element.Name})
+	}
+	for _, dash := range manifest.Dashboards {
+		items = append(items, Item{Kind: kindDashboard, UID: dash.UID, Title: dash.Title})
+	}
+	for _, rule := range manifest.AlertRules {
+		items = append(items, Item{Kind: kindAlertRule, UID: rule.UID, Title: rule.Title})
+	}
+	return items
+}
+
+// findExisting returns the objects of the bundle that already exist in the
+// org. Alert rules exist if a rule with the same title exists in their folder.
+func (imp *importer) findExisting(ctx context.Context) (map[Item]bool, error) {
+	orgID := imp.user.OrgId
+	existing := map[Item]bool{}
+	err := imp.s.SQLStore.WithDbSession(ctx, func(sess *sqlstore.DBSession) error {
+		for _, item := range imp.items() {
+			var exists bool
+			var err error
+			switch item.Kind {
+			case kindFolder, kindDashboard:
+				exists, err = sess.Table("dashboard").Where("org_id=? AND uid=?", orgID, item.UID).Exist()
+			case kindLibraryElement:
+				exists, err = sess.Table("library_element").Where("org_id=? AND uid=?", orgID, item.UID).Exist()
+			case kindAlertRule:
+				rule := imp.alertRule(item.UID)
+				exists, err = sess.Table("alert_rule").Where("org_id=? AND namespace_uid=? AND title=?",
+					orgID, rule.FolderUID, rule.Title).Exist()
+			}
+			if err != nil {
+				return err
+			}
+			if exists {
+				existing[item] = true
+			}
+		}
+		return nil
+	})
+	return existing, err
+}
+
+func (imp *importer) alertRule(uid string) AlertRule {
+	for _, rule := range imp.bundle.manifest.AlertRules {
+		if rule.UID == uid {
+			return rule
+		}
+	}
+	return AlertRule{}
+}
+
+// skip records whether an object of the bundle is imported or skipped, and
+// returns whether it's skipped.
+func (imp *importer) skip(item Item) bool {
+	if imp.skipped(item) {
+		imp.result.Skipped = append(imp.result.Skipped, item)
+		return true
+	}
+	imp.result.Imported = append(imp.result.Imported, item)
+	return false
+}
+
+func (imp *importer) skipped(item Item) bool {
+	return imp.existing[item] && imp.options.Conflict == ConflictSkip
+}
+
+func (imp *importer) warn(format string, args ...interface{}) {
+	imp.result.Warnings = append(imp.result.Warnings, fmt.Sprintf(format, args...))
+}
+
+// importFolders creates the folders of the bundle. Existing folders are
+// reused, and updated if the conflict strategy is overwrite.
+func (imp *importer) importFolders(ctx context.Context) error {
+	folderService := dashboards.NewFolderService(imp.user.OrgId, imp.user, imp.s.SQLStore)
+	for i, folder := range imp.bundle.manifest.Folders {
+		parentUID := folder.ParentUID
+		if i == 0 {
+			parentUID = imp.options.ParentUID
+		}
+		item := Item{Kind: kindFolder, UID: folder.UID, Title: folder.Title}
+
+		if imp.skipped(item) {
+			existing, err := imp.s.getFolderByUID(ctx, imp.user.OrgId, folder.UID)
+			if err != nil {
+				return err
+			}
+			imp.folderIDs[folder.UID] = existing.Id
+			continue
+		}
+
+		if imp.existing[item] {
+			err := folderService.UpdateFolder(ctx, folder.UID, &models.UpdateFolderCommand{Title: folder.Title, Overwrite: true})
+			if err != nil {
+				return fmt.Errorf("failed to update folder %s: %w", folder.UID, err)
+			}
+			err = folderService.MoveFolder(ctx, folder.UID, &models.MoveFolderCommand{ParentUid: parentUID, Overwrite: true})
+			if err != nil {
+				return fmt.Errorf("failed to move folder %s: %w", folder.UID, err)
+			}
+		} else {
+			if _, err := folderService.CreateFolder(ctx, folder.Title, folder.UID, parentUID); err != nil {
+				return fmt.Errorf("failed to create folder %s: %w", folder.UID, err)
+			}
+		}
+
+		created, err := imp.s.getFolderByUID(ctx, imp.user.OrgId, folder.UID)
+		if err != nil {
+			return err
+		}
+		imp.folderIDs[folder.UID] = created.Id
+
+		if permissions, ok := imp.folderPermissions[folder.UID]; ok {
+			for _, permission := range permissions {
+				permission.DashboardID = created.Id
+			}
+			if err := imp.s.SQLStore.UpdateDashboardACLCtx(ctx, created.Id, permissions); err != nil {
+				return fmt.Errorf("failed to set the permissions of folder %s: %w", folder.UID, err)
+			}
+		}
+	}
+	return nil
+}
+
+// resolveFolderPermissions returns the permissions of a folder of the
+// bundle. Users and teams that don't exist in the org are skipped with a
+// warning.
+func (imp *importer) resolveFolderPermissions(ctx context.Context, folder Folder) ([]*models.DashboardAcl, error) {
+	items := []*models.DashboardAcl{}
+	err := imp.s.SQLStore.WithDbSession(ctx, func(sess *sqlstore.DBSession) error {
+		for _, permission := range folder.Permissions {
+			item := &models.DashboardAcl{
+				OrgID:      imp.user.OrgId,
+				Role:       permission.Role,
+				Permission: permission.Permission,
+				Created:    time.Now(),
+				Updated:    time.Now(),
+			}
+
+			switch {
+			case permission.UserLogin != "":
+				var userIDs []int64
+				err := sess.SQL("SELECT u.id FROM "+imp.s.SQLStore.Dialect.Quote("user")+" AS u"+
+					" INNER JOIN org_user AS ou ON ou.user_id = u.id WHERE ou.org_id = ? AND u.login = ?",
+					imp.user.OrgId, permission.UserLogin).Find(&userIDs)
+				if err != nil {
+					return err
+				}
+				if len(userIDs) == 0 {
+					imp.warn("user %s of the permissions of folder %s not found", permission.UserLogin, folder.UID)
+					continue
+				}
+				item.UserID = userIDs[0]
+			case permission.Team != "":
+				team := models.Team{}
+				exists, err := sess.Where("org_id=? AND name=?", imp.user.OrgId, permission.Team).Get(&team)
+				if err != nil {
+					return err
+				}
+				if !exists {
+					imp.warn("team %s of the permissions of folder %s not found", permission.Team, folder.UID)
+					continue
+				}
+				item.TeamID = team.Id
+			}
+			items = append(items, item)
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+func (imp *importer) prepareLibraryElement(element LibraryElement) ([]byte, error) {
+	data, err := imp.bundle.file(element.Path)
+	if err != nil {
+		return nil, err
+	}
+	var model interface{}
+	if err := json.Unmarshal(data, &model); err != nil {
+		return nil, fmt.Errorf("%w: %s: %v", errInvalidBundle, element.Path, err)
+	}
+	walkDataSourceRefs(model, imp.mapping.rewrite)
+	return json.Marshal(model)
+}
+
+func (imp *importer) importLibraryElements(ctx context.Context) error {
+	for _, element := range imp.bundle.manifest.LibraryElements {
+		model, ok := imp.libraryElementModels[element.UID]
+		if !ok {
+			continue
+		}
+
+		item := Item{Kind: kindLibraryElement, UID: element.UID, Title: element.Name}
+		folderID := imp.folderIDs[element.FolderUID]
+		var err error
+		if imp.existing[item] {
+			var existing libraryelements.LibraryElementDTO
+			existing, err = imp.s.LibraryElementService.GetElement(ctx, imp.user, element.UID)
+			if err == nil {
+				_, err = imp.s.LibraryElementService.PatchElement(ctx, imp.user, libraryelements.PatchLibraryElementCommand{
+					FolderID: folderID,
+					Name:     element.Name,
+					Model:    model,
+					Kind:     element.Kind,
+					Version:  existing.Version,
+				}, element.UID)
+			}
+		} else {
+			_, err = imp.s.LibraryElementService.CreateElement(ctx, imp.user, libraryelements.CreateLibraryElementCommand{
+				FolderID: folderID,
+				Name:     element.Name,
+				Model:    model,
+				Kind:     element.Kind,
+				UID:      element.UID,
+			})
+		}
+		if err != nil {
+			return fmt.Errorf("failed to import library element %s: %w", element.UID, err)
+		}
+	}
+	return nil
+}
+
+func (imp *importer) prepareDashboard(dashboard Dashboard) (*simplejson.Json, error) {
+	data, err := imp.bundle.file(dashboard.Path)
+	if err != nil {
+		return nil, err
+	}
+	dashJSON, err := simplejson.NewJson(data)
+	if err != nil {
+		return nil, fmt.Errorf("%w: %s: %v", errInvalidBundle, dashboard.Path, err)
+	}
+	dashJSON.Del("id")
+	dashJSON.Set("uid", dashboard.UID)
+	walkDataSourceRefs(dashJSON.Interface(), imp.mapping.rewrite)
+	return dashJSON, nil
+}
+
+func (imp *importer) importDashboards(ctx context.Context) error {
+	dashboardService := dashboards.NewService(imp.s.SQLStore)
+	for _, dashboard := range imp.bundle.manifest.Dashboards {
+		dashJSON, ok := imp.dashboards[dashboard.UID]
+		if !ok {
+			continue
+		}
+
+		item := Item{Kind: kindDashboard, UID: dashboard.UID, Title: dashboard.Title}
+		cmd := models.SaveDashboardCommand{
+			OrgId:     imp.user.OrgId,
+			UserId:    imp.user.UserId,
+			Dashboard: dashJSON,
+			FolderId:  imp.folderIDs[dashboard.FolderUID],
+			Overwrite: imp.existing[item],
+		}
+		dash, err := dashboardService.ImportDashboard(ctx, &dashboards.SaveDashboardDTO{
+			OrgId:     imp.user.OrgId,
+			User:      imp.user,
+			Overwrite: cmd.Overwrite,
+			Dashboard: cmd.GetDashboardModel(),
+		})
+		if err != nil {
+			return fmt.Errorf("failed to import dashboard %s: %w", dashboard.UID, err)
+		}
+
+		if err := imp.s.LibraryPanelService.ConnectLibraryPanelsForDashboard(ctx, imp.user, dash); err != nil {
+			return fmt.Errorf("failed to connect library panels of dashboard %s: %w", dashboard.UID, err)
+		}
+	}
+	return nil
+}
+
+func (imp *importer) prepareAlertRule(rule AlertRule) (store.UpsertRule, error) {
+	forDuration, err := time.ParseDuration(rule.For)
+	if err != nil {
+		return store.UpsertRule{}, fmt.Errorf("%w: alert rule %s: %v", errInvalidBundle, rule.UID, err)
+	}
+	data := make([]ngmodels.AlertQuery, 0, len(rule.Data))
+	for _, query := range rule.Data {
+		query.DatasourceUID = imp.mapping.rewriteUID(query.DatasourceUID)
+		var model interface{}
+		if err := json.Unmarshal(query.Model, &model); err == nil {
+			walkDataSourceRefs(model, imp.mapping.rewrite)
+			if query.Model, err = json.Marshal(model); err != nil {
+				return store.UpsertRule{}, err
+			}
+		}
+		data = append(data, query)
+	}
+
+	return store.UpsertRule{New: ngmodels.AlertRule{
+		OrgID:           imp.user.OrgId,
+		Title:           rule.Title,
+		Condition:       rule.Condition,
+		Data:            data,
+		IntervalSeconds: rule.IntervalSeconds,
+		NamespaceUID:    rule.FolderUID,
+		DashboardUID:    rule.DashboardUID,
+		PanelID:         rule.PanelID,
+		RuleGroup:       rule.RuleGroup,
+		NoDataState:     rule.NoDataState,
+		ExecErrState:    rule.ExecErrState,
+		For:             forDuration,
+		Annotations:     rule.Annotations,
+		Labels:          rule.Labels,
+	}}, nil
+}
+
+func (imp *importer) importAlertRules(ctx context.Context) error {
+	var rules []store.UpsertRule
+	for _, rule := range imp.bundle.manifest.AlertRules {
+		upsert, ok := imp.alertRules[rule.UID]
+		if !ok {
+			continue
+		}
+
+		item := Item{Kind: kindAlertRule, UID: rule.UID, Title: rule.Title}
+		if imp.existing[item] {
+			existing := ngmodels.AlertRule{}
+			err := imp.s.SQLStore.WithDbSession(ctx, func(sess *sqlstore.DBSession) error {
+				_, err := sess.Table("alert_rule").Where("org_id=? AND namespace_uid=? AND title=?",
+					imp.user.OrgId, rule.FolderUID, rule.Title).Get(&existing)
+				return err
+			})
+			if err != nil {
+				return err
+			}
+			upsert.Existing = &existing
+			upsert.New.UID = existing.UID
+		}
+		rules = append(rules, upsert)
+	}
+
+	if len(rules) == 0 {
+		return nil
+	}
+	if err := imp.s.alertRuleStore().UpsertAlertRules(ctx, rules); err != nil {
+		return fmt.Errorf("failed to import alert rules: %w", err)
+	}
+	return nil
+}
+
+func (s *Service) getFolderByUID(ctx context.Context, orgID int64, uid string) (*models.Dashboard, error) {
+	folder := models.Dashboard{}
+	err := s.SQLStore.WithDbSession(ctx, func(sess *sqlstore.DBSession) error {
+		exists, err := sess.Where("org_id=? AND uid=? AND is_folder=?", orgID, uid, s.SQLStore.Dialect.BooleanStr(true)).Get(&folder)
+		if err != nil {
+			return err
+		}
+		if !exists {
+			return errFolderNotFound
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return &folder, nil
+}
+
+// isBadRequest returns whether an import error is caused by the bundle or
+// the options.
+func isBadRequest(err error) bool {
+	return errors.Is(err, errInvalidBundle) || errors.Is(err, errInvalidConflict) || errors.Is(err, errUnsupportedVersion)
+}
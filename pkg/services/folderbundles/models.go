@@ -0,0 +1,182 @@
+package folderbundles
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strings"
+
+	"github.com/grafana/grafana/pkg/components/simplejson"
+	"github.com/grafana/grafana/pkg/models"
+	ngmodels "github.com/grafana/grafana/pkg/services/ngalert/models"
+)
+
+// bundleVersion is the version of the bundle format.
+const bundleVersion = 1
+
+// manifestPath is the path of the manifest in the bundle archive.
+const manifestPath = "bundle.json"
+
+var (
+	errFolderNotFound     = errors.New("folder not found")
+	errInvalidBundle      = errors.New("invalid bundle")
+	errInvalidConflict    = errors.New("invalid conflict strategy, must be one of fail, skip or overwrite")
+	errUnsupportedVersion = fmt.Errorf("unsupported bundle version, only version %d is supported", bundleVersion)
+)
+
+// ConflictStrategy is how objects of a bundle that already exist are imported.
+type ConflictStrategy string
+
+const (
+	// ConflictFail fails the import without importing anything.
+	ConflictFail ConflictStrategy = "fail"
+	// ConflictSkip keeps the existing objects. Existing folders are reused.
+	ConflictSkip ConflictStrategy = "skip"
+	// ConflictOverwrite replaces the existing objects.
+	ConflictOverwrite ConflictStrategy = "overwrite"
+)
+
+// Manifest describes the content of a bundle. Dashboards and library elements
+// are stored in separate files of the archive.
+type Manifest struct {
+	Version         int                    `json:"version"`
+	FolderUID       string                 `json:"folderUid"`
+	Folders         []Folder               `json:"folders"`
+	Dashboards      []Dashboard            `json:"dashboards"`
+	LibraryElements []LibraryElement       `json:"libraryElements"`
+	AlertRules      []AlertRule            `json:"alertRules"`
+	DataSources     []DataSourceDescriptor `json:"dataSources"`
+}
+
+// Folder is a folder of the bundle, parents are listed before their subfolders.
+type Folder struct {
+	UID         string       `json:"uid"`
+	Title       string       `json:"title"`
+	ParentUID   string       `json:"parentUid,omitempty"`
+	Permissions []Permission `json:"permissions"`
+}
+
+// Permission is a folder permission. Users are referenced by login and teams
+// by name, as ids differ between Grafana instances.
+type Permission struct {
+	Role       *models.RoleType      `json:"role,omitempty"`
+	UserLogin  string                `json:"userLogin,omitempty"`
+	Team       string                `json:"team,omitempty"`
+	Permission models.PermissionType `json:"permission"`
+}
+
+// Dashboard is a dashboard of the bundle.
+type Dashboard struct {
+	UID       string `json:"uid"`
+	Title     string `json:"title"`
+	FolderUID string `json:"folderUid"`
+	Path      string `json:"path"`
+}
+
+// LibraryElement is a library element of the bundle, either stored in one of
+// the folders or used by one of the dashboards.
+type LibraryElement struct {
+	UID       string `json:"uid"`
+	Name      string `json:"name"`
+	Kind      int64  `json:"kind"`
+	FolderUID string `json:"folderUid,omitempty"`
+	Path      string `json:"path"`
+}
+
+// AlertRule is a Grafana managed alert rule of the bundle.
+type AlertRule struct {
+	UID             string                       `json:"uid"`
+	Title           string                       `json:"title"`
+	FolderUID       string                       `json:"folderUid"`
+	RuleGroup       string                       `json:"ruleGroup"`
+	Condition       string                       `json:"condition"`
+	Data            []ngmodels.AlertQuery        `json:"data"`
+	IntervalSeconds int64                        `json:"intervalSeconds"`
+	NoDataState     ngmodels.NoDataState         `json:"noDataState"`
+	ExecErrState    ngmodels.ExecutionErrorState `json:"execErrState"`
+	For             string                       `json:"for"`
+	DashboardUID    *string                      `json:"dashboardUid,omitempty"`
+	PanelID         *int64                       `json:"panelId,omitempty"`
+	Annotations     map[string]string            `json:"annotations,omitempty"`
+	Labels          map[string]string            `json:"labels,omitempty"`
+}
+
+// DataSourceDescriptor describes a data source used by the bundle, without
+// its secrets.
+type DataSourceDescriptor struct {
+	UID       string           `json:"uid"`
+	Name      string           `json:"name"`
+	Type      string           `json:"type"`
+	Access    models.DsAccess  `json:"access"`
+	URL       string           `json:"url"`
+	Database  string           `json:"database,omitempty"`
+	User      string           `json:"user,omitempty"`
+	BasicAuth bool             `json:"basicAuth"`
+	JSONData  *simplejson.Json `json:"jsonData,omitempty"`
+}
+
+// ImportOptions are the options of a bundle import.
+type ImportOptions struct {
+	// ParentUID is the folder to import the bundle into, the bundle is
+	// imported at the top level if it's empty.
+	ParentUID string `json:"parentUid"`
+	// Conflict is how existing objects are handled, fail by default.
+	Conflict ConflictStrategy `json:"conflict"`
+	// DataSources maps the uids of data sources of the bundle to the uids of
+	// the data sources to use instead.
+	DataSources map[string]string `json:"dataSources"`
+}
+
+// Item is an object of a bundle.
+type Item struct {
+	Kind  string `json:"kind"`
+	UID   string `json:"uid"`
+	Title string `json:"title"`
+}
+
+const (
+	kindFolder         = "folder"
+	kindDashboard      = "dashboard"
+	kindLibraryElement = "libraryElement"
+	kindAlertRule      = "alertRule"
+)
+
+// ImportResult is the result of a bundle import.
+type ImportResult struct {
+	FolderUID string   `json:"folderUid"`
+	Imported  []Item   `json:"imported"`
+	Skipped   []Item   `json:"skipped"`
+	Warnings  []string `json:"warnings"`
+}
+
+// ConflictError is returned when objects of the bundle already exist and the
+// conflict strategy is fail.
+type ConflictError struct {
+	Conflicts []Item
+}
+
+func (e *ConflictError) Error() string {
+	uids := make([]string, 0, len(e.Conflicts))
+	for _, item := range e.Conflicts {
+		uids = append(uids, item.Kind+" "+item.UID)
+	}
+	return "objects of the bundle already exist: " + strings.Join(uids, ", ")
+}
+
+// UnresolvedDataSourcesError is returned when data sources of the bundle
+// don't exist and aren't mapped to other data sources.
+type UnresolvedDataSourcesError struct {
+	DataSources []DataSourceDescriptor
+}
+
+func (e *UnresolvedDataSourcesError) Error() string {
+	names := make([]string, 0, len(e.DataSources))
+	for _, ds := range e.DataSources {
+		names = append(names, fmt.Sprintf("%s (%s)", ds.Name, ds.UID))
+	}
+	return "data sources of the bundle not found: " + strings.Join(names, ", ")
+}
+
+func marshalIndent(v interface{}) ([]byte, error) {
+	return json.MarshalIndent(v, "", "  ")
+}
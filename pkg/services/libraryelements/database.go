@@ -433,7 +433,7 @@ func (l *LibraryElementService) handleFolderIDPatches(ctx context.Context, eleme
 }
 
 // patchLibraryElement updates a Library Element.
-func (l *LibraryElementService) patchLibraryElement(c context.Context, signedInUser *models.SignedInUser, cmd patchLibraryElementCommand, uid string) (LibraryElementDTO, error) {
+func (l *LibraryElementService) patchLibraryElement(c context.Context, signedInUser *models.SignedInUser, cmd PatchLibraryElementCommand, uid string) (LibraryElementDTO, error) {
 	var dto LibraryElementDTO
 	if err := l.requireSupportedElementKind(cmd.Kind); err != nil {
 		return LibraryElementDTO{}, err
@@ -697,13 +697,33 @@ func (l *LibraryElementService) deleteLibraryElementsInFolderUID(c context.Conte
 		if err := l.requirePermissionsOnFolder(c, signedInUser, folderID); err != nil {
 			return err
 		}
+
+		// subfolders are deleted with the folder, and inherit its permissions
+		folderIDs := []int64{folderID}
+		for parentIDs := folderIDs; len(parentIDs) > 0; {
+			var subfolderIDs []int64
+			err := session.Table("dashboard").In("folder_id", parentIDs).Where("org_id=? AND is_folder=?",
+				signedInUser.OrgId, l.SQLStore.Dialect.BooleanStr(true)).Cols("id").Find(&subfolderIDs)
+			if err != nil {
+				return err
+			}
+			folderIDs = append(folderIDs, subfolderIDs...)
+			parentIDs = subfolderIDs
+		}
+
+		folderIn := "(?" + strings.Repeat(",?", len(folderIDs)-1) + ")"
+		params := []interface{}{signedInUser.OrgId}
+		for _, id := range folderIDs {
+			params = append(params, id)
+		}
+
 		var connectionIDs []struct {
 			ConnectionID int64 `xorm:"connection_id"`
 		}
 		sql := "SELECT lec.connection_id FROM library_element AS le"
 		sql += " INNER JOIN " + models.LibraryElementConnectionTableName + " AS lec on le.id = lec.element_id"
-		sql += " WHERE le.folder_id=? AND le.org_id=?"
-		err = session.SQL(sql, folderID, signedInUser.OrgId).Find(&connectionIDs)
+		sql += " WHERE le.org_id=? AND le.folder_id IN " + folderIn
+		err = session.SQL(sql, params...).Find(&connectionIDs)
 		if err != nil {
 			return err
 		}
@@ -714,7 +734,7 @@ func (l *LibraryElementService) deleteLibraryElementsInFolderUID(c context.Conte
 		var elementIDs []struct {
 			ID int64 `xorm:"id"`
 		}
-		err = session.SQL("SELECT id from library_element WHERE folder_id=? AND org_id=?", folderID, signedInUser.OrgId).Find(&elementIDs)
+		err = session.SQL("SELECT id from library_element WHERE org_id=? AND folder_id IN "+folderIn, params...).Find(&elementIDs)
 		if err != nil {
 			return err
 		}
@@ -724,7 +744,8 @@ func (l *LibraryElementService) deleteLibraryElementsInFolderUID(c context.Conte
 				return err
 			}
 		}
-		if _, err := session.Exec("DELETE FROM library_element WHERE folder_id=? AND org_id=?", folderID, signedInUser.OrgId); err != nil {
+		deleteParams := append([]interface{}{"DELETE FROM library_element WHERE org_id=? AND folder_id IN " + folderIn}, params...)
+		if _, err := session.Exec(deleteParams...); err != nil {
 			return err
 		}
 
@@ -25,6 +25,7 @@ func ProvideService(cfg *setting.Cfg, sqlStore *sqlstore.SQLStore, routeRegister
 type Service interface {
 	CreateElement(c context.Context, signedInUser *models.SignedInUser, cmd CreateLibraryElementCommand) (LibraryElementDTO, error)
 	GetElement(c context.Context, signedInUser *models.SignedInUser, UID string) (LibraryElementDTO, error)
+	PatchElement(c context.Context, signedInUser *models.SignedInUser, cmd PatchLibraryElementCommand, UID string) (LibraryElementDTO, error)
 	GetElementsForDashboard(c context.Context, dashboardID int64) (map[string]LibraryElementDTO, error)
 	ConnectElementsToDashboard(c context.Context, signedInUser *models.SignedInUser, elementUIDs []string, dashboardID int64) error
 	DisconnectElementsFromDashboard(c context.Context, dashboardID int64) error
@@ -49,6 +50,11 @@ func (l *LibraryElementService) GetElement(c context.Context, signedInUser *mode
 	return l.getLibraryElementByUid(c, signedInUser, UID)
 }
 
+// PatchElement updates a Library Element.
+func (l *LibraryElementService) PatchElement(c context.Context, signedInUser *models.SignedInUser, cmd PatchLibraryElementCommand, UID string) (LibraryElementDTO, error) {
+	return l.patchLibraryElement(c, signedInUser, cmd, UID)
+}
+
 // GetElementsForDashboard gets all connected elements for a specific dashboard.
 func (l *LibraryElementService) GetElementsForDashboard(c context.Context, dashboardID int64) (map[string]LibraryElementDTO, error) {
 	return l.getElementsForDashboardID(c, dashboardID)
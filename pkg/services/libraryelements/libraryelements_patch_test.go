@@ -14,7 +14,7 @@ import (
 func TestPatchLibraryElement(t *testing.T) {
 	scenarioWithPanel(t, "When an admin tries to patch a library panel that does not exist, it should fail",
 		func(t *testing.T, sc scenarioContext) {
-			cmd := patchLibraryElementCommand{Kind: int64(models.PanelElement)}
+			cmd := PatchLibraryElementCommand{Kind: int64(models.PanelElement)}
 			sc.ctx.Req = web.SetURLParams(sc.ctx.Req, map[string]string{":uid": "unknown"})
 			resp := sc.service.patchHandler(sc.reqContext, cmd)
 			require.Equal(t, 404, resp.Status())
@@ -23,7 +23,7 @@ func TestPatchLibraryElement(t *testing.T) {
 	scenarioWithPanel(t, "When an admin tries to patch a library panel that exists, it should succeed",
 		func(t *testing.T, sc scenarioContext) {
 			newFolder := createFolderWithACL(t, sc.sqlStore, "NewFolder", sc.user, []folderACLItem{})
-			cmd := patchLibraryElementCommand{
+			cmd := PatchLibraryElementCommand{
 				FolderID: newFolder.Id,
 				Name:     "Panel - New name",
 				Model: []byte(`
@@ -85,7 +85,7 @@ func TestPatchLibraryElement(t *testing.T) {
 	scenarioWithPanel(t, "When an admin tries to patch a library panel with folder only, it should change folder successfully and return correct result",
 		func(t *testing.T, sc scenarioContext) {
 			newFolder := createFolderWithACL(t, sc.sqlStore, "NewFolder", sc.user, []folderACLItem{})
-			cmd := patchLibraryElementCommand{
+			cmd := PatchLibraryElementCommand{
 				FolderID: newFolder.Id,
 				Kind:     int64(models.PanelElement),
 				Version:  1,
@@ -106,7 +106,7 @@ func TestPatchLibraryElement(t *testing.T) {
 
 	scenarioWithPanel(t, "When an admin tries to patch a library panel with name only, it should change name successfully and return correct result",
 		func(t *testing.T, sc scenarioContext) {
-			cmd := patchLibraryElementCommand{
+			cmd := PatchLibraryElementCommand{
 				FolderID: -1,
 				Name:     "New Name",
 				Kind:     int64(models.PanelElement),
@@ -128,7 +128,7 @@ func TestPatchLibraryElement(t *testing.T) {
 
 	scenarioWithPanel(t, "When an admin tries to patch a library panel with a nonexistent UID, it should change UID successfully and return correct result",
 		func(t *testing.T, sc scenarioContext) {
-			cmd := patchLibraryElementCommand{
+			cmd := PatchLibraryElementCommand{
 				FolderID: -1,
 				UID:      util.GenerateShortUID(),
 				Kind:     int64(models.PanelElement),
@@ -150,7 +150,7 @@ func TestPatchLibraryElement(t *testing.T) {
 
 	scenarioWithPanel(t, "When an admin tries to patch a library panel with an invalid UID, it should fail",
 		func(t *testing.T, sc scenarioContext) {
-			cmd := patchLibraryElementCommand{
+			cmd := PatchLibraryElementCommand{
 				FolderID: -1,
 				UID:      "Testing an invalid UID",
 				Kind:     int64(models.PanelElement),
@@ -163,7 +163,7 @@ func TestPatchLibraryElement(t *testing.T) {
 
 	scenarioWithPanel(t, "When an admin tries to patch a library panel with an UID that is too long, it should fail",
 		func(t *testing.T, sc scenarioContext) {
-			cmd := patchLibraryElementCommand{
+			cmd := PatchLibraryElementCommand{
 				FolderID: -1,
 				UID:      "j6T00KRZzj6T00KRZzj6T00KRZzj6T00KRZzj6T00K",
 				Kind:     int64(models.PanelElement),
@@ -180,7 +180,7 @@ func TestPatchLibraryElement(t *testing.T) {
 			command.UID = util.GenerateShortUID()
 			resp := sc.service.createHandler(sc.reqContext, command)
 			require.Equal(t, 200, resp.Status())
-			cmd := patchLibraryElementCommand{
+			cmd := PatchLibraryElementCommand{
 				FolderID: -1,
 				UID:      command.UID,
 				Kind:     int64(models.PanelElement),
@@ -193,7 +193,7 @@ func TestPatchLibraryElement(t *testing.T) {
 
 	scenarioWithPanel(t, "When an admin tries to patch a library panel with model only, it should change model successfully, sync type and description fields and return correct result",
 		func(t *testing.T, sc scenarioContext) {
-			cmd := patchLibraryElementCommand{
+			cmd := PatchLibraryElementCommand{
 				FolderID: -1,
 				Model:    []byte(`{ "title": "New Model Title", "name": "New Model Name", "type":"graph", "description": "New description" }`),
 				Kind:     int64(models.PanelElement),
@@ -221,7 +221,7 @@ func TestPatchLibraryElement(t *testing.T) {
 
 	scenarioWithPanel(t, "When an admin tries to patch a library panel with model.description only, it should change model successfully, sync type and description fields and return correct result",
 		func(t *testing.T, sc scenarioContext) {
-			cmd := patchLibraryElementCommand{
+			cmd := PatchLibraryElementCommand{
 				FolderID: -1,
 				Model:    []byte(`{ "description": "New description" }`),
 				Kind:     int64(models.PanelElement),
@@ -247,7 +247,7 @@ func TestPatchLibraryElement(t *testing.T) {
 
 	scenarioWithPanel(t, "When an admin tries to patch a library panel with model.type only, it should change model successfully, sync type and description fields and return correct result",
 		func(t *testing.T, sc scenarioContext) {
-			cmd := patchLibraryElementCommand{
+			cmd := PatchLibraryElementCommand{
 				FolderID: -1,
 				Model:    []byte(`{ "type": "graph" }`),
 				Kind:     int64(models.PanelElement),
@@ -273,7 +273,7 @@ func TestPatchLibraryElement(t *testing.T) {
 
 	scenarioWithPanel(t, "When another admin tries to patch a library panel, it should change UpdatedBy successfully and return correct result",
 		func(t *testing.T, sc scenarioContext) {
-			cmd := patchLibraryElementCommand{FolderID: -1, Version: 1, Kind: int64(models.PanelElement)}
+			cmd := PatchLibraryElementCommand{FolderID: -1, Version: 1, Kind: int64(models.PanelElement)}
 			sc.reqContext.UserId = 2
 			sc.ctx.Req = web.SetURLParams(sc.ctx.Req, map[string]string{":uid": sc.initialResult.Result.UID})
 			resp := sc.service.patchHandler(sc.reqContext, cmd)
@@ -293,7 +293,7 @@ func TestPatchLibraryElement(t *testing.T) {
 			command := getCreatePanelCommand(sc.folder.Id, "Another Panel")
 			resp := sc.service.createHandler(sc.reqContext, command)
 			var result = validateAndUnMarshalResponse(t, resp)
-			cmd := patchLibraryElementCommand{
+			cmd := PatchLibraryElementCommand{
 				Name:    "Text - Library Panel",
 				Version: 1,
 				Kind:    int64(models.PanelElement),
@@ -309,7 +309,7 @@ func TestPatchLibraryElement(t *testing.T) {
 			command := getCreatePanelCommand(newFolder.Id, "Text - Library Panel")
 			resp := sc.service.createHandler(sc.reqContext, command)
 			var result = validateAndUnMarshalResponse(t, resp)
-			cmd := patchLibraryElementCommand{
+			cmd := PatchLibraryElementCommand{
 				FolderID: 1,
 				Version:  1,
 				Kind:     int64(models.PanelElement),
@@ -321,7 +321,7 @@ func TestPatchLibraryElement(t *testing.T) {
 
 	scenarioWithPanel(t, "When an admin tries to patch a library panel in another org, it should fail",
 		func(t *testing.T, sc scenarioContext) {
-			cmd := patchLibraryElementCommand{
+			cmd := PatchLibraryElementCommand{
 				FolderID: sc.folder.Id,
 				Version:  1,
 				Kind:     int64(models.PanelElement),
@@ -334,7 +334,7 @@ func TestPatchLibraryElement(t *testing.T) {
 
 	scenarioWithPanel(t, "When an admin tries to patch a library panel with an old version number, it should fail",
 		func(t *testing.T, sc scenarioContext) {
-			cmd := patchLibraryElementCommand{
+			cmd := PatchLibraryElementCommand{
 				FolderID: sc.folder.Id,
 				Version:  1,
 				Kind:     int64(models.PanelElement),
@@ -348,7 +348,7 @@ func TestPatchLibraryElement(t *testing.T) {
 
 	scenarioWithPanel(t, "When an admin tries to patch a library panel with an other kind, it should succeed but panel should not change",
 		func(t *testing.T, sc scenarioContext) {
-			cmd := patchLibraryElementCommand{
+			cmd := PatchLibraryElementCommand{
 				FolderID: sc.folder.Id,
 				Version:  1,
 				Kind:     int64(models.VariableElement),
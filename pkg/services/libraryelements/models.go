@@ -166,8 +166,8 @@ type CreateLibraryElementCommand struct {
 	UID      string          `json:"uid"`
 }
 
-// patchLibraryElementCommand is the command for patching a LibraryElement
-type patchLibraryElementCommand struct {
+// PatchLibraryElementCommand is the command for patching a LibraryElement
+type PatchLibraryElementCommand struct {
 	FolderID int64           `json:"folderId" binding:"Default(-1)"`
 	Name     string          `json:"name"`
 	Model    json.RawMessage `json:"model"`
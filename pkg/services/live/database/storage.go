@@ -1,66 +1,131 @@
 package database
 
 import (
+	"context"
+	"errors"
 	"fmt"
 	"time"
 
-	"github.com/grafana/grafana/pkg/infra/localcache"
+	"github.com/grafana/grafana/pkg/infra/remotecache"
 	"github.com/grafana/grafana/pkg/models"
 	"github.com/grafana/grafana/pkg/services/sqlstore"
+	"github.com/grafana/grafana/pkg/setting"
 )
 
+func init() {
+	remotecache.Register(models.LiveMessage{})
+}
+
+// Storage keeps the last message published into broadcast channels so new
+// subscribers get a consistent initial state. Messages are kept either in
+// the Grafana database or in the remote cache and expire after a TTL.
 type Storage struct {
-	store *sqlstore.SQLStore
-	cache *localcache.CacheService
+	store       *sqlstore.SQLStore
+	remoteCache *remotecache.RemoteCache
+	storageType string
+	ttl         time.Duration
+}
+
+func ProvideStorage(cfg *setting.Cfg, store *sqlstore.SQLStore, remoteCache *remotecache.RemoteCache) *Storage {
+	return NewStorage(store, remoteCache, cfg.LiveMessageStorage, cfg.LiveMessageTTL)
 }
 
-func NewStorage(store *sqlstore.SQLStore, cache *localcache.CacheService) *Storage {
-	return &Storage{store: store, cache: cache}
+func NewStorage(store *sqlstore.SQLStore, remoteCache *remotecache.RemoteCache, storageType string, ttl time.Duration) *Storage {
+	return &Storage{store: store, remoteCache: remoteCache, storageType: storageType, ttl: ttl}
+}
+
+// liveMessage is a database representation of models.LiveMessage.
+type liveMessage struct {
+	Id        int64
+	OrgId     int64
+	Channel   string
+	Data      string
+	Published time.Time
 }
 
 func getLiveMessageCacheKey(orgID int64, channel string) string {
 	return fmt.Sprintf("live_message_%d_%s", orgID, channel)
 }
 
+func (s *Storage) useRemoteCache() bool {
+	return s.storageType == setting.LiveMessageStorageRemoteCache
+}
+
 func (s *Storage) SaveLiveMessage(query *models.SaveLiveMessageQuery) error {
-	// Come back to saving into database after evaluating database structure.
-	//err := s.store.WithDbSession(context.Background(), func(sess *sqlstore.DBSession) error {
-	//	params := []interface{}{query.OrgId, query.Channel, query.Data, time.Now()}
-	//	upsertSQL := s.store.Dialect.UpsertSQL(
-	//		"live_message",
-	//		[]string{"org_id", "channel"},
-	//		[]string{"org_id", "channel", "data", "published"})
-	//	_, err := sess.SQL(upsertSQL, params...).Query()
-	//	return err
-	//})
-	// return err
-	s.cache.Set(getLiveMessageCacheKey(query.OrgId, query.Channel), models.LiveMessage{
-		Id:        0, // Not used actually.
-		OrgId:     query.OrgId,
-		Channel:   query.Channel,
-		Data:      query.Data,
-		Published: time.Now(),
-	}, 0)
-	return nil
+	published := time.Now()
+
+	if s.useRemoteCache() {
+		return s.remoteCache.Set(getLiveMessageCacheKey(query.OrgId, query.Channel), models.LiveMessage{
+			Id:        0, // Not used actually.
+			OrgId:     query.OrgId,
+			Channel:   query.Channel,
+			Data:      query.Data,
+			Published: published,
+		}, s.ttl)
+	}
+
+	return s.store.WithDbSession(context.Background(), func(sess *sqlstore.DBSession) error {
+		params := []interface{}{query.OrgId, query.Channel, string(query.Data), published}
+		upsertSQL := s.store.Dialect.UpsertSQL(
+			"live_message",
+			[]string{"org_id", "channel"},
+			[]string{"org_id", "channel", "data", "published"})
+		_, err := sess.SQL(upsertSQL, params...).Query()
+		return err
+	})
 }
 
 func (s *Storage) GetLiveMessage(query *models.GetLiveMessageQuery) (models.LiveMessage, bool, error) {
-	// Come back to saving into database after evaluating database structure.
-	//var msg models.LiveMessage
-	//var exists bool
-	//err := s.store.WithDbSession(context.Background(), func(sess *sqlstore.DBSession) error {
-	//	var err error
-	//	exists, err = sess.Where("org_id=? AND channel=?", query.OrgId, query.Channel).Get(&msg)
-	//	return err
-	//})
-	//return msg, exists, err
-	m, ok := s.cache.Get(getLiveMessageCacheKey(query.OrgId, query.Channel))
-	if !ok {
-		return models.LiveMessage{}, false, nil
+	if s.useRemoteCache() {
+		m, err := s.remoteCache.Get(getLiveMessageCacheKey(query.OrgId, query.Channel))
+		if err != nil {
+			if errors.Is(err, remotecache.ErrCacheItemNotFound) {
+				return models.LiveMessage{}, false, nil
+			}
+			return models.LiveMessage{}, false, err
+		}
+		msg, ok := m.(models.LiveMessage)
+		if !ok {
+			return models.LiveMessage{}, false, fmt.Errorf("unexpected live message type in cache: %T", m)
+		}
+		return msg, true, nil
 	}
-	msg, ok := m.(models.LiveMessage)
-	if !ok {
-		return models.LiveMessage{}, false, fmt.Errorf("unexpected live message type in cache: %T", m)
+
+	var msg liveMessage
+	var exists bool
+	err := s.store.WithDbSession(context.Background(), func(sess *sqlstore.DBSession) error {
+		var err error
+		exists, err = sess.Table("live_message").
+			Where("org_id=? AND channel=? AND published>?", query.OrgId, query.Channel, time.Now().Add(-s.ttl)).
+			Get(&msg)
+		return err
+	})
+	if err != nil || !exists {
+		return models.LiveMessage{}, false, err
+	}
+	return models.LiveMessage{
+		Id:        msg.Id,
+		OrgId:     msg.OrgId,
+		Channel:   msg.Channel,
+		Data:      []byte(msg.Data),
+		Published: msg.Published,
+	}, true, nil
+}
+
+// DeleteExpiredLiveMessages removes messages older than the configured TTL
+// from the database. Messages in the remote cache expire on their own.
+func (s *Storage) DeleteExpiredLiveMessages(ctx context.Context) (int64, error) {
+	if s.useRemoteCache() {
+		return 0, nil
 	}
-	return msg, true, nil
+	var affected int64
+	err := s.store.WithDbSession(ctx, func(sess *sqlstore.DBSession) error {
+		res, err := sess.Exec("DELETE FROM live_message WHERE published<=?", time.Now().Add(-s.ttl))
+		if err != nil {
+			return err
+		}
+		affected, err = res.RowsAffected()
+		return err
+	})
+	return affected, err
 }
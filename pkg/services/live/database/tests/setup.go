@@ -4,15 +4,19 @@ import (
 	"testing"
 	"time"
 
-	"github.com/grafana/grafana/pkg/infra/localcache"
 	"github.com/grafana/grafana/pkg/services/live/database"
 	"github.com/grafana/grafana/pkg/services/sqlstore"
+	"github.com/grafana/grafana/pkg/setting"
 )
 
 // SetupTestStorage initializes a storage to used by the integration tests.
 // This is required to properly register and execute migrations.
 func SetupTestStorage(t *testing.T) *database.Storage {
+	return SetupTestStorageWithTTL(t, time.Hour)
+}
+
+// SetupTestStorageWithTTL initializes a database backed storage with a custom message TTL.
+func SetupTestStorageWithTTL(t *testing.T, ttl time.Duration) *database.Storage {
 	sqlStore := sqlstore.InitTestDB(t)
-	localCache := localcache.New(time.Hour, time.Hour)
-	return database.NewStorage(sqlStore, localCache)
+	return database.NewStorage(sqlStore, nil, setting.LiveMessageStorageDatabase, ttl)
 }
@@ -4,8 +4,10 @@
 package tests
 
 import (
+	"context"
 	"encoding/json"
 	"testing"
+	"time"
 
 	"github.com/grafana/grafana/pkg/models"
 
@@ -60,3 +62,32 @@ func TestLiveMessage(t *testing.T) {
 	require.Equal(t, json.RawMessage(`{"input": "hello"}`), msg2.Data)
 	require.NotZero(t, msg2.Published)
 }
+
+func TestLiveMessageExpiration(t *testing.T) {
+	storage := SetupTestStorageWithTTL(t, 100*time.Millisecond)
+
+	err := storage.SaveLiveMessage(&models.SaveLiveMessageQuery{
+		OrgId:   1,
+		Channel: "test_channel",
+		Data:    []byte(`{}`),
+	})
+	require.NoError(t, err)
+
+	getQuery := &models.GetLiveMessageQuery{
+		OrgId:   1,
+		Channel: "test_channel",
+	}
+	_, ok, err := storage.GetLiveMessage(getQuery)
+	require.NoError(t, err)
+	require.True(t, ok)
+
+	time.Sleep(200 * time.Millisecond)
+
+	_, ok, err = storage.GetLiveMessage(getQuery)
+	require.NoError(t, err)
+	require.False(t, ok)
+
+	deleted, err := storage.DeleteExpiredLiveMessages(context.Background())
+	require.NoError(t, err)
+	require.Equal(t, int64(1), deleted)
+}
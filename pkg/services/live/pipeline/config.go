@@ -57,6 +57,8 @@ type FrameOutputterConfig struct {
 	ThresholdOutputConfig   *ThresholdOutputConfig     `json:"threshold,omitempty"`
 	RemoteWriteOutputConfig *RemoteWriteOutputConfig   `json:"remoteWrite,omitempty"`
 	ChangeLogOutputConfig   *ChangeLogOutputConfig     `json:"changeLog,omitempty"`
+	WebhookOutputConfig     *WebhookOutputConfig       `json:"webhook,omitempty"`
+	LokiOutputConfig        *LokiOutputConfig          `json:"loki,omitempty"`
 }
 
 type DataOutputterConfig struct {
@@ -395,6 +397,16 @@ func (f *StorageRuleBuilder) extractFrameOutputter(config *FrameOutputterConfig,
 			return nil, missingConfiguration
 		}
 		return NewChangeLogFrameOutput(f.FrameStorage, *config.ChangeLogOutputConfig), nil
+	case FrameOutputTypeWebhook:
+		if config.WebhookOutputConfig == nil {
+			return nil, missingConfiguration
+		}
+		return NewWebhookFrameOutput(*config.WebhookOutputConfig)
+	case FrameOutputTypeLoki:
+		if config.LokiOutputConfig == nil {
+			return nil, missingConfiguration
+		}
+		return NewLokiFrameOutput(*config.LokiOutputConfig)
 	default:
 		return nil, fmt.Errorf("unknown output type: %s", config.Type)
 	}
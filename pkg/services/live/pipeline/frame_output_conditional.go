@@ -21,6 +21,11 @@ func (out *ConditionalOutput) Type() string {
 	return FrameOutputTypeConditional
 }
 
+func (out *ConditionalOutput) Close() error {
+	closeFrameOutputters([]FrameOutputter{out.Outputter})
+	return nil
+}
+
 func (out ConditionalOutput) OutputFrame(ctx context.Context, vars Vars, frame *data.Frame) ([]*ChannelFrame, error) {
 	ok, err := out.Condition.CheckFrameCondition(ctx, frame)
 	if err != nil {
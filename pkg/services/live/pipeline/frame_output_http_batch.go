@@ -0,0 +1,249 @@
+package pipeline
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"net/http"
+	"sync"
+	"time"
+)
+
+const (
+	defaultOutputBatchSize     = 100
+	defaultOutputFlushInterval = time.Second
+	defaultOutputMaxRetries    = 3
+	defaultOutputRetryBackoff  = 500 * time.Millisecond
+	// Buffer is never allowed to grow above this number of batches, oldest
+	// items are dropped when endpoint is not available for a long time.
+	maxOutputBufferedBatches = 10
+	// Max time spent sending buffered items when an output is closed.
+	outputCloseFlushTimeout = 5 * time.Second
+)
+
+// BatchConfig controls batching and retries of outputs which push
+// frames to external HTTP endpoints.
+type BatchConfig struct {
+	// BatchSize is a max number of items sent in one request. Default 100.
+	BatchSize int `json:"batchSize,omitempty"`
+	// FlushIntervalMilliseconds is how often buffered items are sent even
+	// if batch is not full. Default 1000.
+	FlushIntervalMilliseconds int64 `json:"flushIntervalMs,omitempty"`
+	// MaxRetries is a number of additional attempts to send a batch after
+	// a network error, 429 or 5xx response. Default 3.
+	MaxRetries int `json:"maxRetries,omitempty"`
+	// RetryBackoffMilliseconds is an initial delay between retries, it's
+	// doubled after each attempt. Default 500.
+	RetryBackoffMilliseconds int64 `json:"retryBackoffMs,omitempty"`
+}
+
+func (c BatchConfig) batchSize() int {
+	if c.BatchSize <= 0 {
+		return defaultOutputBatchSize
+	}
+	return c.BatchSize
+}
+
+func (c BatchConfig) flushInterval() time.Duration {
+	if c.FlushIntervalMilliseconds <= 0 {
+		return defaultOutputFlushInterval
+	}
+	return time.Duration(c.FlushIntervalMilliseconds) * time.Millisecond
+}
+
+func (c BatchConfig) maxRetries() int {
+	if c.MaxRetries < 0 {
+		return 0
+	}
+	if c.MaxRetries == 0 {
+		return defaultOutputMaxRetries
+	}
+	return c.MaxRetries
+}
+
+func (c BatchConfig) retryBackoff() time.Duration {
+	if c.RetryBackoffMilliseconds <= 0 {
+		return defaultOutputRetryBackoff
+	}
+	return time.Duration(c.RetryBackoffMilliseconds) * time.Millisecond
+}
+
+// errPermanent wraps errors which should not lead to retries.
+type errPermanent struct {
+	err error
+}
+
+func (e errPermanent) Error() string {
+	return e.err.Error()
+}
+
+func (e errPermanent) Unwrap() error {
+	return e.err
+}
+
+// checkOutputResponse checks the response of an external endpoint, 2xx
+// is a success, 429 and 5xx can be retried, everything else is permanent.
+func checkOutputResponse(resp *http.Response) error {
+	defer func() { _ = resp.Body.Close() }()
+	if resp.StatusCode >= 200 && resp.StatusCode < 300 {
+		_, _ = io.Copy(ioutil.Discard, resp.Body)
+		return nil
+	}
+	body, _ := ioutil.ReadAll(io.LimitReader(resp.Body, 512))
+	err := fmt.Errorf("unexpected response code %d: %s", resp.StatusCode, string(body))
+	if resp.StatusCode == http.StatusTooManyRequests || resp.StatusCode >= 500 {
+		return err
+	}
+	return errPermanent{err: err}
+}
+
+// batchSender buffers items and sends them in batches with retries.
+type batchSender struct {
+	mu     sync.Mutex
+	buffer []interface{}
+	// closed is set under mu by Close, so items are never appended to
+	// the buffer after the final flush.
+	closed bool
+	config BatchConfig
+	send   func(ctx context.Context, items []interface{}) error
+	// sleep is used to wait between retries, overridden in tests.
+	sleep   func(ctx context.Context, d time.Duration) error
+	flushCh chan struct{}
+
+	closeOnce sync.Once
+	done      chan struct{}
+	// stopped is closed once run returns, nil if sender was not started.
+	stopped chan struct{}
+}
+
+func newBatchSender(config BatchConfig, send func(ctx context.Context, items []interface{}) error) *batchSender {
+	return &batchSender{
+		config:  config,
+		send:    send,
+		sleep:   sleepContext,
+		flushCh: make(chan struct{}, 1),
+		done:    make(chan struct{}),
+	}
+}
+
+func sleepContext(ctx context.Context, d time.Duration) error {
+	timer := time.NewTimer(d)
+	defer timer.Stop()
+	select {
+	case <-ctx.Done():
+		return ctx.Err()
+	case <-timer.C:
+		return nil
+	}
+}
+
+// Add appends items to the buffer and triggers flush if batch is full.
+// Items added after Close are dropped.
+func (s *batchSender) Add(items ...interface{}) {
+	s.mu.Lock()
+	if s.closed {
+		s.mu.Unlock()
+		logger.Debug("Output is closed, dropping items", "dropped", len(items))
+		return
+	}
+	s.buffer = append(s.buffer, items...)
+	if maxItems := maxOutputBufferedBatches * s.config.batchSize(); len(s.buffer) > maxItems {
+		logger.Warn("Output buffer is full, dropping oldest items", "dropped", len(s.buffer)-maxItems)
+		s.buffer = s.buffer[len(s.buffer)-maxItems:]
+	}
+	full := len(s.buffer) >= s.config.batchSize()
+	s.mu.Unlock()
+	if full {
+		select {
+		case s.flushCh <- struct{}{}:
+		default:
+		}
+	}
+}
+
+// start sends buffered items in background until Close is called.
+func (s *batchSender) start() {
+	s.stopped = make(chan struct{})
+	go s.run()
+}
+
+func (s *batchSender) run() {
+	defer close(s.stopped)
+	ticker := time.NewTicker(s.config.flushInterval())
+	defer ticker.Stop()
+	for {
+		select {
+		case <-s.done:
+			ctx, cancel := context.WithTimeout(context.Background(), outputCloseFlushTimeout)
+			s.flush(ctx)
+			cancel()
+			return
+		case <-ticker.C:
+		case <-s.flushCh:
+		}
+		s.flush(context.Background())
+	}
+}
+
+// Close stops the sender. It waits until buffered items are flushed and
+// the background goroutine exits.
+func (s *batchSender) Close() {
+	s.closeOnce.Do(func() {
+		s.mu.Lock()
+		s.closed = true
+		s.mu.Unlock()
+		close(s.done)
+	})
+	if s.stopped != nil {
+		<-s.stopped
+	}
+}
+
+// flush sends all buffered items batch by batch.
+func (s *batchSender) flush(ctx context.Context) {
+	for {
+		s.mu.Lock()
+		if len(s.buffer) == 0 {
+			s.mu.Unlock()
+			return
+		}
+		n := s.config.batchSize()
+		if n > len(s.buffer) {
+			n = len(s.buffer)
+		}
+		batch := make([]interface{}, n)
+		copy(batch, s.buffer[:n])
+		s.buffer = s.buffer[n:]
+		s.mu.Unlock()
+
+		if err := s.sendWithRetries(ctx, batch); err != nil {
+			logger.Error("Error sending output batch, dropping it", "error", err, "numItems", len(batch))
+		}
+	}
+}
+
+func (s *batchSender) sendWithRetries(ctx context.Context, batch []interface{}) error {
+	backoff := s.config.retryBackoff()
+	maxRetries := s.config.maxRetries()
+	var err error
+	for attempt := 0; attempt <= maxRetries; attempt++ {
+		if attempt > 0 {
+			logger.Debug("Retrying output batch", "attempt", attempt, "backoff", backoff, "error", err)
+			if sleepErr := s.sleep(ctx, backoff); sleepErr != nil {
+				return sleepErr
+			}
+			backoff *= 2
+		}
+		err = s.send(ctx, batch)
+		if err == nil {
+			return nil
+		}
+		var permanent errPermanent
+		if errors.As(err, &permanent) {
+			return err
+		}
+	}
+	return err
+}
@@ -0,0 +1,268 @@
+package pipeline
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+	"text/template"
+	"time"
+
+	"github.com/grafana/grafana-plugin-sdk-go/data"
+)
+
+type LokiOutputConfig struct {
+	// Endpoint is a Loki push API URL, for example http://localhost:3100/loki/api/v1/push.
+	Endpoint string `json:"endpoint"`
+	// User is a user for basic authentication.
+	User string `json:"user,omitempty"`
+	// Password for basic authentication.
+	Password string `json:"password,omitempty"`
+	// TenantID is sent in X-Scope-OrgID header for multi-tenant Loki setups.
+	TenantID string `json:"tenantId,omitempty"`
+	// Labels are static labels attached to each log line. Label channel is
+	// always attached unless overridden here.
+	Labels map[string]string `json:"labels,omitempty"`
+	// LabelFields is a list of frame fields which values become labels.
+	LabelFields []string `json:"labelFields,omitempty"`
+	// LineTemplate is a Go text/template executed over each frame row to build
+	// a log line. Template data has Channel, Name and Row (map of field values).
+	// By default row is formatted as logfmt.
+	LineTemplate string `json:"lineTemplate,omitempty"`
+
+	BatchConfig
+}
+
+type lokiEntry struct {
+	labels map[string]string
+	time   time.Time
+	line   string
+}
+
+type lokiLineTemplateData struct {
+	Channel string
+	Name    string
+	Row     map[string]interface{}
+}
+
+type lokiStream struct {
+	Stream map[string]string `json:"stream"`
+	Values [][2]string       `json:"values"`
+}
+
+type lokiPushRequest struct {
+	Streams []lokiStream `json:"streams"`
+}
+
+var invalidLokiLabelChars = regexp.MustCompile(`[^a-zA-Z0-9_]`)
+
+func lokiLabelName(name string) string {
+	name = invalidLokiLabelChars.ReplaceAllString(name, "_")
+	if name == "" || (name[0] >= '0' && name[0] <= '9') {
+		name = "_" + name
+	}
+	return name
+}
+
+// LokiFrameOutput turns frame rows into log lines and pushes them to Loki.
+// Frame rows time is taken from the first time field of a frame.
+type LokiFrameOutput struct {
+	config     LokiOutputConfig
+	template   *template.Template
+	httpClient *http.Client
+	sender     *batchSender
+}
+
+func NewLokiFrameOutput(config LokiOutputConfig) (*LokiFrameOutput, error) {
+	out := &LokiFrameOutput{
+		config:     config,
+		httpClient: &http.Client{Timeout: 5 * time.Second},
+	}
+	if config.LineTemplate != "" {
+		tmpl, err := template.New("line").Funcs(outputTemplateFuncs).Parse(config.LineTemplate)
+		if err != nil {
+			return nil, fmt.Errorf("error parsing loki line template: %w", err)
+		}
+		out.template = tmpl
+	}
+	out.sender = newBatchSender(config.BatchConfig, out.send)
+	if config.Endpoint != "" {
+		out.sender.start()
+	}
+	return out, nil
+}
+
+const FrameOutputTypeLoki = "loki"
+
+func (out *LokiFrameOutput) Type() string {
+	return FrameOutputTypeLoki
+}
+
+// Close flushes buffered frames and stops sending them.
+func (out *LokiFrameOutput) Close() error {
+	out.sender.Close()
+	return nil
+}
+
+func (out *LokiFrameOutput) OutputFrame(_ context.Context, vars Vars, frame *data.Frame) ([]*ChannelFrame, error) {
+	if out.config.Endpoint == "" {
+		logger.Debug("Skip sending to loki: no url")
+		return nil, nil
+	}
+	if frame == nil {
+		return nil, nil
+	}
+	entries, err := out.frameToEntries(vars, frame)
+	if err != nil {
+		return nil, err
+	}
+	for _, e := range entries {
+		out.sender.Add(e)
+	}
+	return nil, nil
+}
+
+func (out *LokiFrameOutput) frameToEntries(vars Vars, frame *data.Frame) ([]lokiEntry, error) {
+	timeFieldIndex := -1
+	for i, f := range frame.Fields {
+		if f.Type() == data.FieldTypeTime || f.Type() == data.FieldTypeNullableTime {
+			timeFieldIndex = i
+			break
+		}
+	}
+
+	labelFields := make(map[string]struct{}, len(out.config.LabelFields))
+	for _, name := range out.config.LabelFields {
+		labelFields[name] = struct{}{}
+	}
+
+	now := time.Now()
+	var entries []lokiEntry
+	for _, row := range frameRows(frame) {
+		labels := map[string]string{"channel": vars.Channel}
+		for k, v := range out.config.Labels {
+			labels[lokiLabelName(k)] = v
+		}
+		for name := range labelFields {
+			if v, ok := row[name]; ok && v != nil {
+				labels[lokiLabelName(name)] = fmt.Sprintf("%v", v)
+			}
+		}
+
+		ts := now
+		if timeFieldIndex >= 0 {
+			if t, ok := row[frame.Fields[timeFieldIndex].Name].(time.Time); ok {
+				ts = t
+			}
+		}
+
+		line, err := out.formatLine(vars, frame, row, labelFields, timeFieldIndex)
+		if err != nil {
+			return nil, err
+		}
+		entries = append(entries, lokiEntry{labels: labels, time: ts, line: line})
+	}
+	return entries, nil
+}
+
+func (out *LokiFrameOutput) formatLine(vars Vars, frame *data.Frame, row map[string]interface{}, labelFields map[string]struct{}, timeFieldIndex int) (string, error) {
+	if out.template != nil {
+		var buf bytes.Buffer
+		err := out.template.Execute(&buf, lokiLineTemplateData{Channel: vars.Channel, Name: frame.Name, Row: row})
+		if err != nil {
+			return "", fmt.Errorf("error executing loki line template: %w", err)
+		}
+		return buf.String(), nil
+	}
+
+	var parts []string
+	for i, f := range frame.Fields {
+		if i == timeFieldIndex {
+			continue
+		}
+		if _, ok := labelFields[f.Name]; ok {
+			continue
+		}
+		v := row[f.Name]
+		var value string
+		switch val := v.(type) {
+		case nil:
+			value = ""
+		case string:
+			value = strconv.Quote(val)
+		default:
+			value = fmt.Sprintf("%v", val)
+		}
+		parts = append(parts, lokiLabelName(f.Name)+"="+value)
+	}
+	return strings.Join(parts, " "), nil
+}
+
+func lokiLabelsKey(labels map[string]string) string {
+	keys := make([]string, 0, len(labels))
+	for k := range labels {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	var sb strings.Builder
+	for _, k := range keys {
+		sb.WriteString(k)
+		sb.WriteByte('=')
+		sb.WriteString(labels[k])
+		sb.WriteByte(',')
+	}
+	return sb.String()
+}
+
+func lokiPushRequestFromEntries(entries []lokiEntry) lokiPushRequest {
+	var req lokiPushRequest
+	streamIndex := map[string]int{}
+	for _, e := range entries {
+		key := lokiLabelsKey(e.labels)
+		idx, ok := streamIndex[key]
+		if !ok {
+			idx = len(req.Streams)
+			streamIndex[key] = idx
+			req.Streams = append(req.Streams, lokiStream{Stream: e.labels})
+		}
+		req.Streams[idx].Values = append(req.Streams[idx].Values, [2]string{
+			strconv.FormatInt(e.time.UnixNano(), 10), e.line,
+		})
+	}
+	return req
+}
+
+func (out *LokiFrameOutput) send(ctx context.Context, items []interface{}) error {
+	entries := make([]lokiEntry, 0, len(items))
+	for _, item := range items {
+		entries = append(entries, item.(lokiEntry))
+	}
+	body, err := json.Marshal(lokiPushRequestFromEntries(entries))
+	if err != nil {
+		return errPermanent{err: fmt.Errorf("error encoding loki push request: %w", err)}
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, out.config.Endpoint, bytes.NewReader(body))
+	if err != nil {
+		return errPermanent{err: fmt.Errorf("error constructing loki push request: %w", err)}
+	}
+	req.Header.Set("Content-Type", "application/json")
+	if out.config.TenantID != "" {
+		req.Header.Set("X-Scope-OrgID", out.config.TenantID)
+	}
+	if out.config.User != "" {
+		req.SetBasicAuth(out.config.User, out.config.Password)
+	}
+
+	logger.Debug("Sending to loki endpoint", "url", out.config.Endpoint, "numEntries", len(entries), "bodyLength", len(body))
+	resp, err := out.httpClient.Do(req)
+	if err != nil {
+		return fmt.Errorf("error sending loki push request: %w", err)
+	}
+	return checkOutputResponse(resp)
+}
@@ -0,0 +1,74 @@
+package pipeline
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/grafana/grafana-plugin-sdk-go/data"
+	"github.com/stretchr/testify/require"
+)
+
+func TestLokiFrameOutput(t *testing.T) {
+	var mu sync.Mutex
+	var pushRequest lokiPushRequest
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		require.Equal(t, "tenant", r.Header.Get("X-Scope-OrgID"))
+		var req lokiPushRequest
+		require.NoError(t, json.NewDecoder(r.Body).Decode(&req))
+		mu.Lock()
+		pushRequest.Streams = append(pushRequest.Streams, req.Streams...)
+		mu.Unlock()
+		w.WriteHeader(http.StatusNoContent)
+	}))
+	defer server.Close()
+
+	out, err := NewLokiFrameOutput(LokiOutputConfig{
+		Endpoint:    server.URL,
+		TenantID:    "tenant",
+		Labels:      map[string]string{"job": "live"},
+		LabelFields: []string{"host"},
+	})
+	require.NoError(t, err)
+
+	ts := time.Unix(100, 0)
+	frame := data.NewFrame("change",
+		data.NewField("time", nil, []time.Time{ts, ts.Add(time.Second)}),
+		data.NewField("host", nil, []string{"a", "b"}),
+		data.NewField("old", nil, []string{"ok", "ok"}),
+		data.NewField("new", nil, []string{"alerting", "alerting"}),
+	)
+	_, err = out.OutputFrame(context.Background(), Vars{OrgID: 1, Channel: "stream/test/changes"}, frame)
+	require.NoError(t, err)
+	require.NoError(t, out.Close())
+
+	mu.Lock()
+	defer mu.Unlock()
+	require.Len(t, pushRequest.Streams, 2)
+	require.Equal(t, map[string]string{"channel": "stream/test/changes", "job": "live", "host": "a"}, pushRequest.Streams[0].Stream)
+	require.Equal(t, [][2]string{{"100000000000", `old="ok" new="alerting"`}}, pushRequest.Streams[0].Values)
+	require.Equal(t, "b", pushRequest.Streams[1].Stream["host"])
+	require.Equal(t, "101000000000", pushRequest.Streams[1].Values[0][0])
+}
+
+func TestLokiFrameOutput_LineTemplate(t *testing.T) {
+	out, err := NewLokiFrameOutput(LokiOutputConfig{
+		LineTemplate: `{{.Channel}} value changed to {{.Row.new}}`,
+	})
+	require.NoError(t, err)
+
+	frame := data.NewFrame("change", data.NewField("new", nil, []float64{5}))
+	entries, err := out.frameToEntries(Vars{Channel: "stream/test"}, frame)
+	require.NoError(t, err)
+	require.Len(t, entries, 1)
+	require.Equal(t, "stream/test value changed to 5", entries[0].line)
+}
+
+func TestLokiLabelName(t *testing.T) {
+	require.Equal(t, "host_name", lokiLabelName("host.name"))
+	require.Equal(t, "_1abc", lokiLabelName("1abc"))
+}
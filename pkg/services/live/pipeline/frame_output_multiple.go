@@ -18,6 +18,11 @@ func (out *MultipleFrameOutput) Type() string {
 	return FrameOutputTypeMultiple
 }
 
+func (out *MultipleFrameOutput) Close() error {
+	closeFrameOutputters(out.Outputters)
+	return nil
+}
+
 func (out MultipleFrameOutput) OutputFrame(ctx context.Context, vars Vars, frame *data.Frame) ([]*ChannelFrame, error) {
 	var frames []*ChannelFrame
 	for _, out := range out.Outputters {
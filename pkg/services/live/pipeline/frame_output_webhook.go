@@ -0,0 +1,182 @@
+package pipeline
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strings"
+	"text/template"
+	"time"
+
+	"github.com/grafana/grafana-plugin-sdk-go/data"
+)
+
+type WebhookOutputConfig struct {
+	// Endpoint to send events to.
+	Endpoint string `json:"endpoint"`
+	// Method is an HTTP method to use, POST by default.
+	Method string `json:"method,omitempty"`
+	// Headers are additional HTTP headers to set.
+	Headers map[string]string `json:"headers,omitempty"`
+	// User is a user for basic authentication.
+	User string `json:"user,omitempty"`
+	// Password for basic authentication.
+	Password string `json:"password,omitempty"`
+	// BodyTemplate is a Go text/template executed over a batch of events to
+	// build request body. Template data has Events field with a list of
+	// WebhookEvent. If not set then batch is sent as JSON object with events.
+	BodyTemplate string `json:"bodyTemplate,omitempty"`
+	// ContentType of the request body, application/json by default.
+	ContentType string `json:"contentType,omitempty"`
+
+	BatchConfig
+}
+
+// WebhookEvent represents a frame passed to a webhook output.
+type WebhookEvent struct {
+	OrgID   int64                    `json:"orgId"`
+	Channel string                   `json:"channel"`
+	Name    string                   `json:"name"`
+	Time    time.Time                `json:"time"`
+	Rows    []map[string]interface{} `json:"rows"`
+}
+
+type webhookTemplateData struct {
+	Events []WebhookEvent
+}
+
+var outputTemplateFuncs = template.FuncMap{
+	"json": func(v interface{}) (string, error) {
+		b, err := json.Marshal(v)
+		return string(b), err
+	},
+	"join": strings.Join,
+}
+
+// WebhookFrameOutput sends frames to an HTTP endpoint. Frames are batched and
+// delivered with retries, request body can be built with a template.
+type WebhookFrameOutput struct {
+	config     WebhookOutputConfig
+	template   *template.Template
+	httpClient *http.Client
+	sender     *batchSender
+}
+
+func NewWebhookFrameOutput(config WebhookOutputConfig) (*WebhookFrameOutput, error) {
+	out := &WebhookFrameOutput{
+		config:     config,
+		httpClient: &http.Client{Timeout: 5 * time.Second},
+	}
+	if config.BodyTemplate != "" {
+		tmpl, err := template.New("body").Funcs(outputTemplateFuncs).Parse(config.BodyTemplate)
+		if err != nil {
+			return nil, fmt.Errorf("error parsing webhook body template: %w", err)
+		}
+		out.template = tmpl
+	}
+	out.sender = newBatchSender(config.BatchConfig, out.send)
+	if config.Endpoint != "" {
+		out.sender.start()
+	}
+	return out, nil
+}
+
+const FrameOutputTypeWebhook = "webhook"
+
+func (out *WebhookFrameOutput) Type() string {
+	return FrameOutputTypeWebhook
+}
+
+// Close flushes buffered frames and stops sending them.
+func (out *WebhookFrameOutput) Close() error {
+	out.sender.Close()
+	return nil
+}
+
+func frameRows(frame *data.Frame) []map[string]interface{} {
+	numRows, _ := frame.RowLen()
+	rows := make([]map[string]interface{}, 0, numRows)
+	for i := 0; i < numRows; i++ {
+		row := make(map[string]interface{}, len(frame.Fields))
+		for _, f := range frame.Fields {
+			v, ok := f.ConcreteAt(i)
+			if !ok {
+				v = nil
+			}
+			row[f.Name] = v
+		}
+		rows = append(rows, row)
+	}
+	return rows
+}
+
+func (out *WebhookFrameOutput) OutputFrame(_ context.Context, vars Vars, frame *data.Frame) ([]*ChannelFrame, error) {
+	if out.config.Endpoint == "" {
+		logger.Debug("Skip sending to webhook: no url")
+		return nil, nil
+	}
+	if frame == nil {
+		return nil, nil
+	}
+	out.sender.Add(WebhookEvent{
+		OrgID:   vars.OrgID,
+		Channel: vars.Channel,
+		Name:    frame.Name,
+		Time:    time.Now(),
+		Rows:    frameRows(frame),
+	})
+	return nil, nil
+}
+
+func (out *WebhookFrameOutput) body(events []WebhookEvent) ([]byte, error) {
+	if out.template == nil {
+		return json.Marshal(struct {
+			Events []WebhookEvent `json:"events"`
+		}{Events: events})
+	}
+	var buf bytes.Buffer
+	if err := out.template.Execute(&buf, webhookTemplateData{Events: events}); err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
+
+func (out *WebhookFrameOutput) send(ctx context.Context, items []interface{}) error {
+	events := make([]WebhookEvent, 0, len(items))
+	for _, item := range items {
+		events = append(events, item.(WebhookEvent))
+	}
+	body, err := out.body(events)
+	if err != nil {
+		return errPermanent{err: fmt.Errorf("error building webhook body: %w", err)}
+	}
+
+	method := out.config.Method
+	if method == "" {
+		method = http.MethodPost
+	}
+	req, err := http.NewRequestWithContext(ctx, method, out.config.Endpoint, bytes.NewReader(body))
+	if err != nil {
+		return errPermanent{err: fmt.Errorf("error constructing webhook request: %w", err)}
+	}
+	contentType := out.config.ContentType
+	if contentType == "" {
+		contentType = "application/json"
+	}
+	req.Header.Set("Content-Type", contentType)
+	for k, v := range out.config.Headers {
+		req.Header.Set(k, v)
+	}
+	if out.config.User != "" {
+		req.SetBasicAuth(out.config.User, out.config.Password)
+	}
+
+	logger.Debug("Sending to webhook endpoint", "url", out.config.Endpoint, "numEvents", len(events), "bodyLength", len(body))
+	resp, err := out.httpClient.Do(req)
+	if err != nil {
+		return fmt.Errorf("error sending webhook request: %w", err)
+	}
+	return checkOutputResponse(resp)
+}
@@ -0,0 +1,180 @@
+package pipeline
+
+import (
+	"context"
+	"encoding/json"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/grafana/grafana-plugin-sdk-go/data"
+	"github.com/stretchr/testify/require"
+)
+
+func noSleep(_ context.Context, _ time.Duration) error {
+	return nil
+}
+
+func TestWebhookFrameOutput_TemplatedBody(t *testing.T) {
+	bodies := make(chan string, 1)
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		require.Equal(t, http.MethodPut, r.Method)
+		require.Equal(t, "text/plain", r.Header.Get("Content-Type"))
+		require.Equal(t, "secret", r.Header.Get("X-Token"))
+		b, err := ioutil.ReadAll(r.Body)
+		require.NoError(t, err)
+		bodies <- string(b)
+	}))
+	defer server.Close()
+
+	out, err := NewWebhookFrameOutput(WebhookOutputConfig{
+		Endpoint:     server.URL,
+		Method:       http.MethodPut,
+		Headers:      map[string]string{"X-Token": "secret"},
+		ContentType:  "text/plain",
+		BodyTemplate: `{{range .Events}}{{.Channel}}:{{range .Rows}}{{.state}};{{end}}{{end}}`,
+	})
+	require.NoError(t, err)
+
+	frame := data.NewFrame("state",
+		data.NewField("state", nil, []string{"alerting", "ok"}),
+	)
+	_, err = out.OutputFrame(context.Background(), Vars{OrgID: 1, Channel: "stream/test/state"}, frame)
+	require.NoError(t, err)
+
+	require.NoError(t, out.Close())
+	select {
+	case body := <-bodies:
+		require.Equal(t, "stream/test/state:alerting;ok;", body)
+	case <-time.After(time.Second):
+		t.Fatal("timeout waiting for webhook request")
+	}
+}
+
+func TestWebhookFrameOutput_Batching(t *testing.T) {
+	var numEvents int32
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var body struct {
+			Events []WebhookEvent `json:"events"`
+		}
+		require.NoError(t, json.NewDecoder(r.Body).Decode(&body))
+		require.LessOrEqual(t, len(body.Events), 2)
+		atomic.AddInt32(&numEvents, int32(len(body.Events)))
+	}))
+	defer server.Close()
+
+	out, err := NewWebhookFrameOutput(WebhookOutputConfig{
+		Endpoint:    server.URL,
+		BatchConfig: BatchConfig{BatchSize: 2},
+	})
+	require.NoError(t, err)
+
+	frame := data.NewFrame("test", data.NewField("value", nil, []float64{1}))
+	for i := 0; i < 5; i++ {
+		out.sender.Add(WebhookEvent{Channel: "test", Rows: frameRows(frame)})
+	}
+	// Full batches can also be flushed by a background goroutine.
+	require.Eventually(t, func() bool {
+		out.sender.flush(context.Background())
+		return atomic.LoadInt32(&numEvents) == 5
+	}, time.Second, 10*time.Millisecond)
+}
+
+func TestWebhookFrameOutput_Retries(t *testing.T) {
+	var numRequests int32
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if atomic.AddInt32(&numRequests, 1) < 3 {
+			w.WriteHeader(http.StatusServiceUnavailable)
+		}
+	}))
+	defer server.Close()
+
+	out, err := NewWebhookFrameOutput(WebhookOutputConfig{Endpoint: server.URL})
+	require.NoError(t, err)
+	out.sender.sleep = noSleep
+
+	err = out.sender.sendWithRetries(context.Background(), []interface{}{WebhookEvent{Channel: "test"}})
+	require.NoError(t, err)
+	require.Equal(t, int32(3), atomic.LoadInt32(&numRequests))
+}
+
+func TestWebhookFrameOutput_NoRetryOnClientError(t *testing.T) {
+	var numRequests int32
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		atomic.AddInt32(&numRequests, 1)
+		w.WriteHeader(http.StatusBadRequest)
+	}))
+	defer server.Close()
+
+	out, err := NewWebhookFrameOutput(WebhookOutputConfig{Endpoint: server.URL})
+	require.NoError(t, err)
+	out.sender.sleep = noSleep
+
+	err = out.sender.sendWithRetries(context.Background(), []interface{}{WebhookEvent{Channel: "test"}})
+	require.Error(t, err)
+	require.Equal(t, int32(1), atomic.LoadInt32(&numRequests))
+}
+
+func TestWebhookFrameOutput_Close(t *testing.T) {
+	var numEvents int32
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var body struct {
+			Events []WebhookEvent `json:"events"`
+		}
+		require.NoError(t, json.NewDecoder(r.Body).Decode(&body))
+		atomic.AddInt32(&numEvents, int32(len(body.Events)))
+	}))
+	defer server.Close()
+
+	out, err := NewWebhookFrameOutput(WebhookOutputConfig{
+		Endpoint:    server.URL,
+		BatchConfig: BatchConfig{FlushIntervalMilliseconds: time.Hour.Milliseconds()},
+	})
+	require.NoError(t, err)
+
+	out.sender.Add(WebhookEvent{Channel: "test"}, WebhookEvent{Channel: "test"})
+	// Buffered events are sent before Close returns.
+	require.NoError(t, out.Close())
+	require.Equal(t, int32(2), atomic.LoadInt32(&numEvents))
+
+	// Events added after Close are dropped and Close can be called again.
+	out.sender.Add(WebhookEvent{Channel: "test"})
+	require.NoError(t, out.Close())
+	require.Equal(t, int32(2), atomic.LoadInt32(&numEvents))
+}
+
+func TestBatchSender_AddDuringClose(t *testing.T) {
+	for i := 0; i < 20; i++ {
+		sender := newBatchSender(BatchConfig{FlushIntervalMilliseconds: time.Hour.Milliseconds()}, func(_ context.Context, _ []interface{}) error {
+			return nil
+		})
+		sender.start()
+
+		var wg sync.WaitGroup
+		for j := 0; j < 4; j++ {
+			wg.Add(1)
+			go func() {
+				defer wg.Done()
+				for k := 0; k < 100; k++ {
+					sender.Add(k)
+				}
+			}()
+		}
+		sender.Close()
+		wg.Wait()
+
+		// Items are either flushed on Close or dropped, never left in the buffer.
+		sender.mu.Lock()
+		require.Empty(t, sender.buffer)
+		sender.mu.Unlock()
+	}
+}
+
+func TestWebhookFrameOutput_InvalidTemplate(t *testing.T) {
+	_, err := NewWebhookFrameOutput(WebhookOutputConfig{BodyTemplate: "{{.Events"})
+	require.Error(t, err)
+}
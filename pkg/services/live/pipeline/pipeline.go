@@ -4,6 +4,7 @@ import (
 	"context"
 	"errors"
 	"fmt"
+	"io"
 	"os"
 
 	"github.com/grafana/grafana/pkg/models"
@@ -104,6 +105,20 @@ type FrameOutputter interface {
 	OutputFrame(ctx context.Context, vars Vars, frame *data.Frame) ([]*ChannelFrame, error)
 }
 
+// closeFrameOutputters releases resources of outputters which implement
+// io.Closer, e.g. background senders of HTTP outputs.
+func closeFrameOutputters(outputters []FrameOutputter) {
+	for _, out := range outputters {
+		closer, ok := out.(io.Closer)
+		if !ok {
+			continue
+		}
+		if err := closer.Close(); err != nil {
+			logger.Error("Error closing frame output", "error", err, "type", out.Type())
+		}
+	}
+}
+
 // Subscriber can handle channel subscribe events.
 type Subscriber interface {
 	Type() string
@@ -44,6 +44,20 @@ var FrameOutputsRegistry = []EntityInfo{
 		Type:        FrameOutputTypeRemoteWrite,
 		Description: "output to remote write endpoint",
 	},
+	{
+		Type:        FrameOutputTypeWebhook,
+		Description: "send frames to HTTP endpoint in batches with retries",
+		Example: WebhookOutputConfig{
+			Endpoint: "http://localhost:8080/events",
+		},
+	},
+	{
+		Type:        FrameOutputTypeLoki,
+		Description: "push frame rows to Loki as log lines",
+		Example: LokiOutputConfig{
+			Endpoint: "http://localhost:3100/loki/api/v1/push",
+		},
+	},
 }
 
 var ConvertersRegistry = []EntityInfo{
@@ -11,14 +11,17 @@ import (
 
 // CacheSegmentedTree provides a fast access to channel rule configuration.
 type CacheSegmentedTree struct {
-	radixMu     sync.RWMutex
-	radix       map[int64]*tree.Node
+	radixMu sync.RWMutex
+	radix   map[int64]*tree.Node
+	// rules of each org, kept to close outputs of rules once replaced.
+	rules       map[int64][]*LiveChannelRule
 	ruleBuilder RuleBuilder
 }
 
 func NewCacheSegmentedTree(storage RuleBuilder) *CacheSegmentedTree {
 	s := &CacheSegmentedTree{
 		radix:       map[int64]*tree.Node{},
+		rules:       map[int64][]*LiveChannelRule{},
 		ruleBuilder: storage,
 	}
 	go s.updatePeriodically()
@@ -51,14 +54,27 @@ func (s *CacheSegmentedTree) fillOrg(orgID int64) error {
 		return err
 	}
 	s.radixMu.Lock()
-	defer s.radixMu.Unlock()
+	oldChannels := s.rules[orgID]
 	s.radix[orgID] = tree.New()
 	for _, ch := range channels {
 		s.radix[orgID].AddRoute("/"+ch.Pattern, ch)
 	}
+	s.rules[orgID] = channels
+	s.radixMu.Unlock()
+	// Outputs of replaced rules may still flush buffered frames, so they
+	// are closed without blocking lookups.
+	if len(oldChannels) > 0 {
+		go closeRules(oldChannels)
+	}
 	return nil
 }
 
+func closeRules(rules []*LiveChannelRule) {
+	for _, rule := range rules {
+		closeFrameOutputters(rule.FrameOutputters)
+	}
+}
+
 func (s *CacheSegmentedTree) Get(orgID int64, channel string) (*LiveChannelRule, bool, error) {
 	s.radixMu.RLock()
 	_, ok := s.radix[orgID]
@@ -3,7 +3,9 @@ package pipeline
 import (
 	"context"
 	"testing"
+	"time"
 
+	"github.com/grafana/grafana/pkg/services/live/pipeline/tree"
 	"github.com/stretchr/testify/require"
 )
 
@@ -67,3 +69,58 @@ func BenchmarkRuleGet(b *testing.B) {
 		}
 	}
 }
+
+type closableOutput struct {
+	RedirectFrameOutput
+	closed chan struct{}
+}
+
+func (out *closableOutput) Close() error {
+	close(out.closed)
+	return nil
+}
+
+type closableBuilder struct {
+	outputs chan *closableOutput
+}
+
+func (b *closableBuilder) BuildRules(_ context.Context, _ int64) ([]*LiveChannelRule, error) {
+	out := &closableOutput{closed: make(chan struct{})}
+	b.outputs <- out
+	return []*LiveChannelRule{
+		{
+			OrgId:   1,
+			Pattern: "stream/test",
+			FrameOutputters: []FrameOutputter{
+				NewMultipleFrameOutput(NewConditionalOutput(nil, out)),
+			},
+		},
+	}, nil
+}
+
+func TestStorage_ClosesReplacedOutputs(t *testing.T) {
+	builder := &closableBuilder{outputs: make(chan *closableOutput, 2)}
+	// Not using NewCacheSegmentedTree to avoid periodic updates.
+	s := &CacheSegmentedTree{
+		radix:       map[int64]*tree.Node{},
+		rules:       map[int64][]*LiveChannelRule{},
+		ruleBuilder: builder,
+	}
+	_, ok, err := s.Get(1, "stream/test")
+	require.NoError(t, err)
+	require.True(t, ok)
+	first := <-builder.outputs
+
+	require.NoError(t, s.fillOrg(1))
+	second := <-builder.outputs
+	select {
+	case <-first.closed:
+	case <-time.After(time.Second):
+		t.Fatal("replaced output was not closed")
+	}
+	select {
+	case <-second.closed:
+		t.Fatal("current output was closed")
+	default:
+	}
+}
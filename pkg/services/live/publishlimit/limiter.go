@@ -0,0 +1,237 @@
+package publishlimit
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"sync"
+	"time"
+
+	"github.com/gobwas/glob"
+	"github.com/grafana/grafana/pkg/models"
+	"github.com/grafana/grafana/pkg/setting"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/promauto"
+	"golang.org/x/time/rate"
+)
+
+var (
+	// ErrMessageTooLarge returned when a single publication exceeds the max message size.
+	ErrMessageTooLarge = errors.New("message too large")
+	// ErrMessageRateExceeded returned when publisher sends too many messages per second.
+	ErrMessageRateExceeded = errors.New("message rate limit exceeded")
+	// ErrByteRateExceeded returned when publisher sends too many bytes per second.
+	ErrByteRateExceeded = errors.New("byte rate limit exceeded")
+)
+
+var publishRejectedTotal = promauto.NewCounterVec(prometheus.CounterOpts{
+	Namespace: "grafana",
+	Subsystem: "live",
+	Name:      "publish_rejected_total",
+	Help:      "Number of messages rejected by Grafana Live publish limits.",
+}, []string{"protocol", "limit", "reason"})
+
+const (
+	// ProtocolWebsocket used for publications coming over Live WebSocket connection.
+	ProtocolWebsocket = "websocket"
+	// ProtocolHTTP used for publications coming over HTTP push endpoints.
+	ProtocolHTTP = "http"
+)
+
+// Buckets not touched for this period are removed from memory.
+const bucketIdleTimeout = time.Minute
+
+type rule struct {
+	limit setting.LivePublishLimit
+	glob  glob.Glob
+}
+
+type bucketKey struct {
+	orgID     int64
+	ruleName  string
+	publisher string
+}
+
+type bucket struct {
+	messages *rate.Limiter
+	bytes    *rate.Limiter
+	lastUsed time.Time
+}
+
+// Limiter checks publications against configured quotas. Quotas are
+// accounted separately for each organization, channel pattern and
+// publisher (API key or user).
+type Limiter struct {
+	defaultRule rule
+	rules       []rule
+
+	mu        sync.Mutex
+	buckets   map[bucketKey]*bucket
+	lastSweep time.Time
+
+	// getTime should return the current time, overridden in tests.
+	getTime func() time.Time
+}
+
+// NewLimiter creates Limiter from settings.
+func NewLimiter(settings setting.LivePublishLimitSettings) (*Limiter, error) {
+	l := &Limiter{
+		defaultRule: rule{limit: settings.Default},
+		buckets:     map[bucketKey]*bucket{},
+		getTime:     time.Now,
+	}
+	for _, limit := range settings.Channels {
+		r := rule{limit: limit}
+		if limit.ChannelPattern != "" {
+			g, err := glob.Compile(limit.ChannelPattern, '/')
+			if err != nil {
+				return nil, fmt.Errorf("error compiling channel pattern %q: %w", limit.ChannelPattern, err)
+			}
+			r.glob = g
+		}
+		l.rules = append(l.rules, r)
+	}
+	return l, nil
+}
+
+func (l *Limiter) match(channel string) rule {
+	for _, r := range l.rules {
+		if r.glob == nil || r.glob.Match(channel) {
+			return r
+		}
+	}
+	return l.defaultRule
+}
+
+// MaxMessageSize returns max allowed message size for a channel, 0 means no limit.
+func (l *Limiter) MaxMessageSize(channel string) int {
+	return l.match(channel).limit.MaxMessageSize
+}
+
+func publisherKey(user *models.SignedInUser) string {
+	if user.ApiKeyId > 0 {
+		return fmt.Sprintf("api_key:%d", user.ApiKeyId)
+	}
+	return fmt.Sprintf("user:%d", user.UserId)
+}
+
+// Allow checks whether a publication of size bytes from user into a channel
+// (without org ID prefix) is allowed. Returned error is one of ErrMessageTooLarge,
+// ErrMessageRateExceeded or ErrByteRateExceeded. Rejections are counted in
+// Prometheus metrics labeled with protocol.
+func (l *Limiter) Allow(protocol string, user *models.SignedInUser, channel string, size int) error {
+	r := l.match(channel)
+	if r.limit.IsZero() {
+		return nil
+	}
+	err := l.allow(r, user, size)
+	if err != nil {
+		publishRejectedTotal.WithLabelValues(protocol, r.limit.Name, reason(err)).Inc()
+	}
+	return err
+}
+
+func (l *Limiter) allow(r rule, user *models.SignedInUser, size int) error {
+	if r.limit.MaxMessageSize > 0 && size > r.limit.MaxMessageSize {
+		return ErrMessageTooLarge
+	}
+	if r.limit.MaxMessagesPerSecond == 0 && r.limit.MaxBytesPerSecond == 0 {
+		return nil
+	}
+
+	now := l.getTime()
+	key := bucketKey{orgID: user.OrgId, ruleName: r.limit.Name, publisher: publisherKey(user)}
+
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	l.sweepLocked(now)
+
+	b, ok := l.buckets[key]
+	if !ok {
+		b = newBucket(r.limit)
+		l.buckets[key] = b
+	}
+	b.lastUsed = now
+
+	// Tokens are only taken from the buckets when the publication passes
+	// all checks, so rejected messages do not eat into the publisher's budget.
+	var messages *rate.Reservation
+	if b.messages != nil {
+		messages = reserve(b.messages, now, 1)
+		if messages == nil {
+			return ErrMessageRateExceeded
+		}
+	}
+	if b.bytes != nil && reserve(b.bytes, now, size) == nil {
+		if messages != nil {
+			messages.CancelAt(now)
+		}
+		return ErrByteRateExceeded
+	}
+	return nil
+}
+
+// reserve takes n tokens from limiter if they are available at now,
+// otherwise it returns nil and leaves limiter untouched.
+func reserve(limiter *rate.Limiter, now time.Time, n int) *rate.Reservation {
+	r := limiter.ReserveN(now, n)
+	if !r.OK() {
+		return nil
+	}
+	if r.DelayFrom(now) > 0 {
+		r.CancelAt(now)
+		return nil
+	}
+	return r
+}
+
+func newBucket(limit setting.LivePublishLimit) *bucket {
+	b := &bucket{}
+	if limit.MaxMessagesPerSecond > 0 {
+		b.messages = rate.NewLimiter(rate.Limit(limit.MaxMessagesPerSecond), limit.MaxMessagesPerSecond)
+	}
+	if limit.MaxBytesPerSecond > 0 {
+		// Burst should fit at least one message of max allowed size.
+		burst := limit.MaxBytesPerSecond
+		if limit.MaxMessageSize > burst {
+			burst = limit.MaxMessageSize
+		}
+		b.bytes = rate.NewLimiter(rate.Limit(limit.MaxBytesPerSecond), burst)
+	}
+	return b
+}
+
+func (l *Limiter) sweepLocked(now time.Time) {
+	if now.Sub(l.lastSweep) < bucketIdleTimeout {
+		return
+	}
+	l.lastSweep = now
+	for key, b := range l.buckets {
+		if now.Sub(b.lastUsed) > bucketIdleTimeout {
+			delete(l.buckets, key)
+		}
+	}
+}
+
+func reason(err error) string {
+	switch {
+	case errors.Is(err, ErrMessageTooLarge):
+		return "message_size"
+	case errors.Is(err, ErrMessageRateExceeded):
+		return "message_rate"
+	case errors.Is(err, ErrByteRateExceeded):
+		return "byte_rate"
+	default:
+		return "unknown"
+	}
+}
+
+// HTTPStatus converts limiter error to HTTP status code. We are using
+// HTTP status codes for WebSocket errors too.
+func HTTPStatus(err error) int {
+	if errors.Is(err, ErrMessageTooLarge) {
+		return http.StatusRequestEntityTooLarge
+	}
+	return http.StatusTooManyRequests
+}
@@ -0,0 +1,114 @@
+package publishlimit
+
+import (
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/grafana/grafana/pkg/models"
+	"github.com/grafana/grafana/pkg/setting"
+	"github.com/stretchr/testify/require"
+)
+
+func newTestLimiter(t *testing.T, settings setting.LivePublishLimitSettings) (*Limiter, *time.Time) {
+	t.Helper()
+	l, err := NewLimiter(settings)
+	require.NoError(t, err)
+	now := time.Unix(1000, 0)
+	l.getTime = func() time.Time { return now }
+	return l, &now
+}
+
+func TestLimiter_NoLimits(t *testing.T) {
+	l, _ := newTestLimiter(t, setting.LivePublishLimitSettings{})
+	user := &models.SignedInUser{OrgId: 1, UserId: 1}
+	for i := 0; i < 100; i++ {
+		require.NoError(t, l.Allow(ProtocolHTTP, user, "stream/test", 1024*1024))
+	}
+}
+
+func TestLimiter_MaxMessageSize(t *testing.T) {
+	l, _ := newTestLimiter(t, setting.LivePublishLimitSettings{
+		Default: setting.LivePublishLimit{Name: "default", MaxMessageSize: 10},
+	})
+	user := &models.SignedInUser{OrgId: 1, UserId: 1}
+	require.NoError(t, l.Allow(ProtocolHTTP, user, "stream/test", 10))
+	err := l.Allow(ProtocolHTTP, user, "stream/test", 11)
+	require.ErrorIs(t, err, ErrMessageTooLarge)
+	require.Equal(t, http.StatusRequestEntityTooLarge, HTTPStatus(err))
+	require.Equal(t, 10, l.MaxMessageSize("stream/test"))
+}
+
+func TestLimiter_MessageRate(t *testing.T) {
+	l, now := newTestLimiter(t, setting.LivePublishLimitSettings{
+		Default: setting.LivePublishLimit{Name: "default", MaxMessagesPerSecond: 2},
+	})
+	user := &models.SignedInUser{OrgId: 1, UserId: 1}
+	require.NoError(t, l.Allow(ProtocolWebsocket, user, "stream/test", 1))
+	require.NoError(t, l.Allow(ProtocolWebsocket, user, "stream/test", 1))
+	err := l.Allow(ProtocolWebsocket, user, "stream/test", 1)
+	require.ErrorIs(t, err, ErrMessageRateExceeded)
+	require.Equal(t, http.StatusTooManyRequests, HTTPStatus(err))
+
+	// Other org and other API key have their own quotas.
+	require.NoError(t, l.Allow(ProtocolWebsocket, &models.SignedInUser{OrgId: 2, UserId: 1}, "stream/test", 1))
+	require.NoError(t, l.Allow(ProtocolWebsocket, &models.SignedInUser{OrgId: 1, ApiKeyId: 3}, "stream/test", 1))
+
+	*now = now.Add(time.Second)
+	require.NoError(t, l.Allow(ProtocolWebsocket, user, "stream/test", 1))
+}
+
+func TestLimiter_ByteRate(t *testing.T) {
+	l, now := newTestLimiter(t, setting.LivePublishLimitSettings{
+		Default: setting.LivePublishLimit{Name: "default", MaxBytesPerSecond: 100},
+	})
+	user := &models.SignedInUser{OrgId: 1, UserId: 1}
+	require.NoError(t, l.Allow(ProtocolHTTP, user, "stream/test", 60))
+	require.ErrorIs(t, l.Allow(ProtocolHTTP, user, "stream/test", 60), ErrByteRateExceeded)
+	*now = now.Add(time.Second)
+	require.NoError(t, l.Allow(ProtocolHTTP, user, "stream/test", 60))
+}
+
+func TestLimiter_ChannelPattern(t *testing.T) {
+	l, _ := newTestLimiter(t, setting.LivePublishLimitSettings{
+		Default: setting.LivePublishLimit{Name: "default", MaxMessageSize: 100},
+		Channels: []setting.LivePublishLimit{
+			{Name: "telegraf", ChannelPattern: "stream/telegraf/**", MaxMessageSize: 10},
+			{Name: "unlimited", ChannelPattern: "stream/unlimited/*"},
+		},
+	})
+	user := &models.SignedInUser{OrgId: 1, UserId: 1}
+	require.ErrorIs(t, l.Allow(ProtocolHTTP, user, "stream/telegraf/cpu", 50), ErrMessageTooLarge)
+	require.NoError(t, l.Allow(ProtocolHTTP, user, "stream/other", 50))
+	require.NoError(t, l.Allow(ProtocolHTTP, user, "stream/unlimited/test", 500))
+	// Single "*" does not match nested path so default limit applies.
+	require.ErrorIs(t, l.Allow(ProtocolHTTP, user, "stream/unlimited/test/nested", 500), ErrMessageTooLarge)
+}
+
+func TestLimiter_SweepIdleBuckets(t *testing.T) {
+	l, now := newTestLimiter(t, setting.LivePublishLimitSettings{
+		Default: setting.LivePublishLimit{Name: "default", MaxMessagesPerSecond: 1},
+	})
+	require.NoError(t, l.Allow(ProtocolHTTP, &models.SignedInUser{OrgId: 1, UserId: 1}, "stream/test", 1))
+	require.Len(t, l.buckets, 1)
+	*now = now.Add(2 * bucketIdleTimeout)
+	require.NoError(t, l.Allow(ProtocolHTTP, &models.SignedInUser{OrgId: 1, UserId: 2}, "stream/test", 1))
+	require.Len(t, l.buckets, 1)
+}
+
+func TestLimiter_RejectedMessagesKeepBudget(t *testing.T) {
+	l, _ := newTestLimiter(t, setting.LivePublishLimitSettings{
+		Default: setting.LivePublishLimit{Name: "default", MaxMessageSize: 100, MaxMessagesPerSecond: 1, MaxBytesPerSecond: 100},
+	})
+	user := &models.SignedInUser{OrgId: 1, UserId: 1}
+	// Too large and byte-rate rejected messages must not consume message tokens.
+	require.ErrorIs(t, l.Allow(ProtocolHTTP, user, "stream/test", 101), ErrMessageTooLarge)
+	require.NoError(t, l.Allow(ProtocolHTTP, user, "stream/test", 1))
+
+	l2, _ := newTestLimiter(t, setting.LivePublishLimitSettings{
+		Default: setting.LivePublishLimit{Name: "default", MaxMessagesPerSecond: 2, MaxBytesPerSecond: 100},
+	})
+	require.NoError(t, l2.Allow(ProtocolHTTP, user, "stream/test", 90))
+	require.ErrorIs(t, l2.Allow(ProtocolHTTP, user, "stream/test", 50), ErrByteRateExceeded)
+	require.NoError(t, l2.Allow(ProtocolHTTP, user, "stream/test", 10))
+}
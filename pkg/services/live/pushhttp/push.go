@@ -10,6 +10,7 @@ import (
 	"github.com/grafana/grafana/pkg/models"
 	"github.com/grafana/grafana/pkg/services/live"
 	"github.com/grafana/grafana/pkg/services/live/convert"
+	"github.com/grafana/grafana/pkg/services/live/publishlimit"
 	"github.com/grafana/grafana/pkg/services/live/pushurl"
 	"github.com/grafana/grafana/pkg/setting"
 
@@ -59,10 +60,8 @@ func (g *Gateway) Handle(ctx *models.ReqContext) {
 	urlValues := ctx.Req.URL.Query()
 	frameFormat := pushurl.FrameFormatFromValues(urlValues)
 
-	body, err := io.ReadAll(ctx.Req.Body)
-	if err != nil {
-		logger.Error("Error reading body", "error", err)
-		ctx.Resp.WriteHeader(http.StatusInternalServerError)
+	body, ok := g.readBody(ctx, "stream/"+streamID)
+	if !ok {
 		return
 	}
 	logger.Debug("Live Push request",
@@ -100,10 +99,10 @@ func (g *Gateway) HandlePath(ctx *models.ReqContext) {
 	streamID := web.Params(ctx.Req)[":streamId"]
 	path := web.Params(ctx.Req)[":path"]
 
-	body, err := io.ReadAll(ctx.Req.Body)
-	if err != nil {
-		logger.Error("Error reading body", "error", err)
-		ctx.Resp.WriteHeader(http.StatusInternalServerError)
+	channelID := "stream/" + streamID + "/" + path
+
+	body, ok := g.readBody(ctx, channelID)
+	if !ok {
 		return
 	}
 	logger.Debug("Live channel push request",
@@ -113,8 +112,6 @@ func (g *Gateway) HandlePath(ctx *models.ReqContext) {
 		"bodyLength", len(body),
 	)
 
-	channelID := "stream/" + streamID + "/" + path
-
 	ruleFound, err := g.GrafanaLive.Pipeline.ProcessInput(ctx.Req.Context(), ctx.OrgId, channelID, body)
 	if err != nil {
 		logger.Error("Pipeline input processing error", "error", err, "body", string(body))
@@ -131,3 +128,29 @@ func (g *Gateway) HandlePath(ctx *models.ReqContext) {
 		return
 	}
 }
+
+// readBody reads request body checking it against publish limits of a channel.
+// In case of error it writes an appropriate status code and returns false.
+func (g *Gateway) readBody(ctx *models.ReqContext, channel string) ([]byte, bool) {
+	limiter := g.GrafanaLive.PublishLimiter
+
+	var reader io.Reader = ctx.Req.Body
+	if maxSize := limiter.MaxMessageSize(channel); maxSize > 0 {
+		// Read one more byte than allowed to let limiter reject too large body
+		// without reading it all into memory.
+		reader = io.LimitReader(reader, int64(maxSize)+1)
+	}
+	body, err := io.ReadAll(reader)
+	if err != nil {
+		logger.Error("Error reading body", "error", err)
+		ctx.Resp.WriteHeader(http.StatusInternalServerError)
+		return nil, false
+	}
+
+	if err := limiter.Allow(publishlimit.ProtocolHTTP, ctx.SignedInUser, channel, len(body)); err != nil {
+		logger.Info("Push request rejected by limits", "channel", channel, "bodyLength", len(body), "error", err)
+		ctx.Resp.WriteHeader(publishlimit.HTTPStatus(err))
+		return nil, false
+	}
+	return body, true
+}
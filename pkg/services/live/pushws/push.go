@@ -1,6 +1,7 @@
 package pushws
 
 import (
+	"errors"
 	"fmt"
 	"net/http"
 	"net/url"
@@ -11,16 +12,43 @@ import (
 	"github.com/grafana/grafana/pkg/services/live/convert"
 	"github.com/grafana/grafana/pkg/services/live/livecontext"
 	"github.com/grafana/grafana/pkg/services/live/managedstream"
+	"github.com/grafana/grafana/pkg/services/live/publishlimit"
 	"github.com/grafana/grafana/pkg/services/live/pushurl"
 
 	"github.com/gorilla/websocket"
 	liveDto "github.com/grafana/grafana-plugin-sdk-go/live"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/promauto"
 )
 
 var (
 	logger = log.New("live.push_ws")
 )
 
+var pushDroppedTotal = promauto.NewCounterVec(prometheus.CounterOpts{
+	Namespace: "grafana",
+	Subsystem: "live",
+	Name:      "push_ws_dropped_total",
+	Help:      "Number of messages pushed over WebSocket that were dropped.",
+}, []string{"reason"})
+
+// Reasons messages pushed over WebSocket are dropped for.
+const (
+	dropReasonLimit   = "limit"
+	dropReasonStream  = "stream"
+	dropReasonConvert = "convert"
+)
+
+// errorFrameWriteTimeout is how long writing an error frame to the client may take.
+const errorFrameWriteTimeout = time.Second
+
+// ErrorFrame is sent to the client when a pushed message is dropped, Status
+// is an HTTP status code describing the reason.
+type ErrorFrame struct {
+	Status int    `json:"status"`
+	Error  string `json:"error"`
+}
+
 // Handler handles WebSocket client connections that push data to Live.
 type Handler struct {
 	managedStreamRunner *managedstream.Runner
@@ -50,6 +78,9 @@ type Config struct {
 	// PingInterval sets interval server will send ping messages to clients.
 	// By default DefaultWebsocketPingInterval will be used.
 	PingInterval time.Duration
+
+	// PublishLimiter, if set, is used to drop messages exceeding publish limits.
+	PublishLimiter *publishlimit.Limiter
 }
 
 // NewHandler creates new Handler.
@@ -166,9 +197,22 @@ func (s *Handler) ServeHTTP(rw http.ResponseWriter, r *http.Request) {
 			break
 		}
 
+		if s.config.PublishLimiter != nil {
+			if err := s.config.PublishLimiter.Allow(publishlimit.ProtocolWebsocket, user, "stream/"+streamID, len(body)); err != nil {
+				logger.Info("Push message rejected by limits", "streamId", streamID, "bodyLength", len(body), "error", err)
+				if !dropMessage(conn, dropReasonLimit, publishlimit.HTTPStatus(err), err) {
+					return
+				}
+				continue
+			}
+		}
+
 		stream, err := s.managedStreamRunner.GetOrCreateStream(user.OrgId, liveDto.ScopeStream, streamID)
 		if err != nil {
 			logger.Error("Error getting stream", "error", err)
+			if !dropMessage(conn, dropReasonStream, http.StatusInternalServerError, errors.New("internal error")) {
+				return
+			}
 			continue
 		}
 
@@ -186,6 +230,9 @@ func (s *Handler) ServeHTTP(rw http.ResponseWriter, r *http.Request) {
 		metricFrames, err := s.converter.Convert(body, frameFormat)
 		if err != nil {
 			logger.Error("Error converting metrics", "error", err, "frameFormat", frameFormat)
+			if !dropMessage(conn, dropReasonConvert, http.StatusBadRequest, err) {
+				return
+			}
 			continue
 		}
 
@@ -198,3 +245,16 @@ func (s *Handler) ServeHTTP(rw http.ResponseWriter, r *http.Request) {
 		}
 	}
 }
+
+// dropMessage counts a dropped message and tells the client why it was
+// dropped with an error frame. It returns false when the error frame can't be
+// written, in which case the connection should be closed.
+func dropMessage(conn *websocket.Conn, reason string, status int, err error) bool {
+	pushDroppedTotal.WithLabelValues(reason).Inc()
+	_ = conn.SetWriteDeadline(time.Now().Add(errorFrameWriteTimeout))
+	if err := conn.WriteJSON(ErrorFrame{Status: status, Error: err.Error()}); err != nil {
+		logger.Debug("Error writing error frame", "error", err)
+		return false
+	}
+	return true
+}
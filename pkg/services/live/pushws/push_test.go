@@ -0,0 +1,46 @@
+package pushws
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/gorilla/websocket"
+	"github.com/grafana/grafana/pkg/models"
+	"github.com/grafana/grafana/pkg/services/live/livecontext"
+	"github.com/grafana/grafana/pkg/services/live/managedstream"
+	"github.com/grafana/grafana/pkg/services/live/publishlimit"
+	"github.com/grafana/grafana/pkg/setting"
+	"github.com/stretchr/testify/require"
+)
+
+func TestHandler_SendsErrorFrameForDroppedMessages(t *testing.T) {
+	limiter, err := publishlimit.NewLimiter(setting.LivePublishLimitSettings{
+		Default: setting.LivePublishLimit{Name: "default", MaxMessageSize: 10},
+	})
+	require.NoError(t, err)
+
+	runner := managedstream.NewRunner(func(orgID int64, channel string, data []byte) error {
+		return nil
+	}, nil, managedstream.NewMemoryFrameCache())
+	handler := NewHandler(runner, Config{PublishLimiter: limiter})
+
+	server := httptest.NewServer(http.HandlerFunc(func(rw http.ResponseWriter, r *http.Request) {
+		ctx := livecontext.SetContextStreamID(r.Context(), "test")
+		ctx = livecontext.SetContextSignedUser(ctx, &models.SignedInUser{OrgId: 1, UserId: 1})
+		handler.ServeHTTP(rw, r.WithContext(ctx))
+	}))
+	t.Cleanup(server.Close)
+
+	conn, _, err := websocket.DefaultDialer.Dial("ws"+strings.TrimPrefix(server.URL, "http"), nil)
+	require.NoError(t, err)
+	t.Cleanup(func() { _ = conn.Close() })
+
+	require.NoError(t, conn.WriteMessage(websocket.TextMessage, []byte("measurement value=1")))
+
+	frame := ErrorFrame{}
+	require.NoError(t, conn.ReadJSON(&frame))
+	require.Equal(t, http.StatusRequestEntityTooLarge, frame.Status)
+	require.Equal(t, publishlimit.ErrMessageTooLarge.Error(), frame.Error)
+}
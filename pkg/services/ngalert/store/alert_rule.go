@@ -13,6 +13,7 @@ import (
 	"github.com/grafana/grafana/pkg/models"
 
 	"github.com/grafana/grafana/pkg/services/dashboards"
+	"github.com/grafana/grafana/pkg/services/dependencies"
 
 	apimodels "github.com/grafana/grafana/pkg/services/ngalert/api/tooling/definitions"
 	ngmodels "github.com/grafana/grafana/pkg/services/ngalert/models"
@@ -51,7 +52,7 @@ type RuleStore interface {
 	GetNamespaces(context.Context, int64, *models.SignedInUser) (map[string]*models.Folder, error)
 	GetNamespaceByTitle(context.Context, string, int64, *models.SignedInUser, bool) (*models.Folder, error)
 	GetOrgRuleGroups(query *ngmodels.ListOrgRuleGroupsQuery) error
-	UpsertAlertRules([]UpsertRule) error
+	UpsertAlertRules(context.Context, []UpsertRule) error
 	UpdateRuleGroup(UpdateRuleGroupCmd) error
 }
 
@@ -185,10 +186,11 @@ func (st DBstore) GetAlertRuleByUID(query *ngmodels.GetAlertRuleByUIDQuery) erro
 }
 
 // UpsertAlertRules is a handler for creating/updating alert rules.
-func (st DBstore) UpsertAlertRules(rules []UpsertRule) error {
-	return st.SQLStore.WithTransactionalDbSession(context.Background(), func(sess *sqlstore.DBSession) error {
+func (st DBstore) UpsertAlertRules(ctx context.Context, rules []UpsertRule) error {
+	return st.SQLStore.WithTransactionalDbSession(ctx, func(sess *sqlstore.DBSession) error {
 		newRules := make([]ngmodels.AlertRule, 0, len(rules))
 		ruleVersions := make([]ngmodels.AlertRuleVersion, 0, len(rules))
+		savedRules := make([]ngmodels.AlertRule, 0, len(rules))
 		for _, r := range rules {
 			if r.Existing == nil && r.New.UID != "" {
 				// check by UID
@@ -281,6 +283,7 @@ func (st DBstore) UpsertAlertRules(rules []UpsertRule) error {
 				parentVersion = r.Existing.Version
 			}
 
+			savedRules = append(savedRules, r.New)
 			ruleVersions = append(ruleVersions, ngmodels.AlertRuleVersion{
 				RuleOrgID:        r.New.OrgID,
 				RuleUID:          r.New.UID,
@@ -313,6 +316,12 @@ func (st DBstore) UpsertAlertRules(rules []UpsertRule) error {
 			}
 		}
 
+		for i := range savedRules {
+			if err := dependencies.SetAlertRuleDependencies(sess, &savedRules[i]); err != nil {
+				return fmt.Errorf("failed to update dependencies of rule %s: %w", savedRules[i].Title, err)
+			}
+		}
+
 		return nil
 	})
 }
@@ -578,7 +587,7 @@ func (st DBstore) UpdateRuleGroup(cmd UpdateRuleGroupCmd) error {
 			upsertRules = append(upsertRules, upsertRule)
 		}
 
-		if err := st.UpsertAlertRules(upsertRules); err != nil {
+		if err := st.UpsertAlertRules(context.Background(), upsertRules); err != nil {
 			if st.SQLStore.Dialect.IsUniqueConstraintViolation(err) {
 				return ngmodels.ErrAlertRuleUniqueConstraintViolation
 			}
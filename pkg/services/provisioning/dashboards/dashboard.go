@@ -20,6 +20,9 @@ type DashboardProvisioner interface {
 	GetProvisionerResolvedPath(name string) string
 	GetAllowUIUpdatesFromConfig(name string) bool
 	CleanUpOrphanedDashboards(ctx context.Context)
+	WriteDashboard(ctx context.Context, provisioning *models.DashboardProvisioning, dash *models.Dashboard,
+		message string, user *models.SignedInUser) error
+	GetStatus() []ProvisionerStatus
 }
 
 // DashboardProvisionerFactory creates DashboardProvisioners based on input
@@ -120,12 +123,34 @@ func (provider *Provisioner) GetAllowUIUpdatesFromConfig(name string) bool {
 	return false
 }
 
+// WriteDashboard writes a dashboard saved in the UI back to the provider it was
+// provisioned by. Only providers of type git support this, for other types
+// it does nothing.
+func (provider *Provisioner) WriteDashboard(ctx context.Context, provisioning *models.DashboardProvisioning,
+	dash *models.Dashboard, message string, user *models.SignedInUser) error {
+	for _, reader := range provider.fileReaders {
+		if reader.Cfg.Name == provisioning.Name {
+			return reader.writeDashboard(ctx, provisioning, dash, message, user)
+		}
+	}
+	return nil
+}
+
+// GetStatus returns the synchronization status of the dashboard providers.
+func (provider *Provisioner) GetStatus() []ProvisionerStatus {
+	statuses := make([]ProvisionerStatus, 0, len(provider.fileReaders))
+	for _, reader := range provider.fileReaders {
+		statuses = append(statuses, reader.status())
+	}
+	return statuses
+}
+
 func getFileReaders(configs []*config, logger log.Logger, store dashboards.Store) ([]*FileReader, error) {
 	var readers []*FileReader
 
 	for _, config := range configs {
 		switch config.Type {
-		case "file":
+		case "file", "git":
 			fileReader, err := NewDashboardFileReader(config, logger.New("type", config.Type, "name", config.Name),
 				store)
 			if err != nil {
@@ -1,6 +1,10 @@
 package dashboards
 
-import "context"
+import (
+	"context"
+
+	"github.com/grafana/grafana/pkg/models"
+)
 
 // Calls is a mock implementation of the provisioner interface
 type calls struct {
@@ -8,6 +12,7 @@ type calls struct {
 	PollChanges                 []interface{}
 	GetProvisionerResolvedPath  []interface{}
 	GetAllowUIUpdatesFromConfig []interface{}
+	WriteDashboard              []interface{}
 }
 
 // ProvisionerMock is a mock implementation of `Provisioner`
@@ -17,6 +22,9 @@ type ProvisionerMock struct {
 	PollChangesFunc                 func(ctx context.Context)
 	GetProvisionerResolvedPathFunc  func(name string) string
 	GetAllowUIUpdatesFromConfigFunc func(name string) bool
+	WriteDashboardFunc              func(ctx context.Context, provisioning *models.DashboardProvisioning,
+		dash *models.Dashboard, message string, user *models.SignedInUser) error
+	GetStatusFunc func() []ProvisionerStatus
 }
 
 // NewDashboardProvisionerMock returns a new dashboardprovisionermock
@@ -63,3 +71,21 @@ func (dpm *ProvisionerMock) GetAllowUIUpdatesFromConfig(name string) bool {
 
 // CleanUpOrphanedDashboards not implemented for mocks
 func (dpm *ProvisionerMock) CleanUpOrphanedDashboards(ctx context.Context) {}
+
+// WriteDashboard is a mock implementation of `Provisioner.WriteDashboard`
+func (dpm *ProvisionerMock) WriteDashboard(ctx context.Context, provisioning *models.DashboardProvisioning,
+	dash *models.Dashboard, message string, user *models.SignedInUser) error {
+	dpm.Calls.WriteDashboard = append(dpm.Calls.WriteDashboard, provisioning)
+	if dpm.WriteDashboardFunc != nil {
+		return dpm.WriteDashboardFunc(ctx, provisioning, dash, message, user)
+	}
+	return nil
+}
+
+// GetStatus is a mock implementation of `Provisioner.GetStatus`
+func (dpm *ProvisionerMock) GetStatus() []ProvisionerStatus {
+	if dpm.GetStatusFunc != nil {
+		return dpm.GetStatusFunc()
+	}
+	return nil
+}
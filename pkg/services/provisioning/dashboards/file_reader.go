@@ -38,6 +38,9 @@ type FileReader struct {
 	mux                     sync.RWMutex
 	usageTracker            *usageTracker
 	dbWriteAccessRestricted bool
+
+	// git is the Git working tree of providers of type git.
+	git *gitRepository
 }
 
 // NewDashboardFileReader returns a new filereader based on `config`
@@ -58,18 +61,27 @@ func NewDashboardFileReader(cfg *config, log log.Logger, store dboards.Store) (*
 		return nil, fmt.Errorf("'folder' and 'folderUID' should be empty using 'foldersFromFilesStructure' option")
 	}
 
-	return &FileReader{
+	fr := &FileReader{
 		Cfg:                          cfg,
 		Path:                         path,
 		log:                          log,
 		dashboardProvisioningService: dashboards.NewProvisioningService(store),
 		FoldersFromFilesStructure:    foldersFromFilesStructure,
 		usageTracker:                 newUsageTracker(),
-	}, nil
+	}
+	if cfg.Type == "git" {
+		fr.git = newGitRepository(cfg, path, log)
+	}
+
+	return fr, nil
 }
 
 // pollChanges periodically runs walkDisk based on interval specified in the config.
 func (fr *FileReader) pollChanges(ctx context.Context) {
+	if fr.git != nil {
+		go fr.git.runPushes(ctx)
+	}
+
 	ticker := time.NewTicker(time.Duration(int64(time.Second) * fr.Cfg.UpdateIntervalSeconds))
 	for {
 		select {
@@ -86,6 +98,12 @@ func (fr *FileReader) pollChanges(ctx context.Context) {
 // walkDisk traverses the file system for the defined path, reading dashboard definition files,
 // and applies any change to the database.
 func (fr *FileReader) walkDisk(ctx context.Context) error {
+	if fr.git != nil {
+		fr.git.pull(ctx)
+		fr.git.mux.Lock()
+		defer fr.git.mux.Unlock()
+	}
+
 	fr.log.Debug("Start walking disk", "path", fr.Path)
 	resolvedPath := fr.resolvedPath()
 	if _, err := os.Stat(resolvedPath); err != nil {
@@ -122,6 +140,108 @@ func (fr *FileReader) walkDisk(ctx context.Context) error {
 	return nil
 }
 
+// writeDashboard writes a dashboard saved in the UI back to its file and
+// commits it, for providers of type git. Files changed in Git since they were
+// provisioned aren't overwritten, and are reported as conflicts instead.
+func (fr *FileReader) writeDashboard(ctx context.Context, provisioning *models.DashboardProvisioning,
+	dash *models.Dashboard, message string, user *models.SignedInUser) error {
+	if fr.git == nil {
+		return nil
+	}
+
+	fr.git.mux.Lock()
+	defer fr.git.mux.Unlock()
+
+	path := provisioning.ExternalId
+	relPath, err := filepath.Rel(fr.resolvedPath(), path)
+	if err != nil || strings.HasPrefix(relPath, "..") {
+		return fmt.Errorf("dashboard file %q is outside of the provisioning path", path)
+	}
+
+	fileInfo, err := os.Stat(path)
+	if err != nil {
+		return err
+	}
+	// nolint:gosec
+	// We can ignore the gosec G304 warning on this one because `path` comes from the provisioned dashboards.
+	current, err := ioutil.ReadFile(path)
+	if err != nil {
+		return err
+	}
+	if checkSum, err := util.Md5SumString(string(current)); err != nil {
+		return err
+	} else if checkSum != provisioning.CheckSum {
+		fr.git.addConflict(relPath, conflictKindSave, "The file was changed in Git after the dashboard was provisioned")
+		return fmt.Errorf("dashboard file %q was changed in Git after the dashboard was provisioned", relPath)
+	}
+
+	data, err := dashboardFileContent(dash)
+	if err != nil {
+		return err
+	}
+	if err := ioutil.WriteFile(path, data, fileInfo.Mode().Perm()); err != nil {
+		return err
+	}
+
+	checkSum, err := util.Md5SumString(string(data))
+	if err != nil {
+		return err
+	}
+	if err := bus.DispatchCtx(ctx, &models.UpdateDashboardProvisioningCommand{
+		DashboardId: provisioning.DashboardId,
+		Name:        provisioning.Name,
+		CheckSum:    checkSum,
+		Updated:     time.Now().Unix(),
+	}); err != nil {
+		return err
+	}
+
+	if message == "" {
+		message = fmt.Sprintf("Update dashboard %s", dash.Title)
+	}
+	if err := fr.git.commit(ctx, path, message, user); err != nil {
+		fr.git.addConflict(relPath, conflictKindSave, err.Error())
+		return err
+	}
+	fr.git.removeConflict(relPath)
+	return nil
+}
+
+// dashboardFileContent returns the dashboard JSON as written to provisioning
+// files, without the database id and version.
+func dashboardFileContent(dash *models.Dashboard) ([]byte, error) {
+	raw, err := dash.Data.MarshalJSON()
+	if err != nil {
+		return nil, err
+	}
+	data, err := simplejson.NewJson(raw)
+	if err != nil {
+		return nil, err
+	}
+	data.Del("id")
+	data.Del("version")
+
+	content, err := data.EncodePretty()
+	if err != nil {
+		return nil, err
+	}
+	return append(content, '\n'), nil
+}
+
+// status returns the synchronization status of the provider.
+func (fr *FileReader) status() ProvisionerStatus {
+	status := ProvisionerStatus{
+		Name:      fr.Cfg.Name,
+		Type:      fr.Cfg.Type,
+		Path:      fr.Path,
+		Conflicts: []SyncConflict{},
+	}
+	if fr.git != nil {
+		fr.git.setStatus(&status)
+	}
+	return status
+}
+
 func (fr *FileReader) changeWritePermissions(restrict bool) {
 	fr.mux.Lock()
 	defer fr.mux.Unlock()
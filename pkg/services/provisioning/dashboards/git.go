@@ -0,0 +1,339 @@
+package dashboards
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"os"
+	"os/exec"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/grafana/grafana/pkg/infra/log"
+	"github.com/grafana/grafana/pkg/models"
+)
+
+const (
+	conflictKindPull = "pull"
+	conflictKindSave = "save"
+)
+
+const (
+	// pullTimeout limits how long fetching and pushing upstream changes before
+	// reading the dashboards may take.
+	pullTimeout = time.Minute
+	// pushTimeout limits how long pushing commits upstream may take.
+	pushTimeout = time.Minute
+)
+
+// defaultAuthorName is the commit author of dashboards saved by users
+// without a name or login, such as API keys.
+const defaultAuthorName = "Grafana"
+
+// ProvisionerStatus is the synchronization status of a dashboard provider.
+type ProvisionerStatus struct {
+	Name      string         `json:"name"`
+	Type      string         `json:"type"`
+	Path      string         `json:"path"`
+	Revision  string         `json:"revision,omitempty"`
+	LastSync  *time.Time     `json:"lastSync,omitempty"`
+	Error     string         `json:"error,omitempty"`
+	Conflicts []SyncConflict `json:"conflicts"`
+}
+
+// SyncConflict is a dashboard file that couldn't be synchronized with Git.
+type SyncConflict struct {
+	Path    string    `json:"path"`
+	Kind    string    `json:"kind"`
+	Message string    `json:"message"`
+	Time    time.Time `json:"time"`
+}
+
+// gitRepository is the Git working tree of a dashboard provider of type git.
+// Changes are pulled from its upstream before the dashboards are read, and
+// dashboards saved in the UI are committed and pushed back. The working tree
+// is cloned from the repository option, such as a bare repository, if it
+// doesn't exist. Commits are pushed in the background, so saving a dashboard
+// doesn't wait for the upstream.
+type gitRepository struct {
+	path       string
+	repository string
+	branch     string
+	log        log.Logger
+
+	// mux serializes synchronizing the working tree with the database.
+	mux sync.Mutex
+	// pullMux serializes pulls, which fetch from the upstream without
+	// locking the working tree.
+	pullMux sync.Mutex
+	// pushes signals the push worker that there are commits to push.
+	pushes chan struct{}
+
+	statusMux sync.RWMutex
+	revision  string
+	lastSync  time.Time
+	lastError string
+	conflicts map[string]SyncConflict
+}
+
+func newGitRepository(cfg *config, path string, log log.Logger) *gitRepository {
+	repository, _ := cfg.Options["repository"].(string)
+	branch, _ := cfg.Options["branch"].(string)
+
+	return &gitRepository{
+		path:       path,
+		repository: repository,
+		branch:     branch,
+		log:        log,
+		pushes:     make(chan struct{}, 1),
+		conflicts:  map[string]SyncConflict{},
+	}
+}
+
+// git runs a git command in the working tree and returns its trimmed output.
+func (r *gitRepository) git(ctx context.Context, env []string, args ...string) (string, error) {
+	// nolint:gosec
+	// We can ignore the gosec G204 warning on this one because the arguments come from the provisioning
+	// configuration file and from paths of provisioned dashboards.
+	cmd := exec.CommandContext(ctx, "git", append([]string{"-C", r.path}, args...)...)
+	cmd.Env = append(os.Environ(), env...)
+	var stderr bytes.Buffer
+	cmd.Stderr = &stderr
+
+	out, err := cmd.Output()
+	if err != nil {
+		return "", fmt.Errorf("git %s failed: %w: %s", args[0], err, strings.TrimSpace(stderr.String()))
+	}
+	return strings.TrimSpace(string(out)), nil
+}
+
+// cloneIfMissing clones the repository into the working tree path if it
+// doesn't exist yet.
+func (r *gitRepository) cloneIfMissing(ctx context.Context) error {
+	if r.repository == "" {
+		return nil
+	}
+	if _, err := os.Stat(r.path); !os.IsNotExist(err) {
+		return err
+	}
+
+	args := []string{"clone", "--quiet"}
+	if r.branch != "" {
+		args = append(args, "--branch", r.branch)
+	}
+	args = append(args, r.repository, r.path)
+
+	r.log.Info("Cloning dashboards repository", "repository", r.repository, "path", r.path)
+	// nolint:gosec
+	// We can ignore the gosec G204 warning on this one because the arguments come from the provisioning
+	// configuration file.
+	cmd := exec.CommandContext(ctx, "git", args...)
+	if out, err := cmd.CombinedOutput(); err != nil {
+		return fmt.Errorf("git clone failed: %w: %s", err, strings.TrimSpace(string(out)))
+	}
+	return nil
+}
+
+func (r *gitRepository) hasUpstream(ctx context.Context) bool {
+	_, err := r.git(ctx, nil, "rev-parse", "--abbrev-ref", "--symbolic-full-name", "@{u}")
+	return err == nil
+}
+
+// pull fetches the upstream changes, rebases local commits onto them and
+// pushes them. The working tree is only locked for the rebase, so an upstream
+// which is slow or doesn't respond, up to pullTimeout, doesn't block saving
+// dashboards. On conflicts the rebase is aborted, leaving the working tree as
+// it was, and the conflicting files are reported in the status.
+func (r *gitRepository) pull(ctx context.Context) {
+	ctx, cancel := context.WithTimeout(ctx, pullTimeout)
+	defer cancel()
+
+	r.pullMux.Lock()
+	defer r.pullMux.Unlock()
+
+	if err := r.cloneIfMissing(ctx); err != nil {
+		r.setSyncError(err)
+		return
+	}
+
+	upstream := r.hasUpstream(ctx)
+	if upstream {
+		if _, err := r.git(ctx, nil, "fetch", "--quiet"); err != nil {
+			r.setSyncError(err)
+			return
+		}
+	}
+
+	revision, err := r.rebase(ctx, upstream)
+	if err != nil {
+		r.setSyncError(err)
+		return
+	}
+
+	if upstream {
+		if err := r.pushIfAhead(ctx); err != nil {
+			r.setSyncError(err)
+			return
+		}
+	}
+
+	r.statusMux.Lock()
+	defer r.statusMux.Unlock()
+	for path, conflict := range r.conflicts {
+		if conflict.Kind == conflictKindPull {
+			delete(r.conflicts, path)
+		}
+	}
+	r.revision = revision
+	r.lastSync = time.Now()
+	r.lastError = ""
+}
+
+// rebase rebases local commits onto the fetched upstream changes, if any, and
+// returns the resulting revision.
+func (r *gitRepository) rebase(ctx context.Context, upstream bool) (string, error) {
+	r.mux.Lock()
+	defer r.mux.Unlock()
+
+	if upstream {
+		if _, err := r.git(ctx, nil, "rebase", "--autostash", "--quiet", "@{u}"); err != nil {
+			r.abortRebase(ctx)
+			return "", err
+		}
+	}
+	return r.git(ctx, nil, "rev-parse", "HEAD")
+}
+
+// abortRebase aborts a rebase stopped on conflicts, reporting the conflicting files.
+func (r *gitRepository) abortRebase(ctx context.Context) {
+	files, err := r.git(ctx, nil, "diff", "--name-only", "--relative", "--diff-filter=U")
+	if err != nil || files == "" {
+		return
+	}
+
+	if _, err := r.git(ctx, nil, "rebase", "--abort"); err != nil {
+		r.log.Error("Failed to abort rebase", "path", r.path, "error", err)
+	}
+
+	for _, file := range strings.Split(files, "\n") {
+		r.addConflict(file, conflictKindPull, "Local changes conflict with the upstream changes")
+	}
+}
+
+func (r *gitRepository) pushIfAhead(ctx context.Context) error {
+	ahead, err := r.git(ctx, nil, "rev-list", "--count", "@{u}..HEAD")
+	if err != nil || ahead == "0" {
+		return err
+	}
+	_, err = r.git(ctx, nil, "push", "--quiet")
+	return err
+}
+
+// commit commits the file and schedules pushing it upstream, if any.
+func (r *gitRepository) commit(ctx context.Context, path string, message string, author *models.SignedInUser) error {
+	if _, err := r.git(ctx, nil, "add", "--", path); err != nil {
+		return err
+	}
+
+	// diff --quiet exits with 1 when there are staged changes
+	if _, err := r.git(ctx, nil, "diff", "--cached", "--quiet", "--", path); err == nil {
+		return nil
+	}
+
+	name := author.Name
+	if name == "" {
+		name = author.Login
+	}
+	if name == "" {
+		name = defaultAuthorName
+	}
+	env := []string{
+		"GIT_AUTHOR_NAME=" + name,
+		"GIT_AUTHOR_EMAIL=" + author.Email,
+		"GIT_COMMITTER_NAME=" + name,
+		"GIT_COMMITTER_EMAIL=" + author.Email,
+	}
+	if _, err := r.git(ctx, env, "commit", "--quiet", "-m", message, "--", path); err != nil {
+		return err
+	}
+
+	if r.hasUpstream(ctx) {
+		r.schedulePush()
+	}
+	return nil
+}
+
+// schedulePush asks the push worker to push the local commits.
+func (r *gitRepository) schedulePush() {
+	select {
+	case r.pushes <- struct{}{}:
+	default:
+	}
+}
+
+// runPushes pushes the commits of dashboards saved in the UI until the
+// context is canceled.
+func (r *gitRepository) runPushes(ctx context.Context) {
+	for {
+		select {
+		case <-r.pushes:
+			r.push(ctx)
+		case <-ctx.Done():
+			return
+		}
+	}
+}
+
+// push pushes the local commits upstream. The working tree isn't locked while
+// pushing, if the upstream has changed the commits are pulled to rebase them
+// onto the changes first. Failures are reported in the status and pushing is
+// retried by the next pull.
+func (r *gitRepository) push(ctx context.Context) {
+	ctx, cancel := context.WithTimeout(ctx, pushTimeout)
+	defer cancel()
+
+	if _, err := r.git(ctx, nil, "push", "--quiet"); err == nil {
+		return
+	}
+	r.pull(ctx)
+}
+
+func (r *gitRepository) setSyncError(err error) {
+	r.log.Error("Failed to synchronize dashboards repository", "path", r.path, "error", err)
+
+	r.statusMux.Lock()
+	defer r.statusMux.Unlock()
+	r.lastError = err.Error()
+}
+
+func (r *gitRepository) addConflict(path string, kind string, message string) {
+	r.statusMux.Lock()
+	defer r.statusMux.Unlock()
+	r.conflicts[path] = SyncConflict{Path: path, Kind: kind, Message: message, Time: time.Now()}
+}
+
+func (r *gitRepository) removeConflict(path string) {
+	r.statusMux.Lock()
+	defer r.statusMux.Unlock()
+	delete(r.conflicts, path)
+}
+
+func (r *gitRepository) setStatus(status *ProvisionerStatus) {
+	r.statusMux.RLock()
+	defer r.statusMux.RUnlock()
+
+	status.Revision = r.revision
+	if !r.lastSync.IsZero() {
+		lastSync := r.lastSync
+		status.LastSync = &lastSync
+	}
+	status.Error = r.lastError
+	for _, conflict := range r.conflicts {
+		status.Conflicts = append(status.Conflicts, conflict)
+	}
+	sort.Slice(status.Conflicts, func(i, j int) bool {
+		return status.Conflicts[i].Path < status.Conflicts[j].Path
+	})
+}
@@ -0,0 +1,192 @@
+package dashboards
+
+import (
+	"context"
+	"io/ioutil"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/grafana/grafana/pkg/bus"
+	"github.com/grafana/grafana/pkg/components/simplejson"
+	"github.com/grafana/grafana/pkg/infra/log"
+	"github.com/grafana/grafana/pkg/models"
+	"github.com/grafana/grafana/pkg/services/dashboards"
+	"github.com/stretchr/testify/require"
+)
+
+func TestGitDashboardProvisioning(t *testing.T) {
+	if _, err := exec.LookPath("git"); err != nil {
+		t.Skip("git is not installed")
+	}
+
+	t.Setenv("GIT_CONFIG_NOSYSTEM", "1")
+	t.Setenv("GIT_COMMITTER_NAME", "test")
+	t.Setenv("GIT_COMMITTER_EMAIL", "test@example.com")
+	t.Setenv("GIT_AUTHOR_NAME", "test")
+	t.Setenv("GIT_AUTHOR_EMAIL", "test@example.com")
+
+	bus.ClearBusHandlers()
+	origNewDashboardProvisioningService := dashboards.NewProvisioningService
+	t.Cleanup(func() {
+		dashboards.NewProvisioningService = origNewDashboardProvisioningService
+	})
+	fakeService = mockDashboardProvisioningService()
+	bus.AddHandler("test", mockGetDashboardQuery)
+	bus.AddHandlerCtx("test", func(ctx context.Context, cmd *models.UpdateDashboardProvisioningCommand) error {
+		for _, p := range fakeService.provisioned[cmd.Name] {
+			if p.DashboardId == cmd.DashboardId {
+				p.CheckSum = cmd.CheckSum
+				p.Updated = cmd.Updated
+			}
+		}
+		return nil
+	})
+
+	dir := t.TempDir()
+	upstream := filepath.Join(dir, "upstream.git")
+	seed := filepath.Join(dir, "seed")
+	work := filepath.Join(dir, "work")
+
+	runGit(t, dir, "init", "--quiet", "--bare", upstream)
+	runGit(t, dir, "clone", "--quiet", upstream, seed)
+	writeFile(t, filepath.Join(seed, "dashboard.json"), `{"title": "Git dashboard", "uid": "git"}`)
+	runGit(t, seed, "add", "dashboard.json")
+	runGit(t, seed, "commit", "--quiet", "-m", "Add dashboard")
+	runGit(t, seed, "push", "--quiet", "origin", "HEAD")
+
+	cfg := &config{
+		Name:  "git",
+		Type:  "git",
+		OrgID: 1,
+		Options: map[string]interface{}{
+			"path":       work,
+			"repository": upstream,
+		},
+	}
+	reader, err := NewDashboardFileReader(cfg, log.New("test-logger"), nil)
+	require.NoError(t, err)
+
+	ctx := context.Background()
+	pushCtx, cancelPushes := context.WithCancel(ctx)
+	t.Cleanup(cancelPushes)
+	go reader.git.runPushes(pushCtx)
+	user := &models.SignedInUser{Login: "editor", Name: "Jane Editor", Email: "jane@example.com"}
+
+	t.Run("Clones the repository and provisions its dashboards", func(t *testing.T) {
+		err := reader.walkDisk(ctx)
+		require.NoError(t, err)
+		require.Len(t, fakeService.provisioned["git"], 1)
+
+		status := reader.status()
+		require.Empty(t, status.Error)
+		require.NotEmpty(t, status.Revision)
+		require.NotNil(t, status.LastSync)
+		require.Empty(t, status.Conflicts)
+	})
+
+	t.Run("Commits and pushes dashboards saved in the UI", func(t *testing.T) {
+		provisioning := fakeService.provisioned["git"][0]
+		dash := models.NewDashboardFromJson(simplejson.NewFromAny(map[string]interface{}{
+			"title": "Git dashboard", "uid": "git", "id": 1, "version": 2, "description": "saved",
+		}))
+
+		err := reader.writeDashboard(ctx, provisioning, dash, "Describe dashboard", user)
+		require.NoError(t, err)
+
+		require.Equal(t, "Jane Editor <jane@example.com> Describe dashboard",
+			runGit(t, work, "log", "-1", "--format=%an <%ae> %s"))
+		require.Eventually(t, func() bool {
+			return runGit(t, upstream, "log", "-1", "--format=%s") == "Describe dashboard"
+		}, 10*time.Second, 10*time.Millisecond)
+		content := runGit(t, upstream, "show", "HEAD:dashboard.json")
+		require.Contains(t, content, `"description": "saved"`)
+		require.NotContains(t, content, `"version"`)
+		require.Empty(t, reader.status().Conflicts)
+	})
+
+	t.Run("Commits dashboards saved by API keys with the default author", func(t *testing.T) {
+		provisioning := fakeService.provisioned["git"][0]
+		dash := models.NewDashboardFromJson(simplejson.NewFromAny(map[string]interface{}{
+			"title": "Git dashboard", "uid": "git", "description": "saved by API key",
+		}))
+
+		err := reader.writeDashboard(ctx, provisioning, dash, "Save with API key", &models.SignedInUser{ApiKeyId: 1})
+		require.NoError(t, err)
+
+		require.Equal(t, "Grafana Save with API key", runGit(t, work, "log", "-1", "--format=%an %s"))
+		require.Eventually(t, func() bool {
+			return runGit(t, upstream, "log", "-1", "--format=%s") == "Save with API key"
+		}, 10*time.Second, 10*time.Millisecond)
+	})
+
+	t.Run("Saving dashboards doesn't wait for pulling from a slow upstream", func(t *testing.T) {
+		runGit(t, work, "config", "remote.origin.uploadpack", "sleep 2; git-upload-pack")
+		t.Cleanup(func() {
+			runGit(t, work, "config", "--unset", "remote.origin.uploadpack")
+		})
+
+		walked := make(chan error, 1)
+		go func() {
+			walked <- reader.walkDisk(ctx)
+		}()
+		// Give the pull time to start fetching.
+		time.Sleep(200 * time.Millisecond)
+
+		provisioning := fakeService.provisioned["git"][0]
+		dash := models.NewDashboardFromJson(simplejson.NewFromAny(map[string]interface{}{
+			"title": "Git dashboard", "uid": "git", "description": "saved while pulling",
+		}))
+		start := time.Now()
+		err := reader.writeDashboard(ctx, provisioning, dash, "Save while pulling", user)
+		require.NoError(t, err)
+		require.Less(t, int64(time.Since(start)), int64(time.Second))
+
+		require.NoError(t, <-walked)
+		require.Empty(t, reader.status().Error)
+		require.Eventually(t, func() bool {
+			return runGit(t, upstream, "log", "-1", "--format=%s") == "Save while pulling"
+		}, 10*time.Second, 10*time.Millisecond)
+	})
+
+	t.Run("Reports conflicting upstream changes", func(t *testing.T) {
+		runGit(t, seed, "pull", "--quiet")
+		writeFile(t, filepath.Join(seed, "dashboard.json"), `{"title": "Changed in Git", "uid": "git"}`)
+		runGit(t, seed, "commit", "--quiet", "-am", "Rename dashboard")
+		runGit(t, seed, "push", "--quiet")
+
+		provisioning := fakeService.provisioned["git"][0]
+		dash := models.NewDashboardFromJson(simplejson.NewFromAny(map[string]interface{}{
+			"title": "Changed in Grafana", "uid": "git",
+		}))
+
+		// The dashboard is committed, the conflict is found when pushing it.
+		err := reader.writeDashboard(ctx, provisioning, dash, "", user)
+		require.NoError(t, err)
+
+		require.Eventually(t, func() bool {
+			return len(reader.status().Conflicts) > 0
+		}, 10*time.Second, 10*time.Millisecond)
+		status := reader.status()
+		require.Len(t, status.Conflicts, 1)
+		require.Equal(t, "dashboard.json", status.Conflicts[0].Path)
+		require.Equal(t, "Rename dashboard", runGit(t, upstream, "log", "-1", "--format=%s"))
+	})
+}
+
+func runGit(t *testing.T, dir string, args ...string) string {
+	t.Helper()
+
+	out, err := exec.Command("git", append([]string{"-C", dir}, args...)...).CombinedOutput()
+	require.NoError(t, err, string(out))
+	return strings.TrimSpace(string(out))
+}
+
+func writeFile(t *testing.T, path string, content string) {
+	t.Helper()
+
+	err := ioutil.WriteFile(path, []byte(content), 0600)
+	require.NoError(t, err)
+}
@@ -6,6 +6,7 @@ import (
 	"sync"
 
 	"github.com/grafana/grafana/pkg/infra/log"
+	"github.com/grafana/grafana/pkg/models"
 	plugifaces "github.com/grafana/grafana/pkg/plugins"
 	"github.com/grafana/grafana/pkg/registry"
 	"github.com/grafana/grafana/pkg/services/encryption"
@@ -43,6 +44,9 @@ type ProvisioningService interface {
 	ProvisionDashboards(ctx context.Context) error
 	GetDashboardProvisionerResolvedPath(name string) string
 	GetAllowUIUpdatesFromConfig(name string) bool
+	WriteDashboardToProvisioner(ctx context.Context, provisioning *models.DashboardProvisioning, dash *models.Dashboard,
+		message string, user *models.SignedInUser) error
+	GetDashboardProvisionerStatus() []dashboards.ProvisionerStatus
 }
 
 // Add a public constructor for overriding service to be able to instantiate OSS as fallback
@@ -184,6 +188,19 @@ func (ps *ProvisioningServiceImpl) GetAllowUIUpdatesFromConfig(name string) bool
 	return ps.dashboardProvisioner.GetAllowUIUpdatesFromConfig(name)
 }
 
+// WriteDashboardToProvisioner writes a dashboard saved in the UI back to the
+// provider it was provisioned by, if it supports it.
+func (ps *ProvisioningServiceImpl) WriteDashboardToProvisioner(ctx context.Context, provisioning *models.DashboardProvisioning,
+	dash *models.Dashboard, message string, user *models.SignedInUser) error {
+	return ps.dashboardProvisioner.WriteDashboard(ctx, provisioning, dash, message, user)
+}
+
+// GetDashboardProvisionerStatus returns the synchronization status of the
+// dashboard providers.
+func (ps *ProvisioningServiceImpl) GetDashboardProvisionerStatus() []dashboards.ProvisionerStatus {
+	return ps.dashboardProvisioner.GetStatus()
+}
+
 func (ps *ProvisioningServiceImpl) cancelPolling() {
 	if ps.pollingCtxCancel != nil {
 		ps.log.Debug("Stop polling for dashboard changes")
@@ -1,6 +1,11 @@
 package provisioning
 
-import "context"
+import (
+	"context"
+
+	"github.com/grafana/grafana/pkg/models"
+	"github.com/grafana/grafana/pkg/services/provisioning/dashboards"
+)
 
 type Calls struct {
 	RunInitProvisioners                 []interface{}
@@ -10,6 +15,8 @@ type Calls struct {
 	ProvisionDashboards                 []interface{}
 	GetDashboardProvisionerResolvedPath []interface{}
 	GetAllowUIUpdatesFromConfig         []interface{}
+	WriteDashboardToProvisioner         []interface{}
+	GetDashboardProvisionerStatus       []interface{}
 	Run                                 []interface{}
 }
 
@@ -22,6 +29,8 @@ type ProvisioningServiceMock struct {
 	ProvisionDashboardsFunc                 func() error
 	GetDashboardProvisionerResolvedPathFunc func(name string) string
 	GetAllowUIUpdatesFromConfigFunc         func(name string) bool
+	WriteDashboardToProvisionerFunc         func(ctx context.Context, provisioning *models.DashboardProvisioning, dash *models.Dashboard, message string, user *models.SignedInUser) error
+	GetDashboardProvisionerStatusFunc       func() []dashboards.ProvisionerStatus
 	RunFunc                                 func(ctx context.Context) error
 }
 
@@ -87,6 +96,23 @@ func (mock *ProvisioningServiceMock) GetAllowUIUpdatesFromConfig(name string) bo
 	return false
 }
 
+func (mock *ProvisioningServiceMock) WriteDashboardToProvisioner(ctx context.Context, provisioning *models.DashboardProvisioning,
+	dash *models.Dashboard, message string, user *models.SignedInUser) error {
+	mock.Calls.WriteDashboardToProvisioner = append(mock.Calls.WriteDashboardToProvisioner, provisioning)
+	if mock.WriteDashboardToProvisionerFunc != nil {
+		return mock.WriteDashboardToProvisionerFunc(ctx, provisioning, dash, message, user)
+	}
+	return nil
+}
+
+func (mock *ProvisioningServiceMock) GetDashboardProvisionerStatus() []dashboards.ProvisionerStatus {
+	mock.Calls.GetDashboardProvisionerStatus = append(mock.Calls.GetDashboardProvisionerStatus, nil)
+	if mock.GetDashboardProvisionerStatusFunc != nil {
+		return mock.GetDashboardProvisionerStatusFunc()
+	}
+	return nil
+}
+
 func (mock *ProvisioningServiceMock) Run(ctx context.Context) error {
 	mock.Calls.Run = append(mock.Calls.Run, nil)
 	if mock.RunFunc != nil {
@@ -0,0 +1,237 @@
+package querycache
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"sort"
+	"time"
+
+	"github.com/grafana/grafana-plugin-sdk-go/backend"
+	"github.com/grafana/grafana/pkg/infra/log"
+	"github.com/grafana/grafana/pkg/infra/remotecache"
+	"github.com/grafana/grafana/pkg/models"
+	"github.com/grafana/grafana/pkg/plugins"
+	"github.com/grafana/grafana/pkg/services/datasources"
+	"github.com/grafana/grafana/pkg/setting"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/promauto"
+)
+
+// HeaderName is a response header reporting whether query results were served from the cache.
+const HeaderName = "X-Cache"
+
+// Status describes how the query cache handled a request.
+type Status string
+
+const (
+	// StatusHit means results were served from the cache.
+	StatusHit Status = "HIT"
+	// StatusMiss means results were queried from the data source and cached.
+	StatusMiss Status = "MISS"
+	// StatusBypass means caching is not enabled for the data source.
+	StatusBypass Status = "BYPASS"
+)
+
+// Data source settings (jsonData) controlling caching of its query results.
+const (
+	jsonDataEnabledKey = "queryCachingEnabled"
+	jsonDataTTLKey     = "queryCachingTTL"
+)
+
+var queryCacheRequestsTotal = promauto.NewCounterVec(prometheus.CounterOpts{
+	Namespace: "grafana",
+	Name:      "query_cache_requests_total",
+	Help:      "Number of data source query requests handled by the query cache, by status (hit or miss)",
+}, []string{"status", "datasource_type"})
+
+func init() {
+	remotecache.Register(cachedResponse{})
+}
+
+// cachedResponse is a JSON encoded backend.QueryDataResponse.
+type cachedResponse struct {
+	Data []byte
+}
+
+// QueryFunc queries the data source on a cache miss.
+type QueryFunc func() (*backend.QueryDataResponse, error)
+
+// PermissionChecker checks whether users are allowed to query data sources.
+type PermissionChecker interface {
+	CanQuery(ctx context.Context, user *models.SignedInUser, ds *models.DataSource) (bool, error)
+}
+
+// Service caches results of data source queries for data sources opting in.
+type Service struct {
+	cfg         *setting.Cfg
+	cache       remotecache.CacheStorage
+	permissions PermissionChecker
+	log         log.Logger
+}
+
+func ProvideService(cfg *setting.Cfg, remoteCache *remotecache.RemoteCache, permissions *datasources.PermissionsService) *Service {
+	return NewService(cfg, remoteCache, permissions)
+}
+
+func NewService(cfg *setting.Cfg, cache remotecache.CacheStorage, permissions PermissionChecker) *Service {
+	return &Service{cfg: cfg, cache: cache, permissions: permissions, log: log.New("querycache")}
+}
+
+// Query returns cached results of the query, or runs it and caches its results
+// when none are cached yet. Results are only served from the cache to users
+// allowed to query the data source.
+func (s *Service) Query(ctx context.Context, ds *models.DataSource, query plugins.DataQuery, fn QueryFunc) (*backend.QueryDataResponse, Status, error) {
+	ttl, enabled := s.cacheTTL(ds)
+	if !enabled {
+		resp, err := fn()
+		return resp, StatusBypass, err
+	}
+
+	if s.permissions != nil {
+		allowed, err := s.permissions.CanQuery(ctx, query.User, ds)
+		if err != nil {
+			return nil, StatusBypass, err
+		}
+		if !allowed {
+			return nil, StatusBypass, models.ErrDataSourceAccessDenied
+		}
+	}
+
+	key, err := cacheKey(ds, query)
+	if err != nil {
+		s.log.Warn("Failed to compute query cache key", "datasource", ds.Name, "err", err)
+		resp, err := fn()
+		return resp, StatusBypass, err
+	}
+
+	if resp, ok := s.get(key); ok {
+		queryCacheRequestsTotal.WithLabelValues("hit", ds.Type).Inc()
+		return resp, StatusHit, nil
+	}
+	queryCacheRequestsTotal.WithLabelValues("miss", ds.Type).Inc()
+
+	resp, err := fn()
+	if err != nil {
+		return nil, StatusMiss, err
+	}
+	s.set(key, resp, ttl)
+	return resp, StatusMiss, nil
+}
+
+// cacheTTL returns the TTL of cached query results of the data source and
+// whether caching is enabled for it.
+func (s *Service) cacheTTL(ds *models.DataSource) (time.Duration, bool) {
+	if !s.cfg.QueryCachingEnabled || ds.JsonData == nil || !ds.JsonData.Get(jsonDataEnabledKey).MustBool(false) {
+		return 0, false
+	}
+
+	ttl := s.cfg.QueryCachingDefaultTTL
+	if value := ds.JsonData.Get(jsonDataTTLKey).MustString(""); value != "" {
+		parsed, err := time.ParseDuration(value)
+		if err != nil || parsed <= 0 {
+			s.log.Warn("Invalid query caching TTL, using default", "datasource", ds.Name, "ttl", value)
+		} else {
+			ttl = parsed
+		}
+	}
+	return ttl, true
+}
+
+func (s *Service) get(key string) (*backend.QueryDataResponse, bool) {
+	value, err := s.cache.Get(key)
+	if err != nil {
+		if err != remotecache.ErrCacheItemNotFound {
+			s.log.Warn("Failed to read cached query results", "err", err)
+		}
+		return nil, false
+	}
+
+	cached, ok := value.(cachedResponse)
+	if !ok {
+		return nil, false
+	}
+	resp := &backend.QueryDataResponse{}
+	if err := json.Unmarshal(cached.Data, resp); err != nil {
+		s.log.Warn("Failed to decode cached query results", "err", err)
+		return nil, false
+	}
+	return resp, true
+}
+
+// set caches query results unless any of the queries failed.
+func (s *Service) set(key string, resp *backend.QueryDataResponse, ttl time.Duration) {
+	for _, res := range resp.Responses {
+		if res.Error != nil {
+			return
+		}
+	}
+
+	data, err := json.Marshal(resp)
+	if err != nil {
+		s.log.Warn("Failed to encode query results for caching", "err", err)
+		return
+	}
+	if err := s.cache.Set(key, cachedResponse{Data: data}, ttl); err != nil {
+		s.log.Warn("Failed to cache query results", "err", err)
+	}
+}
+
+// cacheKey identifies query results by the data source, the user, the
+// normalized queries and the time range rounded to the query interval.
+// Results are never shared between users since data sources may return
+// different data depending on the user's identity.
+func cacheKey(ds *models.DataSource, query plugins.DataQuery) (string, error) {
+	h := sha256.New()
+	fmt.Fprintf(h, "org:%d\ndatasource:%d:%d\n", ds.OrgId, ds.Id, ds.Version)
+	if query.User != nil {
+		fmt.Fprintf(h, "user:%d:%d\n", query.User.UserId, query.User.ApiKeyId)
+	}
+
+	queries := make([]plugins.DataSubQuery, len(query.Queries))
+	copy(queries, query.Queries)
+	sort.Slice(queries, func(i, j int) bool {
+		return queries[i].RefID < queries[j].RefID
+	})
+
+	var intervalMS int64 = 1000
+	for _, q := range queries {
+		if q.IntervalMS > intervalMS {
+			intervalMS = q.IntervalMS
+		}
+
+		model, err := normalizeModel(q)
+		if err != nil {
+			return "", err
+		}
+		fmt.Fprintf(h, "query:%s:%s:%d:%d:%s\n", q.RefID, q.QueryType, q.MaxDataPoints, q.IntervalMS, model)
+	}
+
+	if query.TimeRange != nil {
+		from := query.TimeRange.GetFromAsMsEpoch()
+		to := query.TimeRange.GetToAsMsEpoch()
+		fmt.Fprintf(h, "range:%d:%d\n", from-from%intervalMS, to-to%intervalMS)
+	}
+
+	return "query_cache:" + hex.EncodeToString(h.Sum(nil)), nil
+}
+
+// normalizeModel encodes the query model with sorted keys and without
+// properties which differ between otherwise identical queries.
+func normalizeModel(q plugins.DataSubQuery) ([]byte, error) {
+	if q.Model == nil {
+		return nil, nil
+	}
+	data, err := q.Model.MarshalJSON()
+	if err != nil {
+		return nil, err
+	}
+	var model map[string]interface{}
+	if err := json.Unmarshal(data, &model); err != nil {
+		return nil, err
+	}
+	delete(model, "requestId")
+	return json.Marshal(model)
+}
@@ -0,0 +1,188 @@
+package querycache
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/grafana/grafana-plugin-sdk-go/backend"
+	"github.com/grafana/grafana-plugin-sdk-go/data"
+	"github.com/grafana/grafana/pkg/components/simplejson"
+	"github.com/grafana/grafana/pkg/infra/remotecache"
+	"github.com/grafana/grafana/pkg/models"
+	"github.com/grafana/grafana/pkg/plugins"
+	"github.com/grafana/grafana/pkg/setting"
+	"github.com/stretchr/testify/require"
+)
+
+func newDataQuery(from, to string, user *models.SignedInUser, queries ...map[string]interface{}) plugins.DataQuery {
+	timeRange := plugins.NewDataTimeRange(from, to)
+	query := plugins.DataQuery{TimeRange: &timeRange, User: user}
+	for _, model := range queries {
+		query.Queries = append(query.Queries, plugins.DataSubQuery{
+			RefID:      model["refId"].(string),
+			IntervalMS: 60000,
+			Model:      simplejson.NewFromAny(model),
+		})
+	}
+	return query
+}
+
+func TestCacheKey(t *testing.T) {
+	ds := &models.DataSource{Id: 1, OrgId: 1, Version: 1, JsonData: simplejson.New()}
+	user := &models.SignedInUser{UserId: 1}
+	key := func(ds *models.DataSource, query plugins.DataQuery) string {
+		key, err := cacheKey(ds, query)
+		require.NoError(t, err)
+		return key
+	}
+
+	base := key(ds, newDataQuery("1599999960000", "1600003560000", user,
+		map[string]interface{}{"refId": "A", "expr": "up", "requestId": "1"},
+		map[string]interface{}{"refId": "B", "expr": "down"}))
+
+	t.Run("Query order and request ids don't change the key", func(t *testing.T) {
+		require.Equal(t, base, key(ds, newDataQuery("1599999960000", "1600003560000", user,
+			map[string]interface{}{"refId": "B", "expr": "down"},
+			map[string]interface{}{"requestId": "2", "expr": "up", "refId": "A"})))
+	})
+
+	t.Run("Time range is rounded to the query interval", func(t *testing.T) {
+		require.Equal(t, base, key(ds, newDataQuery("1599999990000", "1600003590000", user,
+			map[string]interface{}{"refId": "A", "expr": "up"},
+			map[string]interface{}{"refId": "B", "expr": "down"})))
+		require.NotEqual(t, base, key(ds, newDataQuery("1600000020000", "1600003620000", user,
+			map[string]interface{}{"refId": "A", "expr": "up"},
+			map[string]interface{}{"refId": "B", "expr": "down"})))
+	})
+
+	t.Run("Different queries have different keys", func(t *testing.T) {
+		require.NotEqual(t, base, key(ds, newDataQuery("1599999960000", "1600003560000", user,
+			map[string]interface{}{"refId": "A", "expr": "up"},
+			map[string]interface{}{"refId": "B", "expr": "sideways"})))
+	})
+
+	t.Run("Data source version changes the key", func(t *testing.T) {
+		updated := *ds
+		updated.Version = 2
+		require.NotEqual(t, base, key(&updated, newDataQuery("1599999960000", "1600003560000", user,
+			map[string]interface{}{"refId": "A", "expr": "up"},
+			map[string]interface{}{"refId": "B", "expr": "down"})))
+	})
+
+	t.Run("User is part of the key", func(t *testing.T) {
+		query := func(user *models.SignedInUser) plugins.DataQuery {
+			return newDataQuery("1599999960000", "1600003560000", user, map[string]interface{}{"refId": "A", "expr": "up"})
+		}
+		require.Equal(t, key(ds, query(user)), key(ds, query(&models.SignedInUser{UserId: 1})))
+		require.NotEqual(t, key(ds, query(user)), key(ds, query(&models.SignedInUser{UserId: 2})))
+		require.NotEqual(t, key(ds, query(&models.SignedInUser{ApiKeyId: 1})), key(ds, query(&models.SignedInUser{ApiKeyId: 2})))
+	})
+}
+
+type fakePermissionChecker struct {
+	denied map[int64]bool
+}
+
+func (f *fakePermissionChecker) CanQuery(_ context.Context, user *models.SignedInUser, _ *models.DataSource) (bool, error) {
+	return !f.denied[user.UserId], nil
+}
+
+func TestService_Query(t *testing.T) {
+	cfg := setting.NewCfg()
+	cfg.QueryCachingEnabled = true
+	cfg.QueryCachingDefaultTTL = time.Minute
+	permissions := &fakePermissionChecker{denied: map[int64]bool{}}
+	s := NewService(cfg, remotecache.NewFakeStore(t), permissions)
+	ctx := context.Background()
+
+	query := newDataQuery("now-1h", "now", &models.SignedInUser{UserId: 1}, map[string]interface{}{"refId": "A", "expr": "up"})
+	calls := 0
+	fn := func() (*backend.QueryDataResponse, error) {
+		calls++
+		resp := backend.NewQueryDataResponse()
+		resp.Responses["A"] = backend.DataResponse{Frames: data.Frames{
+			data.NewFrame("up", data.NewField("value", nil, []float64{1, 2})),
+		}}
+		return resp, nil
+	}
+
+	t.Run("Data source without caching enabled is bypassed", func(t *testing.T) {
+		calls = 0
+		ds := &models.DataSource{Id: 1, Type: "prometheus", JsonData: simplejson.New()}
+		for i := 0; i < 2; i++ {
+			_, status, err := s.Query(ctx, ds, query, fn)
+			require.NoError(t, err)
+			require.Equal(t, StatusBypass, status)
+		}
+		require.Equal(t, 2, calls)
+	})
+
+	t.Run("Cached results are returned", func(t *testing.T) {
+		calls = 0
+		ds := &models.DataSource{Id: 2, Type: "prometheus", JsonData: simplejson.NewFromAny(map[string]interface{}{
+			"queryCachingEnabled": true,
+			"queryCachingTTL":     "5m",
+		})}
+
+		resp, status, err := s.Query(ctx, ds, query, fn)
+		require.NoError(t, err)
+		require.Equal(t, StatusMiss, status)
+
+		cached, status, err := s.Query(ctx, ds, query, fn)
+		require.NoError(t, err)
+		require.Equal(t, StatusHit, status)
+		require.Equal(t, 1, calls)
+		require.Equal(t, resp.Responses["A"].Frames[0].Name, cached.Responses["A"].Frames[0].Name)
+		require.Equal(t, 2, cached.Responses["A"].Frames[0].Rows())
+	})
+
+	t.Run("Cached results are only returned to users allowed to query the data source", func(t *testing.T) {
+		calls = 0
+		ds := &models.DataSource{Id: 5, Type: "prometheus", JsonData: simplejson.NewFromAny(map[string]interface{}{
+			"queryCachingEnabled": true,
+		})}
+
+		_, status, err := s.Query(ctx, ds, query, fn)
+		require.NoError(t, err)
+		require.Equal(t, StatusMiss, status)
+
+		permissions.denied[1] = true
+		t.Cleanup(func() { delete(permissions.denied, 1) })
+		_, _, err = s.Query(ctx, ds, query, fn)
+		require.ErrorIs(t, err, models.ErrDataSourceAccessDenied)
+		require.Equal(t, 1, calls)
+	})
+
+	t.Run("Caching disabled globally is bypassed", func(t *testing.T) {
+		calls = 0
+		cfg.QueryCachingEnabled = false
+		t.Cleanup(func() { cfg.QueryCachingEnabled = true })
+		ds := &models.DataSource{Id: 3, Type: "prometheus", JsonData: simplejson.NewFromAny(map[string]interface{}{
+			"queryCachingEnabled": true,
+		})}
+		_, status, err := s.Query(ctx, ds, query, fn)
+		require.NoError(t, err)
+		require.Equal(t, StatusBypass, status)
+	})
+
+	t.Run("Failed queries are not cached", func(t *testing.T) {
+		calls = 0
+		ds := &models.DataSource{Id: 4, Type: "prometheus", JsonData: simplejson.NewFromAny(map[string]interface{}{
+			"queryCachingEnabled": true,
+		})}
+		failing := func() (*backend.QueryDataResponse, error) {
+			calls++
+			resp := backend.NewQueryDataResponse()
+			resp.Responses["A"] = backend.DataResponse{Error: errors.New("bad query")}
+			return resp, nil
+		}
+		for i := 0; i < 2; i++ {
+			_, status, err := s.Query(ctx, ds, query, failing)
+			require.NoError(t, err)
+			require.Equal(t, StatusMiss, status)
+		}
+		require.Equal(t, 2, calls)
+	})
+}
@@ -0,0 +1,113 @@
+package queryhistory
+
+import (
+	"errors"
+
+	"github.com/go-macaron/binding"
+	"github.com/grafana/grafana/pkg/api/response"
+	"github.com/grafana/grafana/pkg/api/routing"
+	"github.com/grafana/grafana/pkg/middleware"
+	"github.com/grafana/grafana/pkg/models"
+	"github.com/grafana/grafana/pkg/web"
+)
+
+func (s *QueryHistoryService) registerAPIEndpoints() {
+	s.RouteRegister.Group("/api/query-history", func(entities routing.RouteRegister) {
+		entities.Post("/", middleware.ReqSignedIn, binding.Bind(CreateQueryInQueryHistoryCommand{}), routing.Wrap(s.createHandler))
+		entities.Get("/", middleware.ReqSignedIn, routing.Wrap(s.searchHandler))
+		entities.Delete("/:uid", middleware.ReqSignedIn, routing.Wrap(s.deleteHandler))
+		entities.Patch("/:uid", middleware.ReqSignedIn, binding.Bind(PatchQueryCommentInQueryHistoryCommand{}), routing.Wrap(s.patchCommentHandler))
+		entities.Post("/star/:uid", middleware.ReqSignedIn, routing.Wrap(s.starHandler))
+		entities.Delete("/star/:uid", middleware.ReqSignedIn, routing.Wrap(s.unstarHandler))
+	})
+}
+
+// createHandler handles POST /api/query-history.
+func (s *QueryHistoryService) createHandler(c *models.ReqContext, cmd CreateQueryInQueryHistoryCommand) response.Response {
+	query, err := s.CreateQueryInQueryHistory(c.Req.Context(), c.SignedInUser, cmd)
+	if err != nil {
+		return toQueryHistoryError(err, "Failed to add query to query history")
+	}
+
+	return response.JSON(200, QueryHistoryResponse{Result: query})
+}
+
+// searchHandler handles GET /api/query-history.
+func (s *QueryHistoryService) searchHandler(c *models.ReqContext) response.Response {
+	query := SearchInQueryHistoryQuery{
+		DatasourceUIDs: c.QueryStrings("datasourceUid"),
+		SearchString:   c.Query("searchString"),
+		OnlyStarred:    c.QueryBool("onlyStarred"),
+		Sort:           c.Query("sort"),
+		From:           c.QueryInt64("from"),
+		To:             c.QueryInt64("to"),
+		Page:           c.QueryInt("page"),
+		Limit:          c.QueryInt("limit"),
+	}
+
+	result, err := s.SearchInQueryHistory(c.Req.Context(), c.SignedInUser, query)
+	if err != nil {
+		return toQueryHistoryError(err, "Failed to search in query history")
+	}
+
+	return response.JSON(200, QueryHistorySearchResponse{Result: result})
+}
+
+// deleteHandler handles DELETE /api/query-history/:uid.
+func (s *QueryHistoryService) deleteHandler(c *models.ReqContext) response.Response {
+	id, err := s.DeleteQueryFromQueryHistory(c.Req.Context(), c.SignedInUser, web.Params(c.Req)[":uid"])
+	if err != nil {
+		return toQueryHistoryError(err, "Failed to delete query from query history")
+	}
+
+	return response.JSON(200, QueryHistoryDeleteQueryResponse{
+		ID:      id,
+		Message: "Query deleted",
+	})
+}
+
+// patchCommentHandler handles PATCH /api/query-history/:uid.
+func (s *QueryHistoryService) patchCommentHandler(c *models.ReqContext, cmd PatchQueryCommentInQueryHistoryCommand) response.Response {
+	query, err := s.PatchQueryCommentInQueryHistory(c.Req.Context(), c.SignedInUser, web.Params(c.Req)[":uid"], cmd)
+	if err != nil {
+		return toQueryHistoryError(err, "Failed to update comment of query in query history")
+	}
+
+	return response.JSON(200, QueryHistoryResponse{Result: query})
+}
+
+// starHandler handles POST /api/query-history/star/:uid.
+func (s *QueryHistoryService) starHandler(c *models.ReqContext) response.Response {
+	query, err := s.StarQueryInQueryHistory(c.Req.Context(), c.SignedInUser, web.Params(c.Req)[":uid"])
+	if err != nil {
+		return toQueryHistoryError(err, "Failed to star query in query history")
+	}
+
+	return response.JSON(200, QueryHistoryResponse{Result: query})
+}
+
+// unstarHandler handles DELETE /api/query-history/star/:uid.
+func (s *QueryHistoryService) unstarHandler(c *models.ReqContext) response.Response {
+	query, err := s.UnstarQueryInQueryHistory(c.Req.Context(), c.SignedInUser, web.Params(c.Req)[":uid"])
+	if err != nil {
+		return toQueryHistoryError(err, "Failed to unstar query in query history")
+	}
+
+	return response.JSON(200, QueryHistoryResponse{Result: query})
+}
+
+func toQueryHistoryError(err error, message string) response.Response {
+	if errors.Is(err, ErrQueryNotFound) {
+		return response.Error(404, ErrQueryNotFound.Error(), err)
+	}
+	if errors.Is(err, ErrStarredQueryNotFound) {
+		return response.Error(404, ErrStarredQueryNotFound.Error(), err)
+	}
+	if errors.Is(err, ErrQueryAlreadyStarred) {
+		return response.Error(400, ErrQueryAlreadyStarred.Error(), err)
+	}
+	if errors.Is(err, errQueriesRequired) {
+		return response.Error(400, errQueriesRequired.Error(), err)
+	}
+	return response.Error(500, message, err)
+}
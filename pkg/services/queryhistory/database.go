@@ -0,0 +1,258 @@
+package queryhistory
+
+import (
+	"context"
+	"strings"
+	"time"
+
+	"github.com/grafana/grafana/pkg/models"
+	"github.com/grafana/grafana/pkg/services/sqlstore"
+	"github.com/grafana/grafana/pkg/util"
+)
+
+var getTime = time.Now
+
+func (s QueryHistoryService) createQuery(ctx context.Context, user *models.SignedInUser, cmd CreateQueryInQueryHistoryCommand) (QueryHistoryDTO, error) {
+	if cmd.Queries == nil || len(cmd.Queries.MustArray()) == 0 {
+		return QueryHistoryDTO{}, errQueriesRequired
+	}
+
+	query := QueryHistory{
+		OrgID:         user.OrgId,
+		UID:           util.GenerateShortUID(),
+		DatasourceUID: cmd.DatasourceUID,
+		CreatedBy:     user.UserId,
+		CreatedAt:     getTime().Unix(),
+		Queries:       cmd.Queries,
+	}
+
+	err := s.SQLStore.WithDbSession(ctx, func(session *sqlstore.DBSession) error {
+		_, err := session.Insert(&query)
+		return err
+	})
+	if err != nil {
+		return QueryHistoryDTO{}, err
+	}
+
+	return toQueryHistoryDTO(query, false), nil
+}
+
+func (s QueryHistoryService) searchQueries(ctx context.Context, user *models.SignedInUser, query SearchInQueryHistoryQuery) (QueryHistorySearchResult, error) {
+	if query.Limit <= 0 {
+		query.Limit = defaultSearchLimit
+	}
+	if query.Limit > maxSearchLimit {
+		query.Limit = maxSearchLimit
+	}
+	if query.Page <= 0 {
+		query.Page = 1
+	}
+
+	result := QueryHistorySearchResult{
+		QueryHistory: make([]QueryHistoryDTO, 0),
+		Page:         query.Page,
+		PerPage:      query.Limit,
+	}
+
+	err := s.SQLStore.WithDbSession(ctx, func(session *sqlstore.DBSession) error {
+		builder := sqlstore.SQLBuilder{}
+		builder.Write(`SELECT query_history.*, CASE WHEN query_history_star.query_uid IS NULL THEN 0 ELSE 1 END AS starred`)
+		s.writeSearchFromSQL(user, query, &builder)
+		if query.Sort == sortTimeAsc {
+			builder.Write(" ORDER BY query_history.created_at ASC, query_history.id ASC")
+		} else {
+			builder.Write(" ORDER BY query_history.created_at DESC, query_history.id DESC")
+		}
+		builder.Write(s.SQLStore.Dialect.LimitOffset(int64(query.Limit), int64(query.Limit*(query.Page-1))))
+
+		var queries []queryHistoryWithStar
+		if err := session.SQL(builder.GetSQLString(), builder.GetParams()...).Find(&queries); err != nil {
+			return err
+		}
+		for _, q := range queries {
+			result.QueryHistory = append(result.QueryHistory, toQueryHistoryDTO(q.QueryHistory, q.Starred))
+		}
+
+		countBuilder := sqlstore.SQLBuilder{}
+		countBuilder.Write("SELECT COUNT(*)")
+		s.writeSearchFromSQL(user, query, &countBuilder)
+		_, err := session.SQL(countBuilder.GetSQLString(), countBuilder.GetParams()...).Get(&result.TotalCount)
+		return err
+	})
+
+	return result, err
+}
+
+func (s QueryHistoryService) writeSearchFromSQL(user *models.SignedInUser, query SearchInQueryHistoryQuery, builder *sqlstore.SQLBuilder) {
+	builder.Write(" FROM query_history")
+	builder.Write(" LEFT JOIN query_history_star ON query_history_star.query_uid = query_history.uid AND query_history_star.user_id = ?", user.UserId)
+	builder.Write(" WHERE query_history.org_id = ? AND query_history.created_by = ?", user.OrgId, user.UserId)
+
+	if len(query.DatasourceUIDs) > 0 {
+		params := make([]interface{}, 0, len(query.DatasourceUIDs))
+		for _, uid := range query.DatasourceUIDs {
+			params = append(params, uid)
+		}
+		builder.Write(" AND query_history.datasource_uid IN (?"+strings.Repeat(",?", len(params)-1)+")", params...)
+	}
+	if len(strings.TrimSpace(query.SearchString)) > 0 {
+		pattern := sqlstore.LikeContainsPattern(query.SearchString)
+		builder.Write(" AND (query_history.queries "+s.SQLStore.Dialect.LikeStr()+" ? "+sqlstore.LikeEscapeStr, pattern)
+		builder.Write(" OR query_history.comment "+s.SQLStore.Dialect.LikeStr()+" ? "+sqlstore.LikeEscapeStr+")", pattern)
+	}
+	if query.OnlyStarred {
+		builder.Write(" AND query_history_star.query_uid IS NOT NULL")
+	}
+	if query.From > 0 {
+		builder.Write(" AND query_history.created_at >= ?", query.From)
+	}
+	if query.To > 0 {
+		builder.Write(" AND query_history.created_at <= ?", query.To)
+	}
+}
+
+func (s QueryHistoryService) deleteQuery(ctx context.Context, user *models.SignedInUser, UID string) (int64, error) {
+	var queryID int64
+	err := s.SQLStore.WithTransactionalDbSession(ctx, func(session *sqlstore.DBSession) error {
+		query, err := getQuery(session, user, UID)
+		if err != nil {
+			return err
+		}
+
+		if _, err := session.Exec("DELETE FROM query_history_star WHERE query_uid = ?", query.UID); err != nil {
+			return err
+		}
+		if _, err := session.Exec("DELETE FROM query_history WHERE id = ?", query.ID); err != nil {
+			return err
+		}
+
+		queryID = query.ID
+		return nil
+	})
+
+	return queryID, err
+}
+
+func (s QueryHistoryService) patchQueryComment(ctx context.Context, user *models.SignedInUser, UID string, cmd PatchQueryCommentInQueryHistoryCommand) (QueryHistoryDTO, error) {
+	var dto QueryHistoryDTO
+	err := s.SQLStore.WithTransactionalDbSession(ctx, func(session *sqlstore.DBSession) error {
+		query, err := getQuery(session, user, UID)
+		if err != nil {
+			return err
+		}
+
+		query.Comment = cmd.Comment
+		if _, err := session.ID(query.ID).Cols("comment").Update(&query); err != nil {
+			return err
+		}
+
+		starred, err := isStarred(session, user, UID)
+		if err != nil {
+			return err
+		}
+
+		dto = toQueryHistoryDTO(query, starred)
+		return nil
+	})
+
+	return dto, err
+}
+
+func (s QueryHistoryService) starQuery(ctx context.Context, user *models.SignedInUser, UID string) (QueryHistoryDTO, error) {
+	var dto QueryHistoryDTO
+	err := s.SQLStore.WithTransactionalDbSession(ctx, func(session *sqlstore.DBSession) error {
+		query, err := getQuery(session, user, UID)
+		if err != nil {
+			return err
+		}
+
+		starred, err := isStarred(session, user, UID)
+		if err != nil {
+			return err
+		}
+		if starred {
+			return ErrQueryAlreadyStarred
+		}
+
+		if _, err := session.Insert(&QueryHistoryStar{QueryUID: UID, UserID: user.UserId}); err != nil {
+			return err
+		}
+
+		dto = toQueryHistoryDTO(query, true)
+		return nil
+	})
+
+	return dto, err
+}
+
+func (s QueryHistoryService) unstarQuery(ctx context.Context, user *models.SignedInUser, UID string) (QueryHistoryDTO, error) {
+	var dto QueryHistoryDTO
+	err := s.SQLStore.WithTransactionalDbSession(ctx, func(session *sqlstore.DBSession) error {
+		query, err := getQuery(session, user, UID)
+		if err != nil {
+			return err
+		}
+
+		result, err := session.Exec("DELETE FROM query_history_star WHERE query_uid = ? AND user_id = ?", UID, user.UserId)
+		if err != nil {
+			return err
+		}
+		if rowsAffected, err := result.RowsAffected(); err != nil {
+			return err
+		} else if rowsAffected == 0 {
+			return ErrStarredQueryNotFound
+		}
+
+		dto = toQueryHistoryDTO(query, false)
+		return nil
+	})
+
+	return dto, err
+}
+
+func (s QueryHistoryService) deleteStaleQueries(ctx context.Context, olderThan time.Time) (int64, error) {
+	var deleted int64
+	err := s.SQLStore.WithTransactionalDbSession(ctx, func(session *sqlstore.DBSession) error {
+		var rawSQL = "DELETE FROM query_history WHERE created_at <= ? AND uid NOT IN (SELECT query_uid FROM query_history_star)"
+
+		result, err := session.Exec(rawSQL, olderThan.Unix())
+		if err != nil {
+			return err
+		}
+		deleted, err = result.RowsAffected()
+		return err
+	})
+
+	return deleted, err
+}
+
+// getQuery returns a query of the user, queries of other users are reported
+// as not found.
+func getQuery(session *sqlstore.DBSession, user *models.SignedInUser, UID string) (QueryHistory, error) {
+	var query QueryHistory
+	exists, err := session.Where("org_id = ? AND created_by = ? AND uid = ?", user.OrgId, user.UserId, UID).Get(&query)
+	if err != nil {
+		return QueryHistory{}, err
+	}
+	if !exists {
+		return QueryHistory{}, ErrQueryNotFound
+	}
+
+	return query, nil
+}
+
+func isStarred(session *sqlstore.DBSession, user *models.SignedInUser, UID string) (bool, error) {
+	return session.Where("user_id = ? AND query_uid = ?", user.UserId, UID).Exist(&QueryHistoryStar{})
+}
+
+func toQueryHistoryDTO(query QueryHistory, starred bool) QueryHistoryDTO {
+	return QueryHistoryDTO{
+		UID:           query.UID,
+		DatasourceUID: query.DatasourceUID,
+		CreatedBy:     query.CreatedBy,
+		CreatedAt:     query.CreatedAt,
+		Comment:       query.Comment,
+		Queries:       query.Queries,
+		Starred:       starred,
+	}
+}
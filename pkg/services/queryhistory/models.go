@@ -0,0 +1,107 @@
+package queryhistory
+
+import (
+	"errors"
+
+	"github.com/grafana/grafana/pkg/components/simplejson"
+)
+
+var (
+	ErrQueryNotFound        = errors.New("query in query history not found")
+	ErrStarredQueryNotFound = errors.New("starred query not found")
+	ErrQueryAlreadyStarred  = errors.New("query was already starred")
+	errQueriesRequired      = errors.New("queries are required")
+)
+
+const (
+	sortTimeDesc = "time-desc"
+	sortTimeAsc  = "time-asc"
+
+	defaultSearchLimit = 100
+	maxSearchLimit     = 1000
+)
+
+// QueryHistory is the model for query history definitions.
+type QueryHistory struct {
+	ID            int64  `xorm:"pk autoincr 'id'"`
+	UID           string `xorm:"uid"`
+	DatasourceUID string `xorm:"datasource_uid"`
+	OrgID         int64  `xorm:"org_id"`
+	CreatedBy     int64
+	CreatedAt     int64
+	Comment       string
+	Queries       *simplejson.Json
+}
+
+// QueryHistoryStar is the model for starred queries of a user.
+type QueryHistoryStar struct {
+	ID       int64  `xorm:"pk autoincr 'id'"`
+	QueryUID string `xorm:"query_uid"`
+	UserID   int64  `xorm:"user_id"`
+}
+
+// queryHistoryWithStar is the model used to retrieve queries with their star.
+type queryHistoryWithStar struct {
+	QueryHistory `xorm:"extends"`
+	Starred      bool
+}
+
+// CreateQueryInQueryHistoryCommand is the command for adding a query to query history.
+type CreateQueryInQueryHistoryCommand struct {
+	DatasourceUID string           `json:"datasourceUid"`
+	Queries       *simplejson.Json `json:"queries"`
+}
+
+// PatchQueryCommentInQueryHistoryCommand is the command for updating the comment of a query.
+type PatchQueryCommentInQueryHistoryCommand struct {
+	Comment string `json:"comment"`
+}
+
+// SearchInQueryHistoryQuery is the query for searching in query history.
+type SearchInQueryHistoryQuery struct {
+	DatasourceUIDs []string
+	SearchString   string
+	OnlyStarred    bool
+	// Sort is either time-desc (default) or time-asc.
+	Sort string
+	// From and To limit the search to queries created in the time range, in Unix seconds.
+	From  int64
+	To    int64
+	Page  int
+	Limit int
+}
+
+// QueryHistoryDTO is the frontend DTO for query history.
+type QueryHistoryDTO struct {
+	UID           string           `json:"uid"`
+	DatasourceUID string           `json:"datasourceUid"`
+	CreatedBy     int64            `json:"createdBy"`
+	CreatedAt     int64            `json:"createdAt"`
+	Comment       string           `json:"comment"`
+	Queries       *simplejson.Json `json:"queries"`
+	Starred       bool             `json:"starred"`
+}
+
+// QueryHistorySearchResult is the search result for query history.
+type QueryHistorySearchResult struct {
+	TotalCount   int64             `json:"totalCount"`
+	QueryHistory []QueryHistoryDTO `json:"queryHistory"`
+	Page         int               `json:"page"`
+	PerPage      int               `json:"perPage"`
+}
+
+// QueryHistoryResponse is a response struct for QueryHistoryDTO.
+type QueryHistoryResponse struct {
+	Result QueryHistoryDTO `json:"result"`
+}
+
+// QueryHistorySearchResponse is a response struct for QueryHistorySearchResult.
+type QueryHistorySearchResponse struct {
+	Result QueryHistorySearchResult `json:"result"`
+}
+
+// QueryHistoryDeleteQueryResponse is the response struct for deleting a query from query history.
+type QueryHistoryDeleteQueryResponse struct {
+	ID      int64  `json:"id"`
+	Message string `json:"message"`
+}
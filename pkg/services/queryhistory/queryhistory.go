@@ -0,0 +1,87 @@
+package queryhistory
+
+import (
+	"context"
+	"time"
+
+	"github.com/grafana/grafana/pkg/api/routing"
+	"github.com/grafana/grafana/pkg/infra/log"
+	"github.com/grafana/grafana/pkg/models"
+	"github.com/grafana/grafana/pkg/services/sqlstore"
+	"github.com/grafana/grafana/pkg/setting"
+)
+
+func ProvideService(cfg *setting.Cfg, sqlStore *sqlstore.SQLStore, routeRegister routing.RouteRegister) *QueryHistoryService {
+	s := &QueryHistoryService{
+		Cfg:           cfg,
+		SQLStore:      sqlStore,
+		RouteRegister: routeRegister,
+		log:           log.New("query-history"),
+	}
+
+	// The API is only registered when query history is enabled, stale
+	// queries are cleaned up either way.
+	if cfg.QueryHistoryEnabled {
+		s.registerAPIEndpoints()
+	}
+
+	return s
+}
+
+// Service is a service for operating on the query history of users.
+type Service interface {
+	CreateQueryInQueryHistory(ctx context.Context, user *models.SignedInUser, cmd CreateQueryInQueryHistoryCommand) (QueryHistoryDTO, error)
+	SearchInQueryHistory(ctx context.Context, user *models.SignedInUser, query SearchInQueryHistoryQuery) (QueryHistorySearchResult, error)
+	DeleteQueryFromQueryHistory(ctx context.Context, user *models.SignedInUser, UID string) (int64, error)
+	PatchQueryCommentInQueryHistory(ctx context.Context, user *models.SignedInUser, UID string, cmd PatchQueryCommentInQueryHistoryCommand) (QueryHistoryDTO, error)
+	StarQueryInQueryHistory(ctx context.Context, user *models.SignedInUser, UID string) (QueryHistoryDTO, error)
+	UnstarQueryInQueryHistory(ctx context.Context, user *models.SignedInUser, UID string) (QueryHistoryDTO, error)
+	DeleteStaleQueriesInQueryHistory(ctx context.Context, olderThan time.Time) (int64, error)
+}
+
+// QueryHistoryService is the service for the query history feature.
+type QueryHistoryService struct {
+	Cfg           *setting.Cfg
+	SQLStore      *sqlstore.SQLStore
+	RouteRegister routing.RouteRegister
+	log           log.Logger
+}
+
+// CreateQueryInQueryHistory adds a query to the query history of the user.
+func (s QueryHistoryService) CreateQueryInQueryHistory(ctx context.Context, user *models.SignedInUser, cmd CreateQueryInQueryHistoryCommand) (QueryHistoryDTO, error) {
+	return s.createQuery(ctx, user, cmd)
+}
+
+// SearchInQueryHistory searches in the query history of the user.
+func (s QueryHistoryService) SearchInQueryHistory(ctx context.Context, user *models.SignedInUser, query SearchInQueryHistoryQuery) (QueryHistorySearchResult, error) {
+	return s.searchQueries(ctx, user, query)
+}
+
+// DeleteQueryFromQueryHistory deletes a query of the user and returns its id.
+func (s QueryHistoryService) DeleteQueryFromQueryHistory(ctx context.Context, user *models.SignedInUser, UID string) (int64, error) {
+	return s.deleteQuery(ctx, user, UID)
+}
+
+// PatchQueryCommentInQueryHistory updates the comment of a query of the user.
+func (s QueryHistoryService) PatchQueryCommentInQueryHistory(ctx context.Context, user *models.SignedInUser, UID string, cmd PatchQueryCommentInQueryHistoryCommand) (QueryHistoryDTO, error) {
+	return s.patchQueryComment(ctx, user, UID, cmd)
+}
+
+// StarQueryInQueryHistory stars a query of the user, starred queries are kept
+// after the retention period.
+func (s QueryHistoryService) StarQueryInQueryHistory(ctx context.Context, user *models.SignedInUser, UID string) (QueryHistoryDTO, error) {
+	return s.starQuery(ctx, user, UID)
+}
+
+// UnstarQueryInQueryHistory removes the star of a query of the user.
+func (s QueryHistoryService) UnstarQueryInQueryHistory(ctx context.Context, user *models.SignedInUser, UID string) (QueryHistoryDTO, error) {
+	return s.unstarQuery(ctx, user, UID)
+}
+
+// DeleteStaleQueriesInQueryHistory deletes unstarred queries created before
+// olderThan and returns the number of deleted queries.
+func (s QueryHistoryService) DeleteStaleQueriesInQueryHistory(ctx context.Context, olderThan time.Time) (int64, error) {
+	return s.deleteStaleQueries(ctx, olderThan)
+}
+
+var _ Service = &QueryHistoryService{}
@@ -0,0 +1,137 @@
+package queryhistory
+
+import (
+	"encoding/json"
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/grafana/grafana/pkg/api/response"
+	"github.com/grafana/grafana/pkg/web"
+	"github.com/stretchr/testify/require"
+)
+
+func TestSearchInQueryHistory(t *testing.T) {
+	testScenario(t, "When users tries to search without queries, it should return an empty result",
+		func(t *testing.T, sc scenarioContext) {
+			result := callSearch(t, sc, "")
+			require.Equal(t, int64(0), result.Result.TotalCount)
+			require.Empty(t, result.Result.QueryHistory)
+			require.Equal(t, 1, result.Result.Page)
+			require.Equal(t, defaultSearchLimit, result.Result.PerPage)
+		})
+
+	scenarioWithQueries(t, "When users tries to search by data source, it should only return queries of the data source",
+		func(t *testing.T, sc scenarioContext, uids []string) {
+			result := callSearch(t, sc, "datasourceUid=other")
+			require.Equal(t, int64(1), result.Result.TotalCount)
+			require.Equal(t, uids[2], result.Result.QueryHistory[0].UID)
+
+			result = callSearch(t, sc, "datasourceUid=other&datasourceUid=NCzh67i")
+			require.Equal(t, int64(3), result.Result.TotalCount)
+		})
+
+	scenarioWithQueries(t, "When users tries to search by text, it should match queries and comments",
+		func(t *testing.T, sc scenarioContext, uids []string) {
+			sc.ctx.Req = web.SetURLParams(sc.ctx.Req, map[string]string{":uid": uids[1]})
+			resp := sc.service.patchCommentHandler(sc.reqContext, PatchQueryCommentInQueryHistoryCommand{Comment: "latency"})
+			require.Equal(t, 200, resp.Status())
+
+			result := callSearch(t, sc, "searchString=latency")
+			require.Equal(t, int64(2), result.Result.TotalCount)
+			require.Equal(t, []string{uids[1], uids[0]}, resultUIDs(result))
+		})
+
+	scenarioWithQueries(t, "When users tries to search by text with wildcards, it should match them literally",
+		func(t *testing.T, sc scenarioContext, uids []string) {
+			result := callSearch(t, sc, "searchString=%25")
+			require.Equal(t, int64(0), result.Result.TotalCount)
+
+			result = callSearch(t, sc, "searchString=late_cy")
+			require.Equal(t, int64(0), result.Result.TotalCount)
+		})
+
+	scenarioWithQueries(t, "When users tries to search starred queries, it should only return starred queries",
+		func(t *testing.T, sc scenarioContext, uids []string) {
+			sc.ctx.Req = web.SetURLParams(sc.ctx.Req, map[string]string{":uid": uids[1]})
+			require.Equal(t, 200, sc.service.starHandler(sc.reqContext).Status())
+
+			result := callSearch(t, sc, "onlyStarred=true")
+			require.Equal(t, []string{uids[1]}, resultUIDs(result))
+			require.True(t, result.Result.QueryHistory[0].Starred)
+		})
+
+	scenarioWithQueries(t, "When users tries to search by time, it should only return queries in the time range",
+		func(t *testing.T, sc scenarioContext, uids []string) {
+			result := callSearch(t, sc, "from=1600000100&to=1600000200")
+			require.Equal(t, []string{uids[2], uids[1]}, resultUIDs(result))
+		})
+
+	scenarioWithQueries(t, "When users tries to search with sort and pagination, it should return the page",
+		func(t *testing.T, sc scenarioContext, uids []string) {
+			result := callSearch(t, sc, "sort=time-asc&limit=2&page=2")
+			require.Equal(t, int64(3), result.Result.TotalCount)
+			require.Equal(t, []string{uids[2]}, resultUIDs(result))
+
+			result = callSearch(t, sc, "limit=2")
+			require.Equal(t, []string{uids[2], uids[1]}, resultUIDs(result))
+		})
+
+	scenarioWithQueries(t, "When another user tries to search, it should not return queries of other users",
+		func(t *testing.T, sc scenarioContext, uids []string) {
+			sc.reqContext.SignedInUser.UserId = 2
+			result := callSearch(t, sc, "")
+			require.Equal(t, int64(0), result.Result.TotalCount)
+		})
+}
+
+func scenarioWithQueries(t *testing.T, desc string, fn func(t *testing.T, sc scenarioContext, uids []string)) {
+	t.Helper()
+
+	testScenario(t, desc, func(t *testing.T, sc scenarioContext) {
+		defer func() { getTime = time.Now }()
+
+		var uids []string
+		for i, cmd := range []CreateQueryInQueryHistoryCommand{
+			getCreateCommand("NCzh67i", "latency"),
+			getCreateCommand("NCzh67i", "requests"),
+			getCreateCommand("other", "errors"),
+		} {
+			createdAt := time.Unix(1600000000+int64(i)*100, 0)
+			getTime = func() time.Time { return createdAt }
+			uids = append(uids, createQuery(t, sc, cmd))
+		}
+
+		fn(t, sc, uids)
+	})
+}
+
+func callSearch(t *testing.T, sc scenarioContext, query string) queryHistorySearchResult {
+	t.Helper()
+
+	req, err := http.NewRequest(http.MethodGet, "/api/query-history?"+query, nil)
+	require.NoError(t, err)
+	sc.ctx.Req = req
+
+	return validateAndUnMarshalSearchResponse(t, sc.service.searchHandler(sc.reqContext))
+}
+
+func validateAndUnMarshalSearchResponse(t *testing.T, resp response.Response) queryHistorySearchResult {
+	t.Helper()
+
+	require.Equal(t, 200, resp.Status())
+
+	var result = queryHistorySearchResult{}
+	err := json.Unmarshal(resp.Body(), &result)
+	require.NoError(t, err)
+
+	return result
+}
+
+func resultUIDs(result queryHistorySearchResult) []string {
+	uids := []string{}
+	for _, q := range result.Result.QueryHistory {
+		uids = append(uids, q.UID)
+	}
+	return uids
+}
@@ -0,0 +1,261 @@
+package queryhistory
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/grafana/grafana/pkg/api/response"
+	"github.com/grafana/grafana/pkg/components/simplejson"
+	"github.com/grafana/grafana/pkg/models"
+	"github.com/grafana/grafana/pkg/services/sqlstore"
+	"github.com/grafana/grafana/pkg/setting"
+	"github.com/grafana/grafana/pkg/web"
+	"github.com/stretchr/testify/require"
+)
+
+type scenarioContext struct {
+	ctx        *web.Context
+	service    *QueryHistoryService
+	reqContext *models.ReqContext
+	sqlStore   *sqlstore.SQLStore
+}
+
+type queryHistoryResult struct {
+	Result QueryHistoryDTO `json:"result"`
+}
+
+type queryHistorySearchResult struct {
+	Result QueryHistorySearchResult `json:"result"`
+}
+
+func TestCreateQueryInQueryHistory(t *testing.T) {
+	testScenario(t, "When users tries to add query without queries, it should fail",
+		func(t *testing.T, sc scenarioContext) {
+			resp := sc.service.createHandler(sc.reqContext, CreateQueryInQueryHistoryCommand{DatasourceUID: "NCzh67i"})
+			require.Equal(t, 400, resp.Status())
+		})
+
+	testScenario(t, "When users tries to add query, it should succeed",
+		func(t *testing.T, sc scenarioContext) {
+			resp := sc.service.createHandler(sc.reqContext, getCreateCommand("NCzh67i", "test"))
+			require.Equal(t, 200, resp.Status())
+
+			result := validateAndUnMarshalResponse(t, resp)
+			require.NotEmpty(t, result.Result.UID)
+			require.Equal(t, "NCzh67i", result.Result.DatasourceUID)
+			require.Equal(t, int64(1), result.Result.CreatedBy)
+			require.False(t, result.Result.Starred)
+			require.Equal(t, "test", result.Result.Queries.GetIndex(0).Get("expr").MustString())
+		})
+}
+
+func TestDeleteQueryFromQueryHistory(t *testing.T) {
+	scenarioWithQuery(t, "When users tries to delete query that does not exist, it should fail",
+		func(t *testing.T, sc scenarioContext, uid string) {
+			sc.ctx.Req = web.SetURLParams(sc.ctx.Req, map[string]string{":uid": "unknown"})
+			resp := sc.service.deleteHandler(sc.reqContext)
+			require.Equal(t, 404, resp.Status())
+		})
+
+	scenarioWithQuery(t, "When users tries to delete query of another user, it should fail",
+		func(t *testing.T, sc scenarioContext, uid string) {
+			sc.reqContext.SignedInUser.UserId = 2
+			sc.ctx.Req = web.SetURLParams(sc.ctx.Req, map[string]string{":uid": uid})
+			resp := sc.service.deleteHandler(sc.reqContext)
+			require.Equal(t, 404, resp.Status())
+		})
+
+	scenarioWithQuery(t, "When users tries to delete starred query, it should succeed",
+		func(t *testing.T, sc scenarioContext, uid string) {
+			sc.ctx.Req = web.SetURLParams(sc.ctx.Req, map[string]string{":uid": uid})
+			require.Equal(t, 200, sc.service.starHandler(sc.reqContext).Status())
+
+			resp := sc.service.deleteHandler(sc.reqContext)
+			require.Equal(t, 200, resp.Status())
+
+			result := searchQueries(t, sc, SearchInQueryHistoryQuery{})
+			require.Equal(t, int64(0), result.TotalCount)
+
+			exists, err := sc.sqlStore.NewSession(context.Background()).Where("query_uid = ?", uid).Exist(&QueryHistoryStar{})
+			require.NoError(t, err)
+			require.False(t, exists)
+		})
+}
+
+func TestPatchQueryCommentInQueryHistory(t *testing.T) {
+	scenarioWithQuery(t, "When users tries to update comment of query that does not exist, it should fail",
+		func(t *testing.T, sc scenarioContext, uid string) {
+			sc.ctx.Req = web.SetURLParams(sc.ctx.Req, map[string]string{":uid": "unknown"})
+			resp := sc.service.patchCommentHandler(sc.reqContext, PatchQueryCommentInQueryHistoryCommand{Comment: "test comment"})
+			require.Equal(t, 404, resp.Status())
+		})
+
+	scenarioWithQuery(t, "When users tries to update comment of query, it should succeed",
+		func(t *testing.T, sc scenarioContext, uid string) {
+			sc.ctx.Req = web.SetURLParams(sc.ctx.Req, map[string]string{":uid": uid})
+			resp := sc.service.patchCommentHandler(sc.reqContext, PatchQueryCommentInQueryHistoryCommand{Comment: "test comment"})
+			require.Equal(t, 200, resp.Status())
+			require.Equal(t, "test comment", validateAndUnMarshalResponse(t, resp).Result.Comment)
+
+			result := searchQueries(t, sc, SearchInQueryHistoryQuery{})
+			require.Equal(t, "test comment", result.QueryHistory[0].Comment)
+		})
+}
+
+func TestStarQueryInQueryHistory(t *testing.T) {
+	scenarioWithQuery(t, "When users tries to star query that does not exist, it should fail",
+		func(t *testing.T, sc scenarioContext, uid string) {
+			sc.ctx.Req = web.SetURLParams(sc.ctx.Req, map[string]string{":uid": "unknown"})
+			resp := sc.service.starHandler(sc.reqContext)
+			require.Equal(t, 404, resp.Status())
+		})
+
+	scenarioWithQuery(t, "When users tries to star query, it should succeed",
+		func(t *testing.T, sc scenarioContext, uid string) {
+			sc.ctx.Req = web.SetURLParams(sc.ctx.Req, map[string]string{":uid": uid})
+			resp := sc.service.starHandler(sc.reqContext)
+			require.Equal(t, 200, resp.Status())
+			require.True(t, validateAndUnMarshalResponse(t, resp).Result.Starred)
+
+			result := searchQueries(t, sc, SearchInQueryHistoryQuery{})
+			require.True(t, result.QueryHistory[0].Starred)
+		})
+
+	scenarioWithQuery(t, "When users tries to star query that is already starred, it should fail",
+		func(t *testing.T, sc scenarioContext, uid string) {
+			sc.ctx.Req = web.SetURLParams(sc.ctx.Req, map[string]string{":uid": uid})
+			require.Equal(t, 200, sc.service.starHandler(sc.reqContext).Status())
+			require.Equal(t, 400, sc.service.starHandler(sc.reqContext).Status())
+		})
+}
+
+func TestUnstarQueryInQueryHistory(t *testing.T) {
+	scenarioWithQuery(t, "When users tries to unstar query that is not starred, it should fail",
+		func(t *testing.T, sc scenarioContext, uid string) {
+			sc.ctx.Req = web.SetURLParams(sc.ctx.Req, map[string]string{":uid": uid})
+			resp := sc.service.unstarHandler(sc.reqContext)
+			require.Equal(t, 404, resp.Status())
+		})
+
+	scenarioWithQuery(t, "When users tries to unstar starred query, it should succeed",
+		func(t *testing.T, sc scenarioContext, uid string) {
+			sc.ctx.Req = web.SetURLParams(sc.ctx.Req, map[string]string{":uid": uid})
+			require.Equal(t, 200, sc.service.starHandler(sc.reqContext).Status())
+
+			resp := sc.service.unstarHandler(sc.reqContext)
+			require.Equal(t, 200, resp.Status())
+			require.False(t, validateAndUnMarshalResponse(t, resp).Result.Starred)
+		})
+}
+
+func TestDeleteStaleQueriesInQueryHistory(t *testing.T) {
+	testScenario(t, "When stale queries are deleted, starred and recent queries should be kept",
+		func(t *testing.T, sc scenarioContext) {
+			now := time.Now()
+			getTime = func() time.Time { return now.Add(-30 * 24 * time.Hour) }
+			defer func() { getTime = time.Now }()
+			stale := createQuery(t, sc, getCreateCommand("NCzh67i", "stale"))
+			starred := createQuery(t, sc, getCreateCommand("NCzh67i", "starred"))
+			sc.ctx.Req = web.SetURLParams(sc.ctx.Req, map[string]string{":uid": starred})
+			require.Equal(t, 200, sc.service.starHandler(sc.reqContext).Status())
+
+			getTime = time.Now
+			recent := createQuery(t, sc, getCreateCommand("NCzh67i", "recent"))
+
+			deleted, err := sc.service.DeleteStaleQueriesInQueryHistory(context.Background(), now.Add(-14*24*time.Hour))
+			require.NoError(t, err)
+			require.Equal(t, int64(1), deleted)
+
+			result := searchQueries(t, sc, SearchInQueryHistoryQuery{})
+			var uids []string
+			for _, q := range result.QueryHistory {
+				uids = append(uids, q.UID)
+			}
+			require.ElementsMatch(t, []string{starred, recent}, uids)
+			require.NotContains(t, uids, stale)
+		})
+}
+
+func getCreateCommand(datasourceUID string, expr string) CreateQueryInQueryHistoryCommand {
+	queries := simplejson.NewFromAny([]interface{}{
+		map[string]interface{}{"refId": "A", "expr": expr},
+	})
+	return CreateQueryInQueryHistoryCommand{DatasourceUID: datasourceUID, Queries: queries}
+}
+
+func createQuery(t *testing.T, sc scenarioContext, cmd CreateQueryInQueryHistoryCommand) string {
+	t.Helper()
+
+	resp := sc.service.createHandler(sc.reqContext, cmd)
+	require.Equal(t, 200, resp.Status())
+	return validateAndUnMarshalResponse(t, resp).Result.UID
+}
+
+func searchQueries(t *testing.T, sc scenarioContext, query SearchInQueryHistoryQuery) QueryHistorySearchResult {
+	t.Helper()
+
+	result, err := sc.service.SearchInQueryHistory(context.Background(), sc.reqContext.SignedInUser, query)
+	require.NoError(t, err)
+	return result
+}
+
+func validateAndUnMarshalResponse(t *testing.T, resp response.Response) queryHistoryResult {
+	t.Helper()
+
+	require.Equal(t, 200, resp.Status())
+
+	var result = queryHistoryResult{}
+	err := json.Unmarshal(resp.Body(), &result)
+	require.NoError(t, err)
+
+	return result
+}
+
+func scenarioWithQuery(t *testing.T, desc string, fn func(t *testing.T, sc scenarioContext, uid string)) {
+	t.Helper()
+
+	testScenario(t, desc, func(t *testing.T, sc scenarioContext) {
+		uid := createQuery(t, sc, getCreateCommand("NCzh67i", "test"))
+		fn(t, sc, uid)
+	})
+}
+
+// testScenario is a wrapper around t.Run performing common setup for query history tests.
+// It takes your real test function as a callback.
+func testScenario(t *testing.T, desc string, fn func(t *testing.T, sc scenarioContext)) {
+	t.Helper()
+
+	t.Run(desc, func(t *testing.T) {
+		ctx := web.Context{Req: &http.Request{}}
+		sqlStore := sqlstore.InitTestDB(t)
+		service := QueryHistoryService{
+			Cfg:      setting.NewCfg(),
+			SQLStore: sqlStore,
+		}
+
+		user := models.SignedInUser{
+			UserId:     1,
+			Name:       "Signed In User",
+			Login:      "signed_in_user",
+			Email:      "signed.in.user@test.com",
+			OrgId:      1,
+			OrgRole:    models.ROLE_VIEWER,
+			LastSeenAt: time.Now(),
+		}
+
+		sc := scenarioContext{
+			ctx:      &ctx,
+			service:  &service,
+			sqlStore: sqlStore,
+			reqContext: &models.ReqContext{
+				Context:      &ctx,
+				SignedInUser: &user,
+			},
+		}
+
+		fn(t, sc)
+	})
+}
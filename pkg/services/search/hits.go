@@ -26,6 +26,22 @@ type Hit struct {
 	FolderURL    string   `json:"folderUrl,omitempty"`
 	SortMeta     int64    `json:"sortMeta"`
 	SortMetaName string   `json:"sortMetaName,omitempty"`
+	Matches      []*Match `json:"matches,omitempty"`
+}
+
+// Match is dashboard content matching a full-text search.
+type Match struct {
+	// Kind of the content, e.g. panel_title or query.
+	Kind       string      `json:"kind"`
+	PanelID    int64       `json:"panelId,omitempty"`
+	Value      string      `json:"value"`
+	Highlights []Highlight `json:"highlights"`
+}
+
+// Highlight is the position of the searched text in a match, as byte offsets.
+type Highlight struct {
+	Start int `json:"start"`
+	End   int `json:"end"`
 }
 
 type HitList []*Hit
@@ -23,35 +23,43 @@ func ProvideService(cfg *setting.Cfg, bus bus.Bus) *SearchService {
 }
 
 type Query struct {
-	Title        string
-	Tags         []string
-	OrgId        int64
-	SignedInUser *models.SignedInUser
-	Limit        int64
-	Page         int64
-	IsStarred    bool
-	Type         string
-	DashboardIds []int64
-	FolderIds    []int64
-	Permission   models.PermissionType
-	Sort         string
+	Title          string
+	Content        string
+	ContentKinds   []string
+	DatasourceUIDs []string
+	Tags           []string
+	OrgId          int64
+	SignedInUser   *models.SignedInUser
+	Limit          int64
+	Page           int64
+	IsStarred      bool
+	Type           string
+	DashboardIds   []int64
+	FolderIds      []int64
+	FolderSubtree  bool
+	Permission     models.PermissionType
+	Sort           string
 
 	Result HitList
 }
 
 type FindPersistedDashboardsQuery struct {
-	Title        string
-	OrgId        int64
-	SignedInUser *models.SignedInUser
-	IsStarred    bool
-	DashboardIds []int64
-	Type         string
-	FolderIds    []int64
-	Tags         []string
-	Limit        int64
-	Page         int64
-	Permission   models.PermissionType
-	Sort         SortOption
+	Title          string
+	Content        string
+	ContentKinds   []string
+	DatasourceUIDs []string
+	OrgId          int64
+	SignedInUser   *models.SignedInUser
+	IsStarred      bool
+	DashboardIds   []int64
+	Type           string
+	FolderIds      []int64
+	FolderSubtree  bool
+	Tags           []string
+	Limit          int64
+	Page           int64
+	Permission     models.PermissionType
+	Sort           SortOption
 
 	Filters []interface{}
 
@@ -67,16 +75,20 @@ type SearchService struct {
 
 func (s *SearchService) searchHandler(query *Query) error {
 	dashboardQuery := FindPersistedDashboardsQuery{
-		Title:        query.Title,
-		SignedInUser: query.SignedInUser,
-		IsStarred:    query.IsStarred,
-		DashboardIds: query.DashboardIds,
-		Type:         query.Type,
-		FolderIds:    query.FolderIds,
-		Tags:         query.Tags,
-		Limit:        query.Limit,
-		Page:         query.Page,
-		Permission:   query.Permission,
+		Title:          query.Title,
+		Content:        query.Content,
+		ContentKinds:   query.ContentKinds,
+		DatasourceUIDs: query.DatasourceUIDs,
+		SignedInUser:   query.SignedInUser,
+		IsStarred:      query.IsStarred,
+		DashboardIds:   query.DashboardIds,
+		Type:           query.Type,
+		FolderIds:      query.FolderIds,
+		FolderSubtree:  query.FolderSubtree,
+		Tags:           query.Tags,
+		Limit:          query.Limit,
+		Page:           query.Page,
+		Permission:     query.Permission,
 	}
 
 	if sortOpt, exists := s.sortOptions[query.Sort]; exists {
@@ -175,15 +175,33 @@ func (r *SQLAnnotationRepo) Find(query *annotations.ItemQuery) ([]*annotations.I
 		params = append(params, query.UserId)
 	}
 
+	// region annotations match when any part of them is within the range
 	if query.From > 0 && query.To > 0 {
 		sql.WriteString(` AND a.epoch <= ? AND a.epoch_end >= ?`)
 		params = append(params, query.To, query.From)
 	}
 
-	if query.Type == "alert" {
+	switch query.Type {
+	case "alert":
 		sql.WriteString(` AND a.alert_id > 0`)
-	} else if query.Type == "annotation" {
+	case "annotation":
 		sql.WriteString(` AND a.alert_id = 0`)
+	case "region":
+		sql.WriteString(` AND a.epoch_end > a.epoch`)
+	}
+
+	for _, word := range strings.Fields(query.Query) {
+		sql.WriteString(` AND a.text ` + dialect.LikeStr() + ` ? ` + LikeEscapeStr)
+		params = append(params, LikeContainsPattern(word))
+	}
+
+	if query.Cursor != "" {
+		cursor, err := annotations.ParseCursor(query.Cursor)
+		if err != nil {
+			return nil, err
+		}
+		sql.WriteString(` AND (a.epoch_end < ? OR (a.epoch_end = ? AND (a.epoch < ? OR (a.epoch = ? AND a.id < ?))))`)
+		params = append(params, cursor.EpochEnd, cursor.EpochEnd, cursor.Epoch, cursor.Epoch, cursor.Id)
 	}
 
 	if len(query.Tags) > 0 {
@@ -223,7 +241,8 @@ func (r *SQLAnnotationRepo) Find(query *annotations.ItemQuery) ([]*annotations.I
 	}
 
 	// order of ORDER BY arguments match the order of a sql index for performance
-	sql.WriteString(" ORDER BY a.org_id, a.epoch_end DESC, a.epoch DESC" + dialect.Limit(query.Limit) + " ) dt on dt.id = annotation.id")
+	sql.WriteString(" ORDER BY a.org_id, a.epoch_end DESC, a.epoch DESC, a.id DESC" + dialect.Limit(query.Limit) + " ) dt on dt.id = annotation.id")
+	sql.WriteString(" ORDER BY annotation.epoch_end DESC, annotation.epoch DESC, annotation.id DESC")
 
 	items := make([]*annotations.ItemDTO, 0)
 
@@ -179,6 +179,61 @@ func TestAnnotations(t *testing.T) {
 			assert.Len(t, items, 1)
 		})
 
+		t.Run("Should find annotations by text", func(t *testing.T) {
+			items, err := repo.Find(&annotations.ItemQuery{
+				OrgId: 1,
+				Query: "DEPL",
+			})
+			require.NoError(t, err)
+			require.Len(t, items, 1)
+			assert.Equal(t, globalAnnotation1.Id, items[0].Id)
+
+			items, err = repo.Find(&annotations.ItemQuery{
+				OrgId: 1,
+				Query: "deploy rollback",
+			})
+			require.NoError(t, err)
+			assert.Empty(t, items)
+
+			for _, query := range []string{"de_loy", "%"} {
+				items, err = repo.Find(&annotations.ItemQuery{OrgId: 1, Query: query})
+				require.NoError(t, err)
+				assert.Empty(t, items, query)
+			}
+		})
+
+		t.Run("Should find region annotations overlapping the time range", func(t *testing.T) {
+			items, err := repo.Find(&annotations.ItemQuery{
+				OrgId: 1,
+				From:  21,
+				To:    30,
+				Type:  "region",
+			})
+			require.NoError(t, err)
+			require.Len(t, items, 1)
+			assert.Equal(t, annotation2.Id, items[0].Id)
+		})
+
+		t.Run("Should page through annotations with cursors", func(t *testing.T) {
+			var ids []int64
+			query := &annotations.ItemQuery{OrgId: 1, Limit: 3}
+			for {
+				items, err := repo.Find(query)
+				require.NoError(t, err)
+				for _, item := range items {
+					ids = append(ids, item.Id)
+				}
+				if len(items) < 3 {
+					break
+				}
+				query.Cursor = annotations.NewCursor(items[len(items)-1]).String()
+			}
+			assert.Equal(t, []int64{annotation2.Id, globalAnnotation2.Id, globalAnnotation1.Id, annotation.Id}, ids)
+
+			_, err := repo.Find(&annotations.ItemQuery{OrgId: 1, Cursor: "invalid"})
+			require.ErrorIs(t, err, annotations.ErrInvalidCursor)
+		})
+
 		t.Run("Can update annotation and remove all tags", func(t *testing.T) {
 			query := &annotations.ItemQuery{
 				OrgId:       1,
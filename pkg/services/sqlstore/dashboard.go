@@ -11,6 +11,7 @@ import (
 	"github.com/prometheus/client_golang/prometheus"
 
 	"github.com/grafana/grafana/pkg/bus"
+	"github.com/grafana/grafana/pkg/events"
 	"github.com/grafana/grafana/pkg/infra/metrics"
 	"github.com/grafana/grafana/pkg/models"
 	"github.com/grafana/grafana/pkg/services/search"
@@ -47,8 +48,8 @@ func (ss *SQLStore) addDashboardQueryAndCommandHandlers() {
 
 var generateNewUid func() string = util.GenerateShortUID
 
-func (ss *SQLStore) SaveDashboard(cmd models.SaveDashboardCommand) (*models.Dashboard, error) {
-	err := ss.WithTransactionalDbSession(context.Background(), func(sess *DBSession) error {
+func (ss *SQLStore) SaveDashboard(ctx context.Context, cmd models.SaveDashboardCommand) (*models.Dashboard, error) {
+	err := ss.WithTransactionalDbSession(ctx, func(sess *DBSession) error {
 		return saveDashboard(sess, &cmd)
 	})
 	return cmd.Result, err
@@ -164,11 +165,47 @@ func saveDashboard(sess *DBSession, cmd *models.SaveDashboardCommand) error {
 		}
 	}
 
+	if err := indexDashboardContent(sess, dash); err != nil {
+		return err
+	}
+
+	if !dash.IsFolder {
+		sess.publishAfterCommit(&events.DashboardSaved{
+			Timestamp: dash.Updated,
+			ID:        dash.Id,
+			UID:       dash.Uid,
+			OrgID:     dash.OrgId,
+		})
+	}
+
 	cmd.Result = dash
 
 	return nil
 }
 
+// indexDashboardContent replaces the content of the dashboard indexed for search.
+func indexDashboardContent(sess *DBSession, dash *models.Dashboard) error {
+	if _, err := sess.Exec("DELETE FROM dashboard_content WHERE dashboard_id=?", dash.Id); err != nil {
+		return err
+	}
+
+	if dash.IsFolder {
+		return nil
+	}
+
+	terms := searchstore.ExtractContent(dash.Data)
+	if len(terms) == 0 {
+		return nil
+	}
+
+	content := make([]*DashboardContent, 0, len(terms))
+	for _, t := range terms {
+		content = append(content, &DashboardContent{DashboardId: dash.Id, Kind: t.Kind, PanelId: t.PanelID, Term: t.Term})
+	}
+	_, err := sess.InsertMulti(content)
+	return err
+}
+
 func generateNewDashboardUid(sess *DBSession, orgId int64) (string, error) {
 	for i := 0; i < 3; i++ {
 		uid := generateNewUid()
@@ -212,7 +249,7 @@ func (ss *SQLStore) GetFolderByTitle(orgID int64, title string) (*models.Dashboa
 	}
 
 	// there is a unique constraint on org_id, folder_id, title
-	// there are no nested folders so the parent folder id is always 0
+	// only top-level folders are looked up by title, so the parent folder id is always 0
 	dashboard := models.Dashboard{OrgId: orgID, FolderId: 0, Title: title}
 	has, err := ss.engine.Get(&dashboard)
 	if err != nil {
@@ -309,7 +346,24 @@ func findDashboards(query *search.FindPersistedDashboardsQuery) ([]DashboardSear
 	}
 
 	if len(query.FolderIds) > 0 {
-		filters = append(filters, searchstore.FolderFilter{IDs: query.FolderIds})
+		if query.FolderSubtree {
+			filters = append(filters, searchstore.FolderSubtreeFilter{Dialect: dialect, IDs: query.FolderIds})
+		} else {
+			filters = append(filters, searchstore.FolderFilter{IDs: query.FolderIds})
+		}
+	}
+
+	if len(query.Content) > 0 {
+		filters = append(filters, searchstore.ContentFilter{
+			Dialect: dialect,
+			Pattern: LikeContainsPattern(query.Content),
+			Escape:  LikeEscapeStr,
+			Kinds:   query.ContentKinds,
+		})
+	}
+
+	if len(query.DatasourceUIDs) > 0 {
+		filters = append(filters, searchstore.DatasourceFilter{UIDs: query.DatasourceUIDs})
 	}
 
 	var res []DashboardSearchProjection
@@ -342,9 +396,74 @@ func SearchDashboards(ctx context.Context, query *search.FindPersistedDashboards
 
 	makeQueryResult(query, res)
 
+	if len(query.Content) > 0 {
+		return withDbSession(ctx, x, func(sess *DBSession) error {
+			return setContentMatches(sess, query.Content, query.ContentKinds, query.Result)
+		})
+	}
+
 	return nil
 }
 
+// setContentMatches adds the dashboard content of the given kinds, or of any
+// kind when empty, matching the text of a full-text search to the hits.
+func setContentMatches(sess *DBSession, text string, kinds []string, hits []*search.Hit) error {
+	if len(hits) == 0 {
+		return nil
+	}
+
+	hitsByID := make(map[int64]*search.Hit, len(hits))
+	ids := make([]int64, 0, len(hits))
+	for _, hit := range hits {
+		hitsByID[hit.ID] = hit
+		ids = append(ids, hit.ID)
+	}
+
+	sess.Where(fmt.Sprintf("term %s ? %s", dialect.LikeStr(), LikeEscapeStr), LikeContainsPattern(text)).In("dashboard_id", ids)
+	if len(kinds) > 0 {
+		sess.In("kind", kinds)
+	}
+
+	var content []DashboardContent
+	if err := sess.Asc("id").Find(&content); err != nil {
+		return err
+	}
+
+	for _, c := range content {
+		hit := hitsByID[c.DashboardId]
+		hit.Matches = append(hit.Matches, &search.Match{
+			Kind:       c.Kind,
+			PanelID:    c.PanelId,
+			Value:      c.Term,
+			Highlights: highlights(c.Term, text),
+		})
+	}
+
+	return nil
+}
+
+// highlights returns the positions of the case-insensitive occurrences of
+// text in value.
+func highlights(value, text string) []search.Highlight {
+	result := []search.Highlight{}
+	lower, lowerText := strings.ToLower(value), strings.ToLower(text)
+	// Offsets in the lower case value are only valid if lowering the case
+	// didn't change the length of the value.
+	if len(lower) != len(value) || len(lowerText) == 0 {
+		return result
+	}
+
+	for offset := 0; ; {
+		i := strings.Index(lower[offset:], lowerText)
+		if i < 0 {
+			return result
+		}
+		start := offset + i
+		result = append(result, search.Highlight{Start: start, End: start + len(lowerText)})
+		offset = start + len(lowerText)
+	}
+}
+
 func getHitType(item DashboardSearchProjection) search.HitType {
 	var hitType search.HitType
 	if item.IsFolder {
@@ -416,7 +535,22 @@ func DeleteDashboard(ctx context.Context, cmd *models.DeleteDashboardCommand) er
 	})
 }
 
+// dashboardUserContentDeletes delete the stars, playlist items and annotations
+// of a dashboard, which are kept while the dashboard is in the recycle bin.
+var dashboardUserContentDeletes = []string{
+	"DELETE FROM star WHERE dashboard_id = ? ",
+	"DELETE FROM playlist_item WHERE type = 'dashboard_by_id' AND value = ?",
+	"DELETE FROM annotation WHERE dashboard_id = ?",
+}
+
 func deleteDashboard(cmd *models.DeleteDashboardCommand, sess *DBSession) error {
+	return deleteDashboardWith(cmd, sess, 0)
+}
+
+// deleteDashboardWith deletes the dashboard, moving it to the recycle bin if
+// enabled. deletedWithID is the recycle bin entry of the folder the dashboard
+// is deleted with, or 0.
+func deleteDashboardWith(cmd *models.DeleteDashboardCommand, sess *DBSession, deletedWithID int64) error {
 	dashboard := models.Dashboard{Id: cmd.Id, OrgId: cmd.OrgId}
 	has, err := sess.Get(&dashboard)
 	if err != nil {
@@ -425,24 +559,51 @@ func deleteDashboard(cmd *models.DeleteDashboardCommand, sess *DBSession) error
 		return models.ErrDashboardNotFound
 	}
 
+	recycle := recycleBinEnabled()
+	if recycle {
+		entryID, err := moveDashboardToRecycleBin(sess, &dashboard, deletedWithID)
+		if err != nil {
+			return err
+		}
+		if deletedWithID == 0 {
+			deletedWithID = entryID
+		}
+	}
+
 	deletes := []string{
 		"DELETE FROM dashboard_tag WHERE dashboard_id = ? ",
-		"DELETE FROM star WHERE dashboard_id = ? ",
+		"DELETE FROM dashboard_content WHERE dashboard_id = ? ",
 		"DELETE FROM dashboard WHERE id = ?",
-		"DELETE FROM playlist_item WHERE type = 'dashboard_by_id' AND value = ?",
 		"DELETE FROM dashboard_version WHERE dashboard_id = ?",
-		"DELETE FROM annotation WHERE dashboard_id = ?",
+		"DELETE FROM dashboard_version_label WHERE dashboard_id = ?",
+		"DELETE FROM dashboard_version_retention WHERE dashboard_id = ?",
 		"DELETE FROM dashboard_provisioning WHERE dashboard_id = ?",
 		"DELETE FROM dashboard_acl WHERE dashboard_id = ?",
 	}
+	if !recycle {
+		deletes = append(deletes, dashboardUserContentDeletes...)
+	}
 
 	if dashboard.IsFolder {
+		var subfolderIDs []int64
+		err := sess.Table("dashboard").Where("org_id = ? AND folder_id = ? AND is_folder = ?", dashboard.OrgId, dashboard.Id,
+			dialect.BooleanStr(true)).Cols("id").Find(&subfolderIDs)
+		if err != nil {
+			return err
+		}
+		for _, id := range subfolderIDs {
+			subfolderCmd := &models.DeleteDashboardCommand{Id: id, OrgId: cmd.OrgId, ForceDeleteFolderRules: cmd.ForceDeleteFolderRules}
+			if err := deleteDashboardWith(subfolderCmd, sess, deletedWithID); err != nil {
+				return err
+			}
+		}
+
 		deletes = append(deletes, "DELETE FROM dashboard WHERE folder_id = ?")
 
 		dashIds := []struct {
 			Id int64
 		}{}
-		err := sess.SQL("SELECT id FROM dashboard WHERE folder_id = ?", dashboard.Id).Find(&dashIds)
+		err = sess.SQL("SELECT id FROM dashboard WHERE folder_id = ?", dashboard.Id).Find(&dashIds)
 		if err != nil {
 			return err
 		}
@@ -453,15 +614,34 @@ func deleteDashboard(cmd *models.DeleteDashboardCommand, sess *DBSession) error
 			}
 		}
 
+		if recycle {
+			var children []*models.Dashboard
+			if err := sess.Where("org_id = ? AND folder_id = ?", dashboard.OrgId, dashboard.Id).Find(&children); err != nil {
+				return err
+			}
+			for _, child := range children {
+				if _, err := moveDashboardToRecycleBin(sess, child, deletedWithID); err != nil {
+					return err
+				}
+			}
+		}
+
 		if len(dashIds) > 0 {
 			childrenDeletes := []string{
 				"DELETE FROM dashboard_tag WHERE dashboard_id IN (SELECT id FROM dashboard WHERE org_id = ? AND folder_id = ?)",
-				"DELETE FROM star WHERE dashboard_id IN (SELECT id FROM dashboard WHERE org_id = ? AND folder_id = ?)",
+				"DELETE FROM dashboard_content WHERE dashboard_id IN (SELECT id FROM dashboard WHERE org_id = ? AND folder_id = ?)",
 				"DELETE FROM dashboard_version WHERE dashboard_id IN (SELECT id FROM dashboard WHERE org_id = ? AND folder_id = ?)",
-				"DELETE FROM annotation WHERE dashboard_id IN (SELECT id FROM dashboard WHERE org_id = ? AND folder_id = ?)",
+				"DELETE FROM dashboard_version_label WHERE dashboard_id IN (SELECT id FROM dashboard WHERE org_id = ? AND folder_id = ?)",
+				"DELETE FROM dashboard_version_retention WHERE dashboard_id IN (SELECT id FROM dashboard WHERE org_id = ? AND folder_id = ?)",
 				"DELETE FROM dashboard_provisioning WHERE dashboard_id IN (SELECT id FROM dashboard WHERE org_id = ? AND folder_id = ?)",
 				"DELETE FROM dashboard_acl WHERE dashboard_id IN (SELECT id FROM dashboard WHERE org_id = ? AND folder_id = ?)",
 			}
+			if !recycle {
+				childrenDeletes = append(childrenDeletes,
+					"DELETE FROM star WHERE dashboard_id IN (SELECT id FROM dashboard WHERE org_id = ? AND folder_id = ?)",
+					"DELETE FROM annotation WHERE dashboard_id IN (SELECT id FROM dashboard WHERE org_id = ? AND folder_id = ?)",
+				)
+			}
 			for _, sql := range childrenDeletes {
 				_, err := sess.Exec(sql, dashboard.OrgId, dashboard.Id)
 				if err != nil {
@@ -546,8 +726,8 @@ func GetDashboardPermissionsForUser(ctx context.Context, query *models.GetDashbo
 
 	// check dashboards that have ACLs via user id, team id or role
 	sql := `SELECT d.id AS dashboard_id, MAX(COALESCE(da.permission, pt.permission)) AS permission
-	FROM dashboard AS d
-		LEFT JOIN dashboard_acl as da on d.folder_id = da.dashboard_id or d.id = da.dashboard_id
+	FROM dashboard AS d` + permissions.AncestorFoldersJoin("d") + `
+		LEFT JOIN dashboard_acl as da on ` + permissions.DashboardOrAncestorACL("da", "d") + `
 		LEFT JOIN team_member as ugm on ugm.team_id =  da.team_id
 		LEFT JOIN org_user ou ON ou.role = da.role AND ou.user_id = ?
 	`
@@ -693,6 +873,12 @@ func getExistingDashboardByIdOrUidForUpdate(sess *DBSession, dash *models.Dashbo
 		if !folderExists {
 			return isParentFolderChanged, models.ErrDashboardFolderNotFound
 		}
+
+		if dash.IsFolder {
+			if err := validateFolderNesting(sess, dash, &existingFolder); err != nil {
+				return isParentFolderChanged, err
+			}
+		}
 	}
 
 	if !dashWithIdExists && !dashWithUidExists {
@@ -720,7 +906,7 @@ func getExistingDashboardByIdOrUidForUpdate(sess *DBSession, dash *models.Dashbo
 		return isParentFolderChanged, models.ErrDashboardTypeMismatch
 	}
 
-	if !dash.IsFolder && dash.FolderId != existing.FolderId {
+	if dash.FolderId != existing.FolderId {
 		isParentFolderChanged = true
 	}
 
@@ -741,10 +927,58 @@ func getExistingDashboardByIdOrUidForUpdate(sess *DBSession, dash *models.Dashbo
 	return isParentFolderChanged, nil
 }
 
+// validateFolderNesting checks that the folder can be saved in parent without
+// creating a cycle or nesting folders more than models.MaxNestedFolderDepth levels deep.
+func validateFolderNesting(sess *DBSession, folder *models.Dashboard, parent *models.Dashboard) error {
+	// depth of the parent folder, 1 for a top-level folder
+	depth := 1
+	for ancestor := parent; ; depth++ {
+		if folder.Id > 0 && ancestor.Id == folder.Id {
+			return models.ErrDashboardFolderCannotBeOwnAncestor
+		}
+		if ancestor.FolderId == 0 || depth >= models.MaxNestedFolderDepth {
+			break
+		}
+
+		next := models.Dashboard{}
+		exists, err := sess.Where("org_id=? AND id=?", folder.OrgId, ancestor.FolderId).Get(&next)
+		if err != nil {
+			return fmt.Errorf("SQL query for parent folder failed: %w", err)
+		}
+		if !exists {
+			break
+		}
+		ancestor = &next
+	}
+
+	// an existing folder is moved together with its subfolders
+	level := depth + 1
+	ids := []int64{folder.Id}
+	for folder.Id > 0 && len(ids) > 0 && level <= models.MaxNestedFolderDepth {
+		var children []int64
+		err := sess.Table("dashboard").In("folder_id", ids).Where("is_folder=?", dialect.BooleanStr(true)).
+			Cols("id").Find(&children)
+		if err != nil {
+			return fmt.Errorf("SQL query for subfolders failed: %w", err)
+		}
+		if len(children) > 0 {
+			level++
+		}
+		ids = children
+	}
+
+	if level > models.MaxNestedFolderDepth {
+		return models.ErrDashboardFolderTooDeep
+	}
+	return nil
+}
+
 func getExistingDashboardByTitleAndFolder(sess *DBSession, dash *models.Dashboard, overwrite,
 	isParentFolderChanged bool) (bool, error) {
+	// folders and dashboards must not share the name of a top-level folder or
+	// of anything else in the same folder
 	var existing models.Dashboard
-	exists, err := sess.Where("org_id=? AND slug=? AND (is_folder=? OR folder_id=?)", dash.OrgId, dash.Slug,
+	exists, err := sess.Where("org_id=? AND slug=? AND ((is_folder=? AND folder_id=0) OR folder_id=?)", dash.OrgId, dash.Slug,
 		dialect.BooleanStr(true), dash.FolderId).Get(&existing)
 	if err != nil {
 		return isParentFolderChanged, fmt.Errorf("SQL query for existing dashboard by org ID or folder ID failed: %w", err)
@@ -775,9 +1009,9 @@ func getExistingDashboardByTitleAndFolder(sess *DBSession, dash *models.Dashboar
 	return isParentFolderChanged, nil
 }
 
-func (ss *SQLStore) ValidateDashboardBeforeSave(dashboard *models.Dashboard, overwrite bool) (bool, error) {
+func (ss *SQLStore) ValidateDashboardBeforeSave(ctx context.Context, dashboard *models.Dashboard, overwrite bool) (bool, error) {
 	isParentFolderChanged := false
-	err := ss.WithTransactionalDbSession(context.Background(), func(sess *DBSession) error {
+	err := ss.WithTransactionalDbSession(ctx, func(sess *DBSession) error {
 		var err error
 		isParentFolderChanged, err = getExistingDashboardByIdOrUidForUpdate(sess, dashboard, overwrite)
 		if err != nil {
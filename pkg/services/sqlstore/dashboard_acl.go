@@ -6,6 +6,7 @@ import (
 
 	"github.com/grafana/grafana/pkg/bus"
 	"github.com/grafana/grafana/pkg/models"
+	"github.com/grafana/grafana/pkg/services/sqlstore/permissions"
 )
 
 func (ss *SQLStore) addDashboardACLQueryAndCommandHandlers() {
@@ -49,8 +50,8 @@ func (ss *SQLStore) UpdateDashboardACLCtx(ctx context.Context, dashboardID int64
 // GetDashboardAclInfoList returns a list of permissions for a dashboard. They can be fetched from three
 // different places.
 // 1) Permissions for the dashboard
-// 2) permissions for its parent folder
-// 3) if no specific permissions have been set for the dashboard or its parent folder then get the default permissions
+// 2) permissions for its parent folders, up to the top-level folder
+// 3) if no specific permissions have been set for the dashboard or its parent folders then get the default permissions
 func (ss *SQLStore) GetDashboardAclInfoList(ctx context.Context, query *models.GetDashboardAclInfoListQuery) error {
 	outerErr := ss.WithDbSession(ctx, func(dbSession *DBSession) error {
 		query.Result = make([]*models.DashboardAclInfoDTO, 0)
@@ -100,18 +101,13 @@ func (ss *SQLStore) GetDashboardAclInfoList(ctx context.Context, query *models.G
 				d.slug,
 				d.uid,
 				d.is_folder,
-				CASE WHEN (da.dashboard_id = -1 AND d.folder_id > 0) OR da.dashboard_id = d.folder_id THEN ` + dialect.BooleanStr(true) + ` ELSE ` + falseStr + ` END AS inherited
-			FROM dashboard as d
-				LEFT JOIN dashboard folder on folder.id = d.folder_id
+				CASE WHEN (da.dashboard_id = -1 AND d.folder_id > 0) OR (da.dashboard_id <> -1 AND da.dashboard_id <> d.id) THEN ` + dialect.BooleanStr(true) + ` ELSE ` + falseStr + ` END AS inherited
+			FROM dashboard as d` + permissions.AncestorFoldersJoin("d") + `
 				LEFT JOIN dashboard_acl AS da ON
-				da.dashboard_id = d.id OR
-				da.dashboard_id = d.folder_id OR
+				` + permissions.DashboardOrAncestorACL("da", "d") + ` OR
 				(
 					-- include default permissions -->
-					da.org_id = -1 AND (
-					  (folder.id IS NOT NULL AND folder.has_acl = ` + falseStr + `) OR
-					  (folder.id IS NULL AND d.has_acl = ` + falseStr + `)
-					)
+					da.org_id = -1 AND ` + permissions.DefaultACLApplies(dialect, "d") + `
 				)
 				LEFT JOIN ` + dialect.Quote("user") + ` AS u ON u.id = da.user_id
 				LEFT JOIN team ug on ug.id = da.team_id
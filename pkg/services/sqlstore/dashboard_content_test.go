@@ -0,0 +1,117 @@
+//go:build integration
+// +build integration
+
+package sqlstore
+
+import (
+	"context"
+	"testing"
+
+	"github.com/grafana/grafana/pkg/components/simplejson"
+	"github.com/grafana/grafana/pkg/models"
+	"github.com/grafana/grafana/pkg/services/search"
+	"github.com/stretchr/testify/require"
+)
+
+func TestDashboardContentSearch(t *testing.T) {
+	sqlStore := InitTestDB(t)
+	user := &models.SignedInUser{UserId: 1, OrgId: 1, OrgRole: models.ROLE_EDITOR}
+
+	saveDashboard := func(title string, panels ...interface{}) *models.Dashboard {
+		dash, err := sqlStore.SaveDashboard(context.Background(), models.SaveDashboardCommand{
+			OrgId: 1,
+			Dashboard: simplejson.NewFromAny(map[string]interface{}{
+				"title":  title,
+				"panels": panels,
+			}),
+		})
+		require.NoError(t, err)
+		return dash
+	}
+	panel := func(id int, title, dsUID, expr string) map[string]interface{} {
+		return map[string]interface{}{
+			"id":         id,
+			"title":      title,
+			"datasource": map[string]interface{}{"uid": dsUID},
+			"targets":    []interface{}{map[string]interface{}{"expr": expr}},
+		}
+	}
+
+	frontend := saveDashboard("Frontend", panel(1, "Request rate", "prom", "rate(http_requests_total[5m])"))
+	saveDashboard("Database", panel(1, "Connections", "mysql", "SELECT count(*) FROM connections"))
+
+	searchDashboards := func(query *search.FindPersistedDashboardsQuery) search.HitList {
+		query.SignedInUser = user
+		require.NoError(t, SearchDashboards(context.Background(), query))
+		return query.Result
+	}
+
+	t.Run("dashboards are found by the content of panels", func(t *testing.T) {
+		hits := searchDashboards(&search.FindPersistedDashboardsQuery{Content: "HTTP_requests"})
+		require.Len(t, hits, 1)
+		require.Equal(t, frontend.Id, hits[0].ID)
+		require.Equal(t, []*search.Match{{
+			Kind:       "query",
+			PanelID:    1,
+			Value:      "rate(http_requests_total[5m])",
+			Highlights: []search.Highlight{{Start: 5, End: 18}},
+		}}, hits[0].Matches)
+
+		hits = searchDashboards(&search.FindPersistedDashboardsQuery{Content: "connections"})
+		require.Len(t, hits, 1)
+		require.Equal(t, "Database", hits[0].Title)
+		require.Len(t, hits[0].Matches, 2)
+	})
+
+	t.Run("wildcards in the text are matched literally", func(t *testing.T) {
+		require.Empty(t, searchDashboards(&search.FindPersistedDashboardsQuery{Content: "http%total"}))
+		require.Empty(t, searchDashboards(&search.FindPersistedDashboardsQuery{Content: "http_requests_tota_"}))
+
+		hits := searchDashboards(&search.FindPersistedDashboardsQuery{Content: "count(*)"})
+		require.Len(t, hits, 1)
+		require.Equal(t, "Database", hits[0].Title)
+	})
+
+	t.Run("search is limited to content kinds", func(t *testing.T) {
+		hits := searchDashboards(&search.FindPersistedDashboardsQuery{Content: "connections", ContentKinds: []string{"panel_title"}})
+		require.Len(t, hits, 1)
+		require.Equal(t, []*search.Match{{
+			Kind:       "panel_title",
+			PanelID:    1,
+			Value:      "Connections",
+			Highlights: []search.Highlight{{Start: 0, End: 11}},
+		}}, hits[0].Matches)
+
+		require.Empty(t, searchDashboards(&search.FindPersistedDashboardsQuery{Content: "http_requests_total", ContentKinds: []string{"panel_title"}}))
+	})
+
+	t.Run("dashboards are found by data source", func(t *testing.T) {
+		hits := searchDashboards(&search.FindPersistedDashboardsQuery{DatasourceUIDs: []string{"prom"}})
+		require.Len(t, hits, 1)
+		require.Equal(t, frontend.Id, hits[0].ID)
+		require.Empty(t, hits[0].Matches)
+	})
+
+	t.Run("the content is indexed again when the dashboard is saved", func(t *testing.T) {
+		frontend.Data.Set("id", frontend.Id)
+		frontend.Data.Set("panels", []interface{}{panel(1, "Latency", "prom", "histogram_quantile(0.99, rate(http_request_duration_seconds_bucket[5m]))")})
+		_, err := sqlStore.SaveDashboard(context.Background(), models.SaveDashboardCommand{OrgId: 1, Dashboard: frontend.Data})
+		require.NoError(t, err)
+
+		require.Empty(t, searchDashboards(&search.FindPersistedDashboardsQuery{Content: "http_requests_total"}))
+		require.Len(t, searchDashboards(&search.FindPersistedDashboardsQuery{Content: "latency"}), 1)
+	})
+
+	t.Run("the content is removed with the dashboard", func(t *testing.T) {
+		require.NoError(t, DeleteDashboard(context.Background(), &models.DeleteDashboardCommand{Id: frontend.Id, OrgId: 1}))
+
+		var count int64
+		err := sqlStore.WithDbSession(context.Background(), func(sess *DBSession) error {
+			var err error
+			count, err = sess.Where("dashboard_id = ?", frontend.Id).Count(&DashboardContent{})
+			return err
+		})
+		require.NoError(t, err)
+		require.Zero(t, count)
+	})
+}
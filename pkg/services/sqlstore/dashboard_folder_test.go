@@ -520,7 +520,7 @@ func moveDashboard(t *testing.T, sqlStore *SQLStore, orgId int64, dashboard *sim
 		Dashboard: dashboard,
 		Overwrite: true,
 	}
-	dash, err := sqlStore.SaveDashboard(cmd)
+	dash, err := sqlStore.SaveDashboard(context.Background(), cmd)
 	require.NoError(t, err)
 
 	return dash
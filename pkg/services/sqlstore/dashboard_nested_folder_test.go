@@ -0,0 +1,169 @@
+//go:build integration
+// +build integration
+
+package sqlstore
+
+import (
+	"context"
+	"testing"
+
+	"github.com/grafana/grafana/pkg/models"
+	"github.com/grafana/grafana/pkg/services/search"
+	"github.com/stretchr/testify/require"
+)
+
+func TestNestedFolders(t *testing.T) {
+	var sqlStore *SQLStore
+	var top, middle, bottom, dash *models.Dashboard
+	var viewer models.User
+
+	// top > middle > bottom > dash
+	setup := func(t *testing.T) {
+		sqlStore = InitTestDB(t)
+		top = insertTestDashboard(t, sqlStore, "top", 1, 0, true)
+		middle = insertTestDashboard(t, sqlStore, "middle", 1, top.Id, true)
+		bottom = insertTestDashboard(t, sqlStore, "bottom", 1, middle.Id, true)
+		dash = insertTestDashboard(t, sqlStore, "dash", 1, bottom.Id, false)
+		viewer = createUser(t, sqlStore, "viewer", "Viewer", false)
+	}
+
+	searchIDs := func(t *testing.T, query *search.FindPersistedDashboardsQuery) []int64 {
+		t.Helper()
+		require.NoError(t, SearchDashboards(context.Background(), query))
+		ids := make([]int64, 0, len(query.Result))
+		for _, hit := range query.Result {
+			ids = append(ids, hit.ID)
+		}
+		return ids
+	}
+
+	t.Run("permissions are inherited from all parent folders", func(t *testing.T) {
+		setup(t)
+
+		var otherUser int64 = 999
+		err := testHelperUpdateDashboardAcl(t, sqlStore, top.Id, models.DashboardAcl{
+			DashboardID: top.Id, OrgID: 1, UserID: otherUser, Permission: models.PERMISSION_EDIT,
+		})
+		require.NoError(t, err)
+
+		query := &search.FindPersistedDashboardsQuery{
+			SignedInUser: &models.SignedInUser{UserId: viewer.Id, OrgId: 1, OrgRole: models.ROLE_VIEWER},
+			OrgId:        1,
+		}
+		require.Empty(t, searchIDs(t, query))
+
+		err = testHelperUpdateDashboardAcl(t, sqlStore, middle.Id, models.DashboardAcl{
+			DashboardID: middle.Id, OrgID: 1, UserID: viewer.Id, Permission: models.PERMISSION_VIEW,
+		})
+		require.NoError(t, err)
+
+		query = &search.FindPersistedDashboardsQuery{
+			SignedInUser: &models.SignedInUser{UserId: viewer.Id, OrgId: 1, OrgRole: models.ROLE_VIEWER},
+			OrgId:        1,
+		}
+		require.ElementsMatch(t, []int64{middle.Id, bottom.Id, dash.Id}, searchIDs(t, query))
+
+		aclQuery := &models.GetDashboardAclInfoListQuery{DashboardID: dash.Id, OrgID: 1}
+		require.NoError(t, sqlStore.GetDashboardAclInfoList(context.Background(), aclQuery))
+		require.Len(t, aclQuery.Result, 2)
+		for _, item := range aclQuery.Result {
+			require.True(t, item.Inherited)
+		}
+	})
+
+	t.Run("default permissions apply when no parent folder has permissions", func(t *testing.T) {
+		setup(t)
+
+		aclQuery := &models.GetDashboardAclInfoListQuery{DashboardID: dash.Id, OrgID: 1}
+		require.NoError(t, sqlStore.GetDashboardAclInfoList(context.Background(), aclQuery))
+		require.Len(t, aclQuery.Result, 2)
+		require.Equal(t, int64(-1), aclQuery.Result[0].DashboardId)
+	})
+
+	t.Run("search can filter by folder subtree", func(t *testing.T) {
+		setup(t)
+		insertTestDashboard(t, sqlStore, "other", 1, 0, false)
+
+		query := &search.FindPersistedDashboardsQuery{
+			SignedInUser: &models.SignedInUser{OrgId: 1, OrgRole: models.ROLE_ADMIN},
+			OrgId:        1,
+			FolderIds:    []int64{top.Id},
+		}
+		require.Equal(t, []int64{middle.Id}, searchIDs(t, query))
+
+		query = &search.FindPersistedDashboardsQuery{
+			SignedInUser:  &models.SignedInUser{OrgId: 1, OrgRole: models.ROLE_ADMIN},
+			OrgId:         1,
+			FolderIds:     []int64{top.Id},
+			FolderSubtree: true,
+		}
+		require.ElementsMatch(t, []int64{middle.Id, bottom.Id, dash.Id}, searchIDs(t, query))
+	})
+
+	t.Run("folders cannot be nested too deep", func(t *testing.T) {
+		setup(t)
+		deepest := insertTestDashboard(t, sqlStore, "deepest", 1, bottom.Id, true)
+
+		tooDeep := models.NewDashboardFolder("too deep")
+		tooDeep.OrgId = 1
+		tooDeep.FolderId = deepest.Id
+		_, err := sqlStore.ValidateDashboardBeforeSave(context.Background(), tooDeep, false)
+		require.ErrorIs(t, err, models.ErrDashboardFolderTooDeep)
+
+		// moving middle with its two levels of subfolders into a second-level folder
+		other := insertTestDashboard(t, sqlStore, "other", 1, 0, true)
+		otherChild := insertTestDashboard(t, sqlStore, "other child", 1, other.Id, true)
+		moved := *middle
+		moved.FolderId = otherChild.Id
+		_, err = sqlStore.ValidateDashboardBeforeSave(context.Background(), &moved, false)
+		require.ErrorIs(t, err, models.ErrDashboardFolderTooDeep)
+
+		moved = *middle
+		moved.FolderId = other.Id
+		isParentFolderChanged, err := sqlStore.ValidateDashboardBeforeSave(context.Background(), &moved, false)
+		require.NoError(t, err)
+		require.True(t, isParentFolderChanged)
+	})
+
+	t.Run("folders cannot be moved into their subfolders", func(t *testing.T) {
+		setup(t)
+
+		moved := *top
+		moved.FolderId = middle.Id
+		_, err := sqlStore.ValidateDashboardBeforeSave(context.Background(), &moved, false)
+		require.ErrorIs(t, err, models.ErrDashboardFolderCannotBeOwnAncestor)
+
+		moved = *middle
+		moved.FolderId = middle.Id
+		_, err = sqlStore.ValidateDashboardBeforeSave(context.Background(), &moved, false)
+		require.ErrorIs(t, err, models.ErrDashboardFolderCannotBeOwnAncestor)
+	})
+
+	t.Run("subfolders in different folders can have the same name", func(t *testing.T) {
+		setup(t)
+		other := insertTestDashboard(t, sqlStore, "other", 1, 0, true)
+
+		insertTestDashboard(t, sqlStore, "team", 1, top.Id, true)
+		insertTestDashboard(t, sqlStore, "team", 1, other.Id, true)
+
+		sameName := models.NewDashboardFolder("team")
+		sameName.OrgId = 1
+		sameName.FolderId = top.Id
+		sameName.UpdateSlug()
+		_, err := sqlStore.ValidateDashboardBeforeSave(context.Background(), sameName, false)
+		require.ErrorIs(t, err, models.ErrDashboardWithSameNameInFolderExists)
+	})
+
+	t.Run("deleting a folder deletes its subfolders", func(t *testing.T) {
+		setup(t)
+
+		err := DeleteDashboard(context.Background(), &models.DeleteDashboardCommand{Id: top.Id, OrgId: 1})
+		require.NoError(t, err)
+
+		for _, id := range []int64{middle.Id, bottom.Id, dash.Id} {
+			query := models.GetDashboardQuery{Id: id, OrgId: 1}
+			err := GetDashboard(context.Background(), &query)
+			require.ErrorIs(t, err, models.ErrDashboardNotFound)
+		}
+	})
+}
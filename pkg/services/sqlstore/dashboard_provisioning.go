@@ -11,6 +11,7 @@ import (
 func init() {
 	bus.AddHandlerCtx("sql", UnprovisionDashboard)
 	bus.AddHandlerCtx("sql", DeleteOrphanedProvisionedDashboards)
+	bus.AddHandlerCtx("sql", UpdateDashboardProvisioning)
 }
 
 type DashboardExtras struct {
@@ -87,6 +88,14 @@ func UnprovisionDashboard(ctx context.Context, cmd *models.UnprovisionDashboardC
 	return nil
 }
 
+func UpdateDashboardProvisioning(ctx context.Context, cmd *models.UpdateDashboardProvisioningCommand) error {
+	return withDbSession(ctx, x, func(sess *DBSession) error {
+		_, err := sess.Where("dashboard_id = ? AND name = ?", cmd.DashboardId, cmd.Name).Cols("check_sum", "updated").
+			Update(&models.DashboardProvisioning{CheckSum: cmd.CheckSum, Updated: cmd.Updated})
+		return err
+	})
+}
+
 func DeleteOrphanedProvisionedDashboards(ctx context.Context, cmd *models.DeleteOrphanedProvisionedDashboardsCommand) error {
 	var result []*models.DashboardProvisioning
 
@@ -27,7 +27,7 @@ func TestDashboardProvisioningTest(t *testing.T) {
 		}),
 	}
 
-	dash, err := sqlStore.SaveDashboard(folderCmd)
+	dash, err := sqlStore.SaveDashboard(context.Background(), folderCmd)
 	require.Nil(t, err)
 
 	saveDashboardCmd := models.SaveDashboardCommand{
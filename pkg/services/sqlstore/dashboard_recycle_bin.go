@@ -0,0 +1,261 @@
+package sqlstore
+
+import (
+	"context"
+	"encoding/json"
+	"time"
+
+	"github.com/grafana/grafana/pkg/bus"
+	"github.com/grafana/grafana/pkg/models"
+	"github.com/grafana/grafana/pkg/setting"
+)
+
+func init() {
+	bus.AddHandlerCtx("sql", GetDeletedDashboards)
+	bus.AddHandlerCtx("sql", RestoreDeletedDashboard)
+	bus.AddHandlerCtx("sql", DeleteExpiredDeletedDashboards)
+}
+
+// DeletedDashboard is a dashboard or folder in the recycle bin. Dashboards
+// and subfolders deleted with a folder have DeletedWithId set to the id of
+// the folder's entry.
+type DeletedDashboard struct {
+	Id            int64
+	OrgId         int64
+	DashboardId   int64
+	Uid           string
+	Title         string
+	FolderId      int64
+	IsFolder      bool
+	DeletedWithId int64
+	Deleted       time.Time
+	Data          string
+}
+
+// deletedDashboardData is everything needed to restore a deleted dashboard.
+type deletedDashboardData struct {
+	Dashboard *models.Dashboard
+	Acl       []*models.DashboardAcl
+	Versions  []*models.DashboardVersion
+	Labels    []*models.DashboardVersionLabel
+	Retention *models.DashboardVersionRetention
+}
+
+// recycleBinEnabled returns whether deleted dashboards are moved to the
+// recycle bin instead of being deleted permanently.
+func recycleBinEnabled() bool {
+	return setting.DashboardRecycleBinRetention > 0
+}
+
+// moveDashboardToRecycleBin saves the dashboard with its permissions,
+// versions and version retention to the recycle bin and returns the id of the
+// entry.
+func moveDashboardToRecycleBin(sess *DBSession, dash *models.Dashboard, deletedWithID int64) (int64, error) {
+	data := deletedDashboardData{Dashboard: dash}
+	if err := sess.Where("dashboard_id = ?", dash.Id).Find(&data.Acl); err != nil {
+		return 0, err
+	}
+	if err := sess.Where("dashboard_id = ?", dash.Id).Asc("version").Find(&data.Versions); err != nil {
+		return 0, err
+	}
+	if err := sess.Where("dashboard_id = ?", dash.Id).Find(&data.Labels); err != nil {
+		return 0, err
+	}
+	retention := models.DashboardVersionRetention{}
+	hasRetention, err := sess.Where("dashboard_id = ?", dash.Id).Get(&retention)
+	if err != nil {
+		return 0, err
+	}
+	if hasRetention {
+		data.Retention = &retention
+	}
+
+	raw, err := json.Marshal(data)
+	if err != nil {
+		return 0, err
+	}
+
+	entry := &DeletedDashboard{
+		OrgId:         dash.OrgId,
+		DashboardId:   dash.Id,
+		Uid:           dash.Uid,
+		Title:         dash.Title,
+		FolderId:      dash.FolderId,
+		IsFolder:      dash.IsFolder,
+		DeletedWithId: deletedWithID,
+		Deleted:       time.Now(),
+		Data:          string(raw),
+	}
+	if _, err := sess.Insert(entry); err != nil {
+		return 0, err
+	}
+	return entry.Id, nil
+}
+
+// GetDeletedDashboards returns the dashboards and folders in the recycle bin,
+// leaving out those deleted with a folder.
+func GetDeletedDashboards(ctx context.Context, query *models.GetDeletedDashboardsQuery) error {
+	return withDbSession(ctx, x, func(sess *DBSession) error {
+		query.Result = make([]*models.DeletedDashboardDTO, 0)
+		err := sess.SQL(`SELECT
+			deleted_dashboard.id,
+			deleted_dashboard.uid,
+			deleted_dashboard.title,
+			deleted_dashboard.is_folder,
+			deleted_dashboard.folder_id,
+			deleted_dashboard.deleted,
+			(SELECT COUNT(*) FROM deleted_dashboard AS item WHERE item.deleted_with_id = deleted_dashboard.id) AS item_count
+			FROM deleted_dashboard
+			WHERE deleted_dashboard.org_id = ? AND deleted_dashboard.deleted_with_id = 0
+			ORDER BY deleted_dashboard.deleted DESC`, query.OrgId).Find(&query.Result)
+		if err != nil {
+			return err
+		}
+
+		for _, item := range query.Result {
+			item.Expires = item.Deleted.Add(setting.DashboardRecycleBinRetention)
+		}
+		return nil
+	})
+}
+
+// RestoreDeletedDashboard restores a dashboard or folder from the recycle bin,
+// along with its versions and permissions and everything deleted with it. A
+// dashboard whose folder no longer exists is restored to the General folder.
+func RestoreDeletedDashboard(ctx context.Context, cmd *models.RestoreDeletedDashboardCommand) error {
+	return inTransactionCtx(ctx, func(sess *DBSession) error {
+		var entries []*DeletedDashboard
+		err := sess.Where("org_id = ? AND (id = ? OR deleted_with_id = ?)", cmd.OrgId, cmd.Id, cmd.Id).Asc("id").Find(&entries)
+		if err != nil {
+			return err
+		}
+		if len(entries) == 0 || entries[0].Id != cmd.Id || entries[0].DeletedWithId != 0 {
+			return models.ErrDeletedDashboardNotFound
+		}
+
+		for i, entry := range entries {
+			var data deletedDashboardData
+			if err := json.Unmarshal([]byte(entry.Data), &data); err != nil {
+				return err
+			}
+
+			if i == 0 && data.Dashboard.FolderId > 0 {
+				folderExists, err := sess.Table("dashboard").Where("id = ? AND org_id = ? AND is_folder = ?",
+					data.Dashboard.FolderId, cmd.OrgId, dialect.BooleanStr(true)).Exist()
+				if err != nil {
+					return err
+				}
+				if !folderExists {
+					data.Dashboard.FolderId = 0
+				}
+			}
+
+			if err := restoreDashboard(sess, &data); err != nil {
+				return err
+			}
+			if i == 0 {
+				cmd.Result = data.Dashboard
+			}
+		}
+
+		_, err = sess.Exec("DELETE FROM deleted_dashboard WHERE org_id = ? AND (id = ? OR deleted_with_id = ?)",
+			cmd.OrgId, cmd.Id, cmd.Id)
+		return err
+	})
+}
+
+func restoreDashboard(sess *DBSession, data *deletedDashboardData) error {
+	dash := data.Dashboard
+
+	uidExists, err := sess.Table("dashboard").Where("org_id = ? AND uid = ?", dash.OrgId, dash.Uid).Exist()
+	if err != nil {
+		return err
+	}
+	if uidExists {
+		return models.ErrDashboardWithSameUIDExists
+	}
+
+	titleExists, err := sess.Table("dashboard").Where("org_id = ? AND folder_id = ? AND title = ?",
+		dash.OrgId, dash.FolderId, dash.Title).Exist()
+	if err != nil {
+		return err
+	}
+	if titleExists {
+		return models.ErrDashboardWithSameNameInFolderExists
+	}
+
+	if _, err := sess.InsertId(dash); err != nil {
+		return err
+	}
+
+	for _, tag := range dash.GetTags() {
+		if _, err := sess.Insert(&DashboardTag{DashboardId: dash.Id, Term: tag}); err != nil {
+			return err
+		}
+	}
+
+	if err := indexDashboardContent(sess, dash); err != nil {
+		return err
+	}
+
+	for _, acl := range data.Acl {
+		acl.Id = 0
+		if _, err := sess.Insert(acl); err != nil {
+			return err
+		}
+	}
+
+	for _, version := range data.Versions {
+		version.Id = 0
+		if _, err := sess.Insert(version); err != nil {
+			return err
+		}
+	}
+
+	for _, label := range data.Labels {
+		label.Id = 0
+		if _, err := sess.Insert(label); err != nil {
+			return err
+		}
+	}
+
+	if data.Retention != nil {
+		data.Retention.Id = 0
+		data.Retention.OrgId = dash.OrgId
+		if _, err := sess.Insert(data.Retention); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// DeleteExpiredDeletedDashboards permanently deletes the dashboards that have
+// been in the recycle bin since before cmd.OlderThan, together with their
+// annotations, stars and playlist items.
+func DeleteExpiredDeletedDashboards(ctx context.Context, cmd *models.DeleteExpiredDeletedDashboardsCommand) error {
+	return inTransactionCtx(ctx, func(sess *DBSession) error {
+		var dashboardIDs []int64
+		if err := sess.Table("deleted_dashboard").Where("deleted < ?", cmd.OlderThan).Cols("dashboard_id").Find(&dashboardIDs); err != nil {
+			return err
+		}
+		if len(dashboardIDs) == 0 {
+			return nil
+		}
+
+		for _, id := range dashboardIDs {
+			for _, sql := range dashboardUserContentDeletes {
+				if _, err := sess.Exec(sql, id); err != nil {
+					return err
+				}
+			}
+		}
+
+		res, err := sess.Exec("DELETE FROM deleted_dashboard WHERE deleted < ?", cmd.OlderThan)
+		if err != nil {
+			return err
+		}
+		cmd.DeletedRows, err = res.RowsAffected()
+		return err
+	})
+}
@@ -0,0 +1,167 @@
+//go:build integration
+// +build integration
+
+package sqlstore
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/grafana/grafana/pkg/components/simplejson"
+	"github.com/grafana/grafana/pkg/models"
+	"github.com/grafana/grafana/pkg/setting"
+	"github.com/stretchr/testify/require"
+)
+
+func TestDashboardRecycleBin(t *testing.T) {
+	var sqlStore *SQLStore
+	var folder, subfolder, dash, other *models.Dashboard
+
+	// folder > subfolder > dash, and other in the General folder
+	setup := func(t *testing.T) {
+		origRetention := setting.DashboardRecycleBinRetention
+		t.Cleanup(func() {
+			setting.DashboardRecycleBinRetention = origRetention
+		})
+		setting.DashboardRecycleBinRetention = 24 * time.Hour
+
+		sqlStore = InitTestDB(t)
+		folder = insertTestDashboard(t, sqlStore, "folder", 1, 0, true)
+		subfolder = insertTestDashboard(t, sqlStore, "subfolder", 1, folder.Id, true)
+		dash = insertTestDashboard(t, sqlStore, "dash", 1, subfolder.Id, false, "prod")
+		other = insertTestDashboard(t, sqlStore, "other", 1, 0, false)
+	}
+
+	getDeleted := func(t *testing.T) []*models.DeletedDashboardDTO {
+		t.Helper()
+		query := &models.GetDeletedDashboardsQuery{OrgId: 1}
+		require.NoError(t, GetDeletedDashboards(context.Background(), query))
+		return query.Result
+	}
+
+	t.Run("deleting a folder moves it to the recycle bin with its content", func(t *testing.T) {
+		setup(t)
+
+		err := DeleteDashboard(context.Background(), &models.DeleteDashboardCommand{Id: folder.Id, OrgId: 1})
+		require.NoError(t, err)
+
+		for _, id := range []int64{folder.Id, subfolder.Id, dash.Id} {
+			err := GetDashboard(context.Background(), &models.GetDashboardQuery{Id: id, OrgId: 1})
+			require.ErrorIs(t, err, models.ErrDashboardNotFound)
+		}
+
+		deleted := getDeleted(t)
+		require.Len(t, deleted, 1)
+		require.Equal(t, folder.Uid, deleted[0].Uid)
+		require.True(t, deleted[0].IsFolder)
+		require.Equal(t, int64(2), deleted[0].ItemCount)
+		require.Equal(t, deleted[0].Deleted.Add(24*time.Hour), deleted[0].Expires)
+	})
+
+	t.Run("restoring a folder restores its content, versions and permissions", func(t *testing.T) {
+		setup(t)
+
+		err := testHelperUpdateDashboardAcl(t, sqlStore, subfolder.Id, models.DashboardAcl{
+			DashboardID: subfolder.Id, OrgID: 1, UserID: 2, Permission: models.PERMISSION_EDIT,
+		})
+		require.NoError(t, err)
+		require.NoError(t, DeleteDashboard(context.Background(), &models.DeleteDashboardCommand{Id: folder.Id, OrgId: 1}))
+
+		cmd := &models.RestoreDeletedDashboardCommand{Id: getDeleted(t)[0].Id, OrgId: 1}
+		require.NoError(t, RestoreDeletedDashboard(context.Background(), cmd))
+		require.Equal(t, folder.Id, cmd.Result.Id)
+		require.Empty(t, getDeleted(t))
+
+		query := &models.GetDashboardQuery{Uid: dash.Uid, OrgId: 1}
+		require.NoError(t, GetDashboard(context.Background(), query))
+		require.Equal(t, dash.Id, query.Result.Id)
+		require.Equal(t, subfolder.Id, query.Result.FolderId)
+
+		versionsQuery := &models.GetDashboardVersionsQuery{DashboardId: dash.Id, OrgId: 1}
+		require.NoError(t, GetDashboardVersions(versionsQuery))
+		require.Len(t, versionsQuery.Result, 1)
+
+		aclQuery := &models.GetDashboardAclInfoListQuery{DashboardID: subfolder.Id, OrgID: 1}
+		require.NoError(t, sqlStore.GetDashboardAclInfoList(context.Background(), aclQuery))
+		var restoredACL []*models.DashboardAclInfoDTO
+		for _, item := range aclQuery.Result {
+			if !item.Inherited {
+				restoredACL = append(restoredACL, item)
+			}
+		}
+		require.Len(t, restoredACL, 1)
+		require.Equal(t, int64(2), restoredACL[0].UserId)
+
+		tagsQuery := &models.GetDashboardTagsQuery{OrgId: 1}
+		require.NoError(t, GetDashboardTags(context.Background(), tagsQuery))
+		require.Len(t, tagsQuery.Result, 1)
+	})
+
+	t.Run("a dashboard whose folder is gone is restored to the General folder", func(t *testing.T) {
+		setup(t)
+
+		require.NoError(t, DeleteDashboard(context.Background(), &models.DeleteDashboardCommand{Id: dash.Id, OrgId: 1}))
+		require.NoError(t, DeleteDashboard(context.Background(), &models.DeleteDashboardCommand{Id: folder.Id, OrgId: 1}))
+
+		var dashEntry int64
+		for _, item := range getDeleted(t) {
+			if item.Uid == dash.Uid {
+				dashEntry = item.Id
+			}
+		}
+		cmd := &models.RestoreDeletedDashboardCommand{Id: dashEntry, OrgId: 1}
+		require.NoError(t, RestoreDeletedDashboard(context.Background(), cmd))
+		require.Equal(t, int64(0), cmd.Result.FolderId)
+	})
+
+	t.Run("restoring fails when a dashboard with the same uid exists", func(t *testing.T) {
+		setup(t)
+
+		require.NoError(t, DeleteDashboard(context.Background(), &models.DeleteDashboardCommand{Id: other.Id, OrgId: 1}))
+		_, err := sqlStore.SaveDashboard(context.Background(), models.SaveDashboardCommand{
+			OrgId:     1,
+			Dashboard: simplejson.NewFromAny(map[string]interface{}{"title": "other again", "uid": other.Uid}),
+		})
+		require.NoError(t, err)
+
+		cmd := &models.RestoreDeletedDashboardCommand{Id: getDeleted(t)[0].Id, OrgId: 1}
+		err = RestoreDeletedDashboard(context.Background(), cmd)
+		require.ErrorIs(t, err, models.ErrDashboardWithSameUIDExists)
+		require.Len(t, getDeleted(t), 1)
+	})
+
+	t.Run("dashboards deleted with a folder cannot be restored on their own", func(t *testing.T) {
+		setup(t)
+
+		require.NoError(t, DeleteDashboard(context.Background(), &models.DeleteDashboardCommand{Id: folder.Id, OrgId: 1}))
+
+		cmd := &models.RestoreDeletedDashboardCommand{Id: getDeleted(t)[0].Id + 1, OrgId: 1}
+		err := RestoreDeletedDashboard(context.Background(), cmd)
+		require.ErrorIs(t, err, models.ErrDeletedDashboardNotFound)
+	})
+
+	t.Run("expired dashboards are deleted permanently", func(t *testing.T) {
+		setup(t)
+
+		require.NoError(t, DeleteDashboard(context.Background(), &models.DeleteDashboardCommand{Id: folder.Id, OrgId: 1}))
+		require.NoError(t, DeleteDashboard(context.Background(), &models.DeleteDashboardCommand{Id: other.Id, OrgId: 1}))
+
+		cmd := &models.DeleteExpiredDeletedDashboardsCommand{OlderThan: time.Now().Add(-time.Hour)}
+		require.NoError(t, DeleteExpiredDeletedDashboards(context.Background(), cmd))
+		require.Equal(t, int64(0), cmd.DeletedRows)
+
+		cmd = &models.DeleteExpiredDeletedDashboardsCommand{OlderThan: time.Now().Add(time.Hour)}
+		require.NoError(t, DeleteExpiredDeletedDashboards(context.Background(), cmd))
+		require.Equal(t, int64(4), cmd.DeletedRows)
+		require.Empty(t, getDeleted(t))
+	})
+
+	t.Run("dashboards are deleted permanently when the recycle bin is disabled", func(t *testing.T) {
+		setup(t)
+		setting.DashboardRecycleBinRetention = 0
+
+		require.NoError(t, DeleteDashboard(context.Background(), &models.DeleteDashboardCommand{Id: folder.Id, OrgId: 1}))
+		require.Empty(t, getDeleted(t))
+	})
+}
@@ -140,7 +140,7 @@ func TestDashboardDataAccess(t *testing.T) {
 				"tags":  []interface{}{},
 			}),
 		}
-		_, err := sqlStore.SaveDashboard(cmd)
+		_, err := sqlStore.SaveDashboard(context.Background(), cmd)
 		require.NoError(t, err)
 
 		generateNewUid = util.GenerateShortUID
@@ -156,7 +156,7 @@ func TestDashboardDataAccess(t *testing.T) {
 			}),
 			UserId: 100,
 		}
-		dashboard, err := sqlStore.SaveDashboard(cmd)
+		dashboard, err := sqlStore.SaveDashboard(context.Background(), cmd)
 		require.NoError(t, err)
 		require.EqualValues(t, dashboard.CreatedBy, 100)
 		require.False(t, dashboard.Created.IsZero())
@@ -176,7 +176,7 @@ func TestDashboardDataAccess(t *testing.T) {
 			FolderId:  2,
 			UserId:    100,
 		}
-		dash, err := sqlStore.SaveDashboard(cmd)
+		dash, err := sqlStore.SaveDashboard(context.Background(), cmd)
 		require.NoError(t, err)
 		require.EqualValues(t, dash.FolderId, 2)
 
@@ -191,7 +191,7 @@ func TestDashboardDataAccess(t *testing.T) {
 			Overwrite: true,
 			UserId:    100,
 		}
-		_, err = sqlStore.SaveDashboard(cmd)
+		_, err = sqlStore.SaveDashboard(context.Background(), cmd)
 		require.NoError(t, err)
 
 		query := models.GetDashboardQuery{
@@ -268,7 +268,7 @@ func TestDashboardDataAccess(t *testing.T) {
 			}),
 		}
 
-		_, err := sqlStore.SaveDashboard(cmd)
+		_, err := sqlStore.SaveDashboard(context.Background(), cmd)
 		require.Equal(t, err, models.ErrDashboardNotFound)
 	})
 
@@ -283,7 +283,7 @@ func TestDashboardDataAccess(t *testing.T) {
 				"tags":  []interface{}{},
 			}),
 		}
-		_, err := sqlStore.SaveDashboard(cmd)
+		_, err := sqlStore.SaveDashboard(context.Background(), cmd)
 		require.NoError(t, err)
 	})
 
@@ -483,7 +483,7 @@ func insertTestDashboard(t *testing.T, sqlStore *SQLStore, title string, orgId i
 			"tags":  tags,
 		}),
 	}
-	dash, err := sqlStore.SaveDashboard(cmd)
+	dash, err := sqlStore.SaveDashboard(context.Background(), cmd)
 	require.NoError(t, err)
 	require.NotNil(t, dash)
 	dash.Data.Set("id", dash.Id)
@@ -575,7 +575,7 @@ func insertTestDashboardForPlugin(t *testing.T, sqlStore *SQLStore, title string
 		PluginId: pluginId,
 	}
 
-	dash, err := sqlStore.SaveDashboard(cmd)
+	dash, err := sqlStore.SaveDashboard(context.Background(), cmd)
 	require.NoError(t, err)
 
 	return dash
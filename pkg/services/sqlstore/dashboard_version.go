@@ -1,7 +1,9 @@
 package sqlstore
 
 import (
+	"context"
 	"strings"
+	"time"
 
 	"github.com/grafana/grafana/pkg/bus"
 	"github.com/grafana/grafana/pkg/models"
@@ -12,8 +14,16 @@ func init() {
 	bus.AddHandler("sql", GetDashboardVersion)
 	bus.AddHandler("sql", GetDashboardVersions)
 	bus.AddHandler("sql", DeleteExpiredVersions)
+	bus.AddHandlerCtx("sql", GetDashboardVersionByLabel)
+	bus.AddHandlerCtx("sql", GetDashboardVersionLabels)
+	bus.AddHandlerCtx("sql", AddDashboardVersionLabel)
+	bus.AddHandlerCtx("sql", DeleteDashboardVersionLabel)
 }
 
+// maxVersionLabelLength is the length of the dashboard_version_label.label
+// column.
+const maxVersionLabelLength = 190
+
 // GetDashboardVersion gets the dashboard version for the given dashboard ID and version number.
 func GetDashboardVersion(query *models.GetDashboardVersionQuery) error {
 	version := models.DashboardVersion{}
@@ -64,6 +74,138 @@ func GetDashboardVersions(query *models.GetDashboardVersionsQuery) error {
 	if len(query.Result) < 1 {
 		return models.ErrNoVersionsForDashboardId
 	}
+
+	var labels []*models.DashboardVersionLabel
+	if err := x.Where("dashboard_id = ?", query.DashboardId).Asc("label").Find(&labels); err != nil {
+		return err
+	}
+	labelsByVersion := make(map[int][]string, len(labels))
+	for _, label := range labels {
+		labelsByVersion[label.Version] = append(labelsByVersion[label.Version], label.Label)
+	}
+	for _, version := range query.Result {
+		version.Labels = labelsByVersion[version.Version]
+		if version.Labels == nil {
+			version.Labels = []string{}
+		}
+	}
+	return nil
+}
+
+// GetDashboardVersionByLabel gets the dashboard version with the given label.
+func GetDashboardVersionByLabel(ctx context.Context, query *models.GetDashboardVersionByLabelQuery) error {
+	var version int
+	err := withDbSession(ctx, x, func(sess *DBSession) error {
+		if err := checkDashboardInOrg(sess, query.DashboardId, query.OrgId); err != nil {
+			return err
+		}
+		label := models.DashboardVersionLabel{}
+		has, err := sess.Where("dashboard_id = ? AND label = ?", query.DashboardId, query.Label).Get(&label)
+		if err != nil {
+			return err
+		}
+		if !has {
+			return models.ErrDashboardVersionLabelNotFound
+		}
+		version = label.Version
+		return nil
+	})
+	if err != nil {
+		return err
+	}
+
+	versionQuery := models.GetDashboardVersionQuery{DashboardId: query.DashboardId, OrgId: query.OrgId, Version: version}
+	if err := GetDashboardVersion(&versionQuery); err != nil {
+		return err
+	}
+	query.Result = versionQuery.Result
+	return nil
+}
+
+// GetDashboardVersionLabels gets the labels of a dashboard version.
+func GetDashboardVersionLabels(ctx context.Context, query *models.GetDashboardVersionLabelsQuery) error {
+	return withDbSession(ctx, x, func(sess *DBSession) error {
+		query.Result = []string{}
+		return sess.Table("dashboard_version_label").Where("dashboard_id = ? AND version = ?", query.DashboardId, query.Version).
+			Asc("label").Cols("label").Find(&query.Result)
+	})
+}
+
+// AddDashboardVersionLabel adds a label to a dashboard version. Labels are
+// unique per dashboard.
+func AddDashboardVersionLabel(ctx context.Context, cmd *models.AddDashboardVersionLabelCommand) error {
+	cmd.Label = strings.TrimSpace(cmd.Label)
+	if cmd.Label == "" || len(cmd.Label) > maxVersionLabelLength {
+		return models.ErrDashboardVersionLabelInvalid
+	}
+
+	return inTransactionCtx(ctx, func(sess *DBSession) error {
+		if err := checkDashboardInOrg(sess, cmd.DashboardId, cmd.OrgId); err != nil {
+			return err
+		}
+
+		versionExists, err := sess.Table("dashboard_version").Where("dashboard_id = ? AND version = ?", cmd.DashboardId, cmd.Version).Exist()
+		if err != nil {
+			return err
+		}
+		if !versionExists {
+			return models.ErrDashboardVersionNotFound
+		}
+
+		labelExists, err := sess.Table("dashboard_version_label").Where("dashboard_id = ? AND label = ?", cmd.DashboardId, cmd.Label).Exist()
+		if err != nil {
+			return err
+		}
+		if labelExists {
+			return models.ErrDashboardVersionLabelExists
+		}
+
+		label := &models.DashboardVersionLabel{
+			DashboardId: cmd.DashboardId,
+			Version:     cmd.Version,
+			Label:       cmd.Label,
+			Created:     time.Now(),
+			CreatedBy:   cmd.UserId,
+		}
+		if _, err := sess.Insert(label); err != nil {
+			return err
+		}
+		cmd.Result = label
+		return nil
+	})
+}
+
+// DeleteDashboardVersionLabel removes a label from the dashboard version it
+// was added to.
+func DeleteDashboardVersionLabel(ctx context.Context, cmd *models.DeleteDashboardVersionLabelCommand) error {
+	return inTransactionCtx(ctx, func(sess *DBSession) error {
+		if err := checkDashboardInOrg(sess, cmd.DashboardId, cmd.OrgId); err != nil {
+			return err
+		}
+
+		res, err := sess.Exec("DELETE FROM dashboard_version_label WHERE dashboard_id = ? AND label = ?", cmd.DashboardId, cmd.Label)
+		if err != nil {
+			return err
+		}
+		deleted, err := res.RowsAffected()
+		if err != nil {
+			return err
+		}
+		if deleted == 0 {
+			return models.ErrDashboardVersionLabelNotFound
+		}
+		return nil
+	})
+}
+
+func checkDashboardInOrg(sess *DBSession, dashboardID int64, orgID int64) error {
+	exists, err := sess.Table("dashboard").Where("id = ? AND org_id = ?", dashboardID, orgID).Exist()
+	if err != nil {
+		return err
+	}
+	if !exists {
+		return models.ErrDashboardNotFound
+	}
 	return nil
 }
 
@@ -74,61 +216,81 @@ func DeleteExpiredVersions(cmd *models.DeleteExpiredVersionsCommand) error {
 	return deleteExpiredVersions(cmd, MAX_VERSIONS_TO_DELETE_PER_BATCH, MAX_VERSION_DELETION_BATCHES)
 }
 
+// deleteExpiredVersions deletes the versions that the retention policies of
+// the dashboards don't keep, deleting at most perBatch * maxBatches versions.
+// Dashboards without a policy keep the versions_to_keep most recent versions.
 func deleteExpiredVersions(cmd *models.DeleteExpiredVersionsCommand, perBatch int, maxBatches int) error {
 	versionsToKeep := setting.DashboardVersionsToKeep
 	if versionsToKeep < 1 {
 		versionsToKeep = 1
 	}
+	defaultPolicy := &models.DashboardVersionRetention{Policy: models.VersionRetentionCount, VersionsToKeep: versionsToKeep}
+	maxDeleted := int64(perBatch * maxBatches)
+	now := time.Now()
 
-	for batch := 0; batch < maxBatches; batch++ {
-		deleted := int64(0)
-
-		batchErr := inTransaction(func(sess *DBSession) error {
-			// Idea of this query is finding version IDs to delete based on formula:
-			// min_version_to_keep = min_version + (versions_count - versions_to_keep)
-			// where version stats is processed for each dashboard. This guarantees that we keep at least versions_to_keep
-			// versions, but in some cases (when versions are sparse) this number may be more.
-			versionIdsToDeleteQuery := `SELECT id
-				FROM dashboard_version, (
-					SELECT dashboard_id, count(version) as count, min(version) as min
-					FROM dashboard_version
-					GROUP BY dashboard_id
-				) AS vtd
-				WHERE dashboard_version.dashboard_id=vtd.dashboard_id
-				AND version < vtd.min + vtd.count - ?
-				LIMIT ?`
-
-			var versionIdsToDelete []interface{}
-			err := sess.SQL(versionIdsToDeleteQuery, versionsToKeep, perBatch).Find(&versionIdsToDelete)
-			if err != nil {
-				return err
-			}
-
-			if len(versionIdsToDelete) < 1 {
-				return nil
-			}
+	var candidates []versionRetentionCandidate
+	err := withDbSession(context.Background(), x, func(sess *DBSession) error {
+		var err error
+		candidates, err = getVersionRetentionCandidates(sess, defaultPolicy)
+		return err
+	})
+	if err != nil {
+		return err
+	}
 
-			deleteExpiredSQL := `DELETE FROM dashboard_version WHERE id IN (?` + strings.Repeat(",?", len(versionIdsToDelete)-1) + `)`
-			sqlOrArgs := append([]interface{}{deleteExpiredSQL}, versionIdsToDelete...)
-			expiredResponse, err := sess.Exec(sqlOrArgs...)
-			if err != nil {
-				return err
-			}
+	// the versions of the candidates are loaded perBatch dashboards at a time
+	for len(candidates) > 0 && cmd.DeletedRows < maxDeleted {
+		chunk := candidates
+		if len(chunk) > perBatch {
+			chunk = chunk[:perBatch]
+		}
+		candidates = candidates[len(chunk):]
 
-			deleted, err = expiredResponse.RowsAffected()
+		var versionIDs []int64
+		err := withDbSession(context.Background(), x, func(sess *DBSession) error {
+			var err error
+			versionIDs, err = expiredVersionIDs(sess, chunk, now)
 			return err
 		})
-
-		if batchErr != nil {
-			return batchErr
+		if err != nil {
+			return err
+		}
+		if remaining := maxDeleted - cmd.DeletedRows; int64(len(versionIDs)) > remaining {
+			versionIDs = versionIDs[:remaining]
 		}
 
-		cmd.DeletedRows += deleted
+		for len(versionIDs) > 0 {
+			batch := versionIDs
+			if len(batch) > perBatch {
+				batch = batch[:perBatch]
+			}
+			versionIDs = versionIDs[len(batch):]
 
-		if deleted < int64(perBatch) {
-			break
+			deleted, err := deleteVersions(batch)
+			if err != nil {
+				return err
+			}
+			cmd.DeletedRows += deleted
 		}
 	}
 
 	return nil
 }
+
+func deleteVersions(ids []int64) (int64, error) {
+	var deleted int64
+	err := inTransaction(func(sess *DBSession) error {
+		deleteExpiredSQL := `DELETE FROM dashboard_version WHERE id IN (?` + strings.Repeat(",?", len(ids)-1) + `)`
+		sqlOrArgs := []interface{}{deleteExpiredSQL}
+		for _, id := range ids {
+			sqlOrArgs = append(sqlOrArgs, id)
+		}
+		res, err := sess.Exec(sqlOrArgs...)
+		if err != nil {
+			return err
+		}
+		deleted, err = res.RowsAffected()
+		return err
+	})
+	return deleted, err
+}
@@ -0,0 +1,272 @@
+package sqlstore
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/grafana/grafana-plugin-sdk-go/backend/gtime"
+	"github.com/grafana/grafana/pkg/bus"
+	"github.com/grafana/grafana/pkg/models"
+)
+
+func init() {
+	bus.AddHandlerCtx("sql", GetDashboardVersionRetention)
+	bus.AddHandlerCtx("sql", SetDashboardVersionRetention)
+	bus.AddHandlerCtx("sql", DeleteDashboardVersionRetention)
+}
+
+// GetDashboardVersionRetention gets the version retention policy set on a
+// dashboard or folder, not the one it inherits from its folders.
+func GetDashboardVersionRetention(ctx context.Context, query *models.GetDashboardVersionRetentionQuery) error {
+	return withDbSession(ctx, x, func(sess *DBSession) error {
+		retention := models.DashboardVersionRetention{}
+		has, err := sess.Where("dashboard_id = ? AND org_id = ?", query.DashboardId, query.OrgId).Get(&retention)
+		if err != nil {
+			return err
+		}
+		if !has {
+			return models.ErrDashboardVersionRetentionNotFound
+		}
+		query.Result = &retention
+		return nil
+	})
+}
+
+// SetDashboardVersionRetention sets the version retention policy of a
+// dashboard or folder.
+func SetDashboardVersionRetention(ctx context.Context, cmd *models.SetDashboardVersionRetentionCommand) error {
+	if err := validateVersionRetention(cmd); err != nil {
+		return err
+	}
+
+	return inTransactionCtx(ctx, func(sess *DBSession) error {
+		if err := checkDashboardInOrg(sess, cmd.DashboardId, cmd.OrgId); err != nil {
+			return err
+		}
+
+		retention := models.DashboardVersionRetention{}
+		has, err := sess.Where("dashboard_id = ?", cmd.DashboardId).Get(&retention)
+		if err != nil {
+			return err
+		}
+
+		retention.OrgId = cmd.OrgId
+		retention.DashboardId = cmd.DashboardId
+		retention.Policy = cmd.Policy
+		retention.VersionsToKeep = cmd.VersionsToKeep
+		retention.KeepFor = cmd.KeepFor
+		retention.Updated = time.Now()
+		if has {
+			_, err = sess.ID(retention.Id).AllCols().Update(&retention)
+		} else {
+			retention.Created = retention.Updated
+			_, err = sess.Insert(&retention)
+		}
+		if err != nil {
+			return err
+		}
+
+		cmd.Result = &retention
+		return nil
+	})
+}
+
+// DeleteDashboardVersionRetention deletes the version retention policy of a
+// dashboard or folder, which then inherits the policy of its folders.
+func DeleteDashboardVersionRetention(ctx context.Context, cmd *models.DeleteDashboardVersionRetentionCommand) error {
+	return inTransactionCtx(ctx, func(sess *DBSession) error {
+		res, err := sess.Exec("DELETE FROM dashboard_version_retention WHERE dashboard_id = ? AND org_id = ?", cmd.DashboardId, cmd.OrgId)
+		if err != nil {
+			return err
+		}
+		deleted, err := res.RowsAffected()
+		if err != nil {
+			return err
+		}
+		if deleted == 0 {
+			return models.ErrDashboardVersionRetentionNotFound
+		}
+		return nil
+	})
+}
+
+func validateVersionRetention(cmd *models.SetDashboardVersionRetentionCommand) error {
+	switch cmd.Policy {
+	case models.VersionRetentionCount:
+		if cmd.VersionsToKeep < 1 {
+			return fmt.Errorf("%w: versionsToKeep must be at least 1", models.ErrDashboardVersionRetentionInvalid)
+		}
+		cmd.KeepFor = ""
+	case models.VersionRetentionDuration:
+		if d, err := gtime.ParseDuration(cmd.KeepFor); err != nil || d <= 0 {
+			return fmt.Errorf("%w: keepFor must be a positive duration, for example 90d", models.ErrDashboardVersionRetentionInvalid)
+		}
+		cmd.VersionsToKeep = 0
+	case models.VersionRetentionForever:
+		cmd.VersionsToKeep = 0
+		cmd.KeepFor = ""
+	default:
+		return fmt.Errorf("%w: policy must be one of count, duration or forever", models.ErrDashboardVersionRetentionInvalid)
+	}
+	return nil
+}
+
+// versionRetentionCandidate is a dashboard that can have expired versions,
+// with the version retention policy it has or inherits.
+type versionRetentionCandidate struct {
+	dashboardID int64
+	policy      *models.DashboardVersionRetention
+}
+
+// getVersionRetentionCandidates returns the dashboards that can have expired
+// versions, ordered by id. Dashboards inherit the policy of their closest
+// folder with a policy, or get defaultPolicy. Dashboards kept forever and
+// dashboards with no more versions than their count policy keeps are left
+// out.
+func getVersionRetentionCandidates(sess *DBSession, defaultPolicy *models.DashboardVersionRetention) ([]versionRetentionCandidate, error) {
+	var retentions []*models.DashboardVersionRetention
+	if err := sess.Find(&retentions); err != nil {
+		return nil, err
+	}
+	policies := make(map[int64]*models.DashboardVersionRetention, len(retentions))
+	for _, retention := range retentions {
+		policies[retention.DashboardId] = retention
+	}
+
+	var folders []struct {
+		Id       int64
+		FolderId int64
+	}
+	err := sess.Table("dashboard").Where("is_folder = ?", dialect.BooleanStr(true)).Cols("id", "folder_id").Find(&folders)
+	if err != nil {
+		return nil, err
+	}
+	parents := make(map[int64]int64, len(folders))
+	for _, folder := range folders {
+		parents[folder.Id] = folder.FolderId
+	}
+
+	// folderPolicies caches the policies folders have or inherit
+	folderPolicies := map[int64]*models.DashboardVersionRetention{}
+	folderPolicy := func(folderID int64) *models.DashboardVersionRetention {
+		policy := defaultPolicy
+		var path []int64
+		for id := folderID; id != 0 && len(path) <= len(parents); id = parents[id] {
+			if cached, ok := folderPolicies[id]; ok {
+				policy = cached
+				break
+			}
+			path = append(path, id)
+			if own, ok := policies[id]; ok {
+				policy = own
+				break
+			}
+		}
+		for _, id := range path {
+			folderPolicies[id] = policy
+		}
+		return policy
+	}
+
+	var dashboards []struct {
+		DashboardId int64
+		FolderId    int64
+		Versions    int
+	}
+	err = sess.SQL(`SELECT dashboard_version.dashboard_id, COALESCE(dashboard.folder_id, 0) AS folder_id,
+			count(dashboard_version.id) AS versions
+		FROM dashboard_version
+		LEFT JOIN dashboard ON dashboard.id = dashboard_version.dashboard_id
+		GROUP BY dashboard_version.dashboard_id, dashboard.folder_id
+		ORDER BY dashboard_version.dashboard_id`).Find(&dashboards)
+	if err != nil {
+		return nil, err
+	}
+
+	candidates := []versionRetentionCandidate{}
+	for _, dashboard := range dashboards {
+		policy, ok := policies[dashboard.DashboardId]
+		if !ok {
+			policy = folderPolicy(dashboard.FolderId)
+		}
+		switch policy.Policy {
+		case models.VersionRetentionForever:
+			continue
+		case models.VersionRetentionCount:
+			if dashboard.Versions <= policy.VersionsToKeep {
+				continue
+			}
+		}
+		candidates = append(candidates, versionRetentionCandidate{dashboardID: dashboard.DashboardId, policy: policy})
+	}
+	return candidates, nil
+}
+
+// expiredVersionIDs returns the ids of the versions of dashboards that their
+// retention policies don't keep. The current version and labeled versions
+// are always kept.
+func expiredVersionIDs(sess *DBSession, candidates []versionRetentionCandidate, now time.Time) ([]int64, error) {
+	dashboardIDs := make([]int64, 0, len(candidates))
+	policies := make(map[int64]*models.DashboardVersionRetention, len(candidates))
+	cutoffs := make(map[int64]time.Time, len(candidates))
+	for _, candidate := range candidates {
+		dashboardIDs = append(dashboardIDs, candidate.dashboardID)
+		policies[candidate.dashboardID] = candidate.policy
+		if candidate.policy.Policy == models.VersionRetentionDuration {
+			keepFor, err := gtime.ParseDuration(candidate.policy.KeepFor)
+			if err != nil {
+				return nil, err
+			}
+			cutoffs[candidate.dashboardID] = now.Add(-keepFor)
+		}
+	}
+
+	var versions []*models.DashboardVersion
+	err := sess.Table("dashboard_version").In("dashboard_id", dashboardIDs).
+		Cols("id", "dashboard_id", "version", "created").Asc("dashboard_id").Desc("version").Find(&versions)
+	if err != nil {
+		return nil, err
+	}
+
+	var labels []struct {
+		DashboardId int64
+		Version     int
+	}
+	err = sess.Table("dashboard_version_label").In("dashboard_id", dashboardIDs).Cols("dashboard_id", "version").Find(&labels)
+	if err != nil {
+		return nil, err
+	}
+	labeled := make(map[int64]map[int]bool, len(labels))
+	for _, label := range labels {
+		if labeled[label.DashboardId] == nil {
+			labeled[label.DashboardId] = map[int]bool{}
+		}
+		labeled[label.DashboardId][label.Version] = true
+	}
+
+	var ids []int64
+	var dashboardID int64
+	var i int
+	for _, version := range versions {
+		if version.DashboardId != dashboardID {
+			dashboardID = version.DashboardId
+			i = 0
+		} else {
+			i++
+		}
+
+		policy := policies[dashboardID]
+		if i == 0 || labeled[dashboardID][version.Version] {
+			continue
+		}
+		if policy.Policy == models.VersionRetentionCount && i < policy.VersionsToKeep {
+			continue
+		}
+		if policy.Policy == models.VersionRetentionDuration && !version.Created.Before(cutoffs[dashboardID]) {
+			continue
+		}
+		ids = append(ids, version.Id)
+	}
+	return ids, nil
+}
@@ -7,6 +7,7 @@ import (
 	"context"
 	"reflect"
 	"testing"
+	"time"
 
 	"github.com/grafana/grafana/pkg/components/simplejson"
 	"github.com/grafana/grafana/pkg/models"
@@ -24,7 +25,7 @@ func updateTestDashboard(t *testing.T, sqlStore *SQLStore, dashboard *models.Das
 		Overwrite: true,
 		Dashboard: simplejson.NewFromAny(data),
 	}
-	_, err := sqlStore.SaveDashboard(saveCmd)
+	_, err := sqlStore.SaveDashboard(context.Background(), saveCmd)
 	require.Nil(t, err)
 }
 
@@ -174,3 +175,140 @@ func TestDeleteExpiredVersions(t *testing.T) {
 		require.LessOrEqual(t, versionsToWriteBigNumber-len(query.Result), perBatch*maxBatches)
 	})
 }
+
+func TestDashboardVersionLabels(t *testing.T) {
+	sqlStore := InitTestDB(t)
+	ctx := context.Background()
+	savedDash := insertTestDashboard(t, sqlStore, "test dash 84", 1, 0, false, "labels")
+	updateTestDashboard(t, sqlStore, savedDash, map[string]interface{}{"tags": "different-tag"})
+
+	t.Run("Add a label to a version", func(t *testing.T) {
+		cmd := models.AddDashboardVersionLabelCommand{DashboardId: savedDash.Id, OrgId: 1, Version: 1, Label: " release-2021-10 "}
+		require.NoError(t, AddDashboardVersionLabel(ctx, &cmd))
+		require.Equal(t, "release-2021-10", cmd.Result.Label)
+
+		query := models.GetDashboardVersionByLabelQuery{DashboardId: savedDash.Id, OrgId: 1, Label: "release-2021-10"}
+		require.NoError(t, GetDashboardVersionByLabel(ctx, &query))
+		require.Equal(t, 1, query.Result.Version)
+
+		versionsQuery := models.GetDashboardVersionsQuery{DashboardId: savedDash.Id, OrgId: 1}
+		require.NoError(t, GetDashboardVersions(&versionsQuery))
+		require.Equal(t, []string{}, versionsQuery.Result[0].Labels)
+		require.Equal(t, []string{"release-2021-10"}, versionsQuery.Result[1].Labels)
+	})
+
+	t.Run("Labels are unique per dashboard", func(t *testing.T) {
+		cmd := models.AddDashboardVersionLabelCommand{DashboardId: savedDash.Id, OrgId: 1, Version: 2, Label: "release-2021-10"}
+		require.ErrorIs(t, AddDashboardVersionLabel(ctx, &cmd), models.ErrDashboardVersionLabelExists)
+	})
+
+	t.Run("Can't label a version of another org or that doesn't exist", func(t *testing.T) {
+		cmd := models.AddDashboardVersionLabelCommand{DashboardId: savedDash.Id, OrgId: 2, Version: 2, Label: "other"}
+		require.ErrorIs(t, AddDashboardVersionLabel(ctx, &cmd), models.ErrDashboardNotFound)
+
+		cmd = models.AddDashboardVersionLabelCommand{DashboardId: savedDash.Id, OrgId: 1, Version: 3, Label: "other"}
+		require.ErrorIs(t, AddDashboardVersionLabel(ctx, &cmd), models.ErrDashboardVersionNotFound)
+	})
+
+	t.Run("Delete a label", func(t *testing.T) {
+		cmd := models.DeleteDashboardVersionLabelCommand{DashboardId: savedDash.Id, OrgId: 1, Label: "release-2021-10"}
+		require.NoError(t, DeleteDashboardVersionLabel(ctx, &cmd))
+		require.ErrorIs(t, DeleteDashboardVersionLabel(ctx, &cmd), models.ErrDashboardVersionLabelNotFound)
+
+		query := models.GetDashboardVersionByLabelQuery{DashboardId: savedDash.Id, OrgId: 1, Label: "release-2021-10"}
+		require.ErrorIs(t, GetDashboardVersionByLabel(ctx, &query), models.ErrDashboardVersionLabelNotFound)
+	})
+}
+
+func TestDeleteExpiredVersionsWithRetentionPolicies(t *testing.T) {
+	setting.DashboardVersionsToKeep = 2
+	sqlStore := InitTestDB(t)
+	ctx := context.Background()
+
+	saveVersions := func(t *testing.T, title string, folderID int64) *models.Dashboard {
+		t.Helper()
+		dash := insertTestDashboard(t, sqlStore, title, 1, folderID, false, "retention")
+		for i := 0; i < 5; i++ {
+			_, err := sqlStore.SaveDashboard(context.Background(), models.SaveDashboardCommand{
+				OrgId:     1,
+				FolderId:  folderID,
+				Overwrite: true,
+				Dashboard: simplejson.NewFromAny(map[string]interface{}{
+					"id":    dash.Id,
+					"title": title,
+					"tags":  "different-tag",
+				}),
+			})
+			require.NoError(t, err)
+		}
+		return dash
+	}
+	versions := func(t *testing.T, dash *models.Dashboard) []int {
+		t.Helper()
+		query := models.GetDashboardVersionsQuery{DashboardId: dash.Id, OrgId: 1}
+		require.NoError(t, GetDashboardVersions(&query))
+		result := []int{}
+		for _, version := range query.Result {
+			result = append(result, version.Version)
+		}
+		return result
+	}
+	setRetention := func(t *testing.T, dashID int64, cmd models.SetDashboardVersionRetentionCommand) {
+		t.Helper()
+		cmd.DashboardId = dashID
+		cmd.OrgId = 1
+		require.NoError(t, SetDashboardVersionRetention(ctx, &cmd))
+	}
+
+	folder := insertTestDashboard(t, sqlStore, "retention folder", 1, 0, true)
+	subfolder := insertTestDashboard(t, sqlStore, "retention subfolder", 1, folder.Id, true)
+	nestedFolder := insertTestDashboard(t, sqlStore, "retention nested folder", 1, subfolder.Id, true)
+	defaultDash := saveVersions(t, "default", 0)
+	labeledDash := saveVersions(t, "labeled", 0)
+	countDash := saveVersions(t, "count", 0)
+	inheritedDash := saveVersions(t, "inherited", subfolder.Id)
+	durationDash := saveVersions(t, "duration", 0)
+	overriddenDash := saveVersions(t, "overridden", nestedFolder.Id)
+
+	setRetention(t, countDash.Id, models.SetDashboardVersionRetentionCommand{Policy: models.VersionRetentionCount, VersionsToKeep: 4})
+	setRetention(t, folder.Id, models.SetDashboardVersionRetentionCommand{Policy: models.VersionRetentionForever})
+	setRetention(t, nestedFolder.Id, models.SetDashboardVersionRetentionCommand{Policy: models.VersionRetentionCount, VersionsToKeep: 3})
+	setRetention(t, durationDash.Id, models.SetDashboardVersionRetentionCommand{Policy: models.VersionRetentionDuration, KeepFor: "1h"})
+	_, err := sqlStore.engine.Exec("UPDATE dashboard_version SET created = ? WHERE dashboard_id = ? AND version < 3",
+		time.Now().Add(-2*time.Hour), durationDash.Id)
+	require.NoError(t, err)
+	require.NoError(t, AddDashboardVersionLabel(ctx, &models.AddDashboardVersionLabelCommand{
+		DashboardId: labeledDash.Id, OrgId: 1, Version: 1, Label: "first",
+	}))
+
+	cmd := models.DeleteExpiredVersionsCommand{}
+	require.NoError(t, DeleteExpiredVersions(&cmd))
+
+	require.Equal(t, []int{6, 5}, versions(t, defaultDash))
+	require.Equal(t, []int{6, 5, 1}, versions(t, labeledDash))
+	require.Equal(t, []int{6, 5, 4, 3}, versions(t, countDash))
+	require.Equal(t, []int{6, 5, 4, 3, 2, 1}, versions(t, inheritedDash))
+	require.Equal(t, []int{6, 5, 4, 3}, versions(t, durationDash))
+	require.Equal(t, []int{6, 5, 4}, versions(t, overriddenDash))
+	require.Equal(t, int64(4+3+2+2+3), cmd.DeletedRows)
+
+	t.Run("Invalid policies are rejected", func(t *testing.T) {
+		for _, cmd := range []models.SetDashboardVersionRetentionCommand{
+			{Policy: models.VersionRetentionCount},
+			{Policy: models.VersionRetentionDuration, KeepFor: "soon"},
+			{Policy: "never"},
+		} {
+			cmd.DashboardId = defaultDash.Id
+			cmd.OrgId = 1
+			require.ErrorIs(t, SetDashboardVersionRetention(ctx, &cmd), models.ErrDashboardVersionRetentionInvalid)
+		}
+	})
+
+	t.Run("Delete a policy", func(t *testing.T) {
+		cmd := models.DeleteDashboardVersionRetentionCommand{DashboardId: folder.Id, OrgId: 1}
+		require.NoError(t, DeleteDashboardVersionRetention(ctx, &cmd))
+
+		query := models.GetDashboardVersionRetentionQuery{DashboardId: folder.Id, OrgId: 1}
+		require.ErrorIs(t, GetDashboardVersionRetention(ctx, &query), models.ErrDashboardVersionRetentionNotFound)
+	})
+}
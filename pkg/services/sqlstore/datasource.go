@@ -79,28 +79,35 @@ func (ss *SQLStore) GetDefaultDataSource(query *models.GetDefaultDataSourceQuery
 // DeleteDataSource removes a datasource by org_id as well as either uid (preferred), id, or name
 // and is added to the bus.
 func (ss *SQLStore) DeleteDataSource(ctx context.Context, cmd *models.DeleteDataSourceCommand) error {
-	params := make([]interface{}, 0)
-
-	makeQuery := func(sql string, p ...interface{}) {
-		params = append(params, sql)
-		params = append(params, p...)
-	}
+	var where string
+	var args []interface{}
 
 	switch {
 	case cmd.OrgID == 0:
 		return models.ErrDataSourceIdentifierNotSet
 	case cmd.UID != "":
-		makeQuery("DELETE FROM data_source WHERE uid=? and org_id=?", cmd.UID, cmd.OrgID)
+		where, args = "uid=? and org_id=?", []interface{}{cmd.UID, cmd.OrgID}
 	case cmd.ID != 0:
-		makeQuery("DELETE FROM data_source WHERE id=? and org_id=?", cmd.ID, cmd.OrgID)
+		where, args = "id=? and org_id=?", []interface{}{cmd.ID, cmd.OrgID}
 	case cmd.Name != "":
-		makeQuery("DELETE FROM data_source WHERE name=? and org_id=?", cmd.Name, cmd.OrgID)
+		where, args = "name=? and org_id=?", []interface{}{cmd.Name, cmd.OrgID}
 	default:
 		return models.ErrDataSourceIdentifierNotSet
 	}
 
 	return ss.WithTransactionalDbSession(ctx, func(sess *DBSession) error {
-		result, err := sess.Exec(params...)
+		var dataSources []*models.DataSource
+		if err := sess.Table("data_source").Where(where, args...).Cols("id", "uid").Find(&dataSources); err != nil {
+			return err
+		}
+		for _, ds := range dataSources {
+			if _, err := sess.Exec("DELETE FROM managed_permission WHERE org_id=? and scope IN (?, ?)",
+				cmd.OrgID, "datasources:uid:"+ds.Uid, fmt.Sprintf("datasources:id:%d", ds.Id)); err != nil {
+				return err
+			}
+		}
+
+		result, err := sess.Exec(append([]interface{}{"DELETE FROM data_source WHERE " + where}, args...)...)
 		cmd.DeletedDatasourcesCount, _ = result.RowsAffected()
 
 		sess.publishAfterCommit(&events.DataSourceDeleted{
@@ -0,0 +1,76 @@
+package migrations
+
+import (
+	"fmt"
+
+	"github.com/grafana/grafana/pkg/components/simplejson"
+	. "github.com/grafana/grafana/pkg/services/sqlstore/migrator"
+	"github.com/grafana/grafana/pkg/services/sqlstore/searchstore"
+	"xorm.io/xorm"
+)
+
+func addDashboardContentMigrations(mg *Migrator) {
+	dashboardContentV1 := Table{
+		Name: "dashboard_content",
+		Columns: []*Column{
+			{Name: "id", Type: DB_BigInt, IsPrimaryKey: true, IsAutoIncrement: true},
+			{Name: "dashboard_id", Type: DB_BigInt, Nullable: false},
+			{Name: "kind", Type: DB_NVarchar, Length: 40, Nullable: false},
+			{Name: "panel_id", Type: DB_BigInt, Nullable: false},
+			{Name: "term", Type: DB_Text, Nullable: false},
+		},
+		Indices: []*Index{
+			{Cols: []string{"dashboard_id"}},
+		},
+	}
+
+	mg.AddMigration("create dashboard_content table", NewAddTableMigration(dashboardContentV1))
+	mg.AddMigration("add index dashboard_content.dashboard_id", NewAddIndexMigration(dashboardContentV1, dashboardContentV1.Indices[0]))
+	mg.AddMigration("index content of existing dashboards", &indexDashboardContentMigration{})
+}
+
+// indexDashboardContentBatchSize is the number of dashboards loaded at once
+// when indexing the content of existing dashboards.
+const indexDashboardContentBatchSize = 100
+
+// indexDashboardContentMigration indexes the content of dashboards saved
+// before the dashboard_content table was added.
+type indexDashboardContentMigration struct {
+	MigrationBase
+}
+
+func (m *indexDashboardContentMigration) SQL(_ Dialect) string {
+	return "index dashboard content migration"
+}
+
+func (m *indexDashboardContentMigration) Exec(sess *xorm.Session, mg *Migrator) error {
+	// Dashboards are loaded in batches ordered by ID, so the JSON models of
+	// all dashboards are never held in memory at once.
+	var lastID int64
+	for {
+		var dashboards []struct {
+			ID   int64            `xorm:"id"`
+			Data *simplejson.Json `xorm:"data"`
+		}
+		if err := sess.SQL(fmt.Sprintf("SELECT id, data FROM dashboard WHERE is_folder = %s AND id > ? ORDER BY id ASC LIMIT %d",
+			mg.Dialect.BooleanStr(false), indexDashboardContentBatchSize), lastID).Find(&dashboards); err != nil {
+			return fmt.Errorf("failed to get dashboards: %w", err)
+		}
+		if len(dashboards) == 0 {
+			return nil
+		}
+
+		for _, dash := range dashboards {
+			lastID = dash.ID
+			if dash.Data == nil {
+				continue
+			}
+			for _, t := range searchstore.ExtractContent(dash.Data) {
+				if _, err := sess.Exec("INSERT INTO dashboard_content (dashboard_id, kind, panel_id, term) VALUES (?, ?, ?, ?)",
+					dash.ID, t.Kind, t.PanelID, t.Term); err != nil {
+					return fmt.Errorf("failed to index content of dashboard %d: %w", dash.ID, err)
+				}
+			}
+		}
+	}
+}
@@ -0,0 +1,29 @@
+package migrations
+
+import . "github.com/grafana/grafana/pkg/services/sqlstore/migrator"
+
+func addDashboardRecycleBinMigrations(mg *Migrator) {
+	deletedDashboardV1 := Table{
+		Name: "deleted_dashboard",
+		Columns: []*Column{
+			{Name: "id", Type: DB_BigInt, IsPrimaryKey: true, IsAutoIncrement: true},
+			{Name: "org_id", Type: DB_BigInt, Nullable: false},
+			{Name: "dashboard_id", Type: DB_BigInt, Nullable: false},
+			{Name: "uid", Type: DB_NVarchar, Length: 40, Nullable: false},
+			{Name: "title", Type: DB_NVarchar, Length: 189, Nullable: false},
+			{Name: "folder_id", Type: DB_BigInt, Nullable: false},
+			{Name: "is_folder", Type: DB_Bool, Nullable: false},
+			{Name: "deleted_with_id", Type: DB_BigInt, Nullable: false},
+			{Name: "deleted", Type: DB_DateTime, Nullable: false},
+			{Name: "data", Type: DB_MediumText, Nullable: false},
+		},
+		Indices: []*Index{
+			{Cols: []string{"org_id", "deleted_with_id"}},
+			{Cols: []string{"deleted"}},
+		},
+	}
+
+	mg.AddMigration("create deleted_dashboard table", NewAddTableMigration(deletedDashboardV1))
+	mg.AddMigration("add index deleted_dashboard.org_id-deleted_with_id", NewAddIndexMigration(deletedDashboardV1, deletedDashboardV1.Indices[0]))
+	mg.AddMigration("add index deleted_dashboard.deleted", NewAddIndexMigration(deletedDashboardV1, deletedDashboardV1.Indices[1]))
+}
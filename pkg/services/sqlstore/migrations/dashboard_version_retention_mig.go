@@ -0,0 +1,45 @@
+package migrations
+
+import . "github.com/grafana/grafana/pkg/services/sqlstore/migrator"
+
+func addDashboardVersionRetentionMigrations(mg *Migrator) {
+	dashboardVersionLabelV1 := Table{
+		Name: "dashboard_version_label",
+		Columns: []*Column{
+			{Name: "id", Type: DB_BigInt, IsPrimaryKey: true, IsAutoIncrement: true},
+			{Name: "dashboard_id", Type: DB_BigInt, Nullable: false},
+			{Name: "version", Type: DB_Int, Nullable: false},
+			{Name: "label", Type: DB_NVarchar, Length: 190, Nullable: false},
+			{Name: "created", Type: DB_DateTime, Nullable: false},
+			{Name: "created_by", Type: DB_BigInt, Nullable: false},
+		},
+		Indices: []*Index{
+			{Cols: []string{"dashboard_id", "label"}, Type: UniqueIndex},
+			{Cols: []string{"dashboard_id", "version"}},
+		},
+	}
+
+	mg.AddMigration("create dashboard_version_label table", NewAddTableMigration(dashboardVersionLabelV1))
+	mg.AddMigration("add unique index dashboard_version_label.dashboard_id-label", NewAddIndexMigration(dashboardVersionLabelV1, dashboardVersionLabelV1.Indices[0]))
+	mg.AddMigration("add index dashboard_version_label.dashboard_id-version", NewAddIndexMigration(dashboardVersionLabelV1, dashboardVersionLabelV1.Indices[1]))
+
+	dashboardVersionRetentionV1 := Table{
+		Name: "dashboard_version_retention",
+		Columns: []*Column{
+			{Name: "id", Type: DB_BigInt, IsPrimaryKey: true, IsAutoIncrement: true},
+			{Name: "org_id", Type: DB_BigInt, Nullable: false},
+			{Name: "dashboard_id", Type: DB_BigInt, Nullable: false},
+			{Name: "policy", Type: DB_NVarchar, Length: 20, Nullable: false},
+			{Name: "versions_to_keep", Type: DB_Int, Nullable: false},
+			{Name: "keep_for", Type: DB_NVarchar, Length: 40, Nullable: false},
+			{Name: "created", Type: DB_DateTime, Nullable: false},
+			{Name: "updated", Type: DB_DateTime, Nullable: false},
+		},
+		Indices: []*Index{
+			{Cols: []string{"dashboard_id"}, Type: UniqueIndex},
+		},
+	}
+
+	mg.AddMigration("create dashboard_version_retention table", NewAddTableMigration(dashboardVersionRetentionV1))
+	mg.AddMigration("add unique index dashboard_version_retention.dashboard_id", NewAddIndexMigration(dashboardVersionRetentionV1, dashboardVersionRetentionV1.Indices[0]))
+}
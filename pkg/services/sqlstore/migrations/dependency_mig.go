@@ -0,0 +1,27 @@
+package migrations
+
+import (
+	. "github.com/grafana/grafana/pkg/services/sqlstore/migrator"
+)
+
+func addDependencyMigrations(mg *Migrator) {
+	dependencyV1 := Table{
+		Name: "dependency",
+		Columns: []*Column{
+			{Name: "id", Type: DB_BigInt, IsPrimaryKey: true, IsAutoIncrement: true},
+			{Name: "org_id", Type: DB_BigInt, Nullable: false},
+			{Name: "source_type", Type: DB_NVarchar, Length: 40, Nullable: false},
+			{Name: "source_uid", Type: DB_NVarchar, Length: 40, Nullable: false},
+			{Name: "target_type", Type: DB_NVarchar, Length: 40, Nullable: false},
+			{Name: "target_uid", Type: DB_NVarchar, Length: 190, Nullable: false},
+		},
+		Indices: []*Index{
+			{Cols: []string{"org_id", "source_type", "source_uid"}},
+			{Cols: []string{"org_id", "target_type", "target_uid"}},
+		},
+	}
+
+	mg.AddMigration("create dependency table", NewAddTableMigration(dependencyV1))
+	mg.AddMigration("add index dependency.org_id-source_type-source_uid", NewAddIndexMigration(dependencyV1, dependencyV1.Indices[0]))
+	mg.AddMigration("add index dependency.org_id-target_type-target_uid", NewAddIndexMigration(dependencyV1, dependencyV1.Indices[1]))
+}
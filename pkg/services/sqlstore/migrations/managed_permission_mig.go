@@ -0,0 +1,34 @@
+package migrations
+
+import (
+	. "github.com/grafana/grafana/pkg/services/sqlstore/migrator"
+)
+
+func addManagedPermissionMigrations(mg *Migrator) {
+	managedPermissionV1 := Table{
+		Name: "managed_permission",
+		Columns: []*Column{
+			{Name: "id", Type: DB_BigInt, Nullable: false, IsPrimaryKey: true, IsAutoIncrement: true},
+			{Name: "org_id", Type: DB_BigInt, Nullable: false},
+			{Name: "user_id", Type: DB_BigInt, Nullable: false},
+			{Name: "team_id", Type: DB_BigInt, Nullable: false},
+			{Name: "action", Type: DB_NVarchar, Length: 190, Nullable: false},
+			{Name: "scope", Type: DB_NVarchar, Length: 190, Nullable: false},
+			{Name: "created", Type: DB_DateTime, Nullable: false},
+			{Name: "updated", Type: DB_DateTime, Nullable: false},
+		},
+		Indices: []*Index{
+			{Cols: []string{"org_id", "user_id"}},
+			{Cols: []string{"org_id", "team_id"}},
+			{Cols: []string{"org_id", "scope"}},
+			{Cols: []string{"org_id", "user_id", "team_id", "action", "scope"}, Type: UniqueIndex},
+		},
+	}
+
+	mg.AddMigration("create managed_permission table v1", NewAddTableMigration(managedPermissionV1))
+
+	mg.AddMigration("add index managed_permission.org_id-user_id", NewAddIndexMigration(managedPermissionV1, managedPermissionV1.Indices[0]))
+	mg.AddMigration("add index managed_permission.org_id-team_id", NewAddIndexMigration(managedPermissionV1, managedPermissionV1.Indices[1]))
+	mg.AddMigration("add index managed_permission.org_id-scope", NewAddIndexMigration(managedPermissionV1, managedPermissionV1.Indices[2]))
+	mg.AddMigration("add unique index managed_permission.org_id-user_id-team_id-action-scope", NewAddIndexMigration(managedPermissionV1, managedPermissionV1.Indices[3]))
+}
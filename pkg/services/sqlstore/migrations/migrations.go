@@ -48,13 +48,18 @@ func (*OSSMigrations) AddMigration(mg *Migrator) {
 	ualert.AddTablesMigrations(mg)
 	ualert.AddDashAlertMigration(mg)
 	addLibraryElementsMigrations(mg)
-	if mg.Cfg != nil || mg.Cfg.IsLiveConfigEnabled() {
-		addLiveChannelMigrations(mg)
-	}
+	addLiveChannelMigrations(mg)
 	ualert.RerunDashAlertMigration(mg)
 	addSecretsMigration(mg)
 	addKVStoreMigrations(mg)
 	ualert.AddDashboardUIDPanelIDMigration(mg)
+	addQueryHistoryMigrations(mg)
+	addManagedPermissionMigrations(mg)
+	addDashboardContentMigrations(mg)
+	addDependencyMigrations(mg)
+	addDashboardRecycleBinMigrations(mg)
+	addDashboardVersionRetentionMigrations(mg)
+	addAnnotationWebhookMigrations(mg)
 }
 
 func addMigrationLogMigrations(mg *Migrator) {
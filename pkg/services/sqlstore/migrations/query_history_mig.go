@@ -0,0 +1,46 @@
+package migrations
+
+import (
+	. "github.com/grafana/grafana/pkg/services/sqlstore/migrator"
+)
+
+func addQueryHistoryMigrations(mg *Migrator) {
+	queryHistoryV1 := Table{
+		Name: "query_history",
+		Columns: []*Column{
+			{Name: "id", Type: DB_BigInt, Nullable: false, IsPrimaryKey: true, IsAutoIncrement: true},
+			{Name: "uid", Type: DB_NVarchar, Length: 40, Nullable: false},
+			{Name: "org_id", Type: DB_BigInt, Nullable: false},
+			{Name: "datasource_uid", Type: DB_NVarchar, Length: 40, Nullable: false},
+			{Name: "created_by", Type: DB_BigInt, Nullable: false},
+			{Name: "created_at", Type: DB_Int, Nullable: false},
+			{Name: "comment", Type: DB_Text, Nullable: false},
+			{Name: "queries", Type: DB_Text, Nullable: false},
+		},
+		Indices: []*Index{
+			{Cols: []string{"org_id", "created_by", "datasource_uid"}},
+			{Cols: []string{"org_id", "uid"}, Type: UniqueIndex},
+		},
+	}
+
+	mg.AddMigration("create query_history table v1", NewAddTableMigration(queryHistoryV1))
+
+	mg.AddMigration("add index query_history.org_id-created_by-datasource_uid", NewAddIndexMigration(queryHistoryV1, queryHistoryV1.Indices[0]))
+	mg.AddMigration("add unique index query_history.org_id-uid", NewAddIndexMigration(queryHistoryV1, queryHistoryV1.Indices[1]))
+
+	queryHistoryStarV1 := Table{
+		Name: "query_history_star",
+		Columns: []*Column{
+			{Name: "id", Type: DB_BigInt, Nullable: false, IsPrimaryKey: true, IsAutoIncrement: true},
+			{Name: "query_uid", Type: DB_NVarchar, Length: 40, Nullable: false},
+			{Name: "user_id", Type: DB_BigInt, Nullable: false},
+		},
+		Indices: []*Index{
+			{Cols: []string{"user_id", "query_uid"}, Type: UniqueIndex},
+		},
+	}
+
+	mg.AddMigration("create query_history_star table v1", NewAddTableMigration(queryHistoryStarV1))
+
+	mg.AddMigration("add unique index query_history_star.user_id-query_uid", NewAddIndexMigration(queryHistoryStarV1, queryHistoryStarV1.Indices[0]))
+}
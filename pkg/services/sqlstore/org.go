@@ -235,7 +235,9 @@ func DeleteOrg(cmd *models.DeleteOrgCommand) error {
 		deletes := []string{
 			"DELETE FROM star WHERE EXISTS (SELECT 1 FROM dashboard WHERE org_id = ? AND star.dashboard_id = dashboard.id)",
 			"DELETE FROM dashboard_tag WHERE EXISTS (SELECT 1 FROM dashboard WHERE org_id = ? AND dashboard_tag.dashboard_id = dashboard.id)",
+			"DELETE FROM dashboard_content WHERE EXISTS (SELECT 1 FROM dashboard WHERE org_id = ? AND dashboard_content.dashboard_id = dashboard.id)",
 			"DELETE FROM dashboard WHERE org_id = ?",
+			"DELETE FROM deleted_dashboard WHERE org_id = ?",
 			"DELETE FROM api_key WHERE org_id = ?",
 			"DELETE FROM data_source WHERE org_id = ?",
 			"DELETE FROM org_user WHERE org_id = ?",
@@ -252,6 +254,7 @@ func DeleteOrg(cmd *models.DeleteOrgCommand) error {
 			"DELETE FROM alert WHERE org_id = ?",
 			"DELETE FROM annotation WHERE org_id = ?",
 			"DELETE FROM kv_store WHERE org_id = ?",
+			"DELETE FROM managed_permission WHERE org_id = ?",
 		}
 
 		for _, sql := range deletes {
@@ -25,17 +25,16 @@ func (d DashboardPermissionFilter) Where() (string, []interface{}) {
 		okRoles = append(okRoles, models.ROLE_VIEWER)
 	}
 
-	falseStr := d.Dialect.BooleanStr(false)
+	ancestorFoldersJoin := AncestorFoldersJoin("d")
+	dashboardOrAncestorACL := DashboardOrAncestorACL("da", "d")
+	defaultACLApplies := DefaultACLApplies(d.Dialect, "d")
 
 	sql := `(
 		dashboard.id IN (
 			SELECT distinct DashboardId from (
 				SELECT d.id AS DashboardId
-					FROM dashboard AS d
-					LEFT JOIN dashboard AS folder on folder.id = d.folder_id
-					LEFT JOIN dashboard_acl AS da ON
-						da.dashboard_id = d.id OR
-						da.dashboard_id = d.folder_id
+					FROM dashboard AS d` + ancestorFoldersJoin + `
+					LEFT JOIN dashboard_acl AS da ON ` + dashboardOrAncestorACL + `
 					LEFT JOIN team_member as ugm on ugm.team_id = da.team_id
 					WHERE
 						d.org_id = ? AND
@@ -47,15 +46,11 @@ func (d DashboardPermissionFilter) Where() (string, []interface{}) {
 						)
 				UNION
 				SELECT d.id AS DashboardId
-					FROM dashboard AS d
-					LEFT JOIN dashboard AS folder on folder.id = d.folder_id
+					FROM dashboard AS d` + ancestorFoldersJoin + `
 					LEFT JOIN dashboard_acl AS da ON
 						(
 							-- include default permissions -->
-							da.org_id = -1 AND (
-							  (folder.id IS NOT NULL AND folder.has_acl = ` + falseStr + `) OR
-							  (folder.id IS NULL AND d.has_acl = ` + falseStr + `)
-							)
+							da.org_id = -1 AND ` + defaultACLApplies + `
 						)
 					WHERE
 						d.org_id = ? AND
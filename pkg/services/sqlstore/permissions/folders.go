@@ -0,0 +1,56 @@
+package permissions
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/grafana/grafana/pkg/models"
+	"github.com/grafana/grafana/pkg/services/sqlstore/migrator"
+)
+
+// ancestorFolderAlias returns the alias of the folder the given number of
+// levels above the dashboard, as joined by AncestorFoldersJoin.
+func ancestorFolderAlias(level int) string {
+	return fmt.Sprintf("folder%d", level)
+}
+
+// AncestorFoldersJoin returns LEFT JOINs of the folders containing the
+// dashboard aliased dashboardAlias, up to models.MaxNestedFolderDepth levels.
+func AncestorFoldersJoin(dashboardAlias string) string {
+	var sb strings.Builder
+	child := dashboardAlias
+	for level := 1; level <= models.MaxNestedFolderDepth; level++ {
+		alias := ancestorFolderAlias(level)
+		sb.WriteString(fmt.Sprintf("\n\t\t\t\t\tLEFT JOIN dashboard AS %s ON %s.id = %s.folder_id", alias, alias, child))
+		child = alias
+	}
+	return sb.String()
+}
+
+// DashboardOrAncestorACL returns the condition matching the permissions set on
+// the dashboard or any of its folders. It requires AncestorFoldersJoin.
+func DashboardOrAncestorACL(aclAlias, dashboardAlias string) string {
+	ids := []string{dashboardAlias + ".id"}
+	for level := 1; level <= models.MaxNestedFolderDepth; level++ {
+		ids = append(ids, ancestorFolderAlias(level)+".id")
+	}
+	return fmt.Sprintf("%s.dashboard_id IN (%s)", aclAlias, strings.Join(ids, ", "))
+}
+
+// DefaultACLApplies returns the condition under which the default permissions
+// apply to the dashboard: none of its folders has permissions set or, for a
+// dashboard in the General folder, the dashboard itself has none. It requires
+// AncestorFoldersJoin.
+func DefaultACLApplies(dialect migrator.Dialect, dashboardAlias string) string {
+	falseStr := dialect.BooleanStr(false)
+
+	parent := ancestorFolderAlias(1)
+	conditions := []string{parent + ".id IS NOT NULL", parent + ".has_acl = " + falseStr}
+	for level := 2; level <= models.MaxNestedFolderDepth; level++ {
+		alias := ancestorFolderAlias(level)
+		conditions = append(conditions, fmt.Sprintf("(%s.id IS NULL OR %s.has_acl = %s)", alias, alias, falseStr))
+	}
+
+	return fmt.Sprintf("((%s) OR (%s.id IS NULL AND %s.has_acl = %s))",
+		strings.Join(conditions, " AND "), parent, dashboardAlias, falseStr)
+}
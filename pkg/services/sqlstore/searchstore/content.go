@@ -0,0 +1,144 @@
+package searchstore
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/grafana/grafana/pkg/components/simplejson"
+	"github.com/grafana/grafana/pkg/services/sqlstore/migrator"
+)
+
+// Kinds of dashboard content indexed for search.
+const (
+	ContentDescription      = "description"
+	ContentPanelTitle       = "panel_title"
+	ContentPanelDescription = "panel_description"
+	ContentQuery            = "query"
+	ContentDatasource       = "datasource"
+	ContentVariable         = "variable"
+)
+
+// queryKeys are the properties of panel targets holding the query
+// expression, which differ by data source.
+var queryKeys = []string{"expr", "query", "rawSql", "target", "expression", "queryText"}
+
+// ContentTerm is a piece of dashboard content indexed for search.
+type ContentTerm struct {
+	Kind    string
+	PanelID int64
+	Term    string
+}
+
+// ExtractContent returns the searchable content of the dashboard JSON model:
+// the description of the dashboard, panel titles and descriptions, queries,
+// data source UIDs and variable names.
+func ExtractContent(data *simplejson.Json) []ContentTerm {
+	e := contentExtractor{seen: map[ContentTerm]struct{}{}}
+
+	e.add(ContentDescription, 0, data.Get("description").MustString())
+
+	for _, panel := range data.Get("panels").MustArray() {
+		e.addPanel(simplejson.NewFromAny(panel))
+	}
+	// Dashboards created before the panels property store panels in rows.
+	for _, row := range data.Get("rows").MustArray() {
+		for _, panel := range simplejson.NewFromAny(row).Get("panels").MustArray() {
+			e.addPanel(simplejson.NewFromAny(panel))
+		}
+	}
+
+	for _, variable := range data.Get("templating").Get("list").MustArray() {
+		e.add(ContentVariable, 0, simplejson.NewFromAny(variable).Get("name").MustString())
+	}
+
+	return e.terms
+}
+
+type contentExtractor struct {
+	terms []ContentTerm
+	seen  map[ContentTerm]struct{}
+}
+
+func (e *contentExtractor) add(kind string, panelID int64, term string) {
+	term = strings.TrimSpace(term)
+	if term == "" {
+		return
+	}
+
+	t := ContentTerm{Kind: kind, PanelID: panelID, Term: term}
+	if _, ok := e.seen[t]; ok {
+		return
+	}
+	e.seen[t] = struct{}{}
+	e.terms = append(e.terms, t)
+}
+
+func (e *contentExtractor) addPanel(panel *simplejson.Json) {
+	id := panel.Get("id").MustInt64()
+	e.add(ContentPanelTitle, id, panel.Get("title").MustString())
+	e.add(ContentPanelDescription, id, panel.Get("description").MustString())
+	e.add(ContentDatasource, id, datasourceUID(panel.Get("datasource")))
+
+	for _, target := range panel.Get("targets").MustArray() {
+		target := simplejson.NewFromAny(target)
+		e.add(ContentDatasource, id, datasourceUID(target.Get("datasource")))
+		for _, key := range queryKeys {
+			e.add(ContentQuery, id, target.Get(key).MustString())
+		}
+	}
+
+	// Collapsed rows hold their panels.
+	for _, nested := range panel.Get("panels").MustArray() {
+		e.addPanel(simplejson.NewFromAny(nested))
+	}
+}
+
+// datasourceUID returns the UID of a data source reference, older dashboards
+// reference data sources by name.
+func datasourceUID(ref *simplejson.Json) string {
+	if uid, err := ref.Get("uid").String(); err == nil {
+		return uid
+	}
+	return ref.MustString()
+}
+
+// ContentFilter limits the search to dashboards whose content matches the
+// LIKE pattern, optionally only content of the given kinds. Escape is the
+// ESCAPE clause for wildcards escaped in the pattern.
+type ContentFilter struct {
+	Dialect migrator.Dialect
+	Pattern string
+	Escape  string
+	Kinds   []string
+}
+
+func (f ContentFilter) Where() (string, []interface{}) {
+	sql := fmt.Sprintf("dashboard_content.term %s ? %s", f.Dialect.LikeStr(), f.Escape)
+	params := []interface{}{f.Pattern}
+	if len(f.Kinds) > 0 {
+		sql += ` AND dashboard_content.kind IN (?` + strings.Repeat(",?", len(f.Kinds)-1) + `)`
+		for _, kind := range f.Kinds {
+			params = append(params, kind)
+		}
+	}
+
+	return "dashboard.id IN (SELECT dashboard_content.dashboard_id FROM dashboard_content WHERE " + sql + ")", params
+}
+
+// DatasourceFilter limits the search to dashboards using any of the data sources.
+type DatasourceFilter struct {
+	UIDs []string
+}
+
+func (f DatasourceFilter) Where() (string, []interface{}) {
+	if len(f.UIDs) == 0 {
+		return "", nil
+	}
+
+	params := []interface{}{ContentDatasource}
+	for _, uid := range f.UIDs {
+		params = append(params, uid)
+	}
+	return `dashboard.id IN (SELECT dashboard_content.dashboard_id FROM dashboard_content
+		WHERE dashboard_content.kind = ? AND dashboard_content.term IN (?` + strings.Repeat(",?", len(f.UIDs)-1) + `))`, params
+}
@@ -0,0 +1,68 @@
+package searchstore
+
+import (
+	"testing"
+
+	"github.com/grafana/grafana/pkg/components/simplejson"
+	"github.com/stretchr/testify/require"
+)
+
+func TestExtractContent(t *testing.T) {
+	data, err := simplejson.NewJson([]byte(`{
+		"title": "Frontend",
+		"description": "Requests served by the frontend",
+		"panels": [
+			{
+				"id": 1,
+				"title": "Requests",
+				"description": "Requests per second",
+				"datasource": {"type": "prometheus", "uid": "prom"},
+				"targets": [
+					{"refId": "A", "expr": "rate(http_requests_total[5m])"},
+					{"refId": "B", "expr": "rate(http_requests_total[5m])"}
+				]
+			},
+			{
+				"id": 2,
+				"type": "row",
+				"title": "Logs",
+				"collapsed": true,
+				"panels": [
+					{"id": 3, "title": "Errors", "datasource": "Loki", "targets": [{"expr": "{app=\"frontend\"} |= \"error\""}]}
+				]
+			}
+		],
+		"templating": {"list": [{"name": "instance"}, {"name": ""}]}
+	}`))
+	require.NoError(t, err)
+
+	require.Equal(t, []ContentTerm{
+		{Kind: ContentDescription, Term: "Requests served by the frontend"},
+		{Kind: ContentPanelTitle, PanelID: 1, Term: "Requests"},
+		{Kind: ContentPanelDescription, PanelID: 1, Term: "Requests per second"},
+		{Kind: ContentDatasource, PanelID: 1, Term: "prom"},
+		{Kind: ContentQuery, PanelID: 1, Term: "rate(http_requests_total[5m])"},
+		{Kind: ContentPanelTitle, PanelID: 2, Term: "Logs"},
+		{Kind: ContentPanelTitle, PanelID: 3, Term: "Errors"},
+		{Kind: ContentDatasource, PanelID: 3, Term: "Loki"},
+		{Kind: ContentQuery, PanelID: 3, Term: `{app="frontend"} |= "error"`},
+		{Kind: ContentVariable, Term: "instance"},
+	}, ExtractContent(data))
+}
+
+func TestExtractContent_Rows(t *testing.T) {
+	data := simplejson.NewFromAny(map[string]interface{}{
+		"rows": []interface{}{
+			map[string]interface{}{
+				"panels": []interface{}{
+					map[string]interface{}{"id": 1, "title": "Graph", "targets": []interface{}{map[string]interface{}{"target": "servers.*.cpu"}}},
+				},
+			},
+		},
+	})
+
+	require.Equal(t, []ContentTerm{
+		{Kind: ContentPanelTitle, PanelID: 1, Term: "Graph"},
+		{Kind: ContentQuery, PanelID: 1, Term: "servers.*.cpu"},
+	}, ExtractContent(data))
+}
@@ -4,6 +4,7 @@ import (
 	"fmt"
 	"strings"
 
+	"github.com/grafana/grafana/pkg/models"
 	"github.com/grafana/grafana/pkg/services/sqlstore/migrator"
 )
 
@@ -94,6 +95,31 @@ func (f FolderFilter) Where() (string, []interface{}) {
 	return sqlIDin("dashboard.folder_id", f.IDs)
 }
 
+// FolderSubtreeFilter matches dashboards and folders in the folders or in any
+// of their subfolders.
+type FolderSubtreeFilter struct {
+	Dialect migrator.Dialect
+	IDs     []int64
+}
+
+func (f FolderSubtreeFilter) Where() (string, []interface{}) {
+	if len(f.IDs) == 0 {
+		return "", nil
+	}
+
+	sql, params := sqlIDin("folder_id", f.IDs)
+	folders := sql
+	var conditions []string
+	var args []interface{}
+	for level := 1; level <= models.MaxNestedFolderDepth; level++ {
+		conditions = append(conditions, "dashboard."+folders)
+		args = append(args, params...)
+		folders = fmt.Sprintf("folder_id IN (SELECT id FROM dashboard WHERE is_folder = %s AND %s)", f.Dialect.BooleanStr(true), folders)
+	}
+
+	return "(" + strings.Join(conditions, " OR ") + ")", args
+}
+
 type DashboardFilter struct {
 	IDs []int64
 }
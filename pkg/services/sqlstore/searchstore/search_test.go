@@ -171,7 +171,7 @@ func createDashboards(t *testing.T, db *sqlstore.SQLStore, startID, endID int, o
 			"version": 0
 		}`))
 		require.NoError(t, err)
-		dash, err := db.SaveDashboard(models.SaveDashboardCommand{
+		dash, err := db.SaveDashboard(context.Background(), models.SaveDashboardCommand{
 			Dashboard: dashboard,
 			UserId:    1,
 			OrgId:     orgID,
@@ -32,37 +32,44 @@ func newSession(ctx context.Context) *DBSession {
 	return sess
 }
 
-func startSession(ctx context.Context, engine *xorm.Engine, beginTran bool) (*DBSession, error) {
+// startSession returns the session of the transaction stored in ctx, if
+// any, or a new session. isNew is false for the session from ctx, which must
+// be neither committed nor closed by the caller.
+func startSession(ctx context.Context, engine *xorm.Engine, beginTran bool) (sess *DBSession, isNew bool, err error) {
 	value := ctx.Value(ContextSessionKey{})
-	var sess *DBSession
 	sess, ok := value.(*DBSession)
 
 	if ok {
 		sess.Session = sess.Session.Context(ctx)
-		return sess, nil
+		return sess, false, nil
 	}
 
 	newSess := &DBSession{Session: engine.NewSession()}
 	if beginTran {
 		err := newSess.Begin()
 		if err != nil {
-			return nil, err
+			return nil, false, err
 		}
 	}
 
 	newSess.Session = newSess.Session.Context(ctx)
-	return newSess, nil
+	return newSess, true, nil
 }
 
-// WithDbSession calls the callback with a session.
+// WithDbSession calls the callback with a session. The session of a
+// transaction started by InTransaction is reused.
 func (ss *SQLStore) WithDbSession(ctx context.Context, callback dbTransactionFunc) error {
 	return withDbSession(ctx, ss.engine, callback)
 }
 
 func withDbSession(ctx context.Context, engine *xorm.Engine, callback dbTransactionFunc) error {
-	sess := &DBSession{Session: engine.NewSession()}
-	sess.Session = sess.Session.Context(ctx)
-	defer sess.Close()
+	sess, isNew, err := startSession(ctx, engine, false)
+	if err != nil {
+		return err
+	}
+	if isNew {
+		defer sess.Close()
+	}
 
 	return callback(sess)
 }
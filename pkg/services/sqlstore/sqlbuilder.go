@@ -5,8 +5,29 @@ import (
 	"strings"
 
 	"github.com/grafana/grafana/pkg/models"
+	"github.com/grafana/grafana/pkg/services/sqlstore/permissions"
 )
 
+// likeEscapeChar escapes wildcards in LIKE patterns. It's not a backslash,
+// which MySQL also treats as an escape in string literals.
+const likeEscapeChar = "!"
+
+// LikeEscapeStr is the ESCAPE clause to follow LIKE conditions matching
+// patterns built by LikeContainsPattern.
+const LikeEscapeStr = "ESCAPE '" + likeEscapeChar + "'"
+
+var likePatternReplacer = strings.NewReplacer(
+	likeEscapeChar, likeEscapeChar+likeEscapeChar,
+	"%", likeEscapeChar+"%",
+	"_", likeEscapeChar+"_",
+)
+
+// LikeContainsPattern returns a LIKE pattern matching values containing the
+// text, wildcards in the text are matched literally.
+func LikeContainsPattern(text string) string {
+	return "%" + likePatternReplacer.Replace(text) + "%"
+}
+
 type SQLBuilder struct {
 	sql    bytes.Buffer
 	params []interface{}
@@ -43,18 +64,17 @@ func (sb *SQLBuilder) WriteDashboardPermissionFilter(user *models.SignedInUser,
 		okRoles = append(okRoles, models.ROLE_VIEWER)
 	}
 
-	falseStr := dialect.BooleanStr(false)
+	ancestorFoldersJoin := permissions.AncestorFoldersJoin("d")
+	dashboardOrAncestorACL := permissions.DashboardOrAncestorACL("da", "d")
+	defaultACLApplies := permissions.DefaultACLApplies(dialect, "d")
 
 	sb.sql.WriteString(` AND
 	(
 		dashboard.id IN (
 			SELECT distinct DashboardId from (
 				SELECT d.id AS DashboardId
-					FROM dashboard AS d
-					LEFT JOIN dashboard AS folder on folder.id = d.folder_id
-					LEFT JOIN dashboard_acl AS da ON
-						da.dashboard_id = d.id OR
-						da.dashboard_id = d.folder_id
+					FROM dashboard AS d` + ancestorFoldersJoin + `
+					LEFT JOIN dashboard_acl AS da ON ` + dashboardOrAncestorACL + `
 					LEFT JOIN team_member as ugm on ugm.team_id = da.team_id
 					WHERE
 						d.org_id = ? AND
@@ -66,15 +86,11 @@ func (sb *SQLBuilder) WriteDashboardPermissionFilter(user *models.SignedInUser,
 						)
 				UNION
 				SELECT d.id AS DashboardId
-					FROM dashboard AS d
-					LEFT JOIN dashboard AS folder on folder.id = d.folder_id
+					FROM dashboard AS d` + ancestorFoldersJoin + `
 					LEFT JOIN dashboard_acl AS da ON
 						(
 							-- include default permissions -->
-							da.org_id = -1 AND (
-							  (folder.id IS NOT NULL AND folder.has_acl = ` + falseStr + `) OR
-							  (folder.id IS NULL AND d.has_acl = ` + falseStr + `)
-							)
+							da.org_id = -1 AND ` + defaultACLApplies + `
 						)
 					WHERE
 						d.org_id = ? AND
@@ -244,7 +244,7 @@ func createDummyDashboard(t *testing.T, sqlStore *SQLStore, dashboardProps Dashb
 		saveDashboardCmd.OrgId = 1
 	}
 
-	dash, err := sqlStore.SaveDashboard(saveDashboardCmd)
+	dash, err := sqlStore.SaveDashboard(context.Background(), saveDashboardCmd)
 	require.NoError(t, err)
 
 	t.Logf("Created dashboard with ID %d and org ID %d\n", dash.Id, dash.OrgId)
@@ -7,3 +7,11 @@ type DashboardTag struct {
 	DashboardId int64
 	Term        string
 }
+
+type DashboardContent struct {
+	Id          int64
+	DashboardId int64
+	Kind        string
+	PanelId     int64
+	Term        string
+}
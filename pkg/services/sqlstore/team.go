@@ -136,6 +136,7 @@ func DeleteTeam(ctx context.Context, cmd *models.DeleteTeamCommand) error {
 			"DELETE FROM team_member WHERE org_id=? and team_id = ?",
 			"DELETE FROM team WHERE org_id=? and id = ?",
 			"DELETE FROM dashboard_acl WHERE org_id=? and team_id = ?",
+			"DELETE FROM managed_permission WHERE org_id=? and team_id = ?",
 		}
 
 		for _, sql := range deletes {
@@ -17,6 +17,9 @@ func (ss *SQLStore) WithTransactionalDbSession(ctx context.Context, callback dbT
 	return inTransactionWithRetryCtx(ctx, ss.engine, callback, 0)
 }
 
+// InTransaction calls fn with a context holding a transaction. Store
+// methods called with that context join the transaction instead of starting
+// their own.
 func (ss *SQLStore) InTransaction(ctx context.Context, fn func(ctx context.Context) error) error {
 	return ss.inTransactionWithRetry(ctx, fn, 0)
 }
@@ -33,10 +36,14 @@ func inTransactionWithRetry(callback dbTransactionFunc, retry int) error {
 }
 
 func inTransactionWithRetryCtx(ctx context.Context, engine *xorm.Engine, callback dbTransactionFunc, retry int) error {
-	sess, err := startSession(ctx, engine, true)
+	sess, isNew, err := startSession(ctx, engine, true)
 	if err != nil {
 		return err
 	}
+	// the transaction of ctx is committed or rolled back by its owner
+	if !isNew {
+		return callback(sess)
+	}
 
 	defer sess.Close()
 
@@ -51,7 +58,7 @@ func inTransactionWithRetryCtx(ctx context.Context, engine *xorm.Engine, callbac
 
 		time.Sleep(time.Millisecond * time.Duration(10))
 		sqlog.Info("Database locked, sleeping then retrying", "error", err, "retry", retry)
-		return inTransactionWithRetry(callback, retry+1)
+		return inTransactionWithRetryCtx(ctx, engine, callback, retry+1)
 	}
 
 	if err != nil {
@@ -6,10 +6,13 @@ package sqlstore
 import (
 	"context"
 	"errors"
+	"path/filepath"
 	"testing"
 
 	"github.com/grafana/grafana/pkg/models"
+	"github.com/mattn/go-sqlite3"
 	"github.com/stretchr/testify/require"
+	"xorm.io/xorm"
 )
 
 var ErrProvokedError = errors.New("testing error")
@@ -53,4 +56,95 @@ func TestTransaction(t *testing.T) {
 		require.Nil(t, err)
 		require.Equal(t, cmd.Result.Id, query.Result.Id)
 	})
+
+	t.Run("nested transactions join the outer transaction", func(t *testing.T) {
+		nested := &models.AddApiKeyCommand{Key: "nested-key", Name: "nested", OrgId: 1}
+		err := ss.InTransaction(context.Background(), func(ctx context.Context) error {
+			if err := AddAPIKey(ctx, nested); err != nil {
+				return err
+			}
+
+			return ss.WithDbSession(ctx, func(sess *DBSession) error {
+				exists, err := sess.Table("api_key").Where("id=?", nested.Result.Id).Exist()
+				require.NoError(t, err)
+				require.True(t, exists)
+				return ErrProvokedError
+			})
+		})
+		require.Equal(t, ErrProvokedError, err)
+
+		query := &models.GetApiKeyByIdQuery{ApiKeyId: nested.Result.Id}
+		err = GetApiKeyById(query)
+		require.Equal(t, models.ErrInvalidApiKey, err)
+	})
+
+	t.Run("nested calls don't commit or close the outer transaction", func(t *testing.T) {
+		first := &models.AddApiKeyCommand{Key: "first-key", Name: "first", OrgId: 1}
+		second := &models.AddApiKeyCommand{Key: "second-key", Name: "second", OrgId: 1}
+		err := ss.InTransaction(context.Background(), func(ctx context.Context) error {
+			if err := AddAPIKey(ctx, first); err != nil {
+				return err
+			}
+			if err := ss.WithDbSession(ctx, func(sess *DBSession) error { return nil }); err != nil {
+				return err
+			}
+			return AddAPIKey(ctx, second)
+		})
+		require.NoError(t, err)
+
+		for _, cmd := range []*models.AddApiKeyCommand{first, second} {
+			query := &models.GetApiKeyByIdQuery{ApiKeyId: cmd.Result.Id}
+			require.NoError(t, GetApiKeyById(query))
+			require.Equal(t, cmd.Name, query.Result.Name)
+		}
+	})
+}
+
+func TestTransactionRetry(t *testing.T) {
+	engine, err := xorm.NewEngine("sqlite3", filepath.Join(t.TempDir(), "retry.db"))
+	require.NoError(t, err)
+	t.Cleanup(func() {
+		require.NoError(t, engine.Close())
+	})
+	locked := sqlite3.Error{Code: sqlite3.ErrLocked}
+
+	t.Run("locked transactions are retried with the same engine", func(t *testing.T) {
+		attempts := 0
+		err := inTransactionWithRetryCtx(context.Background(), engine, func(sess *DBSession) error {
+			attempts++
+			require.Same(t, engine.DB(), sess.DB())
+			if attempts < 3 {
+				return locked
+			}
+			return nil
+		}, 0)
+		require.NoError(t, err)
+		require.Equal(t, 3, attempts)
+	})
+
+	t.Run("locked transactions are retried with the same context", func(t *testing.T) {
+		ctx, cancel := context.WithCancel(context.Background())
+		attempts := 0
+		err := inTransactionWithRetryCtx(ctx, engine, func(sess *DBSession) error {
+			attempts++
+			if attempts == 1 {
+				cancel()
+				return locked
+			}
+			_, err := sess.Exec("SELECT 1")
+			return err
+		}, 0)
+		require.ErrorIs(t, err, context.Canceled)
+		require.Equal(t, 2, attempts)
+	})
+
+	t.Run("retries are limited", func(t *testing.T) {
+		attempts := 0
+		err := inTransactionWithRetryCtx(context.Background(), engine, func(sess *DBSession) error {
+			attempts++
+			return locked
+		}, 0)
+		require.ErrorIs(t, err, locked)
+		require.Equal(t, 6, attempts)
+	})
 }
@@ -95,8 +95,9 @@ var (
 	SnapShotRemoveExpired bool
 
 	// Dashboard history
-	DashboardVersionsToKeep int
-	MinRefreshInterval      string
+	DashboardVersionsToKeep      int
+	DashboardRecycleBinRetention time.Duration
+	MinRefreshInterval           string
 
 	// User settings
 	AllowUserSignUp         bool
@@ -256,9 +257,15 @@ type Cfg struct {
 	PluginsAppsSkipVerifyTLS         bool
 	PluginSettings                   PluginSettings
 	PluginsAllowUnsigned             []string
+	PluginsTrustedSigningKeys        []PluginSigningKey
 	PluginCatalogURL                 string
 	PluginAdminEnabled               bool
 	PluginAdminExternalManageEnabled bool
+	PluginRestartBackoffInitial      time.Duration
+	PluginRestartBackoffMax          time.Duration
+	PluginMaxConsecutiveRestarts     int
+	PluginsHotReload                 bool
+	PluginsHotReloadInterval         time.Duration
 	DisableSanitizeHtml              bool
 	EnterpriseLicensePath            string
 
@@ -359,10 +366,25 @@ type Cfg struct {
 
 	// Data sources
 	DataSourceLimit int
+	// QueryCachingEnabled allows data sources to opt in to caching of query results.
+	QueryCachingEnabled bool
+	// QueryCachingDefaultTTL is how long query results are cached unless the
+	// data source configures its own TTL.
+	QueryCachingDefaultTTL time.Duration
 
 	// Snapshots
 	SnapshotPublicMode bool
 
+	// Query history
+	QueryHistoryEnabled   bool
+	QueryHistoryRetention time.Duration
+
+	// Data source health checks
+	DataSourceHealthCheckEnabled  bool
+	DataSourceHealthCheckInterval time.Duration
+	DataSourceHealthCheckTimeout  time.Duration
+	DataSourceHealthCheckEvents   bool
+
 	ErrTemplateName string
 
 	Env string
@@ -404,6 +426,14 @@ type Cfg struct {
 	// LiveAllowedOrigins is a set of origins accepted by Live. If not provided
 	// then Live uses AppURL as the only allowed origin.
 	LiveAllowedOrigins []string
+	// LivePublishLimits contains quotas for messages published into Live
+	// channels over WebSocket and HTTP push endpoints.
+	LivePublishLimits LivePublishLimitSettings
+	// LiveMessageStorage is a type of storage for the last messages of
+	// broadcast channels: "database" or "remote_cache".
+	LiveMessageStorage string
+	// LiveMessageTTL is how long the last message of a broadcast channel is kept.
+	LiveMessageTTL time.Duration
 
 	// Grafana.com URL
 	GrafanaComURL string
@@ -575,6 +605,28 @@ func (cfg *Cfg) readExpressionsSettings() {
 	cfg.ExpressionsEnabled = expressions.Key("enabled").MustBool(true)
 }
 
+func (cfg *Cfg) readQueryHistorySettings() {
+	queryHistory := cfg.Raw.Section("query_history")
+	cfg.QueryHistoryEnabled = queryHistory.Key("enabled").MustBool(true)
+
+	retention, err := gtime.ParseDuration(queryHistory.Key("retention").MustString("14d"))
+	if err != nil || retention <= 0 {
+		retention = 14 * 24 * time.Hour
+	}
+	cfg.QueryHistoryRetention = retention
+}
+
+func (cfg *Cfg) readDataSourceHealthCheckSettings() {
+	healthCheck := cfg.Raw.Section("datasource_health_check")
+	cfg.DataSourceHealthCheckEnabled = healthCheck.Key("enabled").MustBool(false)
+	cfg.DataSourceHealthCheckInterval = healthCheck.Key("interval").MustDuration(5 * time.Minute)
+	if cfg.DataSourceHealthCheckInterval < time.Second {
+		cfg.DataSourceHealthCheckInterval = time.Second
+	}
+	cfg.DataSourceHealthCheckTimeout = healthCheck.Key("timeout").MustDuration(30 * time.Second)
+	cfg.DataSourceHealthCheckEvents = healthCheck.Key("emit_events").MustBool(false)
+}
+
 type AnnotationCleanupSettings struct {
 	MaxAge   time.Duration
 	MaxCount int64
@@ -873,6 +925,10 @@ func (cfg *Cfg) Load(args CommandLineArgs) error {
 	// read dashboard settings
 	dashboards := iniFile.Section("dashboards")
 	DashboardVersionsToKeep = dashboards.Key("versions_to_keep").MustInt(20)
+	DashboardRecycleBinRetention, err = gtime.ParseDuration(dashboards.Key("recycle_bin_retention").MustString("0"))
+	if err != nil {
+		return err
+	}
 	MinRefreshInterval = valueAsString(dashboards, "min_refresh_interval", "5s")
 
 	cfg.DefaultHomeDashboardPath = dashboards.Key("default_home_dashboard_path").MustString("")
@@ -929,9 +985,21 @@ func (cfg *Cfg) Load(args CommandLineArgs) error {
 		plug = strings.TrimSpace(plug)
 		cfg.PluginsAllowUnsigned = append(cfg.PluginsAllowUnsigned, plug)
 	}
+	cfg.PluginsTrustedSigningKeys, err = extractPluginSigningKeys(iniFile.Sections())
+	if err != nil {
+		return err
+	}
 	cfg.PluginCatalogURL = pluginsSection.Key("plugin_catalog_url").MustString("https://grafana.com/grafana/plugins/")
 	cfg.PluginAdminEnabled = pluginsSection.Key("plugin_admin_enabled").MustBool(true)
 	cfg.PluginAdminExternalManageEnabled = pluginsSection.Key("plugin_admin_external_manage_enabled").MustBool(false)
+	cfg.PluginRestartBackoffInitial = pluginsSection.Key("backend_restart_backoff_initial").MustDuration(time.Second)
+	cfg.PluginRestartBackoffMax = pluginsSection.Key("backend_restart_backoff_max").MustDuration(5 * time.Minute)
+	cfg.PluginMaxConsecutiveRestarts = pluginsSection.Key("backend_max_consecutive_restarts").MustInt(10)
+	cfg.PluginsHotReload = pluginsSection.Key("hot_reload").MustBool(false)
+	cfg.PluginsHotReloadInterval = pluginsSection.Key("hot_reload_interval").MustDuration(2 * time.Second)
+	if cfg.PluginsHotReloadInterval <= 0 {
+		cfg.PluginsHotReloadInterval = 2 * time.Second
+	}
 
 	if err := cfg.readFeatureToggles(iniFile); err != nil {
 		return err
@@ -954,6 +1022,8 @@ func (cfg *Cfg) Load(args CommandLineArgs) error {
 	cfg.readQuotaSettings()
 	cfg.readAnnotationSettings()
 	cfg.readExpressionsSettings()
+	cfg.readQueryHistorySettings()
+	cfg.readDataSourceHealthCheckSettings()
 	if err := cfg.readGrafanaEnvironmentMetrics(); err != nil {
 		return err
 	}
@@ -1485,6 +1555,11 @@ func (cfg *Cfg) GetContentDeliveryURL(prefix string) string {
 func (cfg *Cfg) readDataSourcesSettings() {
 	datasources := cfg.Raw.Section("datasources")
 	cfg.DataSourceLimit = datasources.Key("datasource_limit").MustInt(5000)
+	cfg.QueryCachingEnabled = datasources.Key("query_caching_enabled").MustBool(true)
+	cfg.QueryCachingDefaultTTL = datasources.Key("query_caching_default_ttl").MustDuration(time.Minute)
+	if cfg.QueryCachingDefaultTTL <= 0 {
+		cfg.QueryCachingDefaultTTL = time.Minute
+	}
 }
 
 func GetAllowedOriginGlobs(originPatterns []string) ([]glob.Glob, error) {
@@ -1528,5 +1603,22 @@ func (cfg *Cfg) readLiveSettings(iniFile *ini.File) error {
 		return err
 	}
 	cfg.LiveAllowedOrigins = originPatterns
+
+	publishLimits, err := readLivePublishLimitSettings(iniFile)
+	if err != nil {
+		return err
+	}
+	cfg.LivePublishLimits = publishLimits
+
+	cfg.LiveMessageStorage = section.Key("message_storage").MustString(LiveMessageStorageDatabase)
+	switch cfg.LiveMessageStorage {
+	case LiveMessageStorageDatabase, LiveMessageStorageRemoteCache:
+	default:
+		return fmt.Errorf("unsupported live message storage type: %s", cfg.LiveMessageStorage)
+	}
+	cfg.LiveMessageTTL = section.Key("message_ttl").MustDuration(24 * time.Hour)
+	if cfg.LiveMessageTTL <= 0 {
+		return fmt.Errorf("unexpected value %s for [live] message_ttl", cfg.LiveMessageTTL)
+	}
 	return nil
 }
@@ -0,0 +1,91 @@
+package setting
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/gobwas/glob"
+	"gopkg.in/ini.v1"
+)
+
+const livePublishLimitSectionPrefix = "live.publish_limit."
+
+// Storage types for the last messages of Live broadcast channels.
+const (
+	LiveMessageStorageDatabase    = "database"
+	LiveMessageStorageRemoteCache = "remote_cache"
+)
+
+// LivePublishLimit describes quotas applied to messages published into
+// Grafana Live channels. Zero value of a field means no limit.
+type LivePublishLimit struct {
+	// Name of the limit, taken from the config section name.
+	Name string
+	// ChannelPattern is a glob matched over a channel without org ID prefix
+	// (for example "stream/telegraf/*"). Empty pattern matches all channels.
+	ChannelPattern string
+	// MaxMessagesPerSecond limits the number of publications per second.
+	MaxMessagesPerSecond int
+	// MaxBytesPerSecond limits the total payload size published per second.
+	MaxBytesPerSecond int
+	// MaxMessageSize limits the size of a single publication in bytes.
+	MaxMessageSize int
+}
+
+// IsZero returns true if limit does not restrict anything.
+func (l LivePublishLimit) IsZero() bool {
+	return l.MaxMessagesPerSecond == 0 && l.MaxBytesPerSecond == 0 && l.MaxMessageSize == 0
+}
+
+// LivePublishLimitSettings holds publish limits for Grafana Live. Limits are
+// accounted separately for each organization, channel pattern and publisher
+// (user or API key).
+type LivePublishLimitSettings struct {
+	// Default limit applied to channels not matched by any of Channels.
+	Default LivePublishLimit
+	// Channels is an ordered list of per channel pattern limits, the first
+	// matching entry wins.
+	Channels []LivePublishLimit
+}
+
+func readLivePublishLimit(section *ini.Section, name string, keyPrefix string) (LivePublishLimit, error) {
+	limit := LivePublishLimit{
+		Name:                 name,
+		MaxMessagesPerSecond: section.Key(keyPrefix + "max_messages_per_second").MustInt(0),
+		MaxBytesPerSecond:    section.Key(keyPrefix + "max_bytes_per_second").MustInt(0),
+		MaxMessageSize:       section.Key(keyPrefix + "max_message_size").MustInt(0),
+	}
+	if limit.MaxMessagesPerSecond < 0 || limit.MaxBytesPerSecond < 0 || limit.MaxMessageSize < 0 {
+		return limit, fmt.Errorf("negative values are not allowed in [%s] publish limits", section.Name())
+	}
+	return limit, nil
+}
+
+func readLivePublishLimitSettings(iniFile *ini.File) (LivePublishLimitSettings, error) {
+	var settings LivePublishLimitSettings
+
+	defaultLimit, err := readLivePublishLimit(iniFile.Section("live"), "default", "publish_")
+	if err != nil {
+		return settings, err
+	}
+	settings.Default = defaultLimit
+
+	for _, section := range iniFile.Sections() {
+		sectionName := section.Name()
+		if !strings.HasPrefix(sectionName, livePublishLimitSectionPrefix) {
+			continue
+		}
+		limit, err := readLivePublishLimit(section, strings.TrimPrefix(sectionName, livePublishLimitSectionPrefix), "")
+		if err != nil {
+			return settings, err
+		}
+		limit.ChannelPattern = section.Key("channel_pattern").MustString("")
+		if limit.ChannelPattern != "" {
+			if _, err := glob.Compile(limit.ChannelPattern, '/'); err != nil {
+				return settings, fmt.Errorf("error parsing channel_pattern in [%s]: %w", sectionName, err)
+			}
+		}
+		settings.Channels = append(settings.Channels, limit)
+	}
+	return settings, nil
+}
@@ -0,0 +1,54 @@
+package setting
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+	"gopkg.in/ini.v1"
+)
+
+func TestReadLivePublishLimitSettings(t *testing.T) {
+	iniFile, err := ini.Load([]byte(`
+[live]
+publish_max_messages_per_second = 100
+publish_max_message_size = 1024
+
+[live.publish_limit.telegraf]
+channel_pattern = stream/telegraf/**
+max_messages_per_second = 10
+max_bytes_per_second = 2048
+`))
+	require.NoError(t, err)
+
+	settings, err := readLivePublishLimitSettings(iniFile)
+	require.NoError(t, err)
+	require.Equal(t, LivePublishLimit{
+		Name:                 "default",
+		MaxMessagesPerSecond: 100,
+		MaxMessageSize:       1024,
+	}, settings.Default)
+	require.Equal(t, []LivePublishLimit{{
+		Name:                 "telegraf",
+		ChannelPattern:       "stream/telegraf/**",
+		MaxMessagesPerSecond: 10,
+		MaxBytesPerSecond:    2048,
+	}}, settings.Channels)
+}
+
+func TestReadLivePublishLimitSettings_Invalid(t *testing.T) {
+	iniFile, err := ini.Load([]byte(`
+[live]
+publish_max_message_size = -1
+`))
+	require.NoError(t, err)
+	_, err = readLivePublishLimitSettings(iniFile)
+	require.Error(t, err)
+
+	iniFile, err = ini.Load([]byte(`
+[live.publish_limit.broken]
+channel_pattern = stream/[
+`))
+	require.NoError(t, err)
+	_, err = readLivePublishLimitSettings(iniFile)
+	require.Error(t, err)
+}
@@ -1,6 +1,7 @@
 package setting
 
 import (
+	"fmt"
 	"strings"
 
 	"gopkg.in/ini.v1"
@@ -23,3 +24,41 @@ func extractPluginSettings(sections []*ini.Section) PluginSettings {
 
 	return psMap
 }
+
+const pluginSigningKeySectionPrefix = "plugins.signing_key."
+
+// PluginSigningKey is an additional public key trusted to sign plugins.
+type PluginSigningKey struct {
+	// Name of the key, taken from the config section name.
+	Name string
+	// PublicKeyPath is a path to an armored PGP public key.
+	PublicKeyPath string
+	// PluginIDPrefixes limits plugins the key may sign, empty means any plugin.
+	PluginIDPrefixes []string
+}
+
+func extractPluginSigningKeys(sections []*ini.Section) ([]PluginSigningKey, error) {
+	var keys []PluginSigningKey
+	for _, section := range sections {
+		sectionName := section.Name()
+		if !strings.HasPrefix(sectionName, pluginSigningKeySectionPrefix) {
+			continue
+		}
+
+		key := PluginSigningKey{
+			Name:          strings.TrimPrefix(sectionName, pluginSigningKeySectionPrefix),
+			PublicKeyPath: section.Key("public_key_path").MustString(""),
+		}
+		if key.PublicKeyPath == "" {
+			return nil, fmt.Errorf("public_key_path is required in [%s]", sectionName)
+		}
+		for _, prefix := range strings.Split(section.Key("plugin_id_prefixes").MustString(""), ",") {
+			prefix = strings.TrimSpace(prefix)
+			if prefix != "" {
+				key.PluginIDPrefixes = append(key.PluginIDPrefixes, prefix)
+			}
+		}
+		keys = append(keys, key)
+	}
+	return keys, nil
+}
@@ -41,3 +41,30 @@ func TestPluginSettings(t *testing.T) {
 	require.Equal(t, ps["plugin2"]["key3"], "value3")
 	require.Equal(t, ps["plugin2"]["key4"], "value4")
 }
+
+func TestPluginSigningKeys(t *testing.T) {
+	cfg := NewCfg()
+	sec, err := cfg.Raw.NewSection("plugins.signing_key.myorg")
+	require.NoError(t, err)
+	_, err = sec.NewKey("public_key_path", "/etc/grafana/keys/myorg.asc")
+	require.NoError(t, err)
+	_, err = sec.NewKey("plugin_id_prefixes", "myorg-, partner-")
+	require.NoError(t, err)
+
+	sec, err = cfg.Raw.NewSection("plugins.signing_key.any")
+	require.NoError(t, err)
+	_, err = sec.NewKey("public_key_path", "/etc/grafana/keys/any.asc")
+	require.NoError(t, err)
+
+	keys, err := extractPluginSigningKeys(cfg.Raw.Sections())
+	require.NoError(t, err)
+	require.Equal(t, []PluginSigningKey{
+		{Name: "myorg", PublicKeyPath: "/etc/grafana/keys/myorg.asc", PluginIDPrefixes: []string{"myorg-", "partner-"}},
+		{Name: "any", PublicKeyPath: "/etc/grafana/keys/any.asc"},
+	}, keys)
+
+	_, err = cfg.Raw.NewSection("plugins.signing_key.broken")
+	require.NoError(t, err)
+	_, err = extractPluginSigningKeys(cfg.Raw.Sections())
+	require.Error(t, err)
+}
@@ -2606,7 +2606,7 @@ func createFolder(t *testing.T, store *sqlstore.SQLStore, folderID int64, folder
 			"title": folderName,
 		}),
 	}
-	f, err := store.SaveDashboard(cmd)
+	f, err := store.SaveDashboard(context.Background(), cmd)
 
 	if err != nil {
 		return "", err